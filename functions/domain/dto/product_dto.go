@@ -14,7 +14,10 @@ type ProductDTO struct {
 	Price           float64            `json:"price"`
 	StoreID         string             `json:"storeId"`
 	NodeID          string             `json:"nodeId"`
+	UserID          string             `json:"userId"`
 	Images          []string           `json:"images"`
+	// MediaDetails son las dimensiones, el placeholder y los thumbnails de Images, si ya se procesaron
+	MediaDetails    []models.MediaURL  `json:"mediaDetails,omitempty"`
 	Contact         contact.ContactInfo `json:"contact"`
 	DonationPercent int               `json:"donationPercent"`
 	ApprovalStatus  string            `json:"approvalStatus"`
@@ -31,7 +34,9 @@ func (dto *ProductDTO) ToModel() *models.Product {
 		Price:          dto.Price,
 		StoreID:        dto.StoreID,
 		NodeID:         dto.NodeID,
+		UserID:         dto.UserID,
 		Images:         dto.Images,
+		MediaDetails:   dto.MediaDetails,
 		Contact:        dto.Contact,
 		DonationPercent: dto.DonationPercent,
 		ApprovalStatus: dto.ApprovalStatus,
@@ -49,7 +54,9 @@ func FromProductModel(product *models.Product) *ProductDTO {
 		Price:          product.Price,
 		StoreID:        product.StoreID,
 		NodeID:         product.NodeID,
+		UserID:         product.UserID,
 		Images:         product.Images,
+		MediaDetails:   product.MediaDetails,
 		Contact:        product.Contact,
 		DonationPercent: product.DonationPercent,
 		ApprovalStatus: product.ApprovalStatus,
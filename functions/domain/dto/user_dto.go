@@ -11,6 +11,8 @@ type UserDTO struct {
     DisplayName string    `json:"displayName"`
     Email       string    `json:"email"`
     CreatedAt   time.Time `json:"createdAt"`
+    // Level es el nivel del usuario y su avance hacia el siguiente; solo se usa en las respuestas
+    Level       *models.LevelProgress `json:"level,omitempty"`
 }
 
 // ToModel convierte el DTO a un modelo User
@@ -15,6 +15,8 @@ const (
     ConflictError    ErrorType = "CONFLICT"
     UnauthorizedError ErrorType = "UNAUTHORIZED"
     ForbiddenError   ErrorType = "FORBIDDEN"
+    TooLargeError    ErrorType = "TOO_LARGE"
+    QuotaExceededError ErrorType = "QUOTA_EXCEEDED"
     InternalError    ErrorType = "INTERNAL_ERROR"
 )
 
@@ -80,6 +82,24 @@ func NewForbiddenError(message string) *DomainError {
     }
 }
 
+// NewTooLargeError crea un nuevo error de archivo demasiado grande
+func NewTooLargeError(message string) *DomainError {
+    return &DomainError{
+        Type:    TooLargeError,
+        Message: message,
+        Code:    http.StatusRequestEntityTooLarge,
+    }
+}
+
+// NewQuotaExceededError crea un nuevo error de cuota de almacenamiento agotada
+func NewQuotaExceededError(message string) *DomainError {
+    return &DomainError{
+        Type:    QuotaExceededError,
+        Message: message,
+        Code:    http.StatusInsufficientStorage,
+    }
+}
+
 // NewInternalError crea un nuevo error interno
 func NewInternalError(message string, cause error) *DomainError {
     return &DomainError{
@@ -96,6 +116,12 @@ func IsDomainError(err error) bool {
     return ok
 }
 
+// IsNotFoundError verifica si un error es de recurso no encontrado
+func IsNotFoundError(err error) bool {
+    domainErr, ok := err.(*DomainError)
+    return ok && domainErr.Type == NotFoundError
+}
+
 // GetErrorCode obtiene el código HTTP correspondiente al error
 func GetErrorCode(err error) int {
     if domainErr, ok := err.(*DomainError); ok {
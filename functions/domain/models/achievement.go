@@ -1,34 +1,222 @@
 package models
 
+import (
+    "fmt"
+    "regexp"
+)
+
 // AchievementType representa el tipo de logro
 type AchievementType string
 
 const (
-    NodeCreation  AchievementType = "NODE_CREATION"
-    NodeFollow    AchievementType = "NODE_FOLLOW"
-    ProductLink   AchievementType = "PRODUCT_LINK"
-    NodeUpdate    AchievementType = "NODE_UPDATE"
-    NodeShare     AchievementType = "NODE_SHARE"
+    NodeCreation         AchievementType = "NODE_CREATION"
+    NodeFollow           AchievementType = "NODE_FOLLOW"
+    ProductLink          AchievementType = "PRODUCT_LINK"
+    NodeUpdate           AchievementType = "NODE_UPDATE"
+    NodeShare            AchievementType = "NODE_SHARE"
+    StreakAchievement    AchievementType = "STREAK"
+    ChallengeAchievement AchievementType = "CHALLENGE"
+)
+
+// Métricas que pueden usar las condiciones de un logro
+const (
+    MetricNodeCount        = "node_count"
+    MetricFollowCount      = "follow_count"
+    MetricUpdateCount      = "update_count"
+    MetricInteractionCount = "interaction_count"
+    MetricProductLinkCount = "product_link_count"
+    MetricStreakDays       = "streak_days"
 )
 
-// Achievement representa un logro que puede ser desbloqueado por un usuario
+// AchievementMetrics son las métricas soportadas por las condiciones
+var AchievementMetrics = []string{
+    MetricNodeCount,
+    MetricFollowCount,
+    MetricUpdateCount,
+    MetricInteractionCount,
+    MetricProductLinkCount,
+    MetricStreakDays,
+}
+
+// Tipos de condición que agrupan otras condiciones
+const (
+    ConditionAll = "and"
+    ConditionAny = "or"
+)
+
+var achievementIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// Achievement es la definición de un logro que puede ser desbloqueado por un usuario.
+// Cada nivel de un mismo tipo es un logro distinto, con su propio ID, y se desbloquea
+// cuando se cumplen todas sus condiciones.
 type Achievement struct {
     ID          string          `json:"id" firestore:"id"`
     Type        AchievementType `json:"type" firestore:"type"`
     Name        string          `json:"name" firestore:"name"`
     Description string          `json:"description" firestore:"description"`
     Points      int             `json:"points" firestore:"points"`
-    Conditions  []Condition    `json:"conditions" firestore:"conditions"`
-    CreatedAt   int64          `json:"created_at" firestore:"created_at"`
-    UpdatedAt   int64          `json:"updated_at" firestore:"updated_at"`
+    Tier        int             `json:"tier" firestore:"tier"`
+    Conditions  []Condition     `json:"conditions" firestore:"conditions"`
+    // Active indica si el logro se evalúa; los inactivos no se otorgan
+    Active    bool  `json:"active" firestore:"active"`
+    CreatedAt int64 `json:"created_at" firestore:"created_at"`
+    UpdatedAt int64 `json:"updated_at" firestore:"updated_at"`
+}
+
+// Validate verifica que la definición del logro sea evaluable
+func (a *Achievement) Validate() error {
+    if !achievementIDPattern.MatchString(a.ID) {
+        return fmt.Errorf("el ID del logro debe tener solo minúsculas, números, '_' o '-'")
+    }
+    if a.Name == "" {
+        return fmt.Errorf("el nombre del logro es obligatorio")
+    }
+    if a.Points < 0 {
+        return fmt.Errorf("los puntos del logro no pueden ser negativos")
+    }
+    if len(a.Conditions) == 0 {
+        return fmt.Errorf("el logro debe tener al menos una condición")
+    }
+    for _, condition := range a.Conditions {
+        if err := condition.Validate(); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// Metrics retorna las métricas que usan las condiciones del logro
+func (a *Achievement) Metrics() []string {
+    metrics := make([]string, 0)
+    for _, condition := range a.Conditions {
+        metrics = condition.appendMetrics(metrics)
+    }
+    return metrics
+}
+
+// Evaluate indica si las métricas cumplen todas las condiciones del logro
+func (a *Achievement) Evaluate(metrics map[string]int) bool {
+    group := Condition{Type: ConditionAll, Conditions: a.Conditions}
+    return group.Evaluate(metrics)
 }
 
 // UserAchievement has been moved to user.go
 
+// Condition compara una métrica con un valor, p. ej. {node_count >= 10}. Si Type es
+// "and" u "or" la condición es un grupo que se cumple cuando se cumplen todas o alguna
+// de sus Conditions.
 type Condition struct {
-    Type      string      `json:"type" firestore:"type"`
-    Value     interface{} `json:"value" firestore:"value"`
-    Operator  string      `json:"operator" firestore:"operator"`
+    Type       string      `json:"type" firestore:"type"`
+    Value      interface{} `json:"value,omitempty" firestore:"value,omitempty"`
+    Operator   string      `json:"operator,omitempty" firestore:"operator,omitempty"`
+    Conditions []Condition `json:"conditions,omitempty" firestore:"conditions,omitempty"`
+}
+
+// Validate verifica que la métrica, el operador y el valor de la condición sean válidos
+func (c *Condition) Validate() error {
+    if c.Type == ConditionAll || c.Type == ConditionAny {
+        if len(c.Conditions) == 0 {
+            return fmt.Errorf("el grupo '%s' debe tener al menos una condición", c.Type)
+        }
+        for _, condition := range c.Conditions {
+            if err := condition.Validate(); err != nil {
+                return err
+            }
+        }
+        return nil
+    }
+
+    supported := false
+    for _, metric := range AchievementMetrics {
+        if c.Type == metric {
+            supported = true
+            break
+        }
+    }
+    if !supported {
+        return fmt.Errorf("métrica no soportada: %s", c.Type)
+    }
+    if _, ok := compare(c.Operator, 0, 0); !ok {
+        return fmt.Errorf("operador no soportado: %s", c.Operator)
+    }
+    if _, ok := conditionValue(c.Value); !ok {
+        return fmt.Errorf("el valor de la condición %s debe ser numérico", c.Type)
+    }
+    return nil
+}
+
+// Evaluate indica si las métricas cumplen la condición. Una métrica ausente vale 0.
+func (c *Condition) Evaluate(metrics map[string]int) bool {
+    switch c.Type {
+    case ConditionAll:
+        for _, condition := range c.Conditions {
+            if !condition.Evaluate(metrics) {
+                return false
+            }
+        }
+        return true
+    case ConditionAny:
+        for _, condition := range c.Conditions {
+            if condition.Evaluate(metrics) {
+                return true
+            }
+        }
+        return false
+    }
+
+    value, ok := conditionValue(c.Value)
+    if !ok {
+        return false
+    }
+    result, _ := compare(c.Operator, float64(metrics[c.Type]), value)
+    return result
+}
+
+func (c *Condition) appendMetrics(metrics []string) []string {
+    if c.Type != ConditionAll && c.Type != ConditionAny {
+        for _, metric := range metrics {
+            if metric == c.Type {
+                return metrics
+            }
+        }
+        return append(metrics, c.Type)
+    }
+    for _, condition := range c.Conditions {
+        metrics = condition.appendMetrics(metrics)
+    }
+    return metrics
+}
+
+// compare aplica el operador; el segundo resultado es false si el operador no existe
+func compare(operator string, metric float64, value float64) (bool, bool) {
+    switch operator {
+    case ">=":
+        return metric >= value, true
+    case ">":
+        return metric > value, true
+    case "<=":
+        return metric <= value, true
+    case "<":
+        return metric < value, true
+    case "==":
+        return metric == value, true
+    case "!=":
+        return metric != value, true
+    }
+    return false, false
+}
+
+// conditionValue convierte el valor guardado, que según su origen puede ser int, int64 o float64
+func conditionValue(value interface{}) (float64, bool) {
+    switch v := value.(type) {
+    case int:
+        return float64(v), true
+    case int64:
+        return float64(v), true
+    case float64:
+        return v, true
+    }
+    return 0, false
 }
 
 type UserPoints struct {
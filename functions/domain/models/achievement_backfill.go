@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// Estados de un recálculo de logros
+const (
+    BackfillRunning   = "running"
+    BackfillCompleted = "completed"
+)
+
+// AchievementBackfill es un recálculo de los logros de todos los usuarios. Recorre los
+// usuarios por páginas y guarda en Cursor el último procesado para poder retomarse si la
+// función se corta. En DryRun no otorga nada y solo registra los cambios.
+type AchievementBackfill struct {
+    ID             string `json:"id" firestore:"-"`
+    DryRun         bool   `json:"dry_run" firestore:"dry_run"`
+    Status         string `json:"status" firestore:"status"`
+    Cursor         string `json:"cursor,omitempty" firestore:"cursor"`
+    UsersProcessed int    `json:"users_processed" firestore:"users_processed"`
+    UsersChanged   int    `json:"users_changed" firestore:"users_changed"`
+    UsersFailed    int    `json:"users_failed" firestore:"users_failed"`
+    Granted        int    `json:"granted" firestore:"granted"`
+    Points         int    `json:"points" firestore:"points"`
+    LastError      string `json:"last_error,omitempty" firestore:"last_error,omitempty"`
+    CreatedBy      string `json:"created_by" firestore:"created_by"`
+    // LeaseUntil evita que dos ejecuciones procesen el mismo recálculo a la vez
+    LeaseUntil  time.Time  `json:"-" firestore:"lease_until"`
+    CreatedAt   time.Time  `json:"created_at" firestore:"created_at"`
+    UpdatedAt   time.Time  `json:"updated_at" firestore:"updated_at"`
+    CompletedAt *time.Time `json:"completed_at,omitempty" firestore:"completed_at,omitempty"`
+}
+
+// AchievementBackfillChange es un logro que el recálculo otorgó o, en DryRun, otorgaría.
+// Metrics son los valores con los que se cumplió la regla.
+type AchievementBackfillChange struct {
+    UserID        string         `json:"user_id" firestore:"user_id"`
+    AchievementID string         `json:"achievement_id" firestore:"achievement_id"`
+    Name          string         `json:"name" firestore:"name"`
+    Points        int            `json:"points" firestore:"points"`
+    Metrics       map[string]int `json:"metrics" firestore:"metrics"`
+    Applied       bool           `json:"applied" firestore:"applied"`
+    CreatedAt     time.Time      `json:"created_at" firestore:"created_at"`
+}
+
+// AchievementBackfillReport es el estado de un recálculo con una página de sus cambios
+type AchievementBackfillReport struct {
+    Backfill   *AchievementBackfill         `json:"backfill"`
+    Changes    []*AchievementBackfillChange `json:"changes"`
+    NextCursor string                       `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,131 @@
+package models
+
+import (
+    "fmt"
+    "time"
+)
+
+// Actividades que cuentan para las rachas y los retos. Los comentarios no se registran
+// como actividad porque no hay un flujo que identifique a quien comenta.
+const (
+    ActivityFollow = "follow"
+    ActivityUpdate = "update"
+)
+
+// Activity es una acción de un usuario que cuenta para sus rachas y retos
+type Activity struct {
+    UserID   string
+    Type     string
+    NodeID   string
+    NodeType NodeType
+    // Target identifica el objeto de la acción (el nodo seguido, la actualización) para
+    // que registrar dos veces la misma acción no cuente doble
+    Target string
+    At     time.Time
+}
+
+// Periodos de las rachas
+const (
+    StreakDaily  = "daily"
+    StreakWeekly = "weekly"
+)
+
+// StreakRule define cómo avanza la racha de un periodo
+type StreakRule struct {
+    // Days es la duración del periodo
+    Days int
+    // Grace es el número de periodos que se pueden perder sin cortar la racha ni gastar congelamientos
+    Grace int
+    // FreezeEvery indica cada cuántos periodos seguidos se gana un congelamiento
+    FreezeEvery int
+    // MaxFreezes es el máximo de congelamientos acumulados
+    MaxFreezes int
+}
+
+// StreakRules son las reglas de cada periodo
+var StreakRules = map[string]StreakRule{
+    StreakDaily:  {Days: 1, Grace: 1, FreezeEvery: 7, MaxFreezes: 2},
+    StreakWeekly: {Days: 7, Grace: 0, FreezeEvery: 4, MaxFreezes: 2},
+}
+
+// Streak es la racha de actividad de un usuario en un periodo
+type Streak struct {
+    UserID  string `json:"user_id" firestore:"user_id"`
+    Period  string `json:"period" firestore:"period"`
+    Current int    `json:"current" firestore:"current"`
+    Longest int    `json:"longest" firestore:"longest"`
+    // PeriodStart es el inicio, en la zona horaria del usuario, del último periodo con actividad
+    PeriodStart time.Time `json:"period_start" firestore:"period_start"`
+    // Freezes son los congelamientos disponibles; cada uno cubre un periodo perdido
+    Freezes     int       `json:"freezes" firestore:"freezes"`
+    FreezesUsed int       `json:"freezes_used" firestore:"freezes_used"`
+    UpdatedAt   time.Time `json:"updated_at" firestore:"updated_at"`
+}
+
+// StreakPeriodStart retorna el inicio del periodo que contiene t: el día o la semana,
+// que empieza el lunes. Se expresa como fecha en UTC para contar periodos sin cambios de horario.
+func StreakPeriodStart(period string, t time.Time) time.Time {
+    day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+    if period == StreakWeekly {
+        return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+    }
+    return day
+}
+
+// missed retorna cuántos periodos sin actividad hay entre el último registrado y start
+func (s *Streak) missed(start time.Time) int {
+    rule := StreakRules[s.Period]
+    days := int(start.Sub(s.PeriodStart).Hours() / 24)
+    return days/rule.Days - 1
+}
+
+// Advance registra actividad en el periodo que empieza en start. Los periodos perdidos se
+// cubren primero con la gracia y luego con congelamientos; si no alcanzan, la racha vuelve
+// a empezar. Retorna false si el periodo ya tenía actividad.
+func (s *Streak) Advance(start time.Time) (bool, error) {
+    rule, ok := StreakRules[s.Period]
+    if !ok {
+        return false, fmt.Errorf("periodo de racha no soportado: %s", s.Period)
+    }
+    if s.Current > 0 && !start.After(s.PeriodStart) {
+        return false, nil
+    }
+
+    if s.Current == 0 {
+        s.Current = 1
+    } else {
+        uncovered := s.missed(start) - rule.Grace
+        switch {
+        case uncovered <= 0:
+            s.Current++
+        case uncovered <= s.Freezes:
+            s.Freezes -= uncovered
+            s.FreezesUsed += uncovered
+            s.Current++
+        default:
+            s.Current = 1
+        }
+    }
+
+    s.PeriodStart = start
+    if s.Current > s.Longest {
+        s.Longest = s.Current
+    }
+    if s.Current%rule.FreezeEvery == 0 && s.Freezes < rule.MaxFreezes {
+        s.Freezes++
+    }
+    return true, nil
+}
+
+// Alive indica si la racha sigue vigente en el periodo que empieza en start, contando la
+// gracia y los congelamientos disponibles
+func (s *Streak) Alive(start time.Time) bool {
+    if s.Current == 0 {
+        return false
+    }
+    if !start.After(s.PeriodStart) {
+        return true
+    }
+    // El periodo actual todavía puede tener actividad, así que solo cuentan los anteriores
+    return s.missed(start)-StreakRules[s.Period].Grace <= s.Freezes
+}
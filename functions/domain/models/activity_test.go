@@ -0,0 +1,183 @@
+package models
+
+import (
+    "testing"
+    "time"
+)
+
+func day(n int) time.Time {
+    return time.Date(2026, time.January, n, 0, 0, 0, 0, time.UTC)
+}
+
+func TestStreakAdvance(t *testing.T) {
+    tests := []struct {
+        name    string
+        streak  Streak
+        start   time.Time
+        want    Streak
+        changed bool
+    }{
+        {
+            name:    "primera actividad",
+            streak:  Streak{Period: StreakDaily},
+            start:   day(10),
+            want:    Streak{Period: StreakDaily, Current: 1, Longest: 1, PeriodStart: day(10)},
+            changed: true,
+        },
+        {
+            name:   "mismo día",
+            streak: Streak{Period: StreakDaily, Current: 3, Longest: 5, PeriodStart: day(10)},
+            start:  day(10),
+            want:   Streak{Period: StreakDaily, Current: 3, Longest: 5, PeriodStart: day(10)},
+        },
+        {
+            name:   "día anterior al último",
+            streak: Streak{Period: StreakDaily, Current: 3, Longest: 5, PeriodStart: day(10)},
+            start:  day(9),
+            want:   Streak{Period: StreakDaily, Current: 3, Longest: 5, PeriodStart: day(10)},
+        },
+        {
+            name:    "día siguiente",
+            streak:  Streak{Period: StreakDaily, Current: 3, Longest: 5, PeriodStart: day(10)},
+            start:   day(11),
+            want:    Streak{Period: StreakDaily, Current: 4, Longest: 5, PeriodStart: day(11)},
+            changed: true,
+        },
+        {
+            name:    "la gracia cubre un día perdido",
+            streak:  Streak{Period: StreakDaily, Current: 3, Longest: 5, Freezes: 1, PeriodStart: day(10)},
+            start:   day(12),
+            want:    Streak{Period: StreakDaily, Current: 4, Longest: 5, Freezes: 1, PeriodStart: day(12)},
+            changed: true,
+        },
+        {
+            name:    "un congelamiento cubre lo que no cubre la gracia",
+            streak:  Streak{Period: StreakDaily, Current: 3, Longest: 5, Freezes: 1, PeriodStart: day(10)},
+            start:   day(13),
+            want:    Streak{Period: StreakDaily, Current: 4, Longest: 5, FreezesUsed: 1, PeriodStart: day(13)},
+            changed: true,
+        },
+        {
+            name:    "sin congelamientos suficientes la racha vuelve a empezar",
+            streak:  Streak{Period: StreakDaily, Current: 3, Longest: 5, Freezes: 1, PeriodStart: day(10)},
+            start:   day(14),
+            want:    Streak{Period: StreakDaily, Current: 1, Longest: 5, Freezes: 1, PeriodStart: day(14)},
+            changed: true,
+        },
+        {
+            name:    "supera la racha más larga",
+            streak:  Streak{Period: StreakDaily, Current: 5, Longest: 5, PeriodStart: day(10)},
+            start:   day(11),
+            want:    Streak{Period: StreakDaily, Current: 6, Longest: 6, PeriodStart: day(11)},
+            changed: true,
+        },
+        {
+            name:    "gana un congelamiento cada siete días",
+            streak:  Streak{Period: StreakDaily, Current: 6, Longest: 6, PeriodStart: day(10)},
+            start:   day(11),
+            want:    Streak{Period: StreakDaily, Current: 7, Longest: 7, Freezes: 1, PeriodStart: day(11)},
+            changed: true,
+        },
+        {
+            name:    "no acumula más congelamientos que el máximo",
+            streak:  Streak{Period: StreakDaily, Current: 13, Longest: 13, Freezes: 2, PeriodStart: day(10)},
+            start:   day(11),
+            want:    Streak{Period: StreakDaily, Current: 14, Longest: 14, Freezes: 2, PeriodStart: day(11)},
+            changed: true,
+        },
+        {
+            name:    "semana siguiente",
+            streak:  Streak{Period: StreakWeekly, Current: 2, Longest: 2, PeriodStart: day(5)},
+            start:   day(12),
+            want:    Streak{Period: StreakWeekly, Current: 3, Longest: 3, PeriodStart: day(12)},
+            changed: true,
+        },
+        {
+            name:    "una semana perdida sin gracia ni congelamientos",
+            streak:  Streak{Period: StreakWeekly, Current: 2, Longest: 2, PeriodStart: day(5)},
+            start:   day(19),
+            want:    Streak{Period: StreakWeekly, Current: 1, Longest: 2, PeriodStart: day(19)},
+            changed: true,
+        },
+        {
+            name:    "una semana perdida cubierta por un congelamiento",
+            streak:  Streak{Period: StreakWeekly, Current: 3, Longest: 3, Freezes: 1, PeriodStart: day(5)},
+            start:   day(19),
+            want:    Streak{Period: StreakWeekly, Current: 4, Longest: 4, Freezes: 1, FreezesUsed: 1, PeriodStart: day(19)},
+            changed: true,
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            streak := tt.streak
+            changed, err := streak.Advance(tt.start)
+            if err != nil {
+                t.Fatalf("error inesperado: %v", err)
+            }
+            if changed != tt.changed {
+                t.Fatalf("Advance() = %v, se esperaba %v", changed, tt.changed)
+            }
+            if streak != tt.want {
+                t.Fatalf("racha %+v, se esperaba %+v", streak, tt.want)
+            }
+        })
+    }
+}
+
+func TestStreakAdvanceUnsupportedPeriod(t *testing.T) {
+    streak := Streak{Period: "monthly"}
+    if _, err := streak.Advance(day(10)); err == nil {
+        t.Fatal("se esperaba un error")
+    }
+}
+
+func TestStreakAlive(t *testing.T) {
+    tests := []struct {
+        name   string
+        streak Streak
+        start  time.Time
+        want   bool
+    }{
+        {"sin racha", Streak{Period: StreakDaily}, day(10), false},
+        {"mismo día", Streak{Period: StreakDaily, Current: 3, PeriodStart: day(10)}, day(10), true},
+        {"día siguiente", Streak{Period: StreakDaily, Current: 3, PeriodStart: day(10)}, day(11), true},
+        {"un día perdido dentro de la gracia", Streak{Period: StreakDaily, Current: 3, PeriodStart: day(10)}, day(12), true},
+        {"dos días perdidos sin congelamientos", Streak{Period: StreakDaily, Current: 3, PeriodStart: day(10)}, day(13), false},
+        {"dos días perdidos con un congelamiento", Streak{Period: StreakDaily, Current: 3, Freezes: 1, PeriodStart: day(10)}, day(13), true},
+        {"tres días perdidos con un congelamiento", Streak{Period: StreakDaily, Current: 3, Freezes: 1, PeriodStart: day(10)}, day(14), false},
+        {"semana siguiente", Streak{Period: StreakWeekly, Current: 2, PeriodStart: day(5)}, day(12), true},
+        {"una semana perdida", Streak{Period: StreakWeekly, Current: 2, PeriodStart: day(5)}, day(19), false},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := tt.streak.Alive(tt.start); got != tt.want {
+                t.Fatalf("Alive() = %v, se esperaba %v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestStreakPeriodStart(t *testing.T) {
+    bogota := time.FixedZone("UTC-5", -5*60*60)
+    tests := []struct {
+        name   string
+        period string
+        at     time.Time
+        want   time.Time
+    }{
+        {"día", StreakDaily, time.Date(2026, time.January, 7, 15, 30, 0, 0, time.UTC), day(7)},
+        {"día en la zona del usuario", StreakDaily, time.Date(2026, time.January, 7, 23, 0, 0, 0, bogota), day(7)},
+        {"semana desde el domingo", StreakWeekly, time.Date(2026, time.January, 11, 23, 0, 0, 0, bogota), day(5)},
+        {"semana desde el lunes", StreakWeekly, time.Date(2026, time.January, 12, 0, 0, 0, 0, time.UTC), day(12)},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := StreakPeriodStart(tt.period, tt.at); !got.Equal(tt.want) {
+                t.Fatalf("StreakPeriodStart() = %v, se esperaba %v", got, tt.want)
+            }
+        })
+    }
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+    "fmt"
+    "time"
+)
+
+// ChallengeGoal es la meta de un reto, p. ej. seguir 3 nodos ambientales
+type ChallengeGoal struct {
+    Activity string `json:"activity" firestore:"activity"`
+    // NodeType limita la meta a nodos de un tipo; vacío acepta cualquiera
+    NodeType NodeType `json:"node_type,omitempty" firestore:"node_type,omitempty"`
+    Count    int      `json:"count" firestore:"count"`
+}
+
+// Challenge es un reto definido por los administradores con fecha de inicio y de fin.
+// Completarlo desbloquea el logro "challenge_<ID>" y otorga sus puntos.
+type Challenge struct {
+    ID          string        `json:"id" firestore:"-"`
+    Name        string        `json:"name" firestore:"name"`
+    Description string        `json:"description" firestore:"description"`
+    StartAt     time.Time     `json:"start_at" firestore:"start_at"`
+    EndAt       time.Time     `json:"end_at" firestore:"end_at"`
+    Goal        ChallengeGoal `json:"goal" firestore:"goal"`
+    Points      int           `json:"points" firestore:"points"`
+    Active      bool          `json:"active" firestore:"active"`
+    CreatedAt   time.Time     `json:"created_at" firestore:"created_at"`
+    UpdatedAt   time.Time     `json:"updated_at" firestore:"updated_at"`
+}
+
+// Validate verifica que el reto tenga nombre, fechas y una meta alcanzable
+func (c *Challenge) Validate() error {
+    if c.Name == "" {
+        return fmt.Errorf("el nombre del reto es obligatorio")
+    }
+    if c.StartAt.IsZero() || !c.EndAt.After(c.StartAt) {
+        return fmt.Errorf("el reto debe terminar después de empezar")
+    }
+    switch c.Goal.Activity {
+    case ActivityFollow, ActivityUpdate:
+    default:
+        return fmt.Errorf("actividad no soportada: %s", c.Goal.Activity)
+    }
+    switch c.Goal.NodeType {
+    case "", Social, Environmental, Animal:
+    default:
+        return fmt.Errorf("tipo de nodo no soportado: %s", c.Goal.NodeType)
+    }
+    if c.Goal.Count <= 0 {
+        return fmt.Errorf("la meta del reto debe ser mayor que cero")
+    }
+    if c.Points < 0 {
+        return fmt.Errorf("los puntos del reto no pueden ser negativos")
+    }
+    return nil
+}
+
+// Open indica si el reto está activo y en curso en t
+func (c *Challenge) Open(t time.Time) bool {
+    return c.Active && !t.Before(c.StartAt) && t.Before(c.EndAt)
+}
+
+// Matches indica si la actividad cuenta para el reto
+func (c *Challenge) Matches(activity *Activity) bool {
+    if !c.Open(activity.At) || activity.Type != c.Goal.Activity {
+        return false
+    }
+    return c.Goal.NodeType == "" || c.Goal.NodeType == activity.NodeType
+}
+
+// AchievementID retorna el ID del logro que se desbloquea al completar el reto
+func (c *Challenge) AchievementID() string {
+    return "challenge_" + c.ID
+}
+
+// ChallengeProgress es el avance de un usuario en un reto
+type ChallengeProgress struct {
+    ChallengeID string `json:"challenge_id" firestore:"challenge_id"`
+    UserID      string `json:"user_id" firestore:"user_id"`
+    Count       int    `json:"count" firestore:"count"`
+    // Targets son los objetos ya contados, para no contar dos veces la misma acción
+    Targets     []string   `json:"-" firestore:"targets"`
+    Completed   bool       `json:"completed" firestore:"completed"`
+    CompletedAt *time.Time `json:"completed_at,omitempty" firestore:"completed_at,omitempty"`
+    UpdatedAt   time.Time  `json:"updated_at" firestore:"updated_at"`
+}
+
+// Add suma la actividad al avance si no estaba contada. Retorna true si con ella se
+// completó el reto.
+func (p *ChallengeProgress) Add(challenge *Challenge, activity *Activity) bool {
+    if p.Completed {
+        return false
+    }
+    if activity.Target != "" {
+        for _, target := range p.Targets {
+            if target == activity.Target {
+                return false
+            }
+        }
+        p.Targets = append(p.Targets, activity.Target)
+    }
+
+    p.Count++
+    p.UpdatedAt = activity.At
+    if p.Count >= challenge.Goal.Count {
+        completedAt := activity.At
+        p.Completed = true
+        p.CompletedAt = &completedAt
+        return true
+    }
+    return false
+}
+
+// ChallengeStatus es un reto con el avance del usuario que lo consulta
+type ChallengeStatus struct {
+    Challenge *Challenge         `json:"challenge"`
+    Progress  *ChallengeProgress `json:"progress"`
+}
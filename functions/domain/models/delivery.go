@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Estados de una entrega en la cola
+const (
+    DeliveryPending    = "pending"
+    DeliveryProcessing = "processing"
+    DeliveryDead       = "dead"
+)
+
+// DeliveryAttempt registra el resultado de un intento de entrega
+type DeliveryAttempt struct {
+    At      time.Time `json:"at" firestore:"at"`
+    Success bool      `json:"success" firestore:"success"`
+    Error   string    `json:"error,omitempty" firestore:"error,omitempty"`
+}
+
+// DeliveryJob es la entrega de una notificación por un canal externo que se reintenta hasta lograrse
+type DeliveryJob struct {
+    ID            string              `json:"id" firestore:"-"`
+    UserID        string              `json:"user_id" firestore:"user_id"`
+    Channel       NotificationChannel `json:"channel" firestore:"channel"`
+    Notification  Notification        `json:"notification" firestore:"notification"`
+    Status        string              `json:"status" firestore:"status"`
+    Attempts      []DeliveryAttempt   `json:"attempts" firestore:"attempts"`
+    MaxAttempts   int                 `json:"max_attempts" firestore:"max_attempts"`
+    NextAttemptAt time.Time           `json:"next_attempt_at" firestore:"next_attempt_at"`
+    LastError     string              `json:"last_error,omitempty" firestore:"last_error,omitempty"`
+    CreatedAt     time.Time           `json:"created_at" firestore:"created_at"`
+    UpdatedAt     time.Time           `json:"updated_at" firestore:"updated_at"`
+}
+
+// RecordAttempt agrega el resultado de un intento al historial del trabajo
+func (j *DeliveryJob) RecordAttempt(at time.Time, err error) {
+    attempt := DeliveryAttempt{At: at, Success: err == nil}
+    if err != nil {
+        attempt.Error = err.Error()
+        j.LastError = attempt.Error
+    }
+    j.Attempts = append(j.Attempts, attempt)
+    j.UpdatedAt = at
+}
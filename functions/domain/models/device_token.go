@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Plataformas de los dispositivos que reciben notificaciones push
+const (
+    PlatformAndroid = "android"
+    PlatformIOS     = "ios"
+    PlatformWeb     = "web"
+)
+
+// DeviceToken representa un token FCM de un dispositivo de un usuario
+type DeviceToken struct {
+    ID         string    `json:"id" firestore:"-"`
+    UserID     string    `json:"user_id" firestore:"user_id"`
+    Token      string    `json:"token" firestore:"token"`
+    Platform   string    `json:"platform" firestore:"platform"`
+    LastSeenAt time.Time `json:"last_seen_at" firestore:"last_seen_at"`
+    CreatedAt  time.Time `json:"created_at" firestore:"created_at"`
+}
+
+// Validate verifica que el token del dispositivo sea válido
+func (d *DeviceToken) Validate() error {
+    if d.Token == "" {
+        return &ValidationError{Field: "Token", Message: "el token es obligatorio"}
+    }
+    switch d.Platform {
+    case PlatformAndroid, PlatformIOS, PlatformWeb:
+    default:
+        return &ValidationError{Field: "Platform", Message: "plataforma inválida"}
+    }
+    return nil
+}
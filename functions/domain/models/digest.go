@@ -0,0 +1,96 @@
+package models
+
+import (
+    "encoding/json"
+    "time"
+)
+
+// DigestNodeUpdate es una actualización publicada por un nodo que el usuario sigue
+type DigestNodeUpdate struct {
+    NodeID    string    `json:"node_id"`
+    NodeTitle string    `json:"node_title"`
+    Title     string    `json:"title"`
+    CreatedAt time.Time `json:"created_at"`
+}
+
+// DigestMilestone es un número de seguidores alcanzado por un nodo del usuario
+type DigestMilestone struct {
+    NodeID    string `json:"node_id"`
+    NodeTitle string `json:"node_title"`
+    Followers int    `json:"followers"`
+}
+
+// DigestAchievement es un logro desbloqueado por el usuario durante la semana
+type DigestAchievement struct {
+    AchievementID string    `json:"achievement_id"`
+    Name          string    `json:"name"`
+    Type          string    `json:"type"`
+    Points        int       `json:"points"`
+    UnlockedAt    time.Time `json:"unlocked_at"`
+}
+
+// DigestNode es un nodo sugerido en el resumen
+type DigestNode struct {
+    ID             string `json:"id"`
+    Title          string `json:"title"`
+    Type           string `json:"type"`
+    FollowersCount int    `json:"followers_count"`
+}
+
+// DigestNodeStats son las métricas de un nodo del usuario y su variación en la semana
+type DigestNodeStats struct {
+    NodeID  string             `json:"node_id"`
+    Title   string             `json:"title"`
+    Metrics InteractionMetrics `json:"metrics"`
+    Change  InteractionMetrics `json:"change"`
+}
+
+// WeeklyDigest es el resumen semanal de un usuario
+type WeeklyDigest struct {
+    From         time.Time           `json:"from"`
+    To           time.Time           `json:"to"`
+    Updates      []DigestNodeUpdate  `json:"updates"`
+    Milestones   []DigestMilestone   `json:"milestones"`
+    Achievements []DigestAchievement `json:"achievements"`
+    Trending     []DigestNode        `json:"trending"`
+    NodeStats    []DigestNodeStats   `json:"node_stats"`
+}
+
+// Highlights retorna cuántas novedades tiene el resumen, sin contar las estadísticas
+// de los nodos propios ni las sugerencias
+func (d *WeeklyDigest) Highlights() int {
+    return len(d.Updates) + len(d.Milestones) + len(d.Achievements)
+}
+
+// IsEmpty indica si el resumen no tiene nada que contar
+func (d *WeeklyDigest) IsEmpty() bool {
+    return d.Highlights() == 0 && len(d.Trending) == 0 && len(d.NodeStats) == 0
+}
+
+// ToData convierte el resumen al formato de Notification.Data
+func (d *WeeklyDigest) ToData() map[string]interface{} {
+    data := make(map[string]interface{})
+    if raw, err := json.Marshal(d); err == nil {
+        json.Unmarshal(raw, &data)
+    }
+    return data
+}
+
+// DigestFromData reconstruye el resumen guardado en Notification.Data,
+// tanto recién generado como leído de vuelta desde Firestore
+func DigestFromData(data map[string]interface{}) *WeeklyDigest {
+    var digest WeeklyDigest
+    if raw, err := json.Marshal(data); err == nil {
+        json.Unmarshal(raw, &digest)
+    }
+    return &digest
+}
+
+// DigestState recuerda el último resumen enviado a un usuario y las métricas
+// de sus nodos en ese momento, para calcular la variación de la semana siguiente
+type DigestState struct {
+    UserID     string                        `json:"user_id" firestore:"user_id"`
+    Week       string                        `json:"week" firestore:"week"`
+    LastSentAt time.Time                     `json:"last_sent_at" firestore:"last_sent_at"`
+    NodeStats  map[string]InteractionMetrics `json:"node_stats" firestore:"node_stats"`
+}
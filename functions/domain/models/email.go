@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Tipos de rebote de correo
+const (
+    BounceHard      = "hard"
+    BounceSoft      = "soft"
+    BounceComplaint = "complaint"
+)
+
+// EmailMessage representa un correo listo para enviarse
+type EmailMessage struct {
+    To       string
+    Subject  string
+    HTMLBody string
+    TextBody string
+    Headers  map[string]string
+}
+
+// EmailSuppression lleva el registro de rebotes y quejas de una dirección de correo.
+// Una dirección suprimida no vuelve a recibir correos.
+type EmailSuppression struct {
+    Email        string    `json:"email" firestore:"email"`
+    SoftBounces  int       `json:"soft_bounces" firestore:"soft_bounces"`
+    HardBounces  int       `json:"hard_bounces" firestore:"hard_bounces"`
+    Complaints   int       `json:"complaints" firestore:"complaints"`
+    Suppressed   bool      `json:"suppressed" firestore:"suppressed"`
+    Reason       string    `json:"reason,omitempty" firestore:"reason,omitempty"`
+    LastBounceAt time.Time `json:"last_bounce_at" firestore:"last_bounce_at"`
+    UpdatedAt    time.Time `json:"updated_at" firestore:"updated_at"`
+}
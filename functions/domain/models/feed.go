@@ -1,8 +1,18 @@
 package models
 
+import "time"
+
 // This file has been deprecated.
 // FeedFilters has been moved to requests.go
 
+// Tipos de items del feed
+const (
+    FeedItemNodeCreated  = "node_created"
+    FeedItemNodeUpdated  = "node_updated"
+    FeedItemNodeFollowed = "node_followed"
+    FeedItemLevelUp      = "level_up"
+)
+
 type FeedItem struct {
     ID        string      `json:"id" firestore:"id"`
     Type      string      `json:"type" firestore:"type"`
@@ -10,6 +20,10 @@ type FeedItem struct {
     UserID    string      `json:"user_id" firestore:"user_id"`
     Content   interface{} `json:"content" firestore:"content"`
     CreatedAt int64       `json:"created_at" firestore:"created_at"`
+    // Los siguientes campos solo se calculan al leer el feed agregado
+    Aggregated   bool     `json:"aggregated,omitempty" firestore:"-"`
+    Count        int      `json:"count,omitempty" firestore:"-"`
+    ActorSamples []string `json:"actor_samples,omitempty" firestore:"-"`
 }
 
 type FeedFilters struct {
@@ -25,3 +39,16 @@ type FeedFilters struct {
     Cursor    string `json:"cursor,omitempty"`
 }
 
+// FeedAggregationRule define cómo se agrupan los items de un mismo tipo sobre un mismo nodo.
+// Los items caen en ventanas alineadas a Window, por lo que un grupo es el mismo
+// sin importar la página en la que se lea.
+type FeedAggregationRule struct {
+    Window          time.Duration `json:"window"`
+    MaxActorSamples int           `json:"max_actor_samples"`
+}
+
+// FeedPage representa una página del feed con su cursor de continuación
+type FeedPage struct {
+    Items      []*FeedItem `json:"items"`
+    NextCursor string      `json:"next_cursor,omitempty"`
+}
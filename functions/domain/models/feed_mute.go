@@ -0,0 +1,83 @@
+package models
+
+import "time"
+
+// FeedMuteType representa el tipo de contenido que un usuario silencia en su feed
+type FeedMuteType string
+
+const (
+    // MuteFeedItem oculta un item específico del feed
+    MuteFeedItem FeedMuteType = "item"
+    // MuteNode silencia toda la actividad de un nodo
+    MuteNode FeedMuteType = "node"
+    // MuteNodeType marca un tipo de nodo como "no me interesa"
+    MuteNodeType FeedMuteType = "node_type"
+    // MuteTag marca una etiqueta como "no me interesa"
+    MuteTag FeedMuteType = "tag"
+)
+
+// FeedMute representa una preferencia negativa de un usuario sobre su feed
+type FeedMute struct {
+    ID        string       `json:"id" firestore:"id"`
+    UserID    string       `json:"user_id" firestore:"user_id"`
+    Type      FeedMuteType `json:"type" firestore:"type"`
+    Value     string       `json:"value" firestore:"value"`
+    CreatedAt time.Time    `json:"created_at" firestore:"created_at"`
+}
+
+// FeedMuteSet agrupa los silenciamientos de un usuario para consultarlos rápidamente
+type FeedMuteSet struct {
+    items     map[string]bool
+    nodes     map[string]bool
+    nodeTypes map[NodeType]bool
+    tags      map[string]bool
+}
+
+// NewFeedMuteSet crea un FeedMuteSet a partir de los silenciamientos de un usuario
+func NewFeedMuteSet(mutes []*FeedMute) *FeedMuteSet {
+    set := &FeedMuteSet{
+        items:     make(map[string]bool),
+        nodes:     make(map[string]bool),
+        nodeTypes: make(map[NodeType]bool),
+        tags:      make(map[string]bool),
+    }
+    for _, mute := range mutes {
+        switch mute.Type {
+        case MuteFeedItem:
+            set.items[mute.Value] = true
+        case MuteNode:
+            set.nodes[mute.Value] = true
+        case MuteNodeType:
+            set.nodeTypes[NodeType(mute.Value)] = true
+        case MuteTag:
+            set.tags[mute.Value] = true
+        }
+    }
+    return set
+}
+
+// NeedsNodeData indica si para filtrar items hace falta conocer el tipo o las etiquetas del nodo
+func (s *FeedMuteSet) NeedsNodeData() bool {
+    return len(s.nodeTypes) > 0 || len(s.tags) > 0
+}
+
+// HidesItem indica si un item del feed debe ocultarse. node puede ser nil si no se conoce.
+func (s *FeedMuteSet) HidesItem(item *FeedItem, node *Node) bool {
+    if s.items[item.ID] || s.nodes[item.NodeID] {
+        return true
+    }
+    return node != nil && s.HidesNode(node)
+}
+
+// HidesNode indica si un nodo debe ocultarse del feed
+func (s *FeedMuteSet) HidesNode(node *Node) bool {
+    if s.nodes[node.ID] || s.nodeTypes[node.Type] {
+        return true
+    }
+    for _, tag := range node.Tags {
+        if s.tags[tag] {
+            return true
+        }
+    }
+    return false
+}
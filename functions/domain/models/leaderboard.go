@@ -0,0 +1,131 @@
+package models
+
+import (
+    "fmt"
+    "sort"
+    "strings"
+    "time"
+)
+
+// Ventanas de tiempo de los rankings
+const (
+    LeaderboardAllTime = "all_time"
+    LeaderboardWeekly  = "weekly"
+    LeaderboardMonthly = "monthly"
+)
+
+// LeaderboardWindows son las ventanas de tiempo que se precalculan
+var LeaderboardWindows = []string{LeaderboardAllTime, LeaderboardWeekly, LeaderboardMonthly}
+
+// Alcances de los rankings. Los rankings por tipo de causa usan el alcance "type:<tipo>".
+const (
+    LeaderboardScopeGlobal     = "global"
+    LeaderboardScopeFollowing  = "following"
+    leaderboardScopeTypePrefix = "type:"
+)
+
+// LeaderboardScopeForType retorna el alcance del ranking de un tipo de causa
+func LeaderboardScopeForType(nodeType NodeType) string {
+    return leaderboardScopeTypePrefix + string(nodeType)
+}
+
+// LeaderboardID identifica un ranking precalculado, p. ej. "weekly_global" o "monthly_type:animal"
+func LeaderboardID(window string, scope string) string {
+    return window + "_" + scope
+}
+
+// ValidateLeaderboard verifica que la ventana y el alcance existan
+func ValidateLeaderboard(window string, scope string) error {
+    validWindow := false
+    for _, w := range LeaderboardWindows {
+        if w == window {
+            validWindow = true
+            break
+        }
+    }
+    if !validWindow {
+        return fmt.Errorf("ventana de ranking no soportada: %s", window)
+    }
+
+    if scope == LeaderboardScopeGlobal || scope == LeaderboardScopeFollowing {
+        return nil
+    }
+    if strings.HasPrefix(scope, leaderboardScopeTypePrefix) {
+        switch NodeType(strings.TrimPrefix(scope, leaderboardScopeTypePrefix)) {
+        case Social, Environmental, Animal:
+            return nil
+        }
+    }
+    return fmt.Errorf("alcance de ranking no soportado: %s", scope)
+}
+
+// LeaderboardWindowStart retorna el inicio de la ventana que contiene now, en UTC: el lunes
+// de la semana o el primer día del mes. Para el ranking histórico retorna el tiempo cero.
+func LeaderboardWindowStart(window string, now time.Time) time.Time {
+    now = now.UTC()
+    today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+    switch window {
+    case LeaderboardWeekly:
+        return today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+    case LeaderboardMonthly:
+        return today.AddDate(0, 0, 1-today.Day())
+    }
+    return time.Time{}
+}
+
+// Leaderboard describe un ranking precalculado
+type Leaderboard struct {
+    ID          string    `json:"id" firestore:"-"`
+    Window      string    `json:"window" firestore:"window"`
+    Scope       string    `json:"scope" firestore:"scope"`
+    WindowStart time.Time `json:"window_start" firestore:"window_start"`
+    Size        int       `json:"size" firestore:"size"`
+    ComputedAt  time.Time `json:"computed_at" firestore:"computed_at"`
+}
+
+// LeaderboardEntry es la posición de un usuario en un ranking. Position es compartida por
+// quienes empatan en puntos; Index es el orden único dentro del ranking, empezando en 0.
+// Level es el nivel del usuario según sus puntos totales.
+type LeaderboardEntry struct {
+    Board       string `json:"-" firestore:"board"`
+    UserID      string `json:"user_id" firestore:"user_id"`
+    DisplayName string `json:"display_name" firestore:"display_name"`
+    PhotoURL    string `json:"photo_url,omitempty" firestore:"photo_url,omitempty"`
+    Points      int    `json:"points" firestore:"points"`
+    Level       int    `json:"level" firestore:"level"`
+    Highlighted bool   `json:"highlighted,omitempty" firestore:"highlighted,omitempty"`
+    Position    int    `json:"position" firestore:"position"`
+    Index       int    `json:"-" firestore:"index"`
+}
+
+// LeaderboardPage es la respuesta de un ranking: los primeros puestos y la posición del
+// usuario con sus vecinos. Me es nil si el usuario no tiene puntos en la ventana.
+type LeaderboardPage struct {
+    Window     string              `json:"window"`
+    Scope      string              `json:"scope"`
+    ComputedAt time.Time           `json:"computed_at"`
+    Size       int                 `json:"size"`
+    Top        []*LeaderboardEntry `json:"top"`
+    Me         *LeaderboardEntry   `json:"me"`
+    Neighbors  []*LeaderboardEntry `json:"neighbors"`
+}
+
+// RankLeaderboard ordena las entradas por puntos, de mayor a menor y luego por usuario, y
+// les asigna Position e Index
+func RankLeaderboard(board string, entries []*LeaderboardEntry) {
+    sort.Slice(entries, func(i, j int) bool {
+        if entries[i].Points != entries[j].Points {
+            return entries[i].Points > entries[j].Points
+        }
+        return entries[i].UserID < entries[j].UserID
+    })
+    for i, entry := range entries {
+        entry.Board = board
+        entry.Index = i
+        if i > 0 && entry.Points == entries[i-1].Points {
+            entry.Position = entries[i-1].Position
+        } else {
+            entry.Position = i + 1
+        }
+    }
+}
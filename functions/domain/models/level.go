@@ -0,0 +1,124 @@
+package models
+
+import (
+    "fmt"
+    "time"
+)
+
+// LevelPerks son los beneficios de un nivel
+type LevelPerks struct {
+    // MaxNodes es el número máximo de nodos que puede crear el usuario; 0 es sin límite
+    MaxNodes int `json:"max_nodes" firestore:"max_nodes"`
+    // HighlightedProfile destaca el perfil del usuario
+    HighlightedProfile bool `json:"highlighted_profile" firestore:"highlighted_profile"`
+}
+
+// Level es un nivel de la curva, que se alcanza al sumar MinPoints puntos
+type Level struct {
+    Number    int        `json:"number" firestore:"number"`
+    Name      string     `json:"name" firestore:"name"`
+    MinPoints int        `json:"min_points" firestore:"min_points"`
+    Perks     LevelPerks `json:"perks" firestore:"perks"`
+}
+
+// LevelCurve es la lista de niveles ordenada por puntos
+type LevelCurve struct {
+    Levels    []Level   `json:"levels" firestore:"levels"`
+    UpdatedAt time.Time `json:"updated_at" firestore:"updated_at"`
+}
+
+// Validate verifica que la curva empiece en cero puntos, que cada nivel pida más puntos
+// que el anterior y que todos tengan nombre. Numera los niveles desde 1.
+func (c *LevelCurve) Validate() error {
+    if len(c.Levels) == 0 {
+        return fmt.Errorf("la curva debe tener al menos un nivel")
+    }
+    if c.Levels[0].MinPoints != 0 {
+        return fmt.Errorf("el primer nivel debe empezar en 0 puntos")
+    }
+    for i := range c.Levels {
+        level := &c.Levels[i]
+        if level.Name == "" {
+            return fmt.Errorf("el nivel %d no tiene nombre", i+1)
+        }
+        if i > 0 && level.MinPoints <= c.Levels[i-1].MinPoints {
+            return fmt.Errorf("el nivel %d debe pedir más puntos que el anterior", i+1)
+        }
+        if level.Perks.MaxNodes < 0 {
+            return fmt.Errorf("el máximo de nodos del nivel %d no puede ser negativo", i+1)
+        }
+        level.Number = i + 1
+    }
+    return nil
+}
+
+// ForPoints retorna el nivel que corresponde a points y el siguiente, o nil si es el último
+func (c *LevelCurve) ForPoints(points int) (*Level, *Level) {
+    current := 0
+    for i, level := range c.Levels {
+        if points >= level.MinPoints {
+            current = i
+        }
+    }
+    if current+1 < len(c.Levels) {
+        return &c.Levels[current], &c.Levels[current+1]
+    }
+    return &c.Levels[current], nil
+}
+
+// LevelFor retorna el número del nivel que corresponde a points
+func (c *LevelCurve) LevelFor(points int) int {
+    level, _ := c.ForPoints(points)
+    return level.Number
+}
+
+// Progress retorna el nivel de points y el avance hacia el siguiente
+func (c *LevelCurve) Progress(points int) *LevelProgress {
+    level, next := c.ForPoints(points)
+    progress := &LevelProgress{
+        Level:     level.Number,
+        Name:      level.Name,
+        Perks:     level.Perks,
+        Points:    points,
+        MinPoints: level.MinPoints,
+        Progress:  1,
+    }
+    if next != nil {
+        progress.NextLevel = next.Number
+        progress.NextName = next.Name
+        progress.NextMinPoints = next.MinPoints
+        progress.PointsToNext = next.MinPoints - points
+        progress.Progress = float64(points-level.MinPoints) / float64(next.MinPoints-level.MinPoints)
+    }
+    return progress
+}
+
+// LevelProgress es el nivel actual de un usuario y su avance hacia el siguiente.
+// Progress va de 0 a 1 y vale 1 en el último nivel.
+type LevelProgress struct {
+    Level         int        `json:"level"`
+    Name          string     `json:"name"`
+    Perks         LevelPerks `json:"perks"`
+    Points        int        `json:"points"`
+    MinPoints     int        `json:"min_points"`
+    NextLevel     int        `json:"next_level,omitempty"`
+    NextName      string     `json:"next_name,omitempty"`
+    NextMinPoints int        `json:"next_min_points,omitempty"`
+    PointsToNext  int        `json:"points_to_next,omitempty"`
+    Progress      float64    `json:"progress"`
+}
+
+// DefaultLevelCurve retorna la curva de niveles usada mientras no se configure otra. No
+// limita los nodos: los puntos de los usuarios existentes pueden no estar reconciliados y
+// los límites solo se aplican con una curva configurada.
+func DefaultLevelCurve() *LevelCurve {
+    return &LevelCurve{
+        Levels: []Level{
+            {Number: 1, Name: "Semilla", MinPoints: 0},
+            {Number: 2, Name: "Brote", MinPoints: 100},
+            {Number: 3, Name: "Raíz", MinPoints: 500},
+            {Number: 4, Name: "Árbol", MinPoints: 1500, Perks: LevelPerks{HighlightedProfile: true}},
+            {Number: 5, Name: "Bosque", MinPoints: 5000, Perks: LevelPerks{HighlightedProfile: true}},
+        },
+    }
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Tipos de eventos que se envían en vivo a los clientes conectados
+const (
+    LiveEventNotification = "notification"
+    LiveEventFeedItem     = "feed_item"
+    LiveEventUnreadCount  = "unread_count"
+)
+
+// LiveEvent representa un evento dirigido a un usuario que se entrega por el stream en vivo
+type LiveEvent struct {
+    ID        string      `json:"id" firestore:"-"`
+    UserID    string      `json:"user_id" firestore:"user_id"`
+    Type      string      `json:"type" firestore:"type"`
+    Data      interface{} `json:"data" firestore:"data"`
+    CreatedAt time.Time   `json:"created_at" firestore:"created_at"`
+    // ExpiresAt permite configurar una política TTL sobre los eventos persistidos
+    ExpiresAt time.Time `json:"-" firestore:"expires_at"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// Estados de una recolección de archivos huérfanos
+const (
+    MediaGCRunning   = "running"
+    MediaGCCompleted = "completed"
+    MediaGCFailed    = "failed"
+)
+
+// MediaGCRun es una recolección de los archivos del storage que ningún nodo, producto,
+// tienda, actualización o usuario referencia. Los archivos huérfanos se marcan en una
+// ejecución y se eliminan en una posterior si siguen sin referencias. En DryRun no marca
+// ni elimina nada: los contadores indican lo que haría.
+type MediaGCRun struct {
+    ID       string   `json:"id" firestore:"-"`
+    DryRun   bool     `json:"dry_run" firestore:"dry_run"`
+    Status   string   `json:"status" firestore:"status"`
+    Prefixes []string `json:"prefixes" firestore:"prefixes"`
+    // References es la cantidad de archivos distintos referenciados desde Firestore
+    References int `json:"references" firestore:"references"`
+    // Scanned y ScannedBytes cuentan los archivos originales recorridos; los thumbnails se
+    // eliminan junto con su original
+    Scanned      int   `json:"scanned" firestore:"scanned"`
+    ScannedBytes int64 `json:"scanned_bytes" firestore:"scanned_bytes"`
+    Referenced   int   `json:"referenced" firestore:"referenced"`
+    // Recent son los archivos sin referencias que aún están en el período de gracia
+    Recent      int   `json:"recent" firestore:"recent"`
+    Marked      int   `json:"marked" firestore:"marked"`
+    MarkedBytes int64 `json:"marked_bytes" firestore:"marked_bytes"`
+    // Pending son los archivos marcados antes que se eliminarán en una próxima ejecución
+    Pending int `json:"pending" firestore:"pending"`
+    // Unmarked son los archivos marcados que volvieron a tener referencias
+    Unmarked     int   `json:"unmarked" firestore:"unmarked"`
+    Deleted      int   `json:"deleted" firestore:"deleted"`
+    DeletedBytes int64 `json:"deleted_bytes" firestore:"deleted_bytes"`
+    Failed       int   `json:"failed" firestore:"failed"`
+    // Orphans son algunas de las rutas marcadas o eliminadas, para revisar el resultado
+    Orphans     []string   `json:"orphans" firestore:"orphans"`
+    LastError   string     `json:"last_error,omitempty" firestore:"last_error,omitempty"`
+    CreatedBy   string     `json:"created_by" firestore:"created_by"`
+    CreatedAt   time.Time  `json:"created_at" firestore:"created_at"`
+    UpdatedAt   time.Time  `json:"updated_at" firestore:"updated_at"`
+    CompletedAt *time.Time `json:"completed_at,omitempty" firestore:"completed_at,omitempty"`
+}
+
+// OrphanedMedia es un archivo del storage que una recolección encontró sin referencias
+type OrphanedMedia struct {
+    Path     string    `json:"path" firestore:"path"`
+    Size     int64     `json:"size" firestore:"size"`
+    RunID    string    `json:"run_id" firestore:"run_id"`
+    MarkedAt time.Time `json:"marked_at" firestore:"marked_at"`
+}
@@ -46,6 +46,9 @@ type Node struct {
 	Products []string `firestore:"products" json:"products"`
 	// Images es una lista de recursos multimedia asociados al nodo
 	Images []string `firestore:"images" json:"images"`
+	// MediaDetails son las dimensiones, el placeholder y los thumbnails de Media e Images.
+	// Se completan al procesar cada imagen subida para el nodo.
+	MediaDetails []MediaURL `firestore:"mediaDetails,omitempty" json:"mediaDetails,omitempty"`
 	// ApprovalConfig contiene la configuración de aprobación para productos
 	ApprovalConfig ApprovalConfig `firestore:"approvalConfig" json:"approvalConfig"`
 	// Metrics contiene las métricas de interacción del nodo
@@ -2,6 +2,21 @@ package models
 
 import "time"
 
+// Tipos de notificación
+const (
+    NotificationWelcome           = "welcome"
+    NotificationNodeCreated       = "node_created"
+    NotificationNodeUpdated       = "node_updated"
+    NotificationNodeDeleted       = "node_deleted"
+    NotificationWeeklyDigest      = "weekly_digest"
+    NotificationAchievement       = "achievement"
+    NotificationFollowerMilestone = "achievement_followers"
+    NotificationComment           = "comment"
+    NotificationProductApproved   = "product_approved"
+    NotificationProductRejected   = "product_rejected"
+    NotificationLevelUp           = "level_up"
+)
+
 type Notification struct {
     ID          string                 `json:"id" firestore:"id"`
     Type        string                 `json:"type" firestore:"type"`
@@ -9,7 +24,49 @@ type Notification struct {
     Title       string                 `json:"title" firestore:"title"`
     Description string                 `json:"description" firestore:"description"`
     Data        map[string]interface{} `json:"data,omitempty" firestore:"data,omitempty"`
+    // Params son los parámetros con los que se traducen Title y Description desde el catálogo
+    // cuando la notificación se crea sin ellos
+    Params      map[string]interface{} `json:"params,omitempty" firestore:"params,omitempty"`
+    // Target identifica el objeto de la notificación (por ejemplo el ID del nodo) para agruparla
+    Target      string                 `json:"target,omitempty" firestore:"target,omitempty"`
+    // Count es el número de eventos agrupados en esta notificación
+    Count       int                    `json:"count,omitempty" firestore:"count,omitempty"`
     CreatedAt   time.Time             `json:"created_at" firestore:"created_at"`
     Read        bool                  `json:"read" firestore:"read"`
     ReadAt      *time.Time            `json:"read_at,omitempty" firestore:"read_at,omitempty"`
 }
+
+// NotificationFilters define los filtros de la bandeja de notificaciones de un usuario
+type NotificationFilters struct {
+    UserID   string `json:"user_id"`
+    Type     string `json:"type"`
+    // Read filtra por estado de lectura; nil incluye leídas y no leídas
+    Read     *bool  `json:"read,omitempty"`
+    PageSize int    `json:"page_size"`
+    Cursor   string `json:"cursor"`
+}
+
+// NotificationPage es una página de la bandeja de notificaciones
+type NotificationPage struct {
+    Items      []*Notification `json:"items"`
+    NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+// CoalescingRule define cómo se agrupan las notificaciones de un tipo
+type CoalescingRule struct {
+    // Window es el tiempo que se espera agrupando eventos antes de entregar la notificación
+    Window time.Duration
+}
+
+// PendingNotification es una notificación agrupada que espera a que se cierre su ventana
+type PendingNotification struct {
+    ID           string       `json:"id" firestore:"-"`
+    UserID       string       `json:"user_id" firestore:"user_id"`
+    Type         string       `json:"type" firestore:"type"`
+    Target       string       `json:"target" firestore:"target"`
+    Notification Notification `json:"notification" firestore:"notification"`
+    Count        int          `json:"count" firestore:"count"`
+    FirstAt      time.Time    `json:"first_at" firestore:"first_at"`
+    LastAt       time.Time    `json:"last_at" firestore:"last_at"`
+    DeliverAt    time.Time    `json:"deliver_at" firestore:"deliver_at"`
+}
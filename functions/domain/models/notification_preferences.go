@@ -0,0 +1,205 @@
+package models
+
+import (
+    "fmt"
+    "time"
+)
+
+// NotificationChannel representa un canal por el que se entrega una notificación
+type NotificationChannel string
+
+const (
+    // ChannelInApp guarda la notificación en la bandeja de la aplicación
+    ChannelInApp NotificationChannel = "in_app"
+    // ChannelPush envía la notificación por FCM
+    ChannelPush NotificationChannel = "push"
+    // ChannelEmail envía la notificación por correo electrónico
+    ChannelEmail NotificationChannel = "email"
+)
+
+// NotificationChannels lista todos los canales soportados
+var NotificationChannels = []NotificationChannel{ChannelInApp, ChannelPush, ChannelEmail}
+
+// QuietHours define un rango horario diario, en la zona horaria del usuario,
+// durante el cual no se envían notificaciones push ni correos
+type QuietHours struct {
+    Enabled bool   `json:"enabled" firestore:"enabled"`
+    Start   string `json:"start" firestore:"start"` // Formato HH:MM
+    End     string `json:"end" firestore:"end"`     // Formato HH:MM
+}
+
+// NotificationPreferences contiene las preferencias de notificación de un usuario
+type NotificationPreferences struct {
+    UserID   string `json:"user_id" firestore:"user_id"`
+    TimeZone string `json:"time_zone" firestore:"time_zone"`
+    // Types indica por tipo de notificación qué canales están habilitados.
+    // Los tipos o canales ausentes usan el valor por defecto del canal.
+    Types      map[string]map[NotificationChannel]bool `json:"types" firestore:"types"`
+    QuietHours QuietHours                              `json:"quiet_hours" firestore:"quiet_hours"`
+    // EmailUnsubscribed desactiva todos los correos, sin importar lo indicado en Types
+    EmailUnsubscribed bool      `json:"email_unsubscribed" firestore:"email_unsubscribed"`
+    UpdatedAt         time.Time `json:"updated_at" firestore:"updated_at"`
+}
+
+// NotificationPreferencesUpdate son los cambios de preferencias que envía el usuario. Los
+// campos ausentes conservan su valor y en Types solo cambian los canales indicados.
+// EmailUnsubscribed no se acepta: solo cambia con el enlace de baja de los correos.
+type NotificationPreferencesUpdate struct {
+    TimeZone   *string                                 `json:"time_zone"`
+    Types      map[string]map[NotificationChannel]bool `json:"types"`
+    QuietHours *QuietHours                             `json:"quiet_hours"`
+}
+
+// Apply aplica los cambios a las preferencias guardadas
+func (u *NotificationPreferencesUpdate) Apply(preferences *NotificationPreferences) {
+    if u.TimeZone != nil {
+        preferences.TimeZone = *u.TimeZone
+    }
+    if u.QuietHours != nil {
+        preferences.QuietHours = *u.QuietHours
+    }
+    if len(u.Types) > 0 && preferences.Types == nil {
+        preferences.Types = make(map[string]map[NotificationChannel]bool)
+    }
+    for notificationType, channels := range u.Types {
+        if preferences.Types[notificationType] == nil {
+            preferences.Types[notificationType] = make(map[NotificationChannel]bool)
+        }
+        for channel, enabled := range channels {
+            preferences.Types[notificationType][channel] = enabled
+        }
+    }
+}
+
+// DefaultNotificationPreferences retorna las preferencias de un usuario que nunca las configuró
+func DefaultNotificationPreferences(userID string) *NotificationPreferences {
+    return &NotificationPreferences{
+        UserID:   userID,
+        TimeZone: "UTC",
+        Types:    make(map[string]map[NotificationChannel]bool),
+        QuietHours: QuietHours{
+            Enabled: false,
+            Start:   "22:00",
+            End:     "08:00",
+        },
+    }
+}
+
+// IsEnabled indica si un tipo de notificación se entrega por un canal
+func (p *NotificationPreferences) IsEnabled(notificationType string, channel NotificationChannel) bool {
+    if channel == ChannelEmail && p.EmailUnsubscribed {
+        return false
+    }
+    if channels, ok := p.Types[notificationType]; ok {
+        if enabled, ok := channels[channel]; ok {
+            return enabled
+        }
+    }
+    // Por defecto solo el correo está desactivado, salvo para los mensajes de baja frecuencia
+    if channel == ChannelEmail {
+        switch notificationType {
+        case NotificationWelcome, NotificationWeeklyDigest, NotificationProductApproved, NotificationProductRejected:
+            return true
+        }
+        return false
+    }
+    return true
+}
+
+// Location retorna la zona horaria del usuario, o UTC si no es válida
+func (p *NotificationPreferences) Location() *time.Location {
+    if loc, err := time.LoadLocation(p.TimeZone); err == nil && p.TimeZone != "" {
+        return loc
+    }
+    return time.UTC
+}
+
+// QuietHoursEnd indica si now cae dentro de las horas de silencio y, en ese caso,
+// el instante en que terminan
+func (p *NotificationPreferences) QuietHoursEnd(now time.Time) (time.Time, bool) {
+    if !p.QuietHours.Enabled {
+        return time.Time{}, false
+    }
+    start, err := parseClock(p.QuietHours.Start)
+    if err != nil {
+        return time.Time{}, false
+    }
+    end, err := parseClock(p.QuietHours.End)
+    if err != nil || start == end {
+        return time.Time{}, false
+    }
+
+    local := now.In(p.Location())
+    current := local.Hour()*60 + local.Minute()
+
+    var inQuietHours bool
+    if start < end {
+        inQuietHours = current >= start && current < end
+    } else {
+        // El rango cruza la medianoche
+        inQuietHours = current >= start || current < end
+    }
+    if !inQuietHours {
+        return time.Time{}, false
+    }
+
+    endTime := time.Date(local.Year(), local.Month(), local.Day(), end/60, end%60, 0, 0, local.Location())
+    if !endTime.After(local) {
+        endTime = endTime.AddDate(0, 0, 1)
+    }
+    return endTime, true
+}
+
+// Validate verifica que las preferencias sean válidas
+func (p *NotificationPreferences) Validate() error {
+    if p.TimeZone != "" {
+        if _, err := time.LoadLocation(p.TimeZone); err != nil {
+            return &ValidationError{Field: "TimeZone", Message: "zona horaria inválida"}
+        }
+    }
+    if _, err := parseClock(p.QuietHours.Start); err != nil {
+        return &ValidationError{Field: "QuietHours.Start", Message: "la hora debe tener el formato HH:MM"}
+    }
+    if _, err := parseClock(p.QuietHours.End); err != nil {
+        return &ValidationError{Field: "QuietHours.End", Message: "la hora debe tener el formato HH:MM"}
+    }
+    for notificationType, channels := range p.Types {
+        for channel := range channels {
+            if !isNotificationChannel(channel) {
+                return &ValidationError{
+                    Field:   fmt.Sprintf("Types[%s]", notificationType),
+                    Message: fmt.Sprintf("canal inválido: %s", channel),
+                }
+            }
+        }
+    }
+    return nil
+}
+
+// DeferredNotification representa una notificación retenida por las horas de silencio
+type DeferredNotification struct {
+    ID           string              `json:"id" firestore:"id"`
+    UserID       string              `json:"user_id" firestore:"user_id"`
+    Channel      NotificationChannel `json:"channel" firestore:"channel"`
+    Notification Notification        `json:"notification" firestore:"notification"`
+    DeliverAt    time.Time           `json:"deliver_at" firestore:"deliver_at"`
+    CreatedAt    time.Time           `json:"created_at" firestore:"created_at"`
+}
+
+func isNotificationChannel(channel NotificationChannel) bool {
+    for _, c := range NotificationChannels {
+        if c == channel {
+            return true
+        }
+    }
+    return false
+}
+
+// parseClock convierte una hora HH:MM en minutos desde la medianoche
+func parseClock(value string) (int, error) {
+    t, err := time.Parse("15:04", value)
+    if err != nil {
+        return 0, err
+    }
+    return t.Hour()*60 + t.Minute(), nil
+}
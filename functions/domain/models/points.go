@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Motivos de un movimiento de puntos
+const (
+    PointsReasonAchievement = "achievement"
+    PointsReasonAdjustment  = "adjustment"
+)
+
+// PointsEntry es un movimiento del historial de puntos de un usuario. El historial solo
+// crece: las correcciones se registran como movimientos nuevos, con puntos negativos si hace falta.
+type PointsEntry struct {
+    ID     string `json:"id" firestore:"-"`
+    UserID string `json:"user_id" firestore:"user_id"`
+    Points int    `json:"points" firestore:"points"`
+    Reason string `json:"reason" firestore:"reason"`
+    // Reference identifica el objeto que originó el movimiento, p. ej. el ID del logro
+    Reference string `json:"reference,omitempty" firestore:"reference,omitempty"`
+    // Key es la clave de idempotencia: un mismo usuario no puede tener dos movimientos con la misma
+    Key       string    `json:"key" firestore:"key"`
+    CreatedAt time.Time `json:"created_at" firestore:"created_at"`
+}
+
+// PointsAward es el resultado de registrar un movimiento de puntos
+type PointsAward struct {
+    Total int
+    // Created es false si ya existía un movimiento con la misma clave y no se sumó nada
+    Created bool
+    // PreviousLevel y Level son el nivel del usuario antes y después del movimiento
+    PreviousLevel int
+    Level         int
+}
+
+// PointsPage es una página del historial de puntos
+type PointsPage struct {
+    Total      int            `json:"total"`
+    Items      []*PointsEntry `json:"items"`
+    NextCursor string         `json:"next_cursor,omitempty"`
+}
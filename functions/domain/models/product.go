@@ -20,6 +20,7 @@ type Product struct {
 	Description     string              `json:"description" firestore:"description"` // Descripción detallada
 	Price           float64             `json:"price" firestore:"price"`         // Precio en la moneda predeterminada
 	Images          []string            `json:"images" firestore:"images"`       // URLs de las imágenes
+	MediaDetails    []MediaURL          `json:"mediaDetails,omitempty" firestore:"mediaDetails,omitempty"` // Dimensiones, placeholder y thumbnails de las imágenes
 	Contact         contact.ContactInfo `json:"contact" firestore:"contact"`     // Información de contacto
 	DonationPercent int                `json:"donationPercent" firestore:"donationPercent"` // Porcentaje para donación (1-100)
 	ApprovalStatus  string             `json:"approvalStatus" firestore:"approvalStatus"`   // Estado de aprobación
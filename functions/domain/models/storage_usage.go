@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// StorageUsage es el espacio que ocupan los archivos que subió un usuario. Se actualiza
+// con los eventos de Storage al crearse y eliminarse cada archivo.
+type StorageUsage struct {
+    UserID    string    `json:"user_id" firestore:"-"`
+    UsedBytes int64     `json:"used_bytes" firestore:"used_bytes"`
+    Files     int       `json:"files" firestore:"files"`
+    UpdatedAt time.Time `json:"updated_at" firestore:"updated_at"`
+    // QuotaBytes es la cuota configurada; 0 si no hay límite. No se guarda.
+    QuotaBytes int64 `json:"quota_bytes" firestore:"-"`
+}
+
+// Exceeds indica si subir size bytes más superaría la cuota
+func (u *StorageUsage) Exceeds(size int64) bool {
+    return u.QuotaBytes > 0 && u.UsedBytes+size > u.QuotaBytes
+}
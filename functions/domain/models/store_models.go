@@ -14,6 +14,60 @@ type MediaURL struct {
 	Type        string `json:"type" firestore:"type"`             // Tipo de medio (image, video, etc.)
 	Description string `json:"description" firestore:"description"` // Descripción opcional del recurso
 	Thumbnail   string `json:"thumbnail,omitempty" firestore:"thumbnail,omitempty"` // URL de la miniatura (opcional)
+	// Datos calculados al generar los thumbnails para que los clientes reserven el espacio
+	// de la imagen y muestren un placeholder mientras carga
+	Width         int    `json:"width,omitempty" firestore:"width,omitempty"`                 // Ancho en píxeles
+	Height        int    `json:"height,omitempty" firestore:"height,omitempty"`               // Alto en píxeles
+	BlurHash      string `json:"blurHash,omitempty" firestore:"blurHash,omitempty"`           // BlurHash de la imagen
+	DominantColor string `json:"dominantColor,omitempty" firestore:"dominantColor,omitempty"` // Color dominante (#rrggbb)
+	// Variants son los thumbnails generados en cada tamaño y formato
+	Variants []MediaVariant `json:"variants,omitempty" firestore:"variants,omitempty"`
+}
+
+// MediaRewrite registra el reemplazo de una imagen original por su copia sin metadatos,
+// para que el evento que dispara no se confunda con una subida del cliente. MD5 se guarda
+// antes de escribir la copia y Generation, la versión que resultó, después.
+type MediaRewrite struct {
+	MD5        string `json:"md5" firestore:"md5"`
+	Generation int64  `json:"generation" firestore:"generation"`
+}
+
+// MediaVariant es un thumbnail de una imagen en un tamaño y formato
+type MediaVariant struct {
+	Size   string `json:"size" firestore:"size"`     // Nombre del tamaño (small, medium, large...)
+	Format string `json:"format" firestore:"format"` // Formato de la imagen (jpeg, png, gif, webp)
+	URL    string `json:"url" firestore:"url"`       // URL del thumbnail
+	Width  int    `json:"width" firestore:"width"`   // Ancho en píxeles
+	Height int    `json:"height" firestore:"height"` // Alto en píxeles
+}
+
+// Dueños de las imágenes subidas. Una imagen pertenece a un nodo o producto si se sube con
+// el metadato nodeId o productId, o en users/{userId}/nodes/{nodeId}/ o
+// users/{userId}/products/{productId}/.
+const (
+	MediaOwnerNode    = "nodes"
+	MediaOwnerProduct = "products"
+)
+
+// UpsertMedia reemplaza en la lista el recurso con la misma URL o lo agrega al final
+func UpsertMedia(media []MediaURL, item MediaURL) []MediaURL {
+	for i := range media {
+		if media[i].URL == item.URL {
+			media[i] = item
+			return media
+		}
+	}
+	return append(media, item)
+}
+
+// RemoveMedia quita de la lista el recurso con la URL indicada
+func RemoveMedia(media []MediaURL, mediaURL string) []MediaURL {
+	for i := range media {
+		if media[i].URL == mediaURL {
+			return append(media[:i], media[i+1:]...)
+		}
+	}
+	return media
 }
 
 // Store representa una tienda en el sistema.
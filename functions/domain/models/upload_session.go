@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Estados de una sesión de subida
+const (
+    // UploadSessionPending espera a que el cliente suba el archivo con la URL firmada
+    UploadSessionPending = "pending"
+    // UploadSessionUploaded indica que el archivo llegó al storage con el tamaño y el hash declarados
+    UploadSessionUploaded = "uploaded"
+    // UploadSessionCompleted indica que el cliente confirmó la subida
+    UploadSessionCompleted = "completed"
+    // UploadSessionRejected indica que el archivo no coincidía con lo declarado y se eliminó
+    UploadSessionRejected = "rejected"
+)
+
+// UploadSession es una subida directa al storage con una URL firmada. Se guarda en la
+// colección de archivos temporales: si el cliente no la confirma, la limpieza diaria
+// elimina el archivo.
+type UploadSession struct {
+    ID          string `json:"id" firestore:"-"`
+    UserID      string `json:"user_id" firestore:"user_id"`
+    Path        string `json:"path" firestore:"path"`
+    Filename    string `json:"filename" firestore:"filename"`
+    ContentType string `json:"content_type" firestore:"content_type"`
+    Size        int64  `json:"size" firestore:"size"`
+    // MD5 es el hash MD5 del archivo en base64, como lo reporta Storage
+    MD5    string `json:"md5" firestore:"md5"`
+    Status string `json:"status" firestore:"status"`
+    URL    string `json:"url" firestore:"url"`
+    Error  string `json:"error,omitempty" firestore:"error,omitempty"`
+    // UploadURL es la URL firmada para subir el archivo con PUT. No se guarda.
+    UploadURL   string     `json:"upload_url,omitempty" firestore:"-"`
+    ExpiresAt   time.Time  `json:"expires_at" firestore:"expires_at"`
+    CreatedAt   time.Time  `json:"created_at" firestore:"created_at"`
+    UpdatedAt   time.Time  `json:"updated_at" firestore:"updated_at"`
+    CompletedAt *time.Time `json:"completed_at,omitempty" firestore:"completed_at,omitempty"`
+}
+
+// UploadSessionRequest son los datos que declara el cliente para subir un archivo. Con
+// NodeID o ProductID la imagen se agrega a ese nodo o producto al procesarse.
+type UploadSessionRequest struct {
+    Filename    string `json:"filename"`
+    ContentType string `json:"content_type"`
+    Size        int64  `json:"size"`
+    MD5         string `json:"md5"`
+    NodeID      string `json:"node_id,omitempty"`
+    ProductID   string `json:"product_id,omitempty"`
+}
@@ -15,7 +15,10 @@ type User struct {
     StoreID       string           `json:"storeId,omitempty" firestore:"storeId,omitempty"`
     Achievements  []UserAchievement `json:"achievements" firestore:"achievements"`
     Points        int              `json:"points" firestore:"points"`
+    // Level es el número del nivel que corresponde a Points según la curva de niveles
+    Level         int              `json:"level" firestore:"level"`
     Role          string           `json:"role" firestore:"role"`
+    // Deprecated: los tokens de cada dispositivo se guardan en DeviceToken
     FCMToken      string           `json:"fcmToken,omitempty" firestore:"fcmToken,omitempty"`
     CreatedAt     time.Time        `json:"createdAt" firestore:"createdAt"`
     UpdatedAt     time.Time        `json:"updatedAt" firestore:"updatedAt"`
@@ -26,12 +29,15 @@ type User struct {
 type Profile struct {
     Bio         string   `json:"bio" firestore:"bio"`
     Interests   []string `json:"interests" firestore:"interests"`
+    // Language es el idioma preferido para las notificaciones (es, en)
+    Language    string   `json:"language,omitempty" firestore:"language,omitempty"`
 }
 
 type UserAchievement struct {
     ID            string          `json:"id" firestore:"id"`
     UserID        string          `json:"userId" firestore:"userId"`
     AchievementID string          `json:"achievementId" firestore:"achievementId"`
+    Name          string          `json:"name,omitempty" firestore:"name,omitempty"`
     Type          AchievementType `json:"type" firestore:"type"`
     Points        int             `json:"points" firestore:"points"`
     UnlockedAt    time.Time       `json:"unlockedAt" firestore:"unlockedAt"`
@@ -43,4 +49,6 @@ type UserMetrics struct {
     Comments         int `json:"comments" firestore:"comments"`
     Shares           int `json:"shares" firestore:"shares"`
     Views            int `json:"views" firestore:"views"`
+    // StreakDays son los días consecutivos con actividad
+    StreakDays       int `json:"streakDays" firestore:"streakDays"`
 }
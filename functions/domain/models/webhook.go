@@ -0,0 +1,134 @@
+package models
+
+import (
+    "net/url"
+    "time"
+)
+
+// Tipos de eventos que se pueden enviar por webhook
+const (
+    WebhookEventNodeFollowed    = "node.followed"
+    WebhookEventCommentCreated  = "comment.created"
+    WebhookEventProductLinked   = "product.linked"
+    WebhookEventProductApproved = "product.approved"
+    WebhookEventProductRejected = "product.rejected"
+    // WebhookEventTest solo se envía desde el endpoint de prueba
+    WebhookEventTest = "webhook.test"
+)
+
+// WebhookEventTypes lista los eventos a los que se puede suscribir un webhook
+var WebhookEventTypes = []string{
+    WebhookEventNodeFollowed,
+    WebhookEventCommentCreated,
+    WebhookEventProductLinked,
+    WebhookEventProductApproved,
+    WebhookEventProductRejected,
+}
+
+// Dueños posibles de una suscripción de webhook
+const (
+    WebhookOwnerNode  = "node"
+    WebhookOwnerStore = "store"
+)
+
+// Estados de una entrega de webhook
+const (
+    WebhookDeliveryPending   = "pending"
+    WebhookDeliverySucceeded = "succeeded"
+    WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookSubscription es un endpoint externo que recibe los eventos de un nodo o una tienda
+type WebhookSubscription struct {
+    ID        string   `json:"id" firestore:"-"`
+    OwnerType string   `json:"owner_type" firestore:"owner_type"`
+    OwnerID   string   `json:"owner_id" firestore:"owner_id"`
+    UserID    string   `json:"user_id" firestore:"user_id"`
+    URL       string   `json:"url" firestore:"url"`
+    Events    []string `json:"events" firestore:"events"`
+    // Secret firma las entregas; solo se muestra al crear la suscripción
+    Secret    string    `json:"secret,omitempty" firestore:"secret"`
+    Active    bool      `json:"active" firestore:"active"`
+    CreatedAt time.Time `json:"created_at" firestore:"created_at"`
+    UpdatedAt time.Time `json:"updated_at" firestore:"updated_at"`
+}
+
+// Validate verifica que la suscripción sea válida
+func (s *WebhookSubscription) Validate() error {
+    if s.OwnerType != WebhookOwnerNode && s.OwnerType != WebhookOwnerStore {
+        return &ValidationError{Field: "OwnerType", Message: "debe ser node o store"}
+    }
+    if s.OwnerID == "" {
+        return &ValidationError{Field: "OwnerID", Message: "es obligatorio"}
+    }
+    target, err := url.Parse(s.URL)
+    if err != nil || (target.Scheme != "https" && target.Scheme != "http") || target.Host == "" {
+        return &ValidationError{Field: "URL", Message: "debe ser una URL http o https"}
+    }
+    if len(s.Events) == 0 {
+        return &ValidationError{Field: "Events", Message: "debe incluir al menos un evento"}
+    }
+    for _, event := range s.Events {
+        if !isWebhookEventType(event) {
+            return &ValidationError{Field: "Events", Message: "evento desconocido: " + event}
+        }
+    }
+    return nil
+}
+
+// Subscribes indica si la suscripción recibe un tipo de evento. El evento de prueba
+// se acepta siempre.
+func (s *WebhookSubscription) Subscribes(eventType string) bool {
+    if eventType == WebhookEventTest {
+        return true
+    }
+    for _, event := range s.Events {
+        if event == eventType {
+            return true
+        }
+    }
+    return false
+}
+
+func isWebhookEventType(eventType string) bool {
+    for _, known := range WebhookEventTypes {
+        if known == eventType {
+            return true
+        }
+    }
+    return false
+}
+
+// WebhookEvent es un evento enviado a los webhooks de un nodo o una tienda.
+// El ID es estable entre reintentos para que el receptor pueda descartar duplicados.
+type WebhookEvent struct {
+    ID        string                 `json:"id" firestore:"id"`
+    Type      string                 `json:"type" firestore:"type"`
+    OwnerType string                 `json:"owner_type" firestore:"owner_type"`
+    OwnerID   string                 `json:"owner_id" firestore:"owner_id"`
+    CreatedAt time.Time              `json:"created_at" firestore:"created_at"`
+    Data      map[string]interface{} `json:"data" firestore:"data"`
+}
+
+// WebhookAttempt registra el resultado de un intento de entrega
+type WebhookAttempt struct {
+    At           time.Time `json:"at" firestore:"at"`
+    StatusCode   int       `json:"status_code,omitempty" firestore:"status_code,omitempty"`
+    Error        string    `json:"error,omitempty" firestore:"error,omitempty"`
+    ResponseBody string    `json:"response_body,omitempty" firestore:"response_body,omitempty"`
+    DurationMs   int64     `json:"duration_ms" firestore:"duration_ms"`
+}
+
+// WebhookDelivery es el envío de un evento a una suscripción y su historial de intentos
+type WebhookDelivery struct {
+    ID             string           `json:"id" firestore:"-"`
+    SubscriptionID string           `json:"subscription_id" firestore:"subscription_id"`
+    EventID        string           `json:"event_id" firestore:"event_id"`
+    EventType      string           `json:"event_type" firestore:"event_type"`
+    Payload        string           `json:"payload" firestore:"payload"`
+    Status         string           `json:"status" firestore:"status"`
+    Attempts       []WebhookAttempt `json:"attempts" firestore:"attempts"`
+    NextAttemptAt  time.Time        `json:"next_attempt_at,omitempty" firestore:"next_attempt_at"`
+    CreatedAt      time.Time        `json:"created_at" firestore:"created_at"`
+    UpdatedAt      time.Time        `json:"updated_at" firestore:"updated_at"`
+}
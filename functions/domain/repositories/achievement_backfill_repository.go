@@ -0,0 +1,187 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/kha0sys/nodo.social/functions/domain/errors"
+	"github.com/kha0sys/nodo.social/functions/domain/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AchievementBackfillRepository define la interfaz para los recálculos de logros y sus cambios
+type AchievementBackfillRepository interface {
+	Create(ctx context.Context, backfill *models.AchievementBackfill) error
+	Get(ctx context.Context, backfillID string) (*models.AchievementBackfill, error)
+	List(ctx context.Context, limit int) ([]*models.AchievementBackfill, error)
+	ListRunning(ctx context.Context) ([]*models.AchievementBackfill, error)
+	Claim(ctx context.Context, backfillID string, now time.Time, until time.Time) (*models.AchievementBackfill, error)
+	Save(ctx context.Context, backfill *models.AchievementBackfill) error
+	AddChanges(ctx context.Context, backfillID string, changes []*models.AchievementBackfillChange) error
+	ListChanges(ctx context.Context, backfillID string, cursor string, limit int) ([]*models.AchievementBackfillChange, string, error)
+}
+
+// FirestoreAchievementBackfillRepository implementa AchievementBackfillRepository usando Firestore.
+// Los cambios de cada recálculo se guardan en la subcolección "changes" con el ID <usuario>_<logro>.
+type FirestoreAchievementBackfillRepository struct {
+	client     *firestore.Client
+	collection string
+}
+
+// NewFirestoreAchievementBackfillRepository crea una nueva instancia de FirestoreAchievementBackfillRepository
+func NewFirestoreAchievementBackfillRepository(client *firestore.Client) *FirestoreAchievementBackfillRepository {
+	return &FirestoreAchievementBackfillRepository{
+		client:     client,
+		collection: "achievement_backfills",
+	}
+}
+
+// Create guarda un recálculo nuevo y le asigna un ID
+func (r *FirestoreAchievementBackfillRepository) Create(ctx context.Context, backfill *models.AchievementBackfill) error {
+	ref := r.client.Collection(r.collection).NewDoc()
+	backfill.ID = ref.ID
+	_, err := ref.Set(ctx, backfill)
+	return err
+}
+
+// Get obtiene un recálculo
+func (r *FirestoreAchievementBackfillRepository) Get(ctx context.Context, backfillID string) (*models.AchievementBackfill, error) {
+	doc, err := r.client.Collection(r.collection).Doc(backfillID).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, errors.NewNotFoundError("backfill not found")
+		}
+		return nil, err
+	}
+	return r.decode(doc)
+}
+
+// List obtiene los últimos recálculos, del más reciente al más antiguo
+func (r *FirestoreAchievementBackfillRepository) List(ctx context.Context, limit int) ([]*models.AchievementBackfill, error) {
+	return r.query(ctx, r.client.Collection(r.collection).OrderBy("created_at", firestore.Desc).Limit(limit))
+}
+
+// ListRunning obtiene los recálculos que no han terminado
+func (r *FirestoreAchievementBackfillRepository) ListRunning(ctx context.Context) ([]*models.AchievementBackfill, error) {
+	return r.query(ctx, r.client.Collection(r.collection).Where("status", "==", models.BackfillRunning))
+}
+
+// Claim reserva un recálculo hasta until para procesarlo. Falla con un conflicto si otra
+// ejecución lo tiene reservado o si ya terminó.
+func (r *FirestoreAchievementBackfillRepository) Claim(ctx context.Context, backfillID string, now time.Time, until time.Time) (*models.AchievementBackfill, error) {
+	ref := r.client.Collection(r.collection).Doc(backfillID)
+
+	var backfill *models.AchievementBackfill
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if err != nil {
+			if status.Code(err) == codes.NotFound {
+				return errors.NewNotFoundError("backfill not found")
+			}
+			return err
+		}
+		backfill, err = r.decode(doc)
+		if err != nil {
+			return err
+		}
+
+		if backfill.Status != models.BackfillRunning {
+			return errors.NewConflictError("el recálculo ya terminó")
+		}
+		if backfill.LeaseUntil.After(now) {
+			return errors.NewConflictError("el recálculo se está ejecutando")
+		}
+		backfill.LeaseUntil = until
+		return tx.Update(ref, []firestore.Update{{Path: "lease_until", Value: until}})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return backfill, nil
+}
+
+// Save reemplaza el estado de un recálculo
+func (r *FirestoreAchievementBackfillRepository) Save(ctx context.Context, backfill *models.AchievementBackfill) error {
+	_, err := r.client.Collection(r.collection).Doc(backfill.ID).Set(ctx, backfill)
+	return err
+}
+
+// AddChanges guarda los cambios de un recálculo. Un cambio ya registrado se reemplaza, así
+// que repetir una página al retomar no lo duplica.
+func (r *FirestoreAchievementBackfillRepository) AddChanges(ctx context.Context, backfillID string, changes []*models.AchievementBackfillChange) error {
+	changesRef := r.client.Collection(r.collection).Doc(backfillID).Collection("changes")
+	for start := 0; start < len(changes); start += 500 {
+		end := start + 500
+		if end > len(changes) {
+			end = len(changes)
+		}
+
+		batch := r.client.Batch()
+		for _, change := range changes[start:end] {
+			batch.Set(changesRef.Doc(change.UserID+"_"+change.AchievementID), change)
+		}
+		if _, err := batch.Commit(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListChanges obtiene una página de los cambios de un recálculo ordenados por usuario.
+// Retorna también el cursor de la página siguiente, vacío si no hay más.
+func (r *FirestoreAchievementBackfillRepository) ListChanges(ctx context.Context, backfillID string, cursor string, limit int) ([]*models.AchievementBackfillChange, string, error) {
+	query := r.client.Collection(r.collection).Doc(backfillID).Collection("changes").
+		OrderBy(firestore.DocumentID, firestore.Asc).
+		Limit(limit)
+	if cursor != "" {
+		query = query.StartAfter(cursor)
+	}
+
+	docs, err := query.Documents(ctx).GetAll()
+	if err != nil {
+		return nil, "", err
+	}
+
+	changes := make([]*models.AchievementBackfillChange, 0, len(docs))
+	for _, doc := range docs {
+		var change models.AchievementBackfillChange
+		if err := doc.DataTo(&change); err != nil {
+			return nil, "", err
+		}
+		changes = append(changes, &change)
+	}
+
+	next := ""
+	if len(docs) == limit {
+		next = docs[len(docs)-1].Ref.ID
+	}
+	return changes, next, nil
+}
+
+func (r *FirestoreAchievementBackfillRepository) query(ctx context.Context, query firestore.Query) ([]*models.AchievementBackfill, error) {
+	docs, err := query.Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	backfills := make([]*models.AchievementBackfill, 0, len(docs))
+	for _, doc := range docs {
+		backfill, err := r.decode(doc)
+		if err != nil {
+			return nil, err
+		}
+		backfills = append(backfills, backfill)
+	}
+	return backfills, nil
+}
+
+func (r *FirestoreAchievementBackfillRepository) decode(doc *firestore.DocumentSnapshot) (*models.AchievementBackfill, error) {
+	var backfill models.AchievementBackfill
+	if err := doc.DataTo(&backfill); err != nil {
+		return nil, err
+	}
+	backfill.ID = doc.Ref.ID
+	return &backfill, nil
+}
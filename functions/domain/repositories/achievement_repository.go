@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"github.com/kha0sys/nodo.social/functions/domain/errors"
+	"github.com/kha0sys/nodo.social/functions/domain/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AchievementRepository define la interfaz para las definiciones de logros y los logros desbloqueados
+type AchievementRepository interface {
+	List(ctx context.Context) ([]*models.Achievement, error)
+	Get(ctx context.Context, achievementID string) (*models.Achievement, error)
+	Create(ctx context.Context, achievement *models.Achievement) error
+	Update(ctx context.Context, achievement *models.Achievement) error
+	Delete(ctx context.Context, achievementID string) error
+	Unlock(ctx context.Context, userAchievement *models.UserAchievement) (bool, error)
+}
+
+// FirestoreAchievementRepository implementa AchievementRepository usando Firestore. Las
+// definiciones tienen su propia colección: la colección achievements tiene los logros por
+// usuario ({userID}_{tipo}) que se guardaban antes de user_achievements.
+type FirestoreAchievementRepository struct {
+	client             *firestore.Client
+	collection         string
+	unlockedCollection string
+}
+
+// NewFirestoreAchievementRepository crea una nueva instancia de FirestoreAchievementRepository
+func NewFirestoreAchievementRepository(client *firestore.Client) *FirestoreAchievementRepository {
+	return &FirestoreAchievementRepository{
+		client:             client,
+		collection:         "achievement_definitions",
+		unlockedCollection: "user_achievements",
+	}
+}
+
+// List obtiene todas las definiciones de logros
+func (r *FirestoreAchievementRepository) List(ctx context.Context) ([]*models.Achievement, error) {
+	docs, err := r.client.Collection(r.collection).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	achievements := make([]*models.Achievement, 0, len(docs))
+	for _, doc := range docs {
+		var achievement models.Achievement
+		if err := doc.DataTo(&achievement); err != nil {
+			return nil, err
+		}
+		achievement.ID = doc.Ref.ID
+		achievements = append(achievements, &achievement)
+	}
+	return achievements, nil
+}
+
+// Get obtiene la definición de un logro
+func (r *FirestoreAchievementRepository) Get(ctx context.Context, achievementID string) (*models.Achievement, error) {
+	doc, err := r.client.Collection(r.collection).Doc(achievementID).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, errors.NewNotFoundError("achievement not found")
+		}
+		return nil, err
+	}
+
+	var achievement models.Achievement
+	if err := doc.DataTo(&achievement); err != nil {
+		return nil, err
+	}
+	achievement.ID = doc.Ref.ID
+	return &achievement, nil
+}
+
+// Create guarda una definición nueva; falla si ya existe un logro con el mismo ID
+func (r *FirestoreAchievementRepository) Create(ctx context.Context, achievement *models.Achievement) error {
+	_, err := r.client.Collection(r.collection).Doc(achievement.ID).Create(ctx, achievement)
+	if status.Code(err) == codes.AlreadyExists {
+		return errors.NewConflictError("achievement already exists")
+	}
+	return err
+}
+
+// Update reemplaza una definición existente
+func (r *FirestoreAchievementRepository) Update(ctx context.Context, achievement *models.Achievement) error {
+	_, err := r.client.Collection(r.collection).Doc(achievement.ID).Set(ctx, achievement)
+	return err
+}
+
+// Delete elimina una definición. Los usuarios que ya la desbloquearon la conservan.
+func (r *FirestoreAchievementRepository) Delete(ctx context.Context, achievementID string) error {
+	_, err := r.client.Collection(r.collection).Doc(achievementID).Delete(ctx)
+	return err
+}
+
+// Unlock registra que el usuario desbloqueó un logro. Retorna false si ya lo tenía,
+// de modo que cada logro se otorga una sola vez aunque se evalúe en paralelo.
+func (r *FirestoreAchievementRepository) Unlock(ctx context.Context, userAchievement *models.UserAchievement) (bool, error) {
+	docID := userAchievement.UserID + "_" + userAchievement.AchievementID
+	_, err := r.client.Collection(r.unlockedCollection).Doc(docID).Create(ctx, userAchievement)
+	if err != nil {
+		if status.Code(err) == codes.AlreadyExists {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
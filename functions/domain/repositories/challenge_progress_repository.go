@@ -0,0 +1,78 @@
+package repositories
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"github.com/kha0sys/nodo.social/functions/domain/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ChallengeProgressRepository define la interfaz para el avance de los usuarios en los retos
+type ChallengeProgressRepository interface {
+	GetByUser(ctx context.Context, userID string) ([]*models.ChallengeProgress, error)
+	Update(ctx context.Context, challengeID string, userID string, update func(*models.ChallengeProgress) bool) (*models.ChallengeProgress, bool, error)
+}
+
+// FirestoreChallengeProgressRepository implementa ChallengeProgressRepository usando Firestore
+type FirestoreChallengeProgressRepository struct {
+	client     *firestore.Client
+	collection string
+}
+
+// NewFirestoreChallengeProgressRepository crea una nueva instancia de FirestoreChallengeProgressRepository
+func NewFirestoreChallengeProgressRepository(client *firestore.Client) *FirestoreChallengeProgressRepository {
+	return &FirestoreChallengeProgressRepository{
+		client:     client,
+		collection: "challenge_progress",
+	}
+}
+
+// GetByUser obtiene el avance de un usuario en todos los retos en los que participó
+func (r *FirestoreChallengeProgressRepository) GetByUser(ctx context.Context, userID string) ([]*models.ChallengeProgress, error) {
+	docs, err := r.client.Collection(r.collection).Where("user_id", "==", userID).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	progress := make([]*models.ChallengeProgress, 0, len(docs))
+	for _, doc := range docs {
+		var p models.ChallengeProgress
+		if err := doc.DataTo(&p); err != nil {
+			return nil, err
+		}
+		progress = append(progress, &p)
+	}
+	return progress, nil
+}
+
+// Update aplica update al avance de un usuario en un reto dentro de una transacción y lo
+// guarda si update retorna true. Si el usuario no tenía avance, update recibe uno vacío.
+func (r *FirestoreChallengeProgressRepository) Update(ctx context.Context, challengeID string, userID string, update func(*models.ChallengeProgress) bool) (*models.ChallengeProgress, bool, error) {
+	ref := r.client.Collection(r.collection).Doc(challengeID + "_" + userID)
+
+	var progress *models.ChallengeProgress
+	changed := false
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		progress = &models.ChallengeProgress{ChallengeID: challengeID, UserID: userID}
+		doc, err := tx.Get(ref)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+		if err == nil {
+			if err := doc.DataTo(progress); err != nil {
+				return err
+			}
+		}
+
+		if changed = update(progress); !changed {
+			return nil
+		}
+		return tx.Set(ref, progress)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return progress, changed, nil
+}
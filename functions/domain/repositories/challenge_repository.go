@@ -0,0 +1,113 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/kha0sys/nodo.social/functions/domain/errors"
+	"github.com/kha0sys/nodo.social/functions/domain/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ChallengeRepository define la interfaz para los retos
+type ChallengeRepository interface {
+	List(ctx context.Context) ([]*models.Challenge, error)
+	ListOpen(ctx context.Context, now time.Time) ([]*models.Challenge, error)
+	Get(ctx context.Context, challengeID string) (*models.Challenge, error)
+	Create(ctx context.Context, challenge *models.Challenge) error
+	Update(ctx context.Context, challenge *models.Challenge) error
+	Delete(ctx context.Context, challengeID string) error
+}
+
+// FirestoreChallengeRepository implementa ChallengeRepository usando Firestore
+type FirestoreChallengeRepository struct {
+	client     *firestore.Client
+	collection string
+}
+
+// NewFirestoreChallengeRepository crea una nueva instancia de FirestoreChallengeRepository
+func NewFirestoreChallengeRepository(client *firestore.Client) *FirestoreChallengeRepository {
+	return &FirestoreChallengeRepository{
+		client:     client,
+		collection: "challenges",
+	}
+}
+
+// List obtiene todos los retos, del más reciente al más antiguo
+func (r *FirestoreChallengeRepository) List(ctx context.Context) ([]*models.Challenge, error) {
+	return r.query(ctx, r.client.Collection(r.collection).OrderBy("start_at", firestore.Desc))
+}
+
+// ListOpen obtiene los retos activos que están en curso en now
+func (r *FirestoreChallengeRepository) ListOpen(ctx context.Context, now time.Time) ([]*models.Challenge, error) {
+	challenges, err := r.query(ctx, r.client.Collection(r.collection).Where("end_at", ">", now))
+	if err != nil {
+		return nil, err
+	}
+
+	open := make([]*models.Challenge, 0, len(challenges))
+	for _, challenge := range challenges {
+		if challenge.Open(now) {
+			open = append(open, challenge)
+		}
+	}
+	return open, nil
+}
+
+// Get obtiene un reto
+func (r *FirestoreChallengeRepository) Get(ctx context.Context, challengeID string) (*models.Challenge, error) {
+	doc, err := r.client.Collection(r.collection).Doc(challengeID).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, errors.NewNotFoundError("challenge not found")
+		}
+		return nil, err
+	}
+
+	var challenge models.Challenge
+	if err := doc.DataTo(&challenge); err != nil {
+		return nil, err
+	}
+	challenge.ID = doc.Ref.ID
+	return &challenge, nil
+}
+
+// Create guarda un reto nuevo y le asigna un ID
+func (r *FirestoreChallengeRepository) Create(ctx context.Context, challenge *models.Challenge) error {
+	ref := r.client.Collection(r.collection).NewDoc()
+	challenge.ID = ref.ID
+	_, err := ref.Set(ctx, challenge)
+	return err
+}
+
+// Update reemplaza un reto existente
+func (r *FirestoreChallengeRepository) Update(ctx context.Context, challenge *models.Challenge) error {
+	_, err := r.client.Collection(r.collection).Doc(challenge.ID).Set(ctx, challenge)
+	return err
+}
+
+// Delete elimina un reto
+func (r *FirestoreChallengeRepository) Delete(ctx context.Context, challengeID string) error {
+	_, err := r.client.Collection(r.collection).Doc(challengeID).Delete(ctx)
+	return err
+}
+
+func (r *FirestoreChallengeRepository) query(ctx context.Context, query firestore.Query) ([]*models.Challenge, error) {
+	docs, err := query.Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	challenges := make([]*models.Challenge, 0, len(docs))
+	for _, doc := range docs {
+		var challenge models.Challenge
+		if err := doc.DataTo(&challenge); err != nil {
+			return nil, err
+		}
+		challenge.ID = doc.Ref.ID
+		challenges = append(challenges, &challenge)
+	}
+	return challenges, nil
+}
@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/kha0sys/nodo.social/functions/domain/models"
+	"google.golang.org/api/iterator"
+)
+
+// DeferredNotificationRepository define la interfaz para las notificaciones retenidas por horas de silencio
+type DeferredNotificationRepository interface {
+	Create(ctx context.Context, deferred *models.DeferredNotification) error
+	GetDue(ctx context.Context, before time.Time, limit int) ([]*models.DeferredNotification, error)
+	Delete(ctx context.Context, deferredID string) error
+}
+
+// FirestoreDeferredNotificationRepository implementa DeferredNotificationRepository usando Firestore
+type FirestoreDeferredNotificationRepository struct {
+	client     *firestore.Client
+	collection string
+}
+
+// NewFirestoreDeferredNotificationRepository crea una nueva instancia de FirestoreDeferredNotificationRepository
+func NewFirestoreDeferredNotificationRepository(client *firestore.Client) *FirestoreDeferredNotificationRepository {
+	return &FirestoreDeferredNotificationRepository{
+		client:     client,
+		collection: "deferred_notifications",
+	}
+}
+
+// Create guarda una notificación retenida
+func (r *FirestoreDeferredNotificationRepository) Create(ctx context.Context, deferred *models.DeferredNotification) error {
+	docRef := r.client.Collection(r.collection).NewDoc()
+	deferred.ID = docRef.ID
+	_, err := docRef.Set(ctx, deferred)
+	return err
+}
+
+// GetDue obtiene las notificaciones retenidas cuya entrega ya corresponde
+func (r *FirestoreDeferredNotificationRepository) GetDue(ctx context.Context, before time.Time, limit int) ([]*models.DeferredNotification, error) {
+	iter := r.client.Collection(r.collection).
+		Where("deliver_at", "<=", before).
+		OrderBy("deliver_at", firestore.Asc).
+		Limit(limit).
+		Documents(ctx)
+	defer iter.Stop()
+
+	var deferred []*models.DeferredNotification
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var item models.DeferredNotification
+		if err := doc.DataTo(&item); err != nil {
+			return nil, err
+		}
+		item.ID = doc.Ref.ID
+		deferred = append(deferred, &item)
+	}
+
+	return deferred, nil
+}
+
+// Delete elimina una notificación retenida
+func (r *FirestoreDeferredNotificationRepository) Delete(ctx context.Context, deferredID string) error {
+	_, err := r.client.Collection(r.collection).Doc(deferredID).Delete(ctx)
+	return err
+}
@@ -0,0 +1,121 @@
+package repositories
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"cloud.google.com/go/firestore"
+	"github.com/kha0sys/nodo.social/functions/domain/models"
+	"google.golang.org/api/iterator"
+)
+
+// DeviceTokenRepository define la interfaz para operaciones con tokens de dispositivos
+type DeviceTokenRepository interface {
+	Register(ctx context.Context, device *models.DeviceToken) error
+	Get(ctx context.Context, token string) (*models.DeviceToken, error)
+	GetByUser(ctx context.Context, userID string) ([]*models.DeviceToken, error)
+	Delete(ctx context.Context, token string) error
+	DeleteTokens(ctx context.Context, tokens []string) error
+}
+
+// FirestoreDeviceTokenRepository implementa DeviceTokenRepository usando Firestore
+type FirestoreDeviceTokenRepository struct {
+	client     *firestore.Client
+	collection string
+}
+
+// NewFirestoreDeviceTokenRepository crea una nueva instancia de FirestoreDeviceTokenRepository
+func NewFirestoreDeviceTokenRepository(client *firestore.Client) *FirestoreDeviceTokenRepository {
+	return &FirestoreDeviceTokenRepository{
+		client:     client,
+		collection: "device_tokens",
+	}
+}
+
+// Register guarda o actualiza un token. El documento se identifica por el token, de modo que
+// si el dispositivo cambia de cuenta el token pasa al nuevo usuario.
+func (r *FirestoreDeviceTokenRepository) Register(ctx context.Context, device *models.DeviceToken) error {
+	device.ID = tokenDocID(device.Token)
+	docRef := r.client.Collection(r.collection).Doc(device.ID)
+
+	return r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(docRef)
+		if err == nil && doc.Exists() {
+			var existing models.DeviceToken
+			if err := doc.DataTo(&existing); err == nil && existing.UserID == device.UserID {
+				device.CreatedAt = existing.CreatedAt
+			}
+		}
+		return tx.Set(docRef, device)
+	})
+}
+
+// Get obtiene un token registrado
+func (r *FirestoreDeviceTokenRepository) Get(ctx context.Context, token string) (*models.DeviceToken, error) {
+	doc, err := r.client.Collection(r.collection).Doc(tokenDocID(token)).Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var device models.DeviceToken
+	if err := doc.DataTo(&device); err != nil {
+		return nil, err
+	}
+
+	device.ID = doc.Ref.ID
+	return &device, nil
+}
+
+// GetByUser obtiene todos los tokens de un usuario
+func (r *FirestoreDeviceTokenRepository) GetByUser(ctx context.Context, userID string) ([]*models.DeviceToken, error) {
+	iter := r.client.Collection(r.collection).Where("user_id", "==", userID).Documents(ctx)
+	defer iter.Stop()
+
+	var devices []*models.DeviceToken
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var device models.DeviceToken
+		if err := doc.DataTo(&device); err != nil {
+			return nil, err
+		}
+		device.ID = doc.Ref.ID
+		devices = append(devices, &device)
+	}
+
+	return devices, nil
+}
+
+// Delete elimina un token
+func (r *FirestoreDeviceTokenRepository) Delete(ctx context.Context, token string) error {
+	_, err := r.client.Collection(r.collection).Doc(tokenDocID(token)).Delete(ctx)
+	return err
+}
+
+// DeleteTokens elimina varios tokens en lote
+func (r *FirestoreDeviceTokenRepository) DeleteTokens(ctx context.Context, tokens []string) error {
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	batch := r.client.Batch()
+	for _, token := range tokens {
+		batch.Delete(r.client.Collection(r.collection).Doc(tokenDocID(token)))
+	}
+
+	_, err := batch.Commit(ctx)
+	return err
+}
+
+// tokenDocID deriva el ID del documento a partir del token, que puede ser muy largo
+func tokenDocID(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,53 @@
+package repositories
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"github.com/kha0sys/nodo.social/functions/domain/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DigestStateRepository define la interfaz para el estado del resumen semanal de cada usuario
+type DigestStateRepository interface {
+	Get(ctx context.Context, userID string) (*models.DigestState, error)
+	Save(ctx context.Context, state *models.DigestState) error
+}
+
+// FirestoreDigestStateRepository implementa DigestStateRepository usando Firestore
+type FirestoreDigestStateRepository struct {
+	client     *firestore.Client
+	collection string
+}
+
+// NewFirestoreDigestStateRepository crea una nueva instancia de FirestoreDigestStateRepository
+func NewFirestoreDigestStateRepository(client *firestore.Client) *FirestoreDigestStateRepository {
+	return &FirestoreDigestStateRepository{
+		client:     client,
+		collection: "digest_states",
+	}
+}
+
+// Get obtiene el estado del resumen de un usuario. Si nunca recibió uno retorna un estado vacío.
+func (r *FirestoreDigestStateRepository) Get(ctx context.Context, userID string) (*models.DigestState, error) {
+	doc, err := r.client.Collection(r.collection).Doc(userID).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return &models.DigestState{UserID: userID}, nil
+		}
+		return nil, err
+	}
+
+	var state models.DigestState
+	if err := doc.DataTo(&state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// Save guarda el estado del resumen de un usuario
+func (r *FirestoreDigestStateRepository) Save(ctx context.Context, state *models.DigestState) error {
+	_, err := r.client.Collection(r.collection).Doc(state.UserID).Set(ctx, state)
+	return err
+}
@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"context"
+	"strings"
+
+	"cloud.google.com/go/firestore"
+	"github.com/kha0sys/nodo.social/functions/domain/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// EmailSuppressionRepository define la interfaz para el registro de rebotes y supresiones de correo
+type EmailSuppressionRepository interface {
+	Get(ctx context.Context, email string) (*models.EmailSuppression, error)
+	Save(ctx context.Context, suppression *models.EmailSuppression) error
+}
+
+// FirestoreEmailSuppressionRepository implementa EmailSuppressionRepository usando Firestore
+type FirestoreEmailSuppressionRepository struct {
+	client     *firestore.Client
+	collection string
+}
+
+// NewFirestoreEmailSuppressionRepository crea una nueva instancia de FirestoreEmailSuppressionRepository
+func NewFirestoreEmailSuppressionRepository(client *firestore.Client) *FirestoreEmailSuppressionRepository {
+	return &FirestoreEmailSuppressionRepository{
+		client:     client,
+		collection: "email_suppressions",
+	}
+}
+
+// Get obtiene el registro de una dirección. Si no existe retorna un registro vacío.
+func (r *FirestoreEmailSuppressionRepository) Get(ctx context.Context, email string) (*models.EmailSuppression, error) {
+	email = normalizeEmail(email)
+	doc, err := r.client.Collection(r.collection).Doc(tokenDocID(email)).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return &models.EmailSuppression{Email: email}, nil
+		}
+		return nil, err
+	}
+
+	var suppression models.EmailSuppression
+	if err := doc.DataTo(&suppression); err != nil {
+		return nil, err
+	}
+	return &suppression, nil
+}
+
+// Save guarda el registro de una dirección
+func (r *FirestoreEmailSuppressionRepository) Save(ctx context.Context, suppression *models.EmailSuppression) error {
+	suppression.Email = normalizeEmail(suppression.Email)
+	_, err := r.client.Collection(r.collection).Doc(tokenDocID(suppression.Email)).Set(ctx, suppression)
+	return err
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"cloud.google.com/go/firestore"
+	"github.com/kha0sys/nodo.social/functions/domain/models"
+	"google.golang.org/api/iterator"
+)
+
+// FeedMuteRepository define la interfaz para operaciones con los silenciamientos del feed
+type FeedMuteRepository interface {
+	Create(ctx context.Context, mute *models.FeedMute) error
+	Get(ctx context.Context, muteID string) (*models.FeedMute, error)
+	Delete(ctx context.Context, muteID string) error
+	GetByUser(ctx context.Context, userID string) ([]*models.FeedMute, error)
+}
+
+// FirestoreFeedMuteRepository implementa FeedMuteRepository usando Firestore
+type FirestoreFeedMuteRepository struct {
+	client     *firestore.Client
+	collection string
+}
+
+// NewFirestoreFeedMuteRepository crea una nueva instancia de FirestoreFeedMuteRepository
+func NewFirestoreFeedMuteRepository(client *firestore.Client) *FirestoreFeedMuteRepository {
+	return &FirestoreFeedMuteRepository{
+		client:     client,
+		collection: "feed_mutes",
+	}
+}
+
+// Create guarda un silenciamiento. El ID es determinista, por lo que repetirlo no duplica.
+func (r *FirestoreFeedMuteRepository) Create(ctx context.Context, mute *models.FeedMute) error {
+	mute.ID = fmt.Sprintf("%s_%s_%s", mute.UserID, mute.Type, strings.ReplaceAll(mute.Value, "/", "_"))
+	_, err := r.client.Collection(r.collection).Doc(mute.ID).Set(ctx, mute)
+	return err
+}
+
+// Get obtiene un silenciamiento por su ID
+func (r *FirestoreFeedMuteRepository) Get(ctx context.Context, muteID string) (*models.FeedMute, error) {
+	doc, err := r.client.Collection(r.collection).Doc(muteID).Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var mute models.FeedMute
+	if err := doc.DataTo(&mute); err != nil {
+		return nil, err
+	}
+
+	mute.ID = doc.Ref.ID
+	return &mute, nil
+}
+
+// Delete elimina un silenciamiento
+func (r *FirestoreFeedMuteRepository) Delete(ctx context.Context, muteID string) error {
+	_, err := r.client.Collection(r.collection).Doc(muteID).Delete(ctx)
+	return err
+}
+
+// GetByUser obtiene todos los silenciamientos de un usuario
+func (r *FirestoreFeedMuteRepository) GetByUser(ctx context.Context, userID string) ([]*models.FeedMute, error) {
+	iter := r.client.Collection(r.collection).
+		Where("user_id", "==", userID).
+		OrderBy("created_at", firestore.Desc).
+		Documents(ctx)
+	defer iter.Stop()
+
+	var mutes []*models.FeedMute
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var mute models.FeedMute
+		if err := doc.DataTo(&mute); err != nil {
+			return nil, err
+		}
+		mute.ID = doc.Ref.ID
+		mutes = append(mutes, &mute)
+	}
+
+	return mutes, nil
+}
@@ -13,6 +13,7 @@ type FeedRepository interface {
 	Create(ctx context.Context, item *models.FeedItem) error
 	Delete(ctx context.Context, itemID string) error
 	GetUserFeed(ctx context.Context, userID string, limit int, lastTimestamp int64) ([]*models.FeedItem, error)
+	GetFeed(ctx context.Context, filters models.FeedFilters) ([]*models.FeedItem, error)
 	DeleteByNodeID(ctx context.Context, nodeID string) error
 	UpdateMetrics(ctx context.Context, nodeID string, metrics models.InteractionMetrics) error
 }
@@ -72,6 +73,54 @@ func (r *FirestoreFeedRepository) GetUserFeed(ctx context.Context, userID string
 	return items, nil
 }
 
+// GetFeed obtiene los items del feed que cumplen los filtros, del más reciente al más antiguo.
+// EndTime es exclusivo y se usa como cursor; StartTime es inclusivo.
+func (r *FirestoreFeedRepository) GetFeed(ctx context.Context, filters models.FeedFilters) ([]*models.FeedItem, error) {
+	query := r.client.Collection(r.collection).Query
+	if filters.UserID != "" {
+		query = query.Where("user_id", "==", filters.UserID)
+	}
+	if filters.NodeID != "" {
+		query = query.Where("node_id", "==", filters.NodeID)
+	}
+	if filters.Type != "" {
+		query = query.Where("type", "==", filters.Type)
+	}
+	// Con LastID la página sigue después del item (EndTime, LastID); el ID desempata los
+	// items creados en el mismo segundo
+	paged := filters.EndTime > 0 && filters.LastID != ""
+	if filters.EndTime > 0 && !paged {
+		query = query.Where("created_at", "<", filters.EndTime)
+	}
+	if filters.StartTime > 0 {
+		query = query.Where("created_at", ">=", filters.StartTime)
+	}
+	query = query.OrderBy("created_at", firestore.Desc).OrderBy(firestore.DocumentID, firestore.Desc)
+	if paged {
+		query = query.StartAfter(filters.EndTime, filters.LastID)
+	}
+	if filters.PageSize > 0 {
+		query = query.Limit(filters.PageSize)
+	}
+
+	docs, err := query.Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*models.FeedItem, 0, len(docs))
+	for _, doc := range docs {
+		var item models.FeedItem
+		if err := doc.DataTo(&item); err != nil {
+			return nil, err
+		}
+		item.ID = doc.Ref.ID
+		items = append(items, &item)
+	}
+
+	return items, nil
+}
+
 // DeleteByNodeID elimina todos los items del feed relacionados con un nodo
 func (r *FirestoreFeedRepository) DeleteByNodeID(ctx context.Context, nodeID string) error {
 	// Obtener todos los items relacionados con el nodo
@@ -0,0 +1,193 @@
+package repositories
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"github.com/kha0sys/nodo.social/functions/domain/models"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// leaderboardBatchSize es el número de escrituras por lote, por debajo del límite de 500 de Firestore
+const leaderboardBatchSize = 400
+
+// LeaderboardRepository define la interfaz para los rankings precalculados
+type LeaderboardRepository interface {
+	Replace(ctx context.Context, board *models.Leaderboard, entries []*models.LeaderboardEntry) error
+	Get(ctx context.Context, boardID string) (*models.Leaderboard, error)
+	Top(ctx context.Context, boardID string, limit int) ([]*models.LeaderboardEntry, error)
+	Range(ctx context.Context, boardID string, fromIndex int, toIndex int) ([]*models.LeaderboardEntry, error)
+	GetEntry(ctx context.Context, boardID string, userID string) (*models.LeaderboardEntry, error)
+	GetEntries(ctx context.Context, boardID string, userIDs []string) ([]*models.LeaderboardEntry, error)
+}
+
+// FirestoreLeaderboardRepository implementa LeaderboardRepository usando Firestore. Cada
+// posición se guarda como un documento <ranking>_<usuario> para consultar rangos por índice.
+type FirestoreLeaderboardRepository struct {
+	client            *firestore.Client
+	collection        string
+	entriesCollection string
+}
+
+// NewFirestoreLeaderboardRepository crea una nueva instancia de FirestoreLeaderboardRepository
+func NewFirestoreLeaderboardRepository(client *firestore.Client) *FirestoreLeaderboardRepository {
+	return &FirestoreLeaderboardRepository{
+		client:            client,
+		collection:        "leaderboards",
+		entriesCollection: "leaderboard_entries",
+	}
+}
+
+// Replace guarda las posiciones de un ranking, elimina las de usuarios que ya no figuran
+// en él y actualiza su descripción
+func (r *FirestoreLeaderboardRepository) Replace(ctx context.Context, board *models.Leaderboard, entries []*models.LeaderboardEntry) error {
+	current := make(map[string]bool, len(entries))
+	writes := make([]func(*firestore.WriteBatch), 0, len(entries))
+	for _, entry := range entries {
+		entry := entry
+		ref := r.entryRef(board.ID, entry.UserID)
+		current[ref.ID] = true
+		writes = append(writes, func(batch *firestore.WriteBatch) { batch.Set(ref, entry) })
+	}
+
+	stale, err := r.client.Collection(r.entriesCollection).Where("board", "==", board.ID).Select().Documents(ctx).GetAll()
+	if err != nil {
+		return err
+	}
+	for _, doc := range stale {
+		if current[doc.Ref.ID] {
+			continue
+		}
+		ref := doc.Ref
+		writes = append(writes, func(batch *firestore.WriteBatch) { batch.Delete(ref) })
+	}
+
+	for start := 0; start < len(writes); start += leaderboardBatchSize {
+		end := start + leaderboardBatchSize
+		if end > len(writes) {
+			end = len(writes)
+		}
+		batch := r.client.Batch()
+		for _, write := range writes[start:end] {
+			write(batch)
+		}
+		if _, err := batch.Commit(ctx); err != nil {
+			return err
+		}
+	}
+
+	_, err = r.client.Collection(r.collection).Doc(board.ID).Set(ctx, board)
+	return err
+}
+
+// Get obtiene la descripción de un ranking. Si aún no se calculó retorna uno vacío.
+func (r *FirestoreLeaderboardRepository) Get(ctx context.Context, boardID string) (*models.Leaderboard, error) {
+	doc, err := r.client.Collection(r.collection).Doc(boardID).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return &models.Leaderboard{ID: boardID}, nil
+		}
+		return nil, err
+	}
+
+	var board models.Leaderboard
+	if err := doc.DataTo(&board); err != nil {
+		return nil, err
+	}
+	board.ID = doc.Ref.ID
+	return &board, nil
+}
+
+// Top obtiene los primeros limit puestos de un ranking
+func (r *FirestoreLeaderboardRepository) Top(ctx context.Context, boardID string, limit int) ([]*models.LeaderboardEntry, error) {
+	query := r.client.Collection(r.entriesCollection).
+		Where("board", "==", boardID).
+		OrderBy("index", firestore.Asc).
+		Limit(limit)
+	return r.query(ctx, query)
+}
+
+// Range obtiene los puestos con índice entre fromIndex y toIndex, ambos incluidos
+func (r *FirestoreLeaderboardRepository) Range(ctx context.Context, boardID string, fromIndex int, toIndex int) ([]*models.LeaderboardEntry, error) {
+	query := r.client.Collection(r.entriesCollection).
+		Where("board", "==", boardID).
+		Where("index", ">=", fromIndex).
+		Where("index", "<=", toIndex).
+		OrderBy("index", firestore.Asc)
+	return r.query(ctx, query)
+}
+
+// GetEntry obtiene la posición de un usuario; retorna nil si no figura en el ranking
+func (r *FirestoreLeaderboardRepository) GetEntry(ctx context.Context, boardID string, userID string) (*models.LeaderboardEntry, error) {
+	doc, err := r.entryRef(boardID, userID).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var entry models.LeaderboardEntry
+	if err := doc.DataTo(&entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// GetEntries obtiene las posiciones de varios usuarios, omitiendo los que no figuran en el ranking
+func (r *FirestoreLeaderboardRepository) GetEntries(ctx context.Context, boardID string, userIDs []string) ([]*models.LeaderboardEntry, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	refs := make([]*firestore.DocumentRef, 0, len(userIDs))
+	for _, userID := range userIDs {
+		refs = append(refs, r.entryRef(boardID, userID))
+	}
+	docs, err := r.client.GetAll(ctx, refs)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*models.LeaderboardEntry, 0, len(docs))
+	for _, doc := range docs {
+		if !doc.Exists() {
+			continue
+		}
+		var entry models.LeaderboardEntry
+		if err := doc.DataTo(&entry); err != nil {
+			return nil, err
+		}
+		entries = append(entries, &entry)
+	}
+	return entries, nil
+}
+
+func (r *FirestoreLeaderboardRepository) entryRef(boardID string, userID string) *firestore.DocumentRef {
+	return r.client.Collection(r.entriesCollection).Doc(boardID + "_" + userID)
+}
+
+func (r *FirestoreLeaderboardRepository) query(ctx context.Context, query firestore.Query) ([]*models.LeaderboardEntry, error) {
+	iter := query.Documents(ctx)
+	defer iter.Stop()
+
+	entries := make([]*models.LeaderboardEntry, 0)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var entry models.LeaderboardEntry
+		if err := doc.DataTo(&entry); err != nil {
+			return nil, err
+		}
+		entries = append(entries, &entry)
+	}
+	return entries, nil
+}
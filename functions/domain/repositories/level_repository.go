@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"github.com/kha0sys/nodo.social/functions/domain/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// LevelRepository define la interfaz para la curva de niveles configurada
+type LevelRepository interface {
+	Get(ctx context.Context) (*models.LevelCurve, error)
+	Save(ctx context.Context, curve *models.LevelCurve) error
+}
+
+// FirestoreLevelRepository implementa LevelRepository usando Firestore.
+// La curva se guarda en un único documento.
+type FirestoreLevelRepository struct {
+	client     *firestore.Client
+	collection string
+	doc        string
+}
+
+// NewFirestoreLevelRepository crea una nueva instancia de FirestoreLevelRepository
+func NewFirestoreLevelRepository(client *firestore.Client) *FirestoreLevelRepository {
+	return &FirestoreLevelRepository{
+		client:     client,
+		collection: "settings",
+		doc:        "levels",
+	}
+}
+
+// Get obtiene la curva de niveles. Si no se ha configurado ninguna retorna nil.
+func (r *FirestoreLevelRepository) Get(ctx context.Context) (*models.LevelCurve, error) {
+	doc, err := r.client.Collection(r.collection).Doc(r.doc).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var curve models.LevelCurve
+	if err := doc.DataTo(&curve); err != nil {
+		return nil, err
+	}
+	return &curve, nil
+}
+
+// Save guarda la curva de niveles
+func (r *FirestoreLevelRepository) Save(ctx context.Context, curve *models.LevelCurve) error {
+	_, err := r.client.Collection(r.collection).Doc(r.doc).Set(ctx, curve)
+	return err
+}
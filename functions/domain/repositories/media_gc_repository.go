@@ -0,0 +1,241 @@
+package repositories
+
+import (
+	"context"
+	"net/url"
+
+	"cloud.google.com/go/firestore"
+	"github.com/kha0sys/nodo.social/functions/domain/errors"
+	"github.com/kha0sys/nodo.social/functions/domain/models"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MediaGCRepository define la interfaz para las recolecciones de archivos huérfanos, sus
+// marcas y las referencias a archivos guardadas en Firestore
+type MediaGCRepository interface {
+	CreateRun(ctx context.Context, run *models.MediaGCRun) error
+	SaveRun(ctx context.Context, run *models.MediaGCRun) error
+	GetRun(ctx context.Context, runID string) (*models.MediaGCRun, error)
+	ListRuns(ctx context.Context, limit int) ([]*models.MediaGCRun, error)
+	ListMarks(ctx context.Context) (map[string]*models.OrphanedMedia, error)
+	Mark(ctx context.Context, orphan *models.OrphanedMedia) error
+	Unmark(ctx context.Context, path string) error
+	ForEachReference(ctx context.Context, fn func(mediaURL string)) error
+}
+
+// FirestoreMediaGCRepository implementa MediaGCRepository usando Firestore.
+// Las marcas se guardan en "orphaned_media" con la ruta escapada como ID.
+type FirestoreMediaGCRepository struct {
+	client          *firestore.Client
+	collection      string
+	marksCollection string
+}
+
+// NewFirestoreMediaGCRepository crea una nueva instancia de FirestoreMediaGCRepository
+func NewFirestoreMediaGCRepository(client *firestore.Client) *FirestoreMediaGCRepository {
+	return &FirestoreMediaGCRepository{
+		client:          client,
+		collection:      "media_gc_runs",
+		marksCollection: "orphaned_media",
+	}
+}
+
+// CreateRun guarda una recolección nueva y le asigna un ID
+func (r *FirestoreMediaGCRepository) CreateRun(ctx context.Context, run *models.MediaGCRun) error {
+	ref := r.client.Collection(r.collection).NewDoc()
+	run.ID = ref.ID
+	_, err := ref.Set(ctx, run)
+	return err
+}
+
+// SaveRun reemplaza el estado de una recolección
+func (r *FirestoreMediaGCRepository) SaveRun(ctx context.Context, run *models.MediaGCRun) error {
+	_, err := r.client.Collection(r.collection).Doc(run.ID).Set(ctx, run)
+	return err
+}
+
+// GetRun obtiene una recolección
+func (r *FirestoreMediaGCRepository) GetRun(ctx context.Context, runID string) (*models.MediaGCRun, error) {
+	doc, err := r.client.Collection(r.collection).Doc(runID).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, errors.NewNotFoundError("media gc run not found")
+		}
+		return nil, err
+	}
+	return r.decodeRun(doc)
+}
+
+// ListRuns obtiene las últimas recolecciones, de la más reciente a la más antigua
+func (r *FirestoreMediaGCRepository) ListRuns(ctx context.Context, limit int) ([]*models.MediaGCRun, error) {
+	docs, err := r.client.Collection(r.collection).
+		OrderBy("created_at", firestore.Desc).
+		Limit(limit).
+		Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	runs := make([]*models.MediaGCRun, 0, len(docs))
+	for _, doc := range docs {
+		run, err := r.decodeRun(doc)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+// ListMarks obtiene todos los archivos marcados como huérfanos, por ruta
+func (r *FirestoreMediaGCRepository) ListMarks(ctx context.Context) (map[string]*models.OrphanedMedia, error) {
+	iter := r.client.Collection(r.marksCollection).Documents(ctx)
+	defer iter.Stop()
+
+	marks := make(map[string]*models.OrphanedMedia)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var orphan models.OrphanedMedia
+		if err := doc.DataTo(&orphan); err != nil {
+			return nil, err
+		}
+		marks[orphan.Path] = &orphan
+	}
+	return marks, nil
+}
+
+// Mark guarda la marca de un archivo huérfano
+func (r *FirestoreMediaGCRepository) Mark(ctx context.Context, orphan *models.OrphanedMedia) error {
+	_, err := r.mark(orphan.Path).Set(ctx, orphan)
+	return err
+}
+
+// Unmark elimina la marca de un archivo; no falla si no existe
+func (r *FirestoreMediaGCRepository) Unmark(ctx context.Context, path string) error {
+	_, err := r.mark(path).Delete(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil
+	}
+	return err
+}
+
+// ForEachReference llama a fn con cada URL de archivo guardada en los nodos y sus
+// actualizaciones, los productos, las tiendas y los usuarios. Una URL puede repetirse.
+func (r *FirestoreMediaGCRepository) ForEachReference(ctx context.Context, fn func(mediaURL string)) error {
+	err := r.forEach(ctx, r.client.Collection("nodes").Select("media", "images", "mediaDetails", "updates"), func(doc *firestore.DocumentSnapshot) error {
+		var node struct {
+			Media        []string          `firestore:"media"`
+			Images       []string          `firestore:"images"`
+			MediaDetails []models.MediaURL `firestore:"mediaDetails"`
+			Updates      []models.Update   `firestore:"updates"`
+		}
+		if err := doc.DataTo(&node); err != nil {
+			return err
+		}
+		forEachURL(fn, node.Media, node.Images)
+		forEachMediaURL(fn, node.MediaDetails)
+		for _, update := range node.Updates {
+			forEachURL(fn, update.Media)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = r.forEach(ctx, r.client.Collection("products").Select("images", "mediaDetails"), func(doc *firestore.DocumentSnapshot) error {
+		var product struct {
+			Images       []string          `firestore:"images"`
+			MediaDetails []models.MediaURL `firestore:"mediaDetails"`
+		}
+		if err := doc.DataTo(&product); err != nil {
+			return err
+		}
+		forEachURL(fn, product.Images)
+		forEachMediaURL(fn, product.MediaDetails)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = r.forEach(ctx, r.client.Collection("stores").Select("logo"), func(doc *firestore.DocumentSnapshot) error {
+		logo, _ := doc.DataAt("logo")
+		if logo, ok := logo.(string); ok {
+			forEachURL(fn, []string{logo})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.forEach(ctx, r.client.Collection("users").Select("photoUrl"), func(doc *firestore.DocumentSnapshot) error {
+		photoURL, _ := doc.DataAt("photoUrl")
+		if photoURL, ok := photoURL.(string); ok {
+			forEachURL(fn, []string{photoURL})
+		}
+		return nil
+	})
+}
+
+// forEach llama a fn con cada documento de la consulta
+func (r *FirestoreMediaGCRepository) forEach(ctx context.Context, query firestore.Query, fn func(doc *firestore.DocumentSnapshot) error) error {
+	iter := query.Documents(ctx)
+	defer iter.Stop()
+
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(doc); err != nil {
+			return err
+		}
+	}
+}
+
+func (r *FirestoreMediaGCRepository) mark(path string) *firestore.DocumentRef {
+	// Los IDs de Firestore no pueden contener "/"
+	return r.client.Collection(r.marksCollection).Doc(url.PathEscape(path))
+}
+
+func (r *FirestoreMediaGCRepository) decodeRun(doc *firestore.DocumentSnapshot) (*models.MediaGCRun, error) {
+	var run models.MediaGCRun
+	if err := doc.DataTo(&run); err != nil {
+		return nil, err
+	}
+	run.ID = doc.Ref.ID
+	return &run, nil
+}
+
+func forEachURL(fn func(mediaURL string), urls ...[]string) {
+	for _, list := range urls {
+		for _, mediaURL := range list {
+			if mediaURL != "" {
+				fn(mediaURL)
+			}
+		}
+	}
+}
+
+func forEachMediaURL(fn func(mediaURL string), media []models.MediaURL) {
+	for _, item := range media {
+		forEachURL(fn, []string{item.URL, item.Thumbnail})
+		for _, variant := range item.Variants {
+			forEachURL(fn, []string{variant.URL})
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"context"
+	"net/url"
+
+	"cloud.google.com/go/firestore"
+	"github.com/kha0sys/nodo.social/functions/domain/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MediaRepository define la interfaz para los datos de las imágenes subidas, como sus
+// dimensiones y su placeholder
+type MediaRepository interface {
+	Save(ctx context.Context, media *models.MediaURL) error
+	Delete(ctx context.Context, mediaURL string) error
+	GetByURLs(ctx context.Context, urls []string) (map[string]*models.MediaURL, error)
+	SaveRewrite(ctx context.Context, mediaURL string, rewrite models.MediaRewrite) error
+	GetRewrite(ctx context.Context, mediaURL string) (*models.MediaRewrite, error)
+}
+
+// FirestoreMediaRepository implementa MediaRepository usando Firestore.
+// Cada recurso se guarda con su URL escapada como ID.
+type FirestoreMediaRepository struct {
+	client     *firestore.Client
+	collection string
+}
+
+// NewFirestoreMediaRepository crea una nueva instancia de FirestoreMediaRepository
+func NewFirestoreMediaRepository(client *firestore.Client) *FirestoreMediaRepository {
+	return &FirestoreMediaRepository{
+		client:     client,
+		collection: "media",
+	}
+}
+
+// Save guarda o reemplaza los datos de un recurso
+func (r *FirestoreMediaRepository) Save(ctx context.Context, media *models.MediaURL) error {
+	_, err := r.doc(media.URL).Set(ctx, media)
+	return err
+}
+
+// Delete elimina los datos de un recurso; no falla si no existen
+func (r *FirestoreMediaRepository) Delete(ctx context.Context, mediaURL string) error {
+	_, err := r.doc(mediaURL).Delete(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil
+	}
+	return err
+}
+
+// GetByURLs obtiene los datos de los recursos indicados por su URL. Los que no tienen
+// datos no se incluyen en el resultado.
+func (r *FirestoreMediaRepository) GetByURLs(ctx context.Context, urls []string) (map[string]*models.MediaURL, error) {
+	media := make(map[string]*models.MediaURL)
+	if len(urls) == 0 {
+		return media, nil
+	}
+
+	refs := make([]*firestore.DocumentRef, 0, len(urls))
+	for _, mediaURL := range urls {
+		refs = append(refs, r.doc(mediaURL))
+	}
+	docs, err := r.client.GetAll(ctx, refs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, doc := range docs {
+		if !doc.Exists() {
+			continue
+		}
+		var item models.MediaURL
+		if err := doc.DataTo(&item); err != nil {
+			return nil, err
+		}
+		media[item.URL] = &item
+	}
+	return media, nil
+}
+
+// SaveRewrite guarda el reemplazo de la imagen original sin tocar sus demás datos
+func (r *FirestoreMediaRepository) SaveRewrite(ctx context.Context, mediaURL string, rewrite models.MediaRewrite) error {
+	_, err := r.doc(mediaURL).Set(ctx, map[string]interface{}{"rewrite": rewrite}, firestore.MergeAll)
+	return err
+}
+
+// GetRewrite obtiene el último reemplazo de la imagen original, o nil si no tiene
+func (r *FirestoreMediaRepository) GetRewrite(ctx context.Context, mediaURL string) (*models.MediaRewrite, error) {
+	doc, err := r.doc(mediaURL).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var media struct {
+		Rewrite *models.MediaRewrite `firestore:"rewrite"`
+	}
+	if err := doc.DataTo(&media); err != nil {
+		return nil, err
+	}
+	return media.Rewrite, nil
+}
+
+func (r *FirestoreMediaRepository) doc(mediaURL string) *firestore.DocumentRef {
+	// Los IDs de Firestore no pueden contener "/"
+	return r.client.Collection(r.collection).Doc(url.PathEscape(mediaURL))
+}
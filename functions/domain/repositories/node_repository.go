@@ -2,9 +2,15 @@ package repositories
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"cloud.google.com/go/firestore"
+	firestorepb "cloud.google.com/go/firestore/apiv1/firestorepb"
+	"github.com/kha0sys/nodo.social/functions/domain/errors"
 	"github.com/kha0sys/nodo.social/functions/domain/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // NodeRepository define la interfaz para operaciones con nodos
@@ -15,6 +21,9 @@ type NodeRepository interface {
 	Delete(ctx context.Context, nodeID string) error
 	GetTotalNodes(ctx context.Context) (int, error)
 	GetPopularNodes(ctx context.Context, limit int) ([]*models.Node, error)
+	GetByUser(ctx context.Context, userID string) ([]*models.Node, error)
+	CountFollowedBy(ctx context.Context, userID string) (int, error)
+	UpdateMedia(ctx context.Context, nodeID string, update func(node *models.Node) error) error
 }
 
 // FirestoreNodeRepository implementa NodeRepository usando Firestore
@@ -120,3 +129,72 @@ func (r *FirestoreNodeRepository) GetPopularNodes(ctx context.Context, limit int
 
 	return nodes, nil
 }
+
+// GetByUser obtiene los nodos creados por un usuario
+func (r *FirestoreNodeRepository) GetByUser(ctx context.Context, userID string) ([]*models.Node, error) {
+	docs, err := r.client.Collection(r.collection).Where("userId", "==", userID).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]*models.Node, 0, len(docs))
+	for _, doc := range docs {
+		var node models.Node
+		if err := doc.DataTo(&node); err != nil {
+			continue
+		}
+		node.ID = doc.Ref.ID
+		nodes = append(nodes, &node)
+	}
+
+	return nodes, nil
+}
+
+// CountFollowedBy cuenta los nodos que tienen al usuario entre sus seguidores
+func (r *FirestoreNodeRepository) CountFollowedBy(ctx context.Context, userID string) (int, error) {
+	query := r.client.Collection(r.collection).Where("followers", "array-contains", userID)
+	result, err := query.NewAggregationQuery().WithCount("count").Get(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	count, ok := result["count"].(*firestorepb.Value)
+	if !ok {
+		return 0, fmt.Errorf("unexpected count result type %T", result["count"])
+	}
+	return int(count.GetIntegerValue()), nil
+}
+
+// UpdateMedia aplica update al nodo dentro de una transacción y guarda solo sus imágenes y
+// sus datos, sin pisar los cambios de otros campos. Falla con NotFound si el nodo no existe.
+func (r *FirestoreNodeRepository) UpdateMedia(ctx context.Context, nodeID string, update func(node *models.Node) error) error {
+	ref := r.client.Collection(r.collection).Doc(nodeID)
+	return r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if status.Code(err) == codes.NotFound {
+			return errors.NewNotFoundError("node not found")
+		}
+		if err != nil {
+			return err
+		}
+
+		var node models.Node
+		if err := doc.DataTo(&node); err != nil {
+			return err
+		}
+		node.ID = doc.Ref.ID
+		if err := update(&node); err != nil {
+			return err
+		}
+		if err := models.ValidateNode(&node); err != nil {
+			return err
+		}
+
+		return tx.Update(ref, []firestore.Update{
+			{Path: "images", Value: node.Images},
+			{Path: "media", Value: node.Media},
+			{Path: "mediaDetails", Value: node.MediaDetails},
+			{Path: "updatedAt", Value: time.Now()},
+		})
+	})
+}
@@ -0,0 +1,86 @@
+package repositories
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"github.com/kha0sys/nodo.social/functions/domain/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// NotificationPreferencesRepository define la interfaz para operaciones con preferencias de notificación
+type NotificationPreferencesRepository interface {
+	Get(ctx context.Context, userID string) (*models.NotificationPreferences, error)
+	Save(ctx context.Context, preferences *models.NotificationPreferences) error
+	Update(ctx context.Context, userID string, update func(*models.NotificationPreferences) error) (*models.NotificationPreferences, error)
+}
+
+// FirestoreNotificationPreferencesRepository implementa NotificationPreferencesRepository usando Firestore
+type FirestoreNotificationPreferencesRepository struct {
+	client     *firestore.Client
+	collection string
+}
+
+// NewFirestoreNotificationPreferencesRepository crea una nueva instancia de FirestoreNotificationPreferencesRepository
+func NewFirestoreNotificationPreferencesRepository(client *firestore.Client) *FirestoreNotificationPreferencesRepository {
+	return &FirestoreNotificationPreferencesRepository{
+		client:     client,
+		collection: "notification_preferences",
+	}
+}
+
+// Get obtiene las preferencias de un usuario. Si no existen retorna las preferencias por defecto.
+func (r *FirestoreNotificationPreferencesRepository) Get(ctx context.Context, userID string) (*models.NotificationPreferences, error) {
+	doc, err := r.client.Collection(r.collection).Doc(userID).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return models.DefaultNotificationPreferences(userID), nil
+		}
+		return nil, err
+	}
+
+	preferences := models.DefaultNotificationPreferences(userID)
+	if err := doc.DataTo(preferences); err != nil {
+		return nil, err
+	}
+
+	preferences.UserID = userID
+	return preferences, nil
+}
+
+// Save guarda las preferencias de un usuario
+func (r *FirestoreNotificationPreferencesRepository) Save(ctx context.Context, preferences *models.NotificationPreferences) error {
+	_, err := r.client.Collection(r.collection).Doc(preferences.UserID).Set(ctx, preferences)
+	return err
+}
+
+// Update aplica update a las preferencias guardadas de un usuario, o a las por defecto si
+// no tiene, dentro de una transacción y las guarda
+func (r *FirestoreNotificationPreferencesRepository) Update(ctx context.Context, userID string, update func(*models.NotificationPreferences) error) (*models.NotificationPreferences, error) {
+	ref := r.client.Collection(r.collection).Doc(userID)
+
+	var preferences *models.NotificationPreferences
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		preferences = models.DefaultNotificationPreferences(userID)
+		doc, err := tx.Get(ref)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+		if err == nil {
+			if err := doc.DataTo(preferences); err != nil {
+				return err
+			}
+		}
+		preferences.UserID = userID
+
+		if err := update(preferences); err != nil {
+			return err
+		}
+		return tx.Set(ref, preferences)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return preferences, nil
+}
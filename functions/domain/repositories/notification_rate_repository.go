@@ -0,0 +1,66 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// NotificationRateRepository define la interfaz para limitar cuántas notificaciones recibe un usuario por hora
+type NotificationRateRepository interface {
+	Reserve(ctx context.Context, userID string, hour time.Time, limit int) (bool, error)
+}
+
+// FirestoreNotificationRateRepository implementa NotificationRateRepository usando Firestore
+type FirestoreNotificationRateRepository struct {
+	client     *firestore.Client
+	collection string
+}
+
+// NewFirestoreNotificationRateRepository crea una nueva instancia de FirestoreNotificationRateRepository
+func NewFirestoreNotificationRateRepository(client *firestore.Client) *FirestoreNotificationRateRepository {
+	return &FirestoreNotificationRateRepository{
+		client:     client,
+		collection: "notification_rates",
+	}
+}
+
+// Reserve suma una entrega al contador del usuario en la hora indicada.
+// Retorna false sin sumar si el usuario ya alcanzó el límite de esa hora.
+func (r *FirestoreNotificationRateRepository) Reserve(ctx context.Context, userID string, hour time.Time, limit int) (bool, error) {
+	hour = hour.UTC().Truncate(time.Hour)
+	docRef := r.client.Collection(r.collection).Doc(fmt.Sprintf("%s_%s", userID, hour.Format("2006010215")))
+
+	reserved := false
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		reserved = false
+		count := int64(0)
+
+		doc, err := tx.Get(docRef)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+		if err == nil {
+			if value, err := doc.DataAt("count"); err == nil {
+				count, _ = value.(int64)
+			}
+		}
+
+		if count >= int64(limit) {
+			return nil
+		}
+
+		reserved = true
+		return tx.Set(docRef, map[string]interface{}{
+			"user_id": userID,
+			"count":   count + 1,
+			// Los contadores viejos se borran con una política TTL sobre expires_at
+			"expires_at": hour.Add(2 * time.Hour),
+		})
+	})
+	return reserved, err
+}
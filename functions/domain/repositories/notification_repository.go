@@ -6,28 +6,45 @@ import (
 
 	"cloud.google.com/go/firestore"
 	"github.com/kha0sys/nodo.social/functions/domain/models"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+// notificationBatchSize es el máximo de notificaciones que se marcan en una transacción
+const notificationBatchSize = 400
+
 // NotificationRepository define la interfaz para operaciones con notificaciones
 type NotificationRepository interface {
 	Create(ctx context.Context, notification *models.Notification) error
+	Get(ctx context.Context, notificationID string) (*models.Notification, error)
 	MarkAsRead(ctx context.Context, notificationID string) error
+	MarkAllAsRead(ctx context.Context, userID string) (int, error)
 	GetUnreadByUser(ctx context.Context, userID string) ([]*models.Notification, error)
+	List(ctx context.Context, filters models.NotificationFilters) ([]*models.Notification, error)
+	CountUnread(ctx context.Context, userID string) (int, error)
+	RecountUnread(ctx context.Context, userID string) (int, int, error)
 	GetOlderThan(ctx context.Context, olderThan time.Time) ([]*models.Notification, error)
 	Delete(ctx context.Context, notificationID string) error
 }
 
-// FirestoreNotificationRepository implementa NotificationRepository usando Firestore
+// FirestoreNotificationRepository implementa NotificationRepository usando Firestore.
+// El número de no leídas de cada usuario se mantiene en un documento contador que se
+// actualiza en la misma escritura que cada notificación, para no contar la colección.
+// Las notificaciones anteriores al contador no están en él: solo se confía en el contador
+// después de recontarlo una vez, lo que queda marcado en su campo recounted_at.
 type FirestoreNotificationRepository struct {
-	client     *firestore.Client
-	collection string
+	client            *firestore.Client
+	collection        string
+	counterCollection string
 }
 
 // NewFirestoreNotificationRepository crea una nueva instancia de FirestoreNotificationRepository
 func NewFirestoreNotificationRepository(client *firestore.Client) *FirestoreNotificationRepository {
 	return &FirestoreNotificationRepository{
-		client:     client,
-		collection: "notifications",
+		client:            client,
+		collection:        "notifications",
+		counterCollection: "notification_counters",
 	}
 }
 
@@ -35,17 +52,125 @@ func NewFirestoreNotificationRepository(client *firestore.Client) *FirestoreNoti
 func (r *FirestoreNotificationRepository) Create(ctx context.Context, notification *models.Notification) error {
 	docRef := r.client.Collection(r.collection).NewDoc()
 	notification.ID = docRef.ID
-	_, err := docRef.Set(ctx, notification)
+
+	batch := r.client.Batch()
+	batch.Set(docRef, notification)
+	if !notification.Read {
+		r.addUnread(batch, notification.UserID, 1)
+	}
+	_, err := batch.Commit(ctx)
 	return err
 }
 
+// Get obtiene una notificación por su ID
+func (r *FirestoreNotificationRepository) Get(ctx context.Context, notificationID string) (*models.Notification, error) {
+	doc, err := r.client.Collection(r.collection).Doc(notificationID).Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var notification models.Notification
+	if err := doc.DataTo(&notification); err != nil {
+		return nil, err
+	}
+
+	notification.ID = doc.Ref.ID
+	return &notification, nil
+}
+
 // MarkAsRead marca una notificación como leída
 func (r *FirestoreNotificationRepository) MarkAsRead(ctx context.Context, notificationID string) error {
-	_, err := r.client.Collection(r.collection).Doc(notificationID).Update(ctx, []firestore.Update{
-		{Path: "read", Value: true},
-		{Path: "read_at", Value: firestore.ServerTimestamp},
+	docRef := r.client.Collection(r.collection).Doc(notificationID)
+	return r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(docRef)
+		if err != nil {
+			return err
+		}
+
+		var notification models.Notification
+		if err := doc.DataTo(&notification); err != nil {
+			return err
+		}
+		if notification.Read {
+			return nil
+		}
+
+		if err := tx.Update(docRef, []firestore.Update{
+			{Path: "read", Value: true},
+			{Path: "read_at", Value: firestore.ServerTimestamp},
+		}); err != nil {
+			return err
+		}
+		return tx.Set(r.counterRef(notification.UserID), map[string]interface{}{
+			"unread": firestore.Increment(-1),
+		}, firestore.MergeAll)
 	})
-	return err
+}
+
+// MarkAllAsRead marca como leídas todas las notificaciones de un usuario y retorna cuántas marcó
+func (r *FirestoreNotificationRepository) MarkAllAsRead(ctx context.Context, userID string) (int, error) {
+	total := 0
+	for {
+		refs, err := r.client.Collection(r.collection).
+			Where("user_id", "==", userID).
+			Where("read", "==", false).
+			Limit(notificationBatchSize).
+			Documents(ctx).GetAll()
+		if err != nil {
+			return total, err
+		}
+		if len(refs) == 0 {
+			break
+		}
+
+		marked := 0
+		err = r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+			marked = 0
+			docRefs := make([]*firestore.DocumentRef, 0, len(refs))
+			for _, doc := range refs {
+				docRefs = append(docRefs, doc.Ref)
+			}
+
+			// Releer dentro de la transacción para no descontar dos veces una notificación
+			docs, err := tx.GetAll(docRefs)
+			if err != nil {
+				return err
+			}
+
+			for _, doc := range docs {
+				if !doc.Exists() {
+					continue
+				}
+				if read, _ := doc.DataAt("read"); read == true {
+					continue
+				}
+				if err := tx.Update(doc.Ref, []firestore.Update{
+					{Path: "read", Value: true},
+					{Path: "read_at", Value: firestore.ServerTimestamp},
+				}); err != nil {
+					return err
+				}
+				marked++
+			}
+
+			if marked == 0 {
+				return nil
+			}
+			return tx.Set(r.counterRef(userID), map[string]interface{}{
+				"unread": firestore.Increment(-marked),
+			}, firestore.MergeAll)
+		})
+		if err != nil {
+			return total, err
+		}
+		total += marked
+
+		if len(refs) < notificationBatchSize {
+			break
+		}
+	}
+
+	return total, nil
 }
 
 // GetUnreadByUser obtiene todas las notificaciones no leídas de un usuario
@@ -74,6 +199,118 @@ func (r *FirestoreNotificationRepository) GetUnreadByUser(ctx context.Context, u
 	return notifications, nil
 }
 
+// List obtiene una página de notificaciones de un usuario, de la más reciente a la más antigua.
+// El cursor es el ID de la última notificación de la página anterior.
+func (r *FirestoreNotificationRepository) List(ctx context.Context, filters models.NotificationFilters) ([]*models.Notification, error) {
+	query := r.client.Collection(r.collection).Where("user_id", "==", filters.UserID)
+	if filters.Type != "" {
+		query = query.Where("type", "==", filters.Type)
+	}
+	if filters.Read != nil {
+		query = query.Where("read", "==", *filters.Read)
+	}
+	query = query.OrderBy("created_at", firestore.Desc)
+
+	if filters.Cursor != "" {
+		cursor, err := r.client.Collection(r.collection).Doc(filters.Cursor).Get(ctx)
+		if err != nil {
+			return nil, err
+		}
+		query = query.StartAfter(cursor)
+	}
+	if filters.PageSize > 0 {
+		query = query.Limit(filters.PageSize)
+	}
+
+	iter := query.Documents(ctx)
+	defer iter.Stop()
+
+	var notifications []*models.Notification
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var notification models.Notification
+		if err := doc.DataTo(&notification); err != nil {
+			return nil, err
+		}
+		notification.ID = doc.Ref.ID
+		notifications = append(notifications, &notification)
+	}
+
+	return notifications, nil
+}
+
+// CountUnread obtiene el número de notificaciones no leídas de un usuario desde su contador.
+// Si el contador no se recontó nunca o quedó negativo, lo recalcula.
+func (r *FirestoreNotificationRepository) CountUnread(ctx context.Context, userID string) (int, error) {
+	doc, err := r.counterRef(userID).Get(ctx)
+	if err != nil {
+		if status.Code(err) != codes.NotFound {
+			return 0, err
+		}
+		_, count, err := r.RecountUnread(ctx, userID)
+		return count, err
+	}
+
+	if _, err := doc.DataAt("recounted_at"); err != nil {
+		_, count, err := r.RecountUnread(ctx, userID)
+		return count, err
+	}
+	unread, err := doc.DataAt("unread")
+	if err != nil {
+		return 0, nil
+	}
+	count, _ := unread.(int64)
+	if count < 0 {
+		_, recounted, err := r.RecountUnread(ctx, userID)
+		return recounted, err
+	}
+	return int(count), nil
+}
+
+// RecountUnread recalcula el contador de no leídas de un usuario contando sus notificaciones
+// no leídas. Retorna el valor anterior del contador y el recalculado.
+func (r *FirestoreNotificationRepository) RecountUnread(ctx context.Context, userID string) (int, int, error) {
+	counterRef := r.counterRef(userID)
+	query := r.client.Collection(r.collection).
+		Where("user_id", "==", userID).
+		Where("read", "==", false)
+
+	previous, count := 0, 0
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		previous, count = 0, 0
+
+		doc, err := tx.Get(counterRef)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+		if err == nil {
+			if unread, err := doc.DataAt("unread"); err == nil {
+				n, _ := unread.(int64)
+				previous = int(n)
+			}
+		}
+
+		docs, err := tx.Documents(query).GetAll()
+		if err != nil {
+			return err
+		}
+		count = len(docs)
+
+		return tx.Set(counterRef, map[string]interface{}{
+			"unread":       count,
+			"recounted_at": firestore.ServerTimestamp,
+		})
+	})
+	return previous, count, err
+}
+
 // GetOlderThan obtiene todas las notificaciones más antiguas que la fecha especificada
 func (r *FirestoreNotificationRepository) GetOlderThan(ctx context.Context, olderThan time.Time) ([]*models.Notification, error) {
 	iter := r.client.Collection(r.collection).
@@ -100,6 +337,40 @@ func (r *FirestoreNotificationRepository) GetOlderThan(ctx context.Context, olde
 
 // Delete elimina una notificación
 func (r *FirestoreNotificationRepository) Delete(ctx context.Context, notificationID string) error {
-	_, err := r.client.Collection(r.collection).Doc(notificationID).Delete(ctx)
-	return err
+	docRef := r.client.Collection(r.collection).Doc(notificationID)
+	return r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(docRef)
+		if err != nil {
+			if status.Code(err) == codes.NotFound {
+				return nil
+			}
+			return err
+		}
+
+		var notification models.Notification
+		if err := doc.DataTo(&notification); err != nil {
+			return err
+		}
+
+		if err := tx.Delete(docRef); err != nil {
+			return err
+		}
+		if notification.Read {
+			return nil
+		}
+		return tx.Set(r.counterRef(notification.UserID), map[string]interface{}{
+			"unread": firestore.Increment(-1),
+		}, firestore.MergeAll)
+	})
+}
+
+func (r *FirestoreNotificationRepository) counterRef(userID string) *firestore.DocumentRef {
+	return r.client.Collection(r.counterCollection).Doc(userID)
+}
+
+// addUnread suma delta al contador de no leídas del usuario dentro de un lote
+func (r *FirestoreNotificationRepository) addUnread(batch *firestore.WriteBatch, userID string, delta int) {
+	batch.Set(r.counterRef(userID), map[string]interface{}{
+		"unread": firestore.Increment(delta),
+	}, firestore.MergeAll)
 }
@@ -0,0 +1,160 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/kha0sys/nodo.social/functions/domain/models"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// PendingNotificationRepository define la interfaz para las notificaciones agrupadas pendientes de entrega
+type PendingNotificationRepository interface {
+	Add(ctx context.Context, notification *models.Notification, window time.Duration) (*models.PendingNotification, error)
+	GetDue(ctx context.Context, before time.Time, limit int) ([]*models.PendingNotification, error)
+	Claim(ctx context.Context, pendingID string) (*models.PendingNotification, error)
+	Release(ctx context.Context, pending *models.PendingNotification, deliverAt time.Time) error
+}
+
+// FirestorePendingNotificationRepository implementa PendingNotificationRepository usando Firestore
+type FirestorePendingNotificationRepository struct {
+	client     *firestore.Client
+	collection string
+}
+
+// NewFirestorePendingNotificationRepository crea una nueva instancia de FirestorePendingNotificationRepository
+func NewFirestorePendingNotificationRepository(client *firestore.Client) *FirestorePendingNotificationRepository {
+	return &FirestorePendingNotificationRepository{
+		client:     client,
+		collection: "pending_notifications",
+	}
+}
+
+// Add agrupa la notificación con la pendiente del mismo usuario, tipo y objetivo.
+// Si no hay ninguna abierta, crea una que se entregará al cerrarse la ventana.
+func (r *FirestorePendingNotificationRepository) Add(ctx context.Context, notification *models.Notification, window time.Duration) (*models.PendingNotification, error) {
+	now := time.Now()
+	docRef := r.client.Collection(r.collection).Doc(tokenDocID(notification.UserID + "\n" + notification.Type + "\n" + notification.Target))
+
+	var pending models.PendingNotification
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(docRef)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+
+		if err == nil && doc.Exists() {
+			if err := doc.DataTo(&pending); err != nil {
+				return err
+			}
+			pending.Count++
+		} else {
+			pending = models.PendingNotification{
+				UserID:    notification.UserID,
+				Type:      notification.Type,
+				Target:    notification.Target,
+				Count:     1,
+				FirstAt:   now,
+				DeliverAt: now.Add(window),
+			}
+		}
+
+		// Se conservan los datos más recientes
+		pending.Notification = *notification
+		pending.LastAt = now
+		return tx.Set(docRef, &pending)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pending.ID = docRef.ID
+	return &pending, nil
+}
+
+// GetDue obtiene las notificaciones agrupadas cuya ventana ya se cerró
+func (r *FirestorePendingNotificationRepository) GetDue(ctx context.Context, before time.Time, limit int) ([]*models.PendingNotification, error) {
+	iter := r.client.Collection(r.collection).
+		Where("deliver_at", "<=", before).
+		OrderBy("deliver_at", firestore.Asc).
+		Limit(limit).
+		Documents(ctx)
+	defer iter.Stop()
+
+	var pendings []*models.PendingNotification
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var pending models.PendingNotification
+		if err := doc.DataTo(&pending); err != nil {
+			return nil, err
+		}
+		pending.ID = doc.Ref.ID
+		pendings = append(pendings, &pending)
+	}
+
+	return pendings, nil
+}
+
+// Claim elimina la notificación agrupada y la retorna, de modo que solo un proceso la entregue.
+// Retorna nil si otro proceso ya la tomó.
+func (r *FirestorePendingNotificationRepository) Claim(ctx context.Context, pendingID string) (*models.PendingNotification, error) {
+	docRef := r.client.Collection(r.collection).Doc(pendingID)
+
+	var pending *models.PendingNotification
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		pending = nil
+		doc, err := tx.Get(docRef)
+		if err != nil {
+			if status.Code(err) == codes.NotFound {
+				return nil
+			}
+			return err
+		}
+
+		var claimed models.PendingNotification
+		if err := doc.DataTo(&claimed); err != nil {
+			return err
+		}
+		claimed.ID = doc.Ref.ID
+		pending = &claimed
+		return tx.Delete(docRef)
+	})
+	return pending, err
+}
+
+// Release devuelve una notificación tomada con Claim que no se entregó, para entregarla en
+// deliverAt. Si mientras tanto se agruparon otras con ella, se suman a las devueltas.
+func (r *FirestorePendingNotificationRepository) Release(ctx context.Context, pending *models.PendingNotification, deliverAt time.Time) error {
+	docRef := r.client.Collection(r.collection).Doc(pending.ID)
+	return r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		released := *pending
+		doc, err := tx.Get(docRef)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+
+		if err == nil && doc.Exists() {
+			var added models.PendingNotification
+			if err := doc.DataTo(&added); err != nil {
+				return err
+			}
+			// Se conservan los datos más recientes
+			released.Notification = added.Notification
+			released.LastAt = added.LastAt
+			released.Count += added.Count
+		}
+
+		released.DeliverAt = deliverAt
+		return tx.Set(docRef, &released)
+	})
+}
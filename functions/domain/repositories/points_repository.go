@@ -0,0 +1,283 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/kha0sys/nodo.social/functions/domain/models"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// PointsRepository define la interfaz para el historial de puntos y el total de cada usuario
+type PointsRepository interface {
+	Award(ctx context.Context, entry *models.PointsEntry, levelFor func(int) int) (*models.PointsAward, error)
+	List(ctx context.Context, userID string, cursor string, limit int) ([]*models.PointsEntry, error)
+	GetTotal(ctx context.Context, userID string) (*models.UserPoints, error)
+	Reconcile(ctx context.Context, userID string, levelFor func(int) int) (int, int, error)
+	Totals(ctx context.Context) (map[string]int, error)
+	SumSince(ctx context.Context, since time.Time) (map[string]int, error)
+}
+
+// FirestorePointsRepository implementa PointsRepository usando Firestore. Cada movimiento
+// se guarda con el ID <usuario>_<clave>, y el total en user_points y en el campo points del
+// usuario se actualiza en la misma transacción, junto con su nivel.
+type FirestorePointsRepository struct {
+	client           *firestore.Client
+	collection       string
+	totalsCollection string
+	usersCollection  string
+}
+
+// NewFirestorePointsRepository crea una nueva instancia de FirestorePointsRepository
+func NewFirestorePointsRepository(client *firestore.Client) *FirestorePointsRepository {
+	return &FirestorePointsRepository{
+		client:           client,
+		collection:       "points_ledger",
+		totalsCollection: "user_points",
+		usersCollection:  "users",
+	}
+}
+
+// Award registra un movimiento, suma sus puntos al total y actualiza el nivel del usuario
+// según levelFor. Si ya existía un movimiento con la misma clave no se suma nada.
+func (r *FirestorePointsRepository) Award(ctx context.Context, entry *models.PointsEntry, levelFor func(int) int) (*models.PointsAward, error) {
+	entryRef := r.client.Collection(r.collection).Doc(entry.UserID + "_" + entry.Key)
+	totalRef := r.client.Collection(r.totalsCollection).Doc(entry.UserID)
+	userRef := r.client.Collection(r.usersCollection).Doc(entry.UserID)
+
+	var award *models.PointsAward
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		totals, err := r.getTotal(tx, totalRef, entry.UserID)
+		if err != nil {
+			return err
+		}
+		level, userExists, err := r.getLevel(tx, userRef, levelFor(totals.Total))
+		if err != nil {
+			return err
+		}
+		award = &models.PointsAward{Total: totals.Total, PreviousLevel: level, Level: level}
+
+		if _, err := tx.Get(entryRef); err == nil {
+			return nil
+		} else if status.Code(err) != codes.NotFound {
+			return err
+		}
+
+		award.Total += entry.Points
+		award.Created = true
+		award.Level = levelFor(award.Total)
+		if err := tx.Create(entryRef, entry); err != nil {
+			return err
+		}
+		return r.setTotal(tx, totalRef, userRef, userExists, entry.UserID, award.Total, award.Level)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if award.Created {
+		entry.ID = entryRef.ID
+	}
+	return award, nil
+}
+
+// List obtiene una página del historial de un usuario, del movimiento más reciente al más antiguo.
+// El cursor es el ID del último movimiento de la página anterior.
+func (r *FirestorePointsRepository) List(ctx context.Context, userID string, cursor string, limit int) ([]*models.PointsEntry, error) {
+	query := r.client.Collection(r.collection).
+		Where("user_id", "==", userID).
+		OrderBy("created_at", firestore.Desc)
+
+	if cursor != "" {
+		doc, err := r.client.Collection(r.collection).Doc(cursor).Get(ctx)
+		if err != nil {
+			return nil, err
+		}
+		query = query.StartAfter(doc)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	iter := query.Documents(ctx)
+	defer iter.Stop()
+
+	entries := make([]*models.PointsEntry, 0)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var entry models.PointsEntry
+		if err := doc.DataTo(&entry); err != nil {
+			return nil, err
+		}
+		entry.ID = doc.Ref.ID
+		entries = append(entries, &entry)
+	}
+	return entries, nil
+}
+
+// GetTotal obtiene el total de puntos de un usuario; si no tiene movimientos es cero
+func (r *FirestorePointsRepository) GetTotal(ctx context.Context, userID string) (*models.UserPoints, error) {
+	doc, err := r.client.Collection(r.totalsCollection).Doc(userID).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return &models.UserPoints{UserID: userID}, nil
+		}
+		return nil, err
+	}
+
+	var points models.UserPoints
+	if err := doc.DataTo(&points); err != nil {
+		return nil, err
+	}
+	return &points, nil
+}
+
+// Reconcile recalcula el total de un usuario sumando su historial y lo guarda junto con su nivel.
+// Retorna el total anterior y el recalculado.
+func (r *FirestorePointsRepository) Reconcile(ctx context.Context, userID string, levelFor func(int) int) (int, int, error) {
+	totalRef := r.client.Collection(r.totalsCollection).Doc(userID)
+	userRef := r.client.Collection(r.usersCollection).Doc(userID)
+	query := r.client.Collection(r.collection).Where("user_id", "==", userID)
+
+	previous, total := 0, 0
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		previous, total = 0, 0
+
+		totals, err := r.getTotal(tx, totalRef, userID)
+		if err != nil {
+			return err
+		}
+		previous = totals.Total
+
+		docs, err := tx.Documents(query).GetAll()
+		if err != nil {
+			return err
+		}
+		for _, doc := range docs {
+			var entry models.PointsEntry
+			if err := doc.DataTo(&entry); err != nil {
+				return err
+			}
+			total += entry.Points
+		}
+
+		_, userExists, err := r.getLevel(tx, userRef, 0)
+		if err != nil {
+			return err
+		}
+		return r.setTotal(tx, totalRef, userRef, userExists, userID, total, levelFor(total))
+	})
+	return previous, total, err
+}
+
+// Totals obtiene el total de puntos de todos los usuarios que tienen alguno
+func (r *FirestorePointsRepository) Totals(ctx context.Context) (map[string]int, error) {
+	iter := r.client.Collection(r.totalsCollection).Documents(ctx)
+	defer iter.Stop()
+
+	totals := make(map[string]int)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var points models.UserPoints
+		if err := doc.DataTo(&points); err != nil {
+			return nil, err
+		}
+		totals[doc.Ref.ID] = points.Total
+	}
+	return totals, nil
+}
+
+// SumSince suma por usuario los puntos de los movimientos registrados desde since
+func (r *FirestorePointsRepository) SumSince(ctx context.Context, since time.Time) (map[string]int, error) {
+	iter := r.client.Collection(r.collection).Where("created_at", ">=", since).Documents(ctx)
+	defer iter.Stop()
+
+	sums := make(map[string]int)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var entry models.PointsEntry
+		if err := doc.DataTo(&entry); err != nil {
+			return nil, err
+		}
+		sums[entry.UserID] += entry.Points
+	}
+	return sums, nil
+}
+
+func (r *FirestorePointsRepository) getTotal(tx *firestore.Transaction, ref *firestore.DocumentRef, userID string) (*models.UserPoints, error) {
+	doc, err := tx.Get(ref)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return &models.UserPoints{UserID: userID}, nil
+		}
+		return nil, err
+	}
+
+	var points models.UserPoints
+	if err := doc.DataTo(&points); err != nil {
+		return nil, err
+	}
+	return &points, nil
+}
+
+// getLevel obtiene el nivel guardado en el usuario e indica si el usuario existe. Si el
+// usuario no tiene nivel guardado retorna missing.
+func (r *FirestorePointsRepository) getLevel(tx *firestore.Transaction, ref *firestore.DocumentRef, missing int) (int, bool, error) {
+	doc, err := tx.Get(ref)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+
+	level, err := doc.DataAt("level")
+	if err != nil {
+		// Los usuarios anteriores a los niveles no tienen el campo
+		return missing, true, nil
+	}
+	n, _ := level.(int64)
+	return int(n), true, nil
+}
+
+// setTotal guarda el total en user_points y, si el usuario existe, sus campos points y level
+func (r *FirestorePointsRepository) setTotal(tx *firestore.Transaction, totalRef *firestore.DocumentRef, userRef *firestore.DocumentRef, userExists bool, userID string, total int, level int) error {
+	if err := tx.Set(totalRef, &models.UserPoints{
+		UserID:    userID,
+		Total:     total,
+		UpdatedAt: time.Now().Unix(),
+	}); err != nil {
+		return err
+	}
+	if !userExists {
+		return nil
+	}
+	return tx.Update(userRef, []firestore.Update{
+		{Path: "points", Value: total},
+		{Path: "level", Value: level},
+	})
+}
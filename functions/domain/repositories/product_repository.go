@@ -2,9 +2,13 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"cloud.google.com/go/firestore"
+	"github.com/kha0sys/nodo.social/functions/domain/errors"
 	"github.com/kha0sys/nodo.social/functions/domain/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // ProductRepository define la interfaz para operaciones con productos
@@ -12,8 +16,10 @@ type ProductRepository interface {
 	Create(ctx context.Context, product *models.Product) error
 	Get(ctx context.Context, productID string) (*models.Product, error)
 	Update(ctx context.Context, product *models.Product) error
+	UpdateMedia(ctx context.Context, productID string, update func(product *models.Product) error) error
 	Delete(ctx context.Context, productID string) error
 	GetByNode(ctx context.Context, nodeID string) ([]*models.Product, error)
+	GetByUser(ctx context.Context, userID string) ([]*models.Product, error)
 }
 
 // FirestoreProductRepository implementa ProductRepository usando Firestore
@@ -98,3 +104,53 @@ func (r *FirestoreProductRepository) GetByNode(ctx context.Context, nodeID strin
 
 	return products, nil
 }
+
+// GetByUser obtiene todos los productos vinculados por un usuario
+func (r *FirestoreProductRepository) GetByUser(ctx context.Context, userID string) ([]*models.Product, error) {
+	docs, err := r.client.Collection(r.collection).Where("userId", "==", userID).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	products := make([]*models.Product, 0, len(docs))
+	for _, doc := range docs {
+		var product models.Product
+		if err := doc.DataTo(&product); err != nil {
+			continue
+		}
+		product.ID = doc.Ref.ID
+		products = append(products, &product)
+	}
+
+	return products, nil
+}
+
+// UpdateMedia aplica update al producto dentro de una transacción y guarda solo sus imágenes y
+// sus datos, sin pisar los cambios de otros campos. Falla con NotFound si el producto no existe.
+func (r *FirestoreProductRepository) UpdateMedia(ctx context.Context, productID string, update func(product *models.Product) error) error {
+	ref := r.client.Collection(r.collection).Doc(productID)
+	return r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if status.Code(err) == codes.NotFound {
+			return errors.NewNotFoundError("product not found")
+		}
+		if err != nil {
+			return err
+		}
+
+		var product models.Product
+		if err := doc.DataTo(&product); err != nil {
+			return err
+		}
+		product.ID = doc.Ref.ID
+		if err := update(&product); err != nil {
+			return err
+		}
+
+		return tx.Update(ref, []firestore.Update{
+			{Path: "images", Value: product.Images},
+			{Path: "mediaDetails", Value: product.MediaDetails},
+			{Path: "updatedAt", Value: time.Now()},
+		})
+	})
+}
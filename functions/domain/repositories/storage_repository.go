@@ -3,8 +3,16 @@ package repositories
 import (
 	"context"
 	"io"
+	"time"
 )
 
+// StoredObject es un archivo del storage
+type StoredObject struct {
+	Path    string
+	Size    int64
+	Created time.Time
+}
+
 // StorageRepository define la interfaz para operaciones con almacenamiento de archivos
 type StorageRepository interface {
 	// Upload sube un archivo al storage y retorna su URL pública
@@ -18,4 +26,23 @@ type StorageRepository interface {
 
 	// GetURL obtiene la URL pública de un archivo
 	GetURL(ctx context.Context, path string) (string, error)
+
+	// PublicURL retorna la URL pública de un archivo, la misma que retorna Upload
+	PublicURL(path string) string
+
+	// SignedUploadURL genera una URL firmada para subir con PUT un archivo en path hasta
+	// expires. La subida solo se acepta con el tipo, el tamaño y el hash MD5 (en base64) indicados.
+	SignedUploadURL(ctx context.Context, path string, contentType string, size int64, md5 string, expires time.Time) (string, error)
+
+	// Stat obtiene el tamaño y el hash MD5 (en base64) de un archivo. Falla con un error
+	// NotFound si no existe.
+	Stat(ctx context.Context, path string) (int64, string, error)
+
+	// List llama a fn con cada archivo cuya ruta empieza con prefix, en orden alfabético.
+	// Se detiene en el primer error que retorne fn.
+	List(ctx context.Context, prefix string, fn func(object StoredObject) error) error
+
+	// PathFromURL retorna la ruta del archivo del bucket al que apunta una URL pública o
+	// de descarga de Firebase. Retorna false si la URL es de otro sitio o de otro bucket.
+	PathFromURL(fileURL string) (string, bool)
 }
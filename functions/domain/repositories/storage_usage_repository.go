@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/kha0sys/nodo.social/functions/domain/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// StorageUsageRepository define la interfaz para el espacio ocupado por cada usuario
+type StorageUsageRepository interface {
+	Get(ctx context.Context, userID string) (*models.StorageUsage, error)
+	Add(ctx context.Context, userID string, bytes int64, files int) (*models.StorageUsage, error)
+}
+
+// FirestoreStorageUsageRepository implementa StorageUsageRepository usando Firestore.
+// El uso de cada usuario se guarda en un documento con su ID.
+type FirestoreStorageUsageRepository struct {
+	client     *firestore.Client
+	collection string
+}
+
+// NewFirestoreStorageUsageRepository crea una nueva instancia de FirestoreStorageUsageRepository
+func NewFirestoreStorageUsageRepository(client *firestore.Client) *FirestoreStorageUsageRepository {
+	return &FirestoreStorageUsageRepository{
+		client:     client,
+		collection: "storage_usage",
+	}
+}
+
+// Get obtiene el uso de un usuario; si no subió nada retorna un uso vacío
+func (r *FirestoreStorageUsageRepository) Get(ctx context.Context, userID string) (*models.StorageUsage, error) {
+	usage := &models.StorageUsage{UserID: userID}
+	doc, err := r.client.Collection(r.collection).Doc(userID).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return usage, nil
+		}
+		return nil, err
+	}
+	if err := doc.DataTo(usage); err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+// Add suma bytes y archivos al uso de un usuario, negativos al eliminar, y retorna el uso
+// resultante. Nunca baja de cero aunque los eventos lleguen desordenados.
+func (r *FirestoreStorageUsageRepository) Add(ctx context.Context, userID string, bytes int64, files int) (*models.StorageUsage, error) {
+	ref := r.client.Collection(r.collection).Doc(userID)
+
+	var usage *models.StorageUsage
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		usage = &models.StorageUsage{UserID: userID}
+		doc, err := tx.Get(ref)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+		if err == nil {
+			if err := doc.DataTo(usage); err != nil {
+				return err
+			}
+		}
+
+		usage.UsedBytes += bytes
+		if usage.UsedBytes < 0 {
+			usage.UsedBytes = 0
+		}
+		usage.Files += files
+		if usage.Files < 0 {
+			usage.Files = 0
+		}
+		usage.UpdatedAt = time.Now()
+		return tx.Set(ref, usage)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"github.com/kha0sys/nodo.social/functions/domain/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// StreakRepository define la interfaz para las rachas de actividad de los usuarios
+type StreakRepository interface {
+	GetByUser(ctx context.Context, userID string) ([]*models.Streak, error)
+	Update(ctx context.Context, userID string, period string, update func(*models.Streak) (bool, error)) (*models.Streak, bool, error)
+}
+
+// FirestoreStreakRepository implementa StreakRepository usando Firestore
+type FirestoreStreakRepository struct {
+	client     *firestore.Client
+	collection string
+}
+
+// NewFirestoreStreakRepository crea una nueva instancia de FirestoreStreakRepository
+func NewFirestoreStreakRepository(client *firestore.Client) *FirestoreStreakRepository {
+	return &FirestoreStreakRepository{
+		client:     client,
+		collection: "streaks",
+	}
+}
+
+// GetByUser obtiene las rachas de un usuario
+func (r *FirestoreStreakRepository) GetByUser(ctx context.Context, userID string) ([]*models.Streak, error) {
+	docs, err := r.client.Collection(r.collection).Where("user_id", "==", userID).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	streaks := make([]*models.Streak, 0, len(docs))
+	for _, doc := range docs {
+		var streak models.Streak
+		if err := doc.DataTo(&streak); err != nil {
+			return nil, err
+		}
+		streaks = append(streaks, &streak)
+	}
+	return streaks, nil
+}
+
+// Update aplica update a la racha de un periodo dentro de una transacción y la guarda si
+// update retorna true. Si el usuario no tenía racha, update recibe una vacía.
+func (r *FirestoreStreakRepository) Update(ctx context.Context, userID string, period string, update func(*models.Streak) (bool, error)) (*models.Streak, bool, error) {
+	ref := r.client.Collection(r.collection).Doc(userID + "_" + period)
+
+	var streak *models.Streak
+	changed := false
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		streak = &models.Streak{UserID: userID, Period: period}
+		doc, err := tx.Get(ref)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+		if err == nil {
+			if err := doc.DataTo(streak); err != nil {
+				return err
+			}
+		}
+
+		changed, err = update(streak)
+		if err != nil || !changed {
+			return err
+		}
+		return tx.Set(ref, streak)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return streak, changed, nil
+}
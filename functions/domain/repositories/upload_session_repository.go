@@ -0,0 +1,108 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/kha0sys/nodo.social/functions/domain/errors"
+	"github.com/kha0sys/nodo.social/functions/domain/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UploadSessionRepository define la interfaz para las sesiones de subida directa al storage
+type UploadSessionRepository interface {
+	NewID() string
+	Get(ctx context.Context, sessionID string) (*models.UploadSession, error)
+	GetByPath(ctx context.Context, path string) (*models.UploadSession, error)
+	Save(ctx context.Context, session *models.UploadSession) error
+	ListCreatedBefore(ctx context.Context, before time.Time, limit int) ([]*models.UploadSession, error)
+	Delete(ctx context.Context, sessionID string) error
+}
+
+// FirestoreUploadSessionRepository implementa UploadSessionRepository usando Firestore.
+// Las sesiones se guardan en la colección de archivos temporales.
+type FirestoreUploadSessionRepository struct {
+	client     *firestore.Client
+	collection string
+}
+
+// NewFirestoreUploadSessionRepository crea una nueva instancia de FirestoreUploadSessionRepository
+func NewFirestoreUploadSessionRepository(client *firestore.Client) *FirestoreUploadSessionRepository {
+	return &FirestoreUploadSessionRepository{
+		client:     client,
+		collection: "temp_files",
+	}
+}
+
+// NewID genera el ID de una sesión nueva, que también forma parte de la ruta del archivo
+func (r *FirestoreUploadSessionRepository) NewID() string {
+	return r.client.Collection(r.collection).NewDoc().ID
+}
+
+// Get obtiene una sesión
+func (r *FirestoreUploadSessionRepository) Get(ctx context.Context, sessionID string) (*models.UploadSession, error) {
+	doc, err := r.client.Collection(r.collection).Doc(sessionID).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, errors.NewNotFoundError("upload session not found")
+		}
+		return nil, err
+	}
+	return r.decode(doc)
+}
+
+// GetByPath obtiene la sesión de un archivo; retorna nil si el archivo no se subió con una sesión
+func (r *FirestoreUploadSessionRepository) GetByPath(ctx context.Context, path string) (*models.UploadSession, error) {
+	docs, err := r.client.Collection(r.collection).Where("path", "==", path).Limit(1).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, nil
+	}
+	return r.decode(docs[0])
+}
+
+// Save guarda o reemplaza una sesión
+func (r *FirestoreUploadSessionRepository) Save(ctx context.Context, session *models.UploadSession) error {
+	_, err := r.client.Collection(r.collection).Doc(session.ID).Set(ctx, session)
+	return err
+}
+
+// ListCreatedBefore obtiene las sesiones creadas antes de la fecha indicada
+func (r *FirestoreUploadSessionRepository) ListCreatedBefore(ctx context.Context, before time.Time, limit int) ([]*models.UploadSession, error) {
+	docs, err := r.client.Collection(r.collection).
+		Where("created_at", "<", before).
+		Limit(limit).
+		Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*models.UploadSession, 0, len(docs))
+	for _, doc := range docs {
+		session, err := r.decode(doc)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
+
+// Delete elimina una sesión
+func (r *FirestoreUploadSessionRepository) Delete(ctx context.Context, sessionID string) error {
+	_, err := r.client.Collection(r.collection).Doc(sessionID).Delete(ctx)
+	return err
+}
+
+func (r *FirestoreUploadSessionRepository) decode(doc *firestore.DocumentSnapshot) (*models.UploadSession, error) {
+	var session models.UploadSession
+	if err := doc.DataTo(&session); err != nil {
+		return nil, err
+	}
+	session.ID = doc.Ref.ID
+	return &session, nil
+}
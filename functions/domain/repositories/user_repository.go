@@ -13,10 +13,13 @@ type UserRepository interface {
 	Create(ctx context.Context, user *models.User) error
 	Get(ctx context.Context, userID string) (*models.User, error)
 	Update(ctx context.Context, user *models.User) error
+	AddAchievements(ctx context.Context, userID string, achievements []models.UserAchievement) error
+	SetStreakDays(ctx context.Context, userID string, days int) error
 	Delete(ctx context.Context, userID string) error
 	GetFollowers(ctx context.Context, userID string) ([]*models.User, error)
 	GetTotalUsers(ctx context.Context) (int, error)
 	GetActiveUsers(ctx context.Context) (int, error)
+	List(ctx context.Context, startAfter string, limit int) ([]*models.User, error)
 }
 
 // FirestoreUserRepository implementa UserRepository usando Firestore
@@ -67,6 +70,30 @@ func (r *FirestoreUserRepository) Update(ctx context.Context, user *models.User)
 	return err
 }
 
+// AddAchievements agrega logros al usuario sin reescribir el resto del documento, así no
+// pisa los puntos ni el nivel que otra operación haya guardado mientras tanto
+func (r *FirestoreUserRepository) AddAchievements(ctx context.Context, userID string, achievements []models.UserAchievement) error {
+	if len(achievements) == 0 {
+		return nil
+	}
+	values := make([]interface{}, 0, len(achievements))
+	for _, achievement := range achievements {
+		values = append(values, achievement)
+	}
+	_, err := r.client.Collection(r.collection).Doc(userID).Update(ctx, []firestore.Update{
+		{Path: "achievements", Value: firestore.ArrayUnion(values...)},
+	})
+	return err
+}
+
+// SetStreakDays guarda la racha diaria actual del usuario
+func (r *FirestoreUserRepository) SetStreakDays(ctx context.Context, userID string, days int) error {
+	_, err := r.client.Collection(r.collection).Doc(userID).Update(ctx, []firestore.Update{
+		{Path: "metrics.streakDays", Value: days},
+	})
+	return err
+}
+
 // GetFollowers obtiene los seguidores de un usuario
 func (r *FirestoreUserRepository) GetFollowers(ctx context.Context, userID string) ([]*models.User, error) {
 	var followers []*models.User
@@ -112,3 +139,29 @@ func (r *FirestoreUserRepository) GetActiveUsers(ctx context.Context) (int, erro
 	}
 	return len(docs), nil
 }
+
+// List obtiene hasta limit usuarios ordenados por ID, a partir del usuario siguiente a startAfter.
+// Sirve para recorrer todos los usuarios por páginas.
+func (r *FirestoreUserRepository) List(ctx context.Context, startAfter string, limit int) ([]*models.User, error) {
+	query := r.client.Collection(r.collection).OrderBy(firestore.DocumentID, firestore.Asc).Limit(limit)
+	if startAfter != "" {
+		query = query.StartAfter(startAfter)
+	}
+
+	docs, err := query.Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]*models.User, 0, len(docs))
+	for _, doc := range docs {
+		var user models.User
+		if err := doc.DataTo(&user); err != nil {
+			return nil, err
+		}
+		user.ID = doc.Ref.ID
+		users = append(users, &user)
+	}
+
+	return users, nil
+}
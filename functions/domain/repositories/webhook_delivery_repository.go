@@ -0,0 +1,126 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/kha0sys/nodo.social/functions/domain/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// WebhookDeliveryRepository define la interfaz para el registro de entregas de webhooks.
+// Las entregas pendientes funcionan además como cola de reintentos.
+type WebhookDeliveryRepository interface {
+	Create(ctx context.Context, delivery *models.WebhookDelivery) error
+	Update(ctx context.Context, delivery *models.WebhookDelivery) error
+	GetBySubscription(ctx context.Context, subscriptionID string, limit int) ([]*models.WebhookDelivery, error)
+	GetDue(ctx context.Context, before time.Time, limit int) ([]*models.WebhookDelivery, error)
+	Claim(ctx context.Context, deliveryID string, now time.Time, lease time.Duration) (*models.WebhookDelivery, error)
+}
+
+// FirestoreWebhookDeliveryRepository implementa WebhookDeliveryRepository usando Firestore
+type FirestoreWebhookDeliveryRepository struct {
+	client     *firestore.Client
+	collection string
+}
+
+// NewFirestoreWebhookDeliveryRepository crea una nueva instancia de FirestoreWebhookDeliveryRepository
+func NewFirestoreWebhookDeliveryRepository(client *firestore.Client) *FirestoreWebhookDeliveryRepository {
+	return &FirestoreWebhookDeliveryRepository{
+		client:     client,
+		collection: "webhook_deliveries",
+	}
+}
+
+// Create guarda una nueva entrega
+func (r *FirestoreWebhookDeliveryRepository) Create(ctx context.Context, delivery *models.WebhookDelivery) error {
+	docRef := r.client.Collection(r.collection).NewDoc()
+	delivery.ID = docRef.ID
+	_, err := docRef.Set(ctx, delivery)
+	return err
+}
+
+// Update guarda el estado y los intentos de una entrega
+func (r *FirestoreWebhookDeliveryRepository) Update(ctx context.Context, delivery *models.WebhookDelivery) error {
+	_, err := r.client.Collection(r.collection).Doc(delivery.ID).Set(ctx, delivery)
+	return err
+}
+
+// GetBySubscription obtiene las entregas más recientes de una suscripción
+func (r *FirestoreWebhookDeliveryRepository) GetBySubscription(ctx context.Context, subscriptionID string, limit int) ([]*models.WebhookDelivery, error) {
+	docs, err := r.client.Collection(r.collection).
+		Where("subscription_id", "==", subscriptionID).
+		OrderBy("created_at", firestore.Desc).
+		Limit(limit).
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return nil, err
+	}
+	return webhookDeliveriesFrom(docs)
+}
+
+// GetDue obtiene las entregas pendientes cuyo siguiente intento vence antes de before
+func (r *FirestoreWebhookDeliveryRepository) GetDue(ctx context.Context, before time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	docs, err := r.client.Collection(r.collection).
+		Where("status", "==", models.WebhookDeliveryPending).
+		Where("next_attempt_at", "<=", before).
+		OrderBy("next_attempt_at", firestore.Asc).
+		Limit(limit).
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return nil, err
+	}
+	return webhookDeliveriesFrom(docs)
+}
+
+// Claim reserva una entrega pendiente durante lease para que otro proceso no la reintente
+// al mismo tiempo. Retorna nil si la entrega ya no está pendiente o la tomó otro proceso.
+func (r *FirestoreWebhookDeliveryRepository) Claim(ctx context.Context, deliveryID string, now time.Time, lease time.Duration) (*models.WebhookDelivery, error) {
+	docRef := r.client.Collection(r.collection).Doc(deliveryID)
+
+	var claimed *models.WebhookDelivery
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		claimed = nil
+		doc, err := tx.Get(docRef)
+		if err != nil {
+			if status.Code(err) == codes.NotFound {
+				return nil
+			}
+			return err
+		}
+
+		var delivery models.WebhookDelivery
+		if err := doc.DataTo(&delivery); err != nil {
+			return err
+		}
+		if delivery.Status != models.WebhookDeliveryPending || delivery.NextAttemptAt.After(now) {
+			return nil
+		}
+
+		delivery.ID = doc.Ref.ID
+		delivery.NextAttemptAt = now.Add(lease)
+		claimed = &delivery
+		return tx.Update(docRef, []firestore.Update{{Path: "next_attempt_at", Value: delivery.NextAttemptAt}})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claimed, nil
+}
+
+func webhookDeliveriesFrom(docs []*firestore.DocumentSnapshot) ([]*models.WebhookDelivery, error) {
+	deliveries := make([]*models.WebhookDelivery, 0, len(docs))
+	for _, doc := range docs {
+		var delivery models.WebhookDelivery
+		if err := doc.DataTo(&delivery); err != nil {
+			return nil, err
+		}
+		delivery.ID = doc.Ref.ID
+		deliveries = append(deliveries, &delivery)
+	}
+	return deliveries, nil
+}
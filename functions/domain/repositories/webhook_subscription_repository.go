@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"github.com/kha0sys/nodo.social/functions/domain/models"
+)
+
+// WebhookSubscriptionRepository define la interfaz para las suscripciones de webhooks
+type WebhookSubscriptionRepository interface {
+	Create(ctx context.Context, subscription *models.WebhookSubscription) error
+	Get(ctx context.Context, subscriptionID string) (*models.WebhookSubscription, error)
+	Update(ctx context.Context, subscription *models.WebhookSubscription) error
+	Delete(ctx context.Context, subscriptionID string) error
+	GetByOwner(ctx context.Context, ownerType string, ownerID string) ([]*models.WebhookSubscription, error)
+}
+
+// FirestoreWebhookSubscriptionRepository implementa WebhookSubscriptionRepository usando Firestore
+type FirestoreWebhookSubscriptionRepository struct {
+	client     *firestore.Client
+	collection string
+}
+
+// NewFirestoreWebhookSubscriptionRepository crea una nueva instancia de FirestoreWebhookSubscriptionRepository
+func NewFirestoreWebhookSubscriptionRepository(client *firestore.Client) *FirestoreWebhookSubscriptionRepository {
+	return &FirestoreWebhookSubscriptionRepository{
+		client:     client,
+		collection: "webhook_subscriptions",
+	}
+}
+
+// Create guarda una nueva suscripción
+func (r *FirestoreWebhookSubscriptionRepository) Create(ctx context.Context, subscription *models.WebhookSubscription) error {
+	docRef := r.client.Collection(r.collection).NewDoc()
+	subscription.ID = docRef.ID
+	_, err := docRef.Set(ctx, subscription)
+	return err
+}
+
+// Get obtiene una suscripción por su ID
+func (r *FirestoreWebhookSubscriptionRepository) Get(ctx context.Context, subscriptionID string) (*models.WebhookSubscription, error) {
+	doc, err := r.client.Collection(r.collection).Doc(subscriptionID).Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var subscription models.WebhookSubscription
+	if err := doc.DataTo(&subscription); err != nil {
+		return nil, err
+	}
+
+	subscription.ID = doc.Ref.ID
+	return &subscription, nil
+}
+
+// Update actualiza una suscripción existente
+func (r *FirestoreWebhookSubscriptionRepository) Update(ctx context.Context, subscription *models.WebhookSubscription) error {
+	_, err := r.client.Collection(r.collection).Doc(subscription.ID).Set(ctx, subscription)
+	return err
+}
+
+// Delete elimina una suscripción
+func (r *FirestoreWebhookSubscriptionRepository) Delete(ctx context.Context, subscriptionID string) error {
+	_, err := r.client.Collection(r.collection).Doc(subscriptionID).Delete(ctx)
+	return err
+}
+
+// GetByOwner obtiene las suscripciones de un nodo o una tienda
+func (r *FirestoreWebhookSubscriptionRepository) GetByOwner(ctx context.Context, ownerType string, ownerID string) ([]*models.WebhookSubscription, error) {
+	docs, err := r.client.Collection(r.collection).
+		Where("owner_type", "==", ownerType).
+		Where("owner_id", "==", ownerID).
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	subscriptions := make([]*models.WebhookSubscription, 0, len(docs))
+	for _, doc := range docs {
+		var subscription models.WebhookSubscription
+		if err := doc.DataTo(&subscription); err != nil {
+			return nil, err
+		}
+		subscription.ID = doc.Ref.ID
+		subscriptions = append(subscriptions, &subscription)
+	}
+
+	return subscriptions, nil
+}
@@ -1,6 +1,6 @@
 module github.com/kha0sys/nodo.social/functions
 
-go 1.13
+go 1.22.2
 
 toolchain go1.23.3
 
@@ -10,9 +10,11 @@ require (
 	cloud.google.com/go/storage v1.48.0
 	firebase.google.com/go/v4 v4.13.0
 	github.com/GoogleCloudPlatform/functions-framework-go v1.8.0
+	github.com/HugoSmits86/nativewebp v0.9.3
 	github.com/cloudevents/sdk-go/v2 v2.14.0
 	github.com/disintegration/imaging v1.6.2
 	github.com/gorilla/mux v1.8.1
+	golang.org/x/image v0.24.0
 	google.golang.org/api v0.210.0
 	google.golang.org/grpc v1.67.2
 )
@@ -62,12 +64,11 @@ require (
 	go.uber.org/multierr v1.1.0 // indirect
 	go.uber.org/zap v1.10.0 // indirect
 	golang.org/x/crypto v0.29.0 // indirect
-	golang.org/x/image v0.0.0-20220302094943-723b81ca9867 // indirect
 	golang.org/x/net v0.31.0 // indirect
 	golang.org/x/oauth2 v0.24.0 // indirect
-	golang.org/x/sync v0.9.0 // indirect
+	golang.org/x/sync v0.11.0 // indirect
 	golang.org/x/sys v0.27.0 // indirect
-	golang.org/x/text v0.20.0 // indirect
+	golang.org/x/text v0.22.0 // indirect
 	golang.org/x/time v0.8.0 // indirect
 	google.golang.org/appengine/v2 v2.0.2 // indirect
 	google.golang.org/genproto v0.0.0-20241118233622-e639e219e697 // indirect
@@ -0,0 +1,189 @@
+package email
+
+import (
+    "bytes"
+    "context"
+    "crypto/rand"
+    "crypto/tls"
+    "encoding/hex"
+    "fmt"
+    "mime"
+    "mime/multipart"
+    "mime/quotedprintable"
+    "net"
+    "net/smtp"
+    "net/textproto"
+    "sort"
+    "strings"
+    "time"
+
+    "github.com/kha0sys/nodo.social/functions/domain/models"
+)
+
+// SMTPConfig contiene los datos de conexión al servidor SMTP.
+// Sin usuario no se autentica, lo que permite usar un receptor local como MailHog (localhost:1025).
+type SMTPConfig struct {
+    Host     string
+    Port     string
+    Username string
+    Password string
+    From     string
+    Timeout  time.Duration
+}
+
+// SMTPSender implementa services.EmailSender enviando los correos por SMTP
+type SMTPSender struct {
+    config SMTPConfig
+}
+
+func NewSMTPSender(config SMTPConfig) *SMTPSender {
+    if config.Timeout <= 0 {
+        config.Timeout = 10 * time.Second
+    }
+    return &SMTPSender{config: config}
+}
+
+func (s *SMTPSender) Send(ctx context.Context, message *models.EmailMessage) error {
+    body, err := s.buildMessage(message)
+    if err != nil {
+        return fmt.Errorf("error building email: %v", err)
+    }
+
+    dialer := &net.Dialer{Timeout: s.config.Timeout}
+    conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(s.config.Host, s.config.Port))
+    if err != nil {
+        return fmt.Errorf("error connecting to SMTP server: %v", err)
+    }
+    deadline := time.Now().Add(s.config.Timeout)
+    if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+        deadline = d
+    }
+    conn.SetDeadline(deadline)
+
+    client, err := smtp.NewClient(conn, s.config.Host)
+    if err != nil {
+        conn.Close()
+        return fmt.Errorf("error starting SMTP session: %v", err)
+    }
+    defer client.Close()
+
+    if ok, _ := client.Extension("STARTTLS"); ok {
+        if err := client.StartTLS(&tls.Config{ServerName: s.config.Host}); err != nil {
+            return fmt.Errorf("error starting TLS: %v", err)
+        }
+    }
+
+    if s.config.Username != "" {
+        auth := smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+        if err := client.Auth(auth); err != nil {
+            return fmt.Errorf("error authenticating with SMTP server: %v", err)
+        }
+    }
+
+    if err := client.Mail(addressOf(s.config.From)); err != nil {
+        return fmt.Errorf("error setting sender: %v", err)
+    }
+    if err := client.Rcpt(message.To); err != nil {
+        return fmt.Errorf("error setting recipient: %v", err)
+    }
+
+    w, err := client.Data()
+    if err != nil {
+        return fmt.Errorf("error starting email data: %v", err)
+    }
+    if _, err := w.Write(body); err != nil {
+        w.Close()
+        return fmt.Errorf("error writing email: %v", err)
+    }
+    if err := w.Close(); err != nil {
+        return fmt.Errorf("error sending email: %v", err)
+    }
+
+    return client.Quit()
+}
+
+// buildMessage arma un mensaje multipart/alternative con la versión en texto plano y en HTML
+func (s *SMTPSender) buildMessage(message *models.EmailMessage) ([]byte, error) {
+    var buf bytes.Buffer
+    parts := multipart.NewWriter(&buf)
+
+    headers := map[string]string{
+        "From":         s.config.From,
+        "To":           message.To,
+        "Subject":      mime.QEncoding.Encode("utf-8", message.Subject),
+        "Date":         time.Now().Format(time.RFC1123Z),
+        "Message-ID":   fmt.Sprintf("<%s@%s>", randomID(), domainOf(s.config.From)),
+        "MIME-Version": "1.0",
+        "Content-Type": fmt.Sprintf("multipart/alternative; boundary=%q", parts.Boundary()),
+    }
+    for k, v := range message.Headers {
+        headers[k] = v
+    }
+
+    keys := make([]string, 0, len(headers))
+    for k := range headers {
+        keys = append(keys, k)
+    }
+    sort.Strings(keys)
+
+    var out bytes.Buffer
+    for _, k := range keys {
+        fmt.Fprintf(&out, "%s: %s\r\n", k, headers[k])
+    }
+    out.WriteString("\r\n")
+
+    if err := writePart(parts, "text/plain; charset=utf-8", message.TextBody); err != nil {
+        return nil, err
+    }
+    if message.HTMLBody != "" {
+        if err := writePart(parts, "text/html; charset=utf-8", message.HTMLBody); err != nil {
+            return nil, err
+        }
+    }
+    if err := parts.Close(); err != nil {
+        return nil, err
+    }
+
+    out.Write(buf.Bytes())
+    return out.Bytes(), nil
+}
+
+func writePart(parts *multipart.Writer, contentType string, content string) error {
+    header := textproto.MIMEHeader{}
+    header.Set("Content-Type", contentType)
+    header.Set("Content-Transfer-Encoding", "quoted-printable")
+
+    w, err := parts.CreatePart(header)
+    if err != nil {
+        return err
+    }
+    qp := quotedprintable.NewWriter(w)
+    if _, err := qp.Write([]byte(content)); err != nil {
+        return err
+    }
+    return qp.Close()
+}
+
+// addressOf extrae la dirección de un remitente con formato "Nombre <correo>"
+func addressOf(from string) string {
+    if start := strings.LastIndex(from, "<"); start >= 0 {
+        if end := strings.LastIndex(from, ">"); end > start {
+            return from[start+1 : end]
+        }
+    }
+    return from
+}
+
+func domainOf(from string) string {
+    address := addressOf(from)
+    if at := strings.LastIndex(address, "@"); at >= 0 {
+        return address[at+1:]
+    }
+    return "localhost"
+}
+
+func randomID() string {
+    b := make([]byte, 16)
+    rand.Read(b)
+    return hex.EncodeToString(b)
+}
@@ -2,20 +2,26 @@ package firebase
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"io"
+	"net/url"
+	"strings"
 	"time"
 
 	"cloud.google.com/go/storage"
 	firebase "firebase.google.com/go/v4"
+	"github.com/kha0sys/nodo.social/functions/domain/errors"
 	"github.com/kha0sys/nodo.social/functions/domain/repositories"
+	"google.golang.org/api/iterator"
 )
 
 // FirebaseStorageRepository implementa StorageRepository usando Firebase Storage
 type FirebaseStorageRepository struct {
-	bucket  *storage.BucketHandle
-	app     *firebase.App
-	baseURL string
+	bucket     *storage.BucketHandle
+	bucketName string
+	app        *firebase.App
+	baseURL    string
 }
 
 // NewFirebaseStorageRepository crea una nueva instancia de FirebaseStorageRepository
@@ -33,9 +39,10 @@ func NewFirebaseStorageRepository(ctx context.Context, app *firebase.App, bucket
 	baseURL := fmt.Sprintf("https://storage.googleapis.com/%s", bucketName)
 
 	return &FirebaseStorageRepository{
-		bucket:  bucket,
-		app:     app,
-		baseURL: baseURL,
+		bucket:     bucket,
+		bucketName: bucketName,
+		app:        app,
+		baseURL:    baseURL,
 	}, nil
 }
 
@@ -53,7 +60,7 @@ func (r *FirebaseStorageRepository) Upload(ctx context.Context, path string, con
 		return "", fmt.Errorf("error closing writer: %v", err)
 	}
 
-	return fmt.Sprintf("%s/%s", r.baseURL, path), nil
+	return r.PublicURL(path), nil
 }
 
 // Download implementa StorageRepository.Download
@@ -78,3 +85,77 @@ func (r *FirebaseStorageRepository) GetURL(ctx context.Context, path string) (st
 	
 	return r.bucket.SignedURL(path, opts)
 }
+
+// PublicURL implementa StorageRepository.PublicURL
+func (r *FirebaseStorageRepository) PublicURL(path string) string {
+	return fmt.Sprintf("%s/%s", r.baseURL, path)
+}
+
+// SignedUploadURL implementa StorageRepository.SignedUploadURL
+func (r *FirebaseStorageRepository) SignedUploadURL(ctx context.Context, path string, contentType string, size int64, md5 string, expires time.Time) (string, error) {
+	opts := &storage.SignedURLOptions{
+		Scheme:      storage.SigningSchemeV4,
+		Method:      "PUT",
+		Expires:     expires,
+		ContentType: contentType,
+		MD5:         md5,
+		// Storage rechaza el cuerpo si no ocupa exactamente size bytes
+		Headers: []string{fmt.Sprintf("x-goog-content-length-range:%d,%d", size, size)},
+	}
+
+	return r.bucket.SignedURL(path, opts)
+}
+
+// Stat implementa StorageRepository.Stat
+func (r *FirebaseStorageRepository) Stat(ctx context.Context, path string) (int64, string, error) {
+	attrs, err := r.bucket.Object(path).Attrs(ctx)
+	if err != nil {
+		if err == storage.ErrObjectNotExist {
+			return 0, "", errors.NewNotFoundError("file not found")
+		}
+		return 0, "", err
+	}
+	return attrs.Size, base64.StdEncoding.EncodeToString(attrs.MD5), nil
+}
+
+// List implementa StorageRepository.List
+func (r *FirebaseStorageRepository) List(ctx context.Context, prefix string, fn func(object repositories.StoredObject) error) error {
+	query := &storage.Query{Prefix: prefix}
+	if err := query.SetAttrSelection([]string{"Name", "Size", "Created"}); err != nil {
+		return err
+	}
+
+	iter := r.bucket.Objects(ctx, query)
+	for {
+		attrs, err := iter.Next()
+		if err == iterator.Done {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error listing files: %v", err)
+		}
+		if err := fn(repositories.StoredObject{Path: attrs.Name, Size: attrs.Size, Created: attrs.Created}); err != nil {
+			return err
+		}
+	}
+}
+
+// PathFromURL implementa StorageRepository.PathFromURL
+func (r *FirebaseStorageRepository) PathFromURL(fileURL string) (string, bool) {
+	parsed, err := url.Parse(fileURL)
+	if err != nil {
+		return "", false
+	}
+
+	switch parsed.Host {
+	case "storage.googleapis.com":
+		// https://storage.googleapis.com/<bucket>/<ruta>
+		path, ok := strings.CutPrefix(parsed.Path, "/"+r.bucketName+"/")
+		return path, ok && path != ""
+	case "firebasestorage.googleapis.com":
+		// https://firebasestorage.googleapis.com/v0/b/<bucket>/o/<ruta escapada>?alt=media
+		path, ok := strings.CutPrefix(parsed.Path, "/v0/b/"+r.bucketName+"/o/")
+		return path, ok && path != ""
+	}
+	return "", false
+}
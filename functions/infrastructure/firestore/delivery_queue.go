@@ -0,0 +1,182 @@
+package firestore
+
+import (
+    "context"
+    "time"
+
+    "cloud.google.com/go/firestore"
+    "github.com/kha0sys/nodo.social/functions/domain/errors"
+    "github.com/kha0sys/nodo.social/functions/domain/models"
+    "google.golang.org/api/iterator"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/status"
+)
+
+// DeliveryQueue implementa services.DeliveryQueue sobre Firestore.
+// Los trabajos muertos se guardan en una colección aparte para inspeccionarlos y reencolarlos.
+type DeliveryQueue struct {
+    client               *firestore.Client
+    collection           string
+    deadLetterCollection string
+}
+
+func NewDeliveryQueue(client *firestore.Client) *DeliveryQueue {
+    return &DeliveryQueue{
+        client:               client,
+        collection:           "delivery_queue",
+        deadLetterCollection: "delivery_dead_letters",
+    }
+}
+
+func (q *DeliveryQueue) Enqueue(ctx context.Context, job *models.DeliveryJob) error {
+    docRef := q.client.Collection(q.collection).NewDoc()
+    job.ID = docRef.ID
+    job.Status = models.DeliveryPending
+    _, err := docRef.Set(ctx, job)
+    return err
+}
+
+func (q *DeliveryQueue) Lease(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*models.DeliveryJob, error) {
+    iter := q.client.Collection(q.collection).
+        Where("next_attempt_at", "<=", now).
+        OrderBy("next_attempt_at", firestore.Asc).
+        Limit(limit).
+        Documents(ctx)
+    defer iter.Stop()
+
+    var candidates []*firestore.DocumentRef
+    for {
+        doc, err := iter.Next()
+        if err == iterator.Done {
+            break
+        }
+        if err != nil {
+            return nil, err
+        }
+        candidates = append(candidates, doc.Ref)
+    }
+
+    // Cada trabajo se reserva en una transacción para que dos workers no lo tomen a la vez
+    var leased []*models.DeliveryJob
+    for _, ref := range candidates {
+        var job *models.DeliveryJob
+        err := q.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+            job = nil
+            doc, err := tx.Get(ref)
+            if err != nil {
+                if status.Code(err) == codes.NotFound {
+                    return nil
+                }
+                return err
+            }
+
+            var current models.DeliveryJob
+            if err := doc.DataTo(&current); err != nil {
+                return err
+            }
+            if current.NextAttemptAt.After(now) {
+                return nil
+            }
+
+            current.ID = ref.ID
+            current.Status = models.DeliveryProcessing
+            current.NextAttemptAt = now.Add(lease)
+            job = &current
+            return tx.Update(ref, []firestore.Update{
+                {Path: "status", Value: current.Status},
+                {Path: "next_attempt_at", Value: current.NextAttemptAt},
+            })
+        })
+        if err != nil {
+            return leased, err
+        }
+        if job != nil {
+            leased = append(leased, job)
+        }
+    }
+
+    return leased, nil
+}
+
+func (q *DeliveryQueue) Complete(ctx context.Context, job *models.DeliveryJob) error {
+    _, err := q.client.Collection(q.collection).Doc(job.ID).Delete(ctx)
+    return err
+}
+
+func (q *DeliveryQueue) Retry(ctx context.Context, job *models.DeliveryJob) error {
+    job.Status = models.DeliveryPending
+    _, err := q.client.Collection(q.collection).Doc(job.ID).Set(ctx, job)
+    return err
+}
+
+func (q *DeliveryQueue) DeadLetter(ctx context.Context, job *models.DeliveryJob) error {
+    job.Status = models.DeliveryDead
+
+    batch := q.client.Batch()
+    batch.Set(q.client.Collection(q.deadLetterCollection).Doc(job.ID), job)
+    batch.Delete(q.client.Collection(q.collection).Doc(job.ID))
+    _, err := batch.Commit(ctx)
+    return err
+}
+
+func (q *DeliveryQueue) ListDeadLetters(ctx context.Context, limit int) ([]*models.DeliveryJob, error) {
+    iter := q.client.Collection(q.deadLetterCollection).
+        OrderBy("updated_at", firestore.Desc).
+        Limit(limit).
+        Documents(ctx)
+    defer iter.Stop()
+
+    jobs := make([]*models.DeliveryJob, 0)
+    for {
+        doc, err := iter.Next()
+        if err == iterator.Done {
+            break
+        }
+        if err != nil {
+            return nil, err
+        }
+
+        var job models.DeliveryJob
+        if err := doc.DataTo(&job); err != nil {
+            return nil, err
+        }
+        job.ID = doc.Ref.ID
+        jobs = append(jobs, &job)
+    }
+
+    return jobs, nil
+}
+
+func (q *DeliveryQueue) Requeue(ctx context.Context, jobID string, extraAttempts int, now time.Time) (*models.DeliveryJob, error) {
+    deadRef := q.client.Collection(q.deadLetterCollection).Doc(jobID)
+    queueRef := q.client.Collection(q.collection).Doc(jobID)
+
+    var job models.DeliveryJob
+    err := q.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+        doc, err := tx.Get(deadRef)
+        if err != nil {
+            if status.Code(err) == codes.NotFound {
+                return errors.NewNotFoundError("entrega no encontrada")
+            }
+            return err
+        }
+        if err := doc.DataTo(&job); err != nil {
+            return err
+        }
+
+        job.ID = jobID
+        job.Status = models.DeliveryPending
+        job.MaxAttempts = len(job.Attempts) + extraAttempts
+        job.NextAttemptAt = now
+        job.UpdatedAt = now
+
+        if err := tx.Set(queueRef, &job); err != nil {
+            return err
+        }
+        return tx.Delete(deadRef)
+    })
+    if err != nil {
+        return nil, err
+    }
+    return &job, nil
+}
@@ -0,0 +1,108 @@
+package firestore
+
+import (
+    "context"
+    "log"
+    "time"
+
+    "cloud.google.com/go/firestore"
+    "github.com/kha0sys/nodo.social/functions/domain/models"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/status"
+)
+
+// liveEventRetention es el tiempo que se conservan los eventos para poder reanudar un stream
+const liveEventRetention = 24 * time.Hour
+
+// LiveEventPublisher implementa services.EventPublisher persistiendo los eventos en Firestore
+// y escuchándolos con snapshot listeners, de modo que funciona entre instancias distintas.
+type LiveEventPublisher struct {
+    client     *firestore.Client
+    collection string
+}
+
+func NewLiveEventPublisher(client *firestore.Client) *LiveEventPublisher {
+    return &LiveEventPublisher{
+        client:     client,
+        collection: "live_events",
+    }
+}
+
+func (p *LiveEventPublisher) Publish(ctx context.Context, event *models.LiveEvent) error {
+    if event.CreatedAt.IsZero() {
+        event.CreatedAt = time.Now()
+    }
+    event.ExpiresAt = event.CreatedAt.Add(liveEventRetention)
+
+    docRef := p.client.Collection(p.collection).NewDoc()
+    event.ID = docRef.ID
+    _, err := docRef.Set(ctx, event)
+    return err
+}
+
+// Subscribe escucha los eventos de userID en orden de (created_at, id). Al reanudar, sigue
+// después de lastEventID por esa posición, para no perder los eventos creados en el mismo
+// instante que él.
+func (p *LiveEventPublisher) Subscribe(ctx context.Context, userID string, lastEventID string) (<-chan *models.LiveEvent, error) {
+    query := p.client.Collection(p.collection).
+        Where("user_id", "==", userID).
+        OrderBy("created_at", firestore.Asc).
+        OrderBy(firestore.DocumentID, firestore.Asc)
+
+    resumed := false
+    if lastEventID != "" {
+        doc, err := p.client.Collection(p.collection).Doc(lastEventID).Get(ctx)
+        if err != nil && status.Code(err) != codes.NotFound {
+            return nil, err
+        }
+        if err == nil {
+            var last models.LiveEvent
+            if err := doc.DataTo(&last); err != nil {
+                return nil, err
+            }
+            query = query.StartAfter(last.CreatedAt, doc.Ref.ID)
+            resumed = true
+        }
+    }
+    if !resumed {
+        query = query.Where("created_at", ">", time.Now())
+    }
+
+    ch := make(chan *models.LiveEvent, 64)
+    go func() {
+        defer close(ch)
+
+        iter := query.Snapshots(ctx)
+        defer iter.Stop()
+
+        for {
+            snap, err := iter.Next()
+            if err != nil {
+                if ctx.Err() == nil && status.Code(err) != codes.Canceled {
+                    log.Printf("error listening live events for user %s: %v", userID, err)
+                }
+                return
+            }
+
+            for _, change := range snap.Changes {
+                if change.Kind != firestore.DocumentAdded {
+                    continue
+                }
+                var event models.LiveEvent
+                if err := change.Doc.DataTo(&event); err != nil {
+                    log.Printf("error parsing live event %s: %v", change.Doc.Ref.ID, err)
+                    continue
+                }
+                event.ID = change.Doc.Ref.ID
+
+                select {
+                case ch <- &event:
+                case <-ctx.Done():
+                    return
+                }
+            }
+        }
+    }()
+
+    return ch, nil
+}
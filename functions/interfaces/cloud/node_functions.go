@@ -48,7 +48,10 @@ func (f *NodeFunctions) CreateNode(w http.ResponseWriter, r *http.Request) {
 
 // GetNodeFeed es una Cloud Function para obtener el feed de nodos
 func (f *NodeFunctions) GetNodeFeed(w http.ResponseWriter, r *http.Request) {
-    nodes, err := f.nodeService.GetNodeFeed(r.Context())
+    // El feed es público; si la petición viene autenticada se aplican los silenciamientos del usuario
+    userID, _ := authenticateRequest(r)
+
+    nodes, err := f.nodeService.GetNodeFeed(r.Context(), userID)
     if err != nil {
         http.Error(w, err.Error(), http.StatusInternalServerError)
         return
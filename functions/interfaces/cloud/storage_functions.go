@@ -3,21 +3,92 @@ package cloud
 import (
 	"context"
 	"encoding/json"
+	stderrors "errors"
 	"fmt"
 	"net/http"
 	"path"
+	"sync"
 
 	"github.com/GoogleCloudPlatform/functions-framework-go/functions"
+	"github.com/kha0sys/nodo.social/functions/domain/errors"
+	"github.com/kha0sys/nodo.social/functions/domain/models"
+	"github.com/kha0sys/nodo.social/functions/domain/repositories"
+	infrafirebase "github.com/kha0sys/nodo.social/functions/infrastructure/firebase"
+	"github.com/kha0sys/nodo.social/functions/internal/config"
+	"github.com/kha0sys/nodo.social/functions/internal/imageprocessor"
 	"github.com/kha0sys/nodo.social/functions/services"
 )
 
 var (
-	storageService *services.StorageService
+	storageService       *services.StorageService
+	uploadSessionService *services.UploadSessionService
+	storageInitOnce      sync.Once
+	storageInitErr       error
 )
 
 func init() {
 	functions.HTTP("UploadFile", handleFileUpload)
 	functions.HTTP("GetSignedURL", handleGetSignedURL)
+	functions.HTTP("CreateUploadSession", handleCreateUploadSession)
+	functions.HTTP("GetUploadSession", handleGetUploadSession)
+	functions.HTTP("CompleteUploadSession", handleCompleteUploadSession)
+}
+
+// initStorageServices crea una sola vez los servicios de storage a partir de la
+// configuración del entorno, como InitFirebase con la aplicación de Firebase
+func initStorageServices(ctx context.Context) error {
+	storageInitOnce.Do(func() {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			storageInitErr = fmt.Errorf("error loading config: %v", err)
+			return
+		}
+		if cfg.MediaGC.Bucket == "" {
+			storageInitErr = fmt.Errorf("STORAGE_BUCKET is not set")
+			return
+		}
+
+		app, err := InitFirebase(ctx)
+		if err != nil {
+			storageInitErr = err
+			return
+		}
+		client, err := app.Firestore(ctx)
+		if err != nil {
+			storageInitErr = fmt.Errorf("error getting firestore client: %v", err)
+			return
+		}
+		storageRepo, err := infrafirebase.NewFirebaseStorageRepository(ctx, app, cfg.MediaGC.Bucket)
+		if err != nil {
+			storageInitErr = err
+			return
+		}
+		sizes, err := imageprocessor.ParseSizes(cfg.Image.ThumbnailSizes)
+		if err != nil {
+			storageInitErr = fmt.Errorf("error parsing thumbnail sizes: %v", err)
+			return
+		}
+
+		storageService = services.NewStorageService(
+			storageRepo,
+			imageprocessor.NewImageProcessor(sizes, cfg.Image.MaxPixels),
+			cfg.Image.KeepMetadata,
+			services.UploadPolicy{
+				MaxImageBytes:    cfg.Upload.MaxImageBytes,
+				MaxDocumentBytes: cfg.Upload.MaxDocumentBytes,
+				MaxVideoBytes:    cfg.Upload.MaxVideoBytes,
+				MaxAudioBytes:    cfg.Upload.MaxAudioBytes,
+				UserQuotaBytes:   cfg.Upload.UserQuotaBytes,
+			},
+			repositories.NewFirestoreStorageUsageRepository(client),
+		)
+		uploadSessionService = services.NewUploadSessionService(
+			repositories.NewFirestoreUploadSessionRepository(client),
+			storageRepo,
+			storageService,
+		)
+	})
+	return storageInitErr
 }
 
 type uploadResponse struct {
@@ -31,6 +102,10 @@ func handleFileUpload(w http.ResponseWriter, r *http.Request) {
 	}
 
 	ctx := context.Background()
+	if err := initStorageServices(ctx); err != nil {
+		http.Error(w, fmt.Sprintf("Error initializing storage: %v", err), http.StatusInternalServerError)
+		return
+	}
 
 	// Verifica la autenticación
 	userID, err := authenticateRequest(r)
@@ -39,9 +114,17 @@ func handleFileUpload(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Cortar cuerpos más grandes que el mayor archivo permitido más el resto del formulario
+	r.Body = http.MaxBytesReader(w, r.Body, storageService.MaxUploadBytes()+1<<20)
+
 	// Procesa el archivo
 	file, header, err := r.FormFile("file")
 	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if stderrors.As(err, &maxBytesErr) {
+			http.Error(w, "File too large", http.StatusRequestEntityTooLarge)
+			return
+		}
 		http.Error(w, fmt.Sprintf("Error reading file: %v", err), http.StatusBadRequest)
 		return
 	}
@@ -50,14 +133,26 @@ func handleFileUpload(w http.ResponseWriter, r *http.Request) {
 	// Determina si es una imagen
 	isImage := r.FormValue("type") == "image"
 
+	// Las imágenes de un nodo o producto se le agregan al procesarlas
+	nodeID, productID := r.FormValue("nodeId"), r.FormValue("productId")
+
 	var url string
-	if isImage {
-		url, err = storageService.UploadImage(ctx, userID, file, header.Filename)
+	if isImage && nodeID != "" {
+		url, err = storageService.UploadOwnedImage(ctx, userID, models.MediaOwnerNode, nodeID, file, header.Size, header.Filename)
+	} else if isImage && productID != "" {
+		url, err = storageService.UploadOwnedImage(ctx, userID, models.MediaOwnerProduct, productID, file, header.Size, header.Filename)
+	} else if isImage {
+		url, err = storageService.UploadImage(ctx, userID, file, header.Size, header.Filename)
 	} else {
-		url, err = storageService.UploadFile(ctx, userID, file, header.Filename, header.Header.Get("Content-Type"))
+		url, err = storageService.UploadFile(ctx, userID, file, header.Size, header.Filename)
 	}
 
 	if err != nil {
+		// Los archivos rechazados responden el motivo con su código: 400, 413 o 507
+		if errors.IsDomainError(err) {
+			http.Error(w, err.Error(), errors.GetErrorCode(err))
+			return
+		}
 		http.Error(w, fmt.Sprintf("Error uploading file: %v", err), http.StatusInternalServerError)
 		return
 	}
@@ -75,6 +170,10 @@ func handleGetSignedURL(w http.ResponseWriter, r *http.Request) {
 	}
 
 	ctx := context.Background()
+	if err := initStorageServices(ctx); err != nil {
+		http.Error(w, fmt.Sprintf("Error initializing storage: %v", err), http.StatusInternalServerError)
+		return
+	}
 
 	// Verifica la autenticación
 	userID, err := authenticateRequest(r)
@@ -109,6 +208,112 @@ func handleGetSignedURL(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// handleCreateUploadSession retorna una URL firmada para subir un archivo directamente al
+// storage con PUT. El archivo debe tener el tipo, el tamaño y el hash MD5 declarados.
+func handleCreateUploadSession(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx := context.Background()
+	if err := initStorageServices(ctx); err != nil {
+		http.Error(w, fmt.Sprintf("Error initializing storage: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Verifica la autenticación
+	userID, err := authenticateRequest(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.UploadSessionRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	session, err := uploadSessionService.Create(ctx, userID, req)
+	writeUploadSession(w, session, err)
+}
+
+// handleGetUploadSession retorna el estado de una sesión de subida
+func handleGetUploadSession(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx := context.Background()
+	if err := initStorageServices(ctx); err != nil {
+		http.Error(w, fmt.Sprintf("Error initializing storage: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Verifica la autenticación
+	userID, err := authenticateRequest(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	sessionID := r.URL.Query().Get("id")
+	if sessionID == "" {
+		http.Error(w, "id parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	session, err := uploadSessionService.Get(ctx, userID, sessionID)
+	writeUploadSession(w, session, err)
+}
+
+// handleCompleteUploadSession confirma que el cliente terminó de subir el archivo
+func handleCompleteUploadSession(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx := context.Background()
+	if err := initStorageServices(ctx); err != nil {
+		http.Error(w, fmt.Sprintf("Error initializing storage: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Verifica la autenticación
+	userID, err := authenticateRequest(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	sessionID := r.URL.Query().Get("id")
+	if sessionID == "" {
+		http.Error(w, "id parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	session, err := uploadSessionService.Complete(ctx, userID, sessionID)
+	writeUploadSession(w, session, err)
+}
+
+// writeUploadSession responde la sesión o el error con su código
+func writeUploadSession(w http.ResponseWriter, session *models.UploadSession, err error) {
+	if err != nil {
+		if errors.IsDomainError(err) {
+			http.Error(w, err.Error(), errors.GetErrorCode(err))
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error processing upload session: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(session)
+}
+
 // Función auxiliar para verificar que el archivo pertenece al usuario
 func isUserFile(userID string, filePath string) bool {
 	// El path debe comenzar con "users/{userID}/"
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+    "encoding/json"
+    "net/http"
+    "strconv"
+
+    "github.com/gorilla/mux"
+    "github.com/kha0sys/nodo.social/functions/domain/errors"
+    "github.com/kha0sys/nodo.social/functions/domain/models"
+    "github.com/kha0sys/nodo.social/functions/interfaces/http/middleware"
+    "github.com/kha0sys/nodo.social/functions/services"
+)
+
+// AchievementHandler expone a los administradores las definiciones de logros y su recálculo
+type AchievementHandler struct {
+    achievementService *services.AchievementService
+    backfillService    *services.AchievementBackfillService
+}
+
+// NewAchievementHandler crea una nueva instancia de AchievementHandler
+func NewAchievementHandler(achievementService *services.AchievementService, backfillService *services.AchievementBackfillService) *AchievementHandler {
+    return &AchievementHandler{
+        achievementService: achievementService,
+        backfillService:    backfillService,
+    }
+}
+
+// backfillRequest es el cuerpo para iniciar un recálculo de logros
+type backfillRequest struct {
+    DryRun bool `json:"dry_run"`
+}
+
+// RegisterRoutes registra las rutas del handler en el router.
+// El router debe exigir el rol de administrador.
+func (h *AchievementHandler) RegisterRoutes(r *mux.Router) {
+    r.HandleFunc("/achievements", h.ListDefinitions).Methods("GET")
+    r.HandleFunc("/achievements", h.CreateDefinition).Methods("POST")
+    r.HandleFunc("/achievements/defaults", h.SeedDefaultDefinitions).Methods("POST")
+    r.HandleFunc("/achievements/backfills", h.ListBackfills).Methods("GET")
+    r.HandleFunc("/achievements/backfills", h.StartBackfill).Methods("POST")
+    r.HandleFunc("/achievements/backfills/{id}", h.GetBackfillReport).Methods("GET")
+    r.HandleFunc("/achievements/backfills/{id}/resume", h.ResumeBackfill).Methods("POST")
+    r.HandleFunc("/achievements/{id}", h.GetDefinition).Methods("GET")
+    r.HandleFunc("/achievements/{id}", h.UpdateDefinition).Methods("PUT")
+    r.HandleFunc("/achievements/{id}", h.DeleteDefinition).Methods("DELETE")
+}
+
+// ListDefinitions maneja la obtención de todas las definiciones de logros
+func (h *AchievementHandler) ListDefinitions(w http.ResponseWriter, r *http.Request) {
+    definitions, err := h.achievementService.ListDefinitions(r.Context())
+    if err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(definitions)
+}
+
+// GetDefinition maneja la obtención de una definición de logro
+func (h *AchievementHandler) GetDefinition(w http.ResponseWriter, r *http.Request) {
+    definition, err := h.achievementService.GetDefinition(r.Context(), mux.Vars(r)["id"])
+    if err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(definition)
+}
+
+// CreateDefinition maneja la creación de una definición de logro
+func (h *AchievementHandler) CreateDefinition(w http.ResponseWriter, r *http.Request) {
+    var definition models.Achievement
+    if err := json.NewDecoder(r.Body).Decode(&definition); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+
+    if err := h.achievementService.CreateDefinition(r.Context(), &definition); err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusCreated)
+    json.NewEncoder(w).Encode(definition)
+}
+
+// UpdateDefinition maneja el reemplazo de una definición de logro
+func (h *AchievementHandler) UpdateDefinition(w http.ResponseWriter, r *http.Request) {
+    var definition models.Achievement
+    if err := json.NewDecoder(r.Body).Decode(&definition); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+
+    if err := h.achievementService.UpdateDefinition(r.Context(), mux.Vars(r)["id"], &definition); err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(definition)
+}
+
+// DeleteDefinition maneja la eliminación de una definición de logro
+func (h *AchievementHandler) DeleteDefinition(w http.ResponseWriter, r *http.Request) {
+    if err := h.achievementService.DeleteDefinition(r.Context(), mux.Vars(r)["id"]); err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.WriteHeader(http.StatusNoContent)
+}
+
+// SeedDefaultDefinitions maneja la creación de los niveles de logros iniciales que falten
+func (h *AchievementHandler) SeedDefaultDefinitions(w http.ResponseWriter, r *http.Request) {
+    created, err := h.achievementService.SeedDefaultDefinitions(r.Context())
+    if err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(created)
+}
+
+// StartBackfill maneja el inicio de un recálculo de logros de todos los usuarios. Con
+// dry_run solo registra lo que cambiaría. Responde con el avance al agotar el tiempo de la
+// petición; el resto se retoma en segundo plano o con ResumeBackfill.
+func (h *AchievementHandler) StartBackfill(w http.ResponseWriter, r *http.Request) {
+    userID, _, _ := middleware.GetUserFromContext(r.Context())
+
+    var req backfillRequest
+    if r.ContentLength != 0 {
+        if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+            http.Error(w, "Invalid request body", http.StatusBadRequest)
+            return
+        }
+    }
+
+    backfill, err := h.backfillService.Start(r.Context(), userID, req.DryRun)
+    if err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusAccepted)
+    json.NewEncoder(w).Encode(backfill)
+}
+
+// ResumeBackfill maneja la continuación de un recálculo sin terminar
+func (h *AchievementHandler) ResumeBackfill(w http.ResponseWriter, r *http.Request) {
+    backfill, err := h.backfillService.Run(r.Context(), mux.Vars(r)["id"])
+    if err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(backfill)
+}
+
+// ListBackfills maneja la obtención de los últimos recálculos
+func (h *AchievementHandler) ListBackfills(w http.ResponseWriter, r *http.Request) {
+    backfills, err := h.backfillService.List(r.Context())
+    if err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(backfills)
+}
+
+// GetBackfillReport maneja la obtención del estado de un recálculo y de los logros que
+// otorgó o otorgaría. Acepta la paginación con limit y cursor.
+func (h *AchievementHandler) GetBackfillReport(w http.ResponseWriter, r *http.Request) {
+    query := r.URL.Query()
+    limit := 0
+    if limitStr := query.Get("limit"); limitStr != "" {
+        var err error
+        limit, err = strconv.Atoi(limitStr)
+        if err != nil {
+            http.Error(w, "Invalid limit parameter", http.StatusBadRequest)
+            return
+        }
+    }
+
+    report, err := h.backfillService.Report(r.Context(), mux.Vars(r)["id"], query.Get("cursor"), limit)
+    if err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+    "encoding/json"
+    "net/http"
+    "time"
+
+    "github.com/gorilla/mux"
+    "github.com/kha0sys/nodo.social/functions/domain/errors"
+    "github.com/kha0sys/nodo.social/functions/domain/models"
+    "github.com/kha0sys/nodo.social/functions/interfaces/http/middleware"
+    "github.com/kha0sys/nodo.social/functions/services"
+)
+
+// ChallengeHandler maneja las peticiones HTTP de las rachas y los retos
+type ChallengeHandler struct {
+    streakService    *services.StreakService
+    challengeService *services.ChallengeService
+}
+
+// NewChallengeHandler crea una nueva instancia de ChallengeHandler
+func NewChallengeHandler(streakService *services.StreakService, challengeService *services.ChallengeService) *ChallengeHandler {
+    return &ChallengeHandler{
+        streakService:    streakService,
+        challengeService: challengeService,
+    }
+}
+
+// RegisterRoutes registra las rutas del usuario autenticado en el router
+func (h *ChallengeHandler) RegisterRoutes(r *mux.Router) {
+    r.HandleFunc("/streaks", h.GetStreaks).Methods("GET")
+    r.HandleFunc("/challenges", h.ListOwnChallenges).Methods("GET")
+}
+
+// RegisterAdminRoutes registra las rutas de administración.
+// El router debe exigir el rol de administrador.
+func (h *ChallengeHandler) RegisterAdminRoutes(r *mux.Router) {
+    r.HandleFunc("/challenges", h.ListChallenges).Methods("GET")
+    r.HandleFunc("/challenges", h.CreateChallenge).Methods("POST")
+    r.HandleFunc("/challenges/{id}", h.UpdateChallenge).Methods("PUT")
+    r.HandleFunc("/challenges/{id}", h.DeleteChallenge).Methods("DELETE")
+}
+
+// GetStreaks maneja la obtención de las rachas diaria y semanal del usuario
+func (h *ChallengeHandler) GetStreaks(w http.ResponseWriter, r *http.Request) {
+    userID, _, _ := middleware.GetUserFromContext(r.Context())
+    if userID == "" {
+        http.Error(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+
+    streaks, err := h.streakService.GetStreaks(r.Context(), userID, time.Now())
+    if err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(streaks)
+}
+
+// ListOwnChallenges maneja la obtención de los retos en curso con el avance del usuario
+func (h *ChallengeHandler) ListOwnChallenges(w http.ResponseWriter, r *http.Request) {
+    userID, _, _ := middleware.GetUserFromContext(r.Context())
+    if userID == "" {
+        http.Error(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+
+    statuses, err := h.challengeService.ListForUser(r.Context(), userID, time.Now())
+    if err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(statuses)
+}
+
+// ListChallenges maneja la obtención de todos los retos
+func (h *ChallengeHandler) ListChallenges(w http.ResponseWriter, r *http.Request) {
+    challenges, err := h.challengeService.ListChallenges(r.Context())
+    if err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(challenges)
+}
+
+// CreateChallenge maneja la creación de un reto
+func (h *ChallengeHandler) CreateChallenge(w http.ResponseWriter, r *http.Request) {
+    var challenge models.Challenge
+    if err := json.NewDecoder(r.Body).Decode(&challenge); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+
+    if err := h.challengeService.CreateChallenge(r.Context(), &challenge); err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusCreated)
+    json.NewEncoder(w).Encode(challenge)
+}
+
+// UpdateChallenge maneja el reemplazo de un reto
+func (h *ChallengeHandler) UpdateChallenge(w http.ResponseWriter, r *http.Request) {
+    var challenge models.Challenge
+    if err := json.NewDecoder(r.Body).Decode(&challenge); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+
+    if err := h.challengeService.UpdateChallenge(r.Context(), mux.Vars(r)["id"], &challenge); err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(challenge)
+}
+
+// DeleteChallenge maneja la eliminación de un reto
+func (h *ChallengeHandler) DeleteChallenge(w http.ResponseWriter, r *http.Request) {
+    if err := h.challengeService.DeleteChallenge(r.Context(), mux.Vars(r)["id"]); err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.WriteHeader(http.StatusNoContent)
+}
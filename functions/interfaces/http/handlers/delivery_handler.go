@@ -0,0 +1,66 @@
+package handlers
+
+import (
+    "encoding/json"
+    "net/http"
+    "strconv"
+
+    "github.com/gorilla/mux"
+    "github.com/kha0sys/nodo.social/functions/domain/errors"
+    "github.com/kha0sys/nodo.social/functions/services"
+)
+
+// DeliveryHandler expone a los administradores la cola de entregas muertas
+type DeliveryHandler struct {
+    notificationService *services.NotificationService
+}
+
+// NewDeliveryHandler crea una nueva instancia de DeliveryHandler
+func NewDeliveryHandler(notificationService *services.NotificationService) *DeliveryHandler {
+    return &DeliveryHandler{
+        notificationService: notificationService,
+    }
+}
+
+// RegisterRoutes registra las rutas del handler en el router.
+// El router debe exigir el rol de administrador.
+func (h *DeliveryHandler) RegisterRoutes(r *mux.Router) {
+    r.HandleFunc("/deliveries/dead-letters", h.ListDeadLetters).Methods("GET")
+    r.HandleFunc("/deliveries/dead-letters/{id}/requeue", h.RequeueDeadLetter).Methods("POST")
+}
+
+// ListDeadLetters maneja la obtención de las entregas que agotaron sus intentos
+func (h *DeliveryHandler) ListDeadLetters(w http.ResponseWriter, r *http.Request) {
+    limit := 0
+    if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+        var err error
+        limit, err = strconv.Atoi(limitStr)
+        if err != nil {
+            http.Error(w, "Invalid limit parameter", http.StatusBadRequest)
+            return
+        }
+    }
+
+    jobs, err := h.notificationService.ListDeadLetters(r.Context(), limit)
+    if err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(jobs)
+}
+
+// RequeueDeadLetter maneja el reenvío de una entrega muerta a la cola
+func (h *DeliveryHandler) RequeueDeadLetter(w http.ResponseWriter, r *http.Request) {
+    jobID := mux.Vars(r)["id"]
+
+    job, err := h.notificationService.RequeueDeadLetter(r.Context(), jobID)
+    if err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(job)
+}
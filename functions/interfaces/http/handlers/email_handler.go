@@ -0,0 +1,119 @@
+package handlers
+
+import (
+    "crypto/subtle"
+    "encoding/json"
+    "html/template"
+    "net/http"
+
+    "github.com/gorilla/mux"
+    "github.com/kha0sys/nodo.social/functions/domain/errors"
+    "github.com/kha0sys/nodo.social/functions/internal/i18n"
+    "github.com/kha0sys/nodo.social/functions/services"
+)
+
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html lang="{{.Language}}">
+<head><meta charset="utf-8"><title>Nodo Social</title></head>
+<body style="font-family:Helvetica,Arial,sans-serif;max-width:480px;margin:48px auto;color:#222;">
+{{if .Done}}<p>{{.Text "email.unsubscribe.done"}}</p>
+{{else}}<p>{{.Text "email.unsubscribe.confirm"}}</p>
+<form method="POST"><input type="hidden" name="token" value="{{.Token}}"><button type="submit">{{.Text "email.unsubscribe.button"}}</button></form>{{end}}
+</body>
+</html>`))
+
+// EmailHandler maneja las bajas de correo y los avisos de rebote del proveedor
+type EmailHandler struct {
+    emailService  *services.EmailService
+    webhookSecret string
+    catalog       *i18n.Catalog
+}
+
+// unsubscribePageData son los datos de la página de baja
+type unsubscribePageData struct {
+    Language string
+    Token    string
+    Done     bool
+    catalog  *i18n.Catalog
+}
+
+// Text traduce una clave del catálogo al idioma de la página
+func (d *unsubscribePageData) Text(key string) string {
+    return d.catalog.Translate(d.Language, key, nil)
+}
+
+// NewEmailHandler crea una nueva instancia de EmailHandler.
+// webhookSecret protege el endpoint de rebotes; si está vacío el endpoint queda deshabilitado.
+func NewEmailHandler(emailService *services.EmailService, webhookSecret string) *EmailHandler {
+    return &EmailHandler{
+        emailService:  emailService,
+        webhookSecret: webhookSecret,
+        catalog:       i18n.DefaultCatalog(),
+    }
+}
+
+// RegisterRoutes registra las rutas públicas del handler en el router
+func (h *EmailHandler) RegisterRoutes(r *mux.Router) {
+    r.HandleFunc("/email/unsubscribe", h.ShowUnsubscribe).Methods("GET")
+    r.HandleFunc("/email/unsubscribe", h.Unsubscribe).Methods("POST")
+    r.HandleFunc("/email/bounces", h.RecordBounce).Methods("POST")
+}
+
+// ShowUnsubscribe muestra la confirmación de baja. La baja no se hace con GET
+// para que los antivirus que visitan los enlaces no den de baja al usuario.
+func (h *EmailHandler) ShowUnsubscribe(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "text/html; charset=utf-8")
+    unsubscribePage.Execute(w, &unsubscribePageData{
+        Language: i18n.FromAcceptLanguage(r.Header.Get("Accept-Language")),
+        Token:    r.URL.Query().Get("token"),
+        catalog:  h.catalog,
+    })
+}
+
+// Unsubscribe maneja la baja de correos, tanto desde la página de confirmación
+// como la baja en un clic de los clientes de correo
+func (h *EmailHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+    token := r.URL.Query().Get("token")
+    if token == "" {
+        token = r.FormValue("token")
+    }
+
+    if _, err := h.emailService.Unsubscribe(r.Context(), token); err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.Header().Set("Content-Type", "text/html; charset=utf-8")
+    unsubscribePage.Execute(w, &unsubscribePageData{
+        Language: i18n.FromAcceptLanguage(r.Header.Get("Accept-Language")),
+        Done:     true,
+        catalog:  h.catalog,
+    })
+}
+
+// RecordBounce maneja los avisos de rebote y queja enviados por el proveedor de correo
+func (h *EmailHandler) RecordBounce(w http.ResponseWriter, r *http.Request) {
+    secret := r.Header.Get("X-Webhook-Secret")
+    if h.webhookSecret == "" || subtle.ConstantTimeCompare([]byte(secret), []byte(h.webhookSecret)) != 1 {
+        http.Error(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+
+    var req struct {
+        Email string `json:"email"`
+        Type  string `json:"type"`
+    }
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+
+    suppression, err := h.emailService.RecordBounce(r.Context(), req.Email, req.Type)
+    if err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(suppression)
+}
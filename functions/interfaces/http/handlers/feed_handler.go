@@ -0,0 +1,150 @@
+package handlers
+
+import (
+    "encoding/json"
+    "net/http"
+    "strconv"
+
+    "github.com/gorilla/mux"
+    "github.com/kha0sys/nodo.social/functions/domain/errors"
+    "github.com/kha0sys/nodo.social/functions/domain/models"
+    "github.com/kha0sys/nodo.social/functions/interfaces/http/middleware"
+    "github.com/kha0sys/nodo.social/functions/services"
+)
+
+// FeedHandler maneja las peticiones HTTP relacionadas con el feed
+type FeedHandler struct {
+    feedService *services.FeedService
+}
+
+// NewFeedHandler crea una nueva instancia de FeedHandler
+func NewFeedHandler(feedService *services.FeedService) *FeedHandler {
+    return &FeedHandler{
+        feedService: feedService,
+    }
+}
+
+// RegisterRoutes registra las rutas del handler en el router
+func (h *FeedHandler) RegisterRoutes(r *mux.Router) {
+    r.HandleFunc("/feed", h.GetFeed).Methods("GET")
+    r.HandleFunc("/feed/items/{id}/hide", h.HideItem).Methods("POST")
+    r.HandleFunc("/feed/mutes", h.CreateMute).Methods("POST")
+    r.HandleFunc("/feed/mutes", h.ListMutes).Methods("GET")
+    r.HandleFunc("/feed/mutes/{id}", h.DeleteMute).Methods("DELETE")
+}
+
+// GetFeed maneja la obtención de una página del feed agregado
+func (h *FeedHandler) GetFeed(w http.ResponseWriter, r *http.Request) {
+    query := r.URL.Query()
+    filters := models.FeedFilters{
+        NodeID: query.Get("node_id"),
+        Type:   query.Get("type"),
+        Cursor: query.Get("cursor"),
+    }
+
+    if limitStr := query.Get("limit"); limitStr != "" {
+        limit, err := strconv.Atoi(limitStr)
+        if err != nil {
+            http.Error(w, "Invalid limit parameter", http.StatusBadRequest)
+            return
+        }
+        filters.PageSize = limit
+    }
+
+    userID, _, _ := middleware.GetUserFromContext(r.Context())
+
+    page, err := h.feedService.GetFeed(r.Context(), userID, filters)
+    if err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(page)
+}
+
+// HideItem maneja la acción "ocultar" sobre un item del feed
+func (h *FeedHandler) HideItem(w http.ResponseWriter, r *http.Request) {
+    userID, _, _ := middleware.GetUserFromContext(r.Context())
+    if userID == "" {
+        http.Error(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+
+    vars := mux.Vars(r)
+    mute, err := h.feedService.HideItem(r.Context(), userID, vars["id"])
+    if err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusCreated)
+    json.NewEncoder(w).Encode(mute)
+}
+
+// CreateMute maneja el silenciamiento de un nodo, un tipo de nodo o una etiqueta ("no me interesa")
+func (h *FeedHandler) CreateMute(w http.ResponseWriter, r *http.Request) {
+    userID, _, _ := middleware.GetUserFromContext(r.Context())
+    if userID == "" {
+        http.Error(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+
+    var req struct {
+        Type  models.FeedMuteType `json:"type"`
+        Value string              `json:"value"`
+    }
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+
+    mute, err := h.feedService.Mute(r.Context(), userID, req.Type, req.Value)
+    if err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusCreated)
+    json.NewEncoder(w).Encode(mute)
+}
+
+// ListMutes maneja el listado de todo lo que el usuario silenció
+func (h *FeedHandler) ListMutes(w http.ResponseWriter, r *http.Request) {
+    userID, _, _ := middleware.GetUserFromContext(r.Context())
+    if userID == "" {
+        http.Error(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+
+    mutes, err := h.feedService.ListMutes(r.Context(), userID)
+    if err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+    if mutes == nil {
+        mutes = make([]*models.FeedMute, 0)
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(mutes)
+}
+
+// DeleteMute maneja la acción de deshacer un silenciamiento
+func (h *FeedHandler) DeleteMute(w http.ResponseWriter, r *http.Request) {
+    userID, _, _ := middleware.GetUserFromContext(r.Context())
+    if userID == "" {
+        http.Error(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+
+    vars := mux.Vars(r)
+    if err := h.feedService.Unmute(r.Context(), userID, vars["id"]); err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+    "encoding/json"
+    "net/http"
+    "strconv"
+
+    "github.com/gorilla/mux"
+    "github.com/kha0sys/nodo.social/functions/domain/errors"
+    "github.com/kha0sys/nodo.social/functions/domain/models"
+    "github.com/kha0sys/nodo.social/functions/interfaces/http/middleware"
+    "github.com/kha0sys/nodo.social/functions/services"
+)
+
+// LeaderboardHandler maneja las peticiones HTTP de los rankings
+type LeaderboardHandler struct {
+    leaderboardService *services.LeaderboardService
+}
+
+// NewLeaderboardHandler crea una nueva instancia de LeaderboardHandler
+func NewLeaderboardHandler(leaderboardService *services.LeaderboardService) *LeaderboardHandler {
+    return &LeaderboardHandler{
+        leaderboardService: leaderboardService,
+    }
+}
+
+// RegisterRoutes registra las rutas del handler en el router
+func (h *LeaderboardHandler) RegisterRoutes(r *mux.Router) {
+    r.HandleFunc("/leaderboards/{window}", h.GetLeaderboard).Methods("GET")
+}
+
+// GetLeaderboard maneja la obtención de un ranking con la posición del usuario.
+// La ventana es all_time, weekly o monthly; acepta los parámetros scope (global,
+// following o type:<tipo>), limit y neighbors.
+func (h *LeaderboardHandler) GetLeaderboard(w http.ResponseWriter, r *http.Request) {
+    userID, _, _ := middleware.GetUserFromContext(r.Context())
+    if userID == "" {
+        http.Error(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+
+    query := r.URL.Query()
+    scope := query.Get("scope")
+    if scope == "" {
+        scope = models.LeaderboardScopeGlobal
+    }
+
+    limit := 0
+    if limitStr := query.Get("limit"); limitStr != "" {
+        var err error
+        limit, err = strconv.Atoi(limitStr)
+        if err != nil {
+            http.Error(w, "Invalid limit parameter", http.StatusBadRequest)
+            return
+        }
+    }
+
+    neighbors := -1
+    if neighborsStr := query.Get("neighbors"); neighborsStr != "" {
+        var err error
+        neighbors, err = strconv.Atoi(neighborsStr)
+        if err != nil || neighbors < 0 {
+            http.Error(w, "Invalid neighbors parameter", http.StatusBadRequest)
+            return
+        }
+    }
+
+    page, err := h.leaderboardService.Get(r.Context(), userID, mux.Vars(r)["window"], scope, limit, neighbors)
+    if err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(page)
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+    "encoding/json"
+    "net/http"
+
+    "github.com/gorilla/mux"
+    "github.com/kha0sys/nodo.social/functions/domain/errors"
+    "github.com/kha0sys/nodo.social/functions/domain/models"
+    "github.com/kha0sys/nodo.social/functions/interfaces/http/middleware"
+    "github.com/kha0sys/nodo.social/functions/services"
+)
+
+// LevelHandler maneja las peticiones HTTP de la curva de niveles
+type LevelHandler struct {
+    levelService *services.LevelService
+}
+
+// NewLevelHandler crea una nueva instancia de LevelHandler
+func NewLevelHandler(levelService *services.LevelService) *LevelHandler {
+    return &LevelHandler{
+        levelService: levelService,
+    }
+}
+
+// RegisterRoutes registra las rutas del usuario autenticado en el router
+func (h *LevelHandler) RegisterRoutes(r *mux.Router) {
+    r.HandleFunc("/levels", h.GetCurve).Methods("GET")
+    r.HandleFunc("/levels/me", h.GetOwnProgress).Methods("GET")
+}
+
+// RegisterAdminRoutes registra las rutas de administración.
+// El router debe exigir el rol de administrador.
+func (h *LevelHandler) RegisterAdminRoutes(r *mux.Router) {
+    r.HandleFunc("/levels", h.GetCurve).Methods("GET")
+    r.HandleFunc("/levels", h.UpdateCurve).Methods("PUT")
+}
+
+// GetCurve maneja la obtención de la curva de niveles
+func (h *LevelHandler) GetCurve(w http.ResponseWriter, r *http.Request) {
+    curve, err := h.levelService.Curve(r.Context())
+    if err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(curve)
+}
+
+// GetOwnProgress maneja la obtención del nivel del usuario y su avance hacia el siguiente
+func (h *LevelHandler) GetOwnProgress(w http.ResponseWriter, r *http.Request) {
+    userID, _, _ := middleware.GetUserFromContext(r.Context())
+    if userID == "" {
+        http.Error(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+
+    progress, err := h.levelService.UserProgress(r.Context(), userID)
+    if err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(progress)
+}
+
+// UpdateCurve maneja el reemplazo de la curva de niveles
+func (h *LevelHandler) UpdateCurve(w http.ResponseWriter, r *http.Request) {
+    var curve models.LevelCurve
+    if err := json.NewDecoder(r.Body).Decode(&curve); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+
+    updated, err := h.levelService.UpdateCurve(r.Context(), &curve)
+    if err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(updated)
+}
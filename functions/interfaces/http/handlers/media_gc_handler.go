@@ -0,0 +1,83 @@
+package handlers
+
+import (
+    "encoding/json"
+    "net/http"
+
+    "github.com/gorilla/mux"
+    "github.com/kha0sys/nodo.social/functions/domain/errors"
+    "github.com/kha0sys/nodo.social/functions/interfaces/http/middleware"
+    "github.com/kha0sys/nodo.social/functions/services"
+)
+
+// MediaGCHandler expone a los administradores la recolección de archivos huérfanos
+type MediaGCHandler struct {
+    mediaGCService *services.MediaGCService
+}
+
+// NewMediaGCHandler crea una nueva instancia de MediaGCHandler
+func NewMediaGCHandler(mediaGCService *services.MediaGCService) *MediaGCHandler {
+    return &MediaGCHandler{
+        mediaGCService: mediaGCService,
+    }
+}
+
+// mediaGCRequest es el cuerpo para iniciar una recolección de archivos huérfanos
+type mediaGCRequest struct {
+    DryRun bool `json:"dry_run"`
+}
+
+// RegisterRoutes registra las rutas del handler en el router.
+// El router debe exigir el rol de administrador.
+func (h *MediaGCHandler) RegisterRoutes(r *mux.Router) {
+    r.HandleFunc("/media/gc", h.ListRuns).Methods("GET")
+    r.HandleFunc("/media/gc", h.StartRun).Methods("POST")
+    r.HandleFunc("/media/gc/{id}", h.GetRun).Methods("GET")
+}
+
+// StartRun maneja una recolección de archivos huérfanos. Con dry_run solo reporta lo que
+// marcaría y eliminaría.
+func (h *MediaGCHandler) StartRun(w http.ResponseWriter, r *http.Request) {
+    userID, _, _ := middleware.GetUserFromContext(r.Context())
+
+    var req mediaGCRequest
+    if r.ContentLength != 0 {
+        if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+            http.Error(w, "Invalid request body", http.StatusBadRequest)
+            return
+        }
+    }
+
+    run, err := h.mediaGCService.Start(r.Context(), userID, req.DryRun)
+    if err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(run)
+}
+
+// ListRuns maneja la obtención de las últimas recolecciones
+func (h *MediaGCHandler) ListRuns(w http.ResponseWriter, r *http.Request) {
+    runs, err := h.mediaGCService.List(r.Context())
+    if err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(runs)
+}
+
+// GetRun maneja la obtención del reporte de una recolección
+func (h *MediaGCHandler) GetRun(w http.ResponseWriter, r *http.Request) {
+    run, err := h.mediaGCService.Get(r.Context(), mux.Vars(r)["id"])
+    if err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(run)
+}
@@ -1,7 +1,9 @@
 package handlers
 
 import (
+    "context"
     "encoding/json"
+    "log"
     "net/http"
     "time"
     
@@ -9,19 +11,32 @@ import (
     firebase "firebase.google.com/go/v4"
     "github.com/gorilla/mux"
     "google.golang.org/api/iterator"
+    "github.com/kha0sys/nodo.social/functions/domain/errors"
     "github.com/kha0sys/nodo.social/functions/domain/models"
+    "github.com/kha0sys/nodo.social/functions/domain/repositories"
     "github.com/kha0sys/nodo.social/functions/interfaces/http/middleware"
+    "github.com/kha0sys/nodo.social/functions/services"
 )
 
+// maxFeedPages es el máximo de páginas de nodos que se leen para completar una página del
+// feed cuando el usuario silenció parte de ellos
+const maxFeedPages = 5
+
 // NodeHandler maneja las peticiones HTTP relacionadas con nodos
 type NodeHandler struct {
-    app *firebase.App
+    app      *firebase.App
+    levelSvc *services.LevelService
+    feedSvc  *services.FeedService
 }
 
-// NewNodeHandler crea una nueva instancia de NodeHandler
-func NewNodeHandler(app *firebase.App) *NodeHandler {
+// NewNodeHandler crea una nueva instancia de NodeHandler.
+// levelSvc puede ser nil si la creación de nodos no depende del nivel del usuario.
+// feedSvc aplica al feed lo que cada usuario silenció.
+func NewNodeHandler(app *firebase.App, levelSvc *services.LevelService, feedSvc *services.FeedService) *NodeHandler {
     return &NodeHandler{
-        app: app,
+        app:      app,
+        levelSvc: levelSvc,
+        feedSvc:  feedSvc,
     }
 }
 
@@ -63,12 +78,33 @@ func (h *NodeHandler) CreateNode(w http.ResponseWriter, r *http.Request) {
     }
     defer client.Close()
 
+    // El nivel del usuario limita cuántos nodos puede crear
+    if h.levelSvc != nil {
+        user, err := repositories.NewFirestoreUserRepository(client).Get(r.Context(), userID)
+        if err != nil {
+            http.Error(w, "Error getting user", http.StatusInternalServerError)
+            return
+        }
+        if err := h.levelSvc.CheckNodeLimit(r.Context(), user); err != nil {
+            http.Error(w, err.Error(), errors.GetErrorCode(err))
+            return
+        }
+    }
+
     docRef, _, err := client.Collection("nodes").Add(r.Context(), node)
     if err != nil {
         http.Error(w, "Error creating node", http.StatusInternalServerError)
         return
     }
 
+    // Registrar el nodo en el usuario para los límites de nivel y los logros
+    _, err = client.Collection("users").Doc(userID).Update(r.Context(), []firestore.Update{
+        {Path: "nodes", Value: firestore.ArrayUnion(docRef.ID)},
+    })
+    if err != nil {
+        log.Printf("Error adding node %s to user %s: %v", docRef.ID, userID, err)
+    }
+
     node.ID = docRef.ID
     json.NewEncoder(w).Encode(node)
 }
@@ -98,6 +134,9 @@ func (h *NodeHandler) GetNode(w http.ResponseWriter, r *http.Request) {
     }
 
     node.ID = doc.Ref.ID
+    if err := h.describeMedia(r.Context(), client, &node); err != nil {
+        log.Printf("Error describing media of node %s: %v", node.ID, err)
+    }
     json.NewEncoder(w).Encode(node)
 }
 
@@ -203,6 +242,13 @@ func (h *NodeHandler) DeleteNode(w http.ResponseWriter, r *http.Request) {
         return
     }
 
+    _, err = client.Collection("users").Doc(node.UserID).Update(r.Context(), []firestore.Update{
+        {Path: "nodes", Value: firestore.ArrayRemove(nodeID)},
+    })
+    if err != nil {
+        log.Printf("Error removing node %s from user %s: %v", nodeID, node.UserID, err)
+    }
+
     w.WriteHeader(http.StatusNoContent)
 }
 
@@ -246,26 +292,108 @@ func (h *NodeHandler) GetFeed(w http.ResponseWriter, r *http.Request) {
     }
     defer client.Close()
 
-    // Obtener los nodos más recientes
+    // Obtener los nodos más recientes, leyendo más páginas si lo que el usuario silenció
+    // deja la página incompleta
+    userID, _, _ := middleware.GetUserFromContext(r.Context())
     query := client.Collection("nodes").
         OrderBy("CreatedAt", firestore.Desc).
         Limit(limit)
 
-    docs, err := query.Documents(r.Context()).GetAll()
-    if err != nil {
-        http.Error(w, "Error getting feed", http.StatusInternalServerError)
-        return
-    }
+    nodes := make([]*models.Node, 0, limit)
+    for page := 0; page < maxFeedPages && len(nodes) < limit; page++ {
+        docs, err := query.Documents(r.Context()).GetAll()
+        if err != nil {
+            http.Error(w, "Error getting feed", http.StatusInternalServerError)
+            return
+        }
 
-    nodes := make([]models.Node, 0, len(docs))
-    for _, doc := range docs {
-        var node models.Node
-        if err := doc.DataTo(&node); err != nil {
-            continue // Saltar documentos con error
+        batch := make([]*models.Node, 0, len(docs))
+        for _, doc := range docs {
+            var node models.Node
+            if err := doc.DataTo(&node); err != nil {
+                continue // Saltar documentos con error
+            }
+            node.ID = doc.Ref.ID
+            batch = append(batch, &node)
+        }
+        batch, err = h.feedSvc.FilterNodes(r.Context(), userID, batch)
+        if err != nil {
+            http.Error(w, "Error getting feed preferences", http.StatusInternalServerError)
+            return
         }
-        node.ID = doc.Ref.ID
-        nodes = append(nodes, node)
+        nodes = append(nodes, batch...)
+
+        if len(docs) < limit {
+            break
+        }
+        query = query.StartAfter(docs[len(docs)-1])
+    }
+    if len(nodes) > limit {
+        nodes = nodes[:limit]
+    }
+
+    if err := h.describeMedia(r.Context(), client, nodes...); err != nil {
+        log.Printf("Error describing media of feed nodes: %v", err)
     }
 
     json.NewEncoder(w).Encode(nodes)
 }
+
+// describeMedia completa los datos de las imágenes de los nodos para que los clientes
+// reserven su espacio mientras cargan. Las que no tienen datos guardados en el nodo se
+// consultan todas a la vez.
+func (h *NodeHandler) describeMedia(ctx context.Context, client *firestore.Client, nodes ...*models.Node) error {
+    missing := make([]string, 0)
+    for _, node := range nodes {
+        stored := mediaByURL(node.MediaDetails)
+        for _, mediaURL := range nodeMediaURLs(node) {
+            if _, ok := stored[mediaURL]; !ok {
+                missing = append(missing, mediaURL)
+            }
+        }
+    }
+
+    described := make(map[string]models.MediaURL)
+    if len(missing) > 0 {
+        media, err := services.NewMediaService(repositories.NewFirestoreMediaRepository(client)).Describe(ctx, missing)
+        if err != nil {
+            return err
+        }
+        described = mediaByURL(media)
+    }
+
+    for _, node := range nodes {
+        stored := mediaByURL(node.MediaDetails)
+        node.MediaDetails = make([]models.MediaURL, 0)
+        for _, mediaURL := range nodeMediaURLs(node) {
+            if item, ok := stored[mediaURL]; ok {
+                node.MediaDetails = append(node.MediaDetails, item)
+            } else {
+                node.MediaDetails = append(node.MediaDetails, described[mediaURL])
+            }
+        }
+    }
+    return nil
+}
+
+// nodeMediaURLs retorna las URLs de Images y Media del nodo sin repetir
+func nodeMediaURLs(node *models.Node) []string {
+    urls := make([]string, 0, len(node.Images)+len(node.Media))
+    seen := make(map[string]bool)
+    for _, mediaURL := range append(append([]string{}, node.Images...), node.Media...) {
+        if !seen[mediaURL] {
+            seen[mediaURL] = true
+            urls = append(urls, mediaURL)
+        }
+    }
+    return urls
+}
+
+// mediaByURL indexa los recursos por su URL
+func mediaByURL(media []models.MediaURL) map[string]models.MediaURL {
+    byURL := make(map[string]models.MediaURL, len(media))
+    for _, item := range media {
+        byURL[item.URL] = item
+    }
+    return byURL
+}
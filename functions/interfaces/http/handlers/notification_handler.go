@@ -0,0 +1,243 @@
+package handlers
+
+import (
+    "encoding/json"
+    "net/http"
+    "strconv"
+
+    "github.com/gorilla/mux"
+    "github.com/kha0sys/nodo.social/functions/domain/errors"
+    "github.com/kha0sys/nodo.social/functions/domain/models"
+    "github.com/kha0sys/nodo.social/functions/interfaces/http/middleware"
+    "github.com/kha0sys/nodo.social/functions/services"
+)
+
+// NotificationHandler maneja las peticiones HTTP relacionadas con notificaciones
+type NotificationHandler struct {
+    notificationService *services.NotificationService
+}
+
+// NewNotificationHandler crea una nueva instancia de NotificationHandler
+func NewNotificationHandler(notificationService *services.NotificationService) *NotificationHandler {
+    return &NotificationHandler{
+        notificationService: notificationService,
+    }
+}
+
+// RegisterRoutes registra las rutas del handler en el router
+func (h *NotificationHandler) RegisterRoutes(r *mux.Router) {
+    r.HandleFunc("/notifications", h.ListNotifications).Methods("GET")
+    r.HandleFunc("/notifications/unread-count", h.GetUnreadCount).Methods("GET")
+    r.HandleFunc("/notifications/read-all", h.MarkAllAsRead).Methods("POST")
+    r.HandleFunc("/notifications/{id}/read", h.MarkAsRead).Methods("POST")
+    r.HandleFunc("/notifications/{id}", h.DeleteNotification).Methods("DELETE")
+    r.HandleFunc("/settings/notifications", h.GetPreferences).Methods("GET")
+    r.HandleFunc("/settings/notifications", h.UpdatePreferences).Methods("PUT")
+    r.HandleFunc("/devices", h.RegisterDevice).Methods("POST")
+    r.HandleFunc("/devices/{token}", h.UnregisterDevice).Methods("DELETE")
+}
+
+// ListNotifications maneja la obtención de una página de la bandeja de notificaciones.
+// Acepta los filtros type, status (read, unread) y la paginación con limit y cursor.
+func (h *NotificationHandler) ListNotifications(w http.ResponseWriter, r *http.Request) {
+    userID, _, _ := middleware.GetUserFromContext(r.Context())
+    if userID == "" {
+        http.Error(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+
+    query := r.URL.Query()
+    filters := models.NotificationFilters{
+        Type:   query.Get("type"),
+        Cursor: query.Get("cursor"),
+    }
+
+    switch query.Get("status") {
+    case "", "all":
+    case "read":
+        read := true
+        filters.Read = &read
+    case "unread":
+        read := false
+        filters.Read = &read
+    default:
+        http.Error(w, "Invalid status parameter", http.StatusBadRequest)
+        return
+    }
+
+    if limitStr := query.Get("limit"); limitStr != "" {
+        limit, err := strconv.Atoi(limitStr)
+        if err != nil {
+            http.Error(w, "Invalid limit parameter", http.StatusBadRequest)
+            return
+        }
+        filters.PageSize = limit
+    }
+
+    page, err := h.notificationService.ListNotifications(r.Context(), userID, filters)
+    if err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(page)
+}
+
+// GetUnreadCount maneja la obtención del número de notificaciones no leídas
+func (h *NotificationHandler) GetUnreadCount(w http.ResponseWriter, r *http.Request) {
+    userID, _, _ := middleware.GetUserFromContext(r.Context())
+    if userID == "" {
+        http.Error(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+
+    count, err := h.notificationService.GetUnreadCount(r.Context(), userID)
+    if err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(map[string]int{"unread": count})
+}
+
+// MarkAsRead maneja la acción de marcar una notificación como leída
+func (h *NotificationHandler) MarkAsRead(w http.ResponseWriter, r *http.Request) {
+    userID, _, _ := middleware.GetUserFromContext(r.Context())
+    if userID == "" {
+        http.Error(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+
+    vars := mux.Vars(r)
+    if err := h.notificationService.MarkAsRead(r.Context(), userID, vars["id"]); err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.WriteHeader(http.StatusNoContent)
+}
+
+// MarkAllAsRead maneja la acción de marcar todas las notificaciones como leídas
+func (h *NotificationHandler) MarkAllAsRead(w http.ResponseWriter, r *http.Request) {
+    userID, _, _ := middleware.GetUserFromContext(r.Context())
+    if userID == "" {
+        http.Error(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+
+    marked, err := h.notificationService.MarkAllAsRead(r.Context(), userID)
+    if err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(map[string]int{"marked": marked})
+}
+
+// DeleteNotification maneja la eliminación de una notificación
+func (h *NotificationHandler) DeleteNotification(w http.ResponseWriter, r *http.Request) {
+    userID, _, _ := middleware.GetUserFromContext(r.Context())
+    if userID == "" {
+        http.Error(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+
+    vars := mux.Vars(r)
+    if err := h.notificationService.DeleteNotification(r.Context(), userID, vars["id"]); err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.WriteHeader(http.StatusNoContent)
+}
+
+// GetPreferences maneja la obtención de las preferencias de notificación del usuario
+func (h *NotificationHandler) GetPreferences(w http.ResponseWriter, r *http.Request) {
+    userID, _, _ := middleware.GetUserFromContext(r.Context())
+    if userID == "" {
+        http.Error(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+
+    preferences, err := h.notificationService.GetPreferences(r.Context(), userID)
+    if err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(preferences)
+}
+
+// UpdatePreferences maneja la actualización de las preferencias de notificación del usuario.
+// Solo cambian los campos enviados.
+func (h *NotificationHandler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+    userID, _, _ := middleware.GetUserFromContext(r.Context())
+    if userID == "" {
+        http.Error(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+
+    var update models.NotificationPreferencesUpdate
+    if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+
+    preferences, err := h.notificationService.UpdatePreferences(r.Context(), userID, &update)
+    if err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(preferences)
+}
+
+// RegisterDevice maneja el registro del token FCM de un dispositivo del usuario
+func (h *NotificationHandler) RegisterDevice(w http.ResponseWriter, r *http.Request) {
+    userID, _, _ := middleware.GetUserFromContext(r.Context())
+    if userID == "" {
+        http.Error(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+
+    var req struct {
+        Token    string `json:"token"`
+        Platform string `json:"platform"`
+    }
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+
+    device, err := h.notificationService.RegisterDevice(r.Context(), userID, req.Token, req.Platform)
+    if err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusCreated)
+    json.NewEncoder(w).Encode(device)
+}
+
+// UnregisterDevice maneja la eliminación del token FCM de un dispositivo del usuario
+func (h *NotificationHandler) UnregisterDevice(w http.ResponseWriter, r *http.Request) {
+    userID, _, _ := middleware.GetUserFromContext(r.Context())
+    if userID == "" {
+        http.Error(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+
+    vars := mux.Vars(r)
+    if err := h.notificationService.UnregisterDevice(r.Context(), userID, vars["token"]); err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.WriteHeader(http.StatusNoContent)
+}
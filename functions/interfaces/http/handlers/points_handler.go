@@ -0,0 +1,73 @@
+package handlers
+
+import (
+    "encoding/json"
+    "net/http"
+    "strconv"
+
+    "github.com/gorilla/mux"
+    "github.com/kha0sys/nodo.social/functions/domain/errors"
+    "github.com/kha0sys/nodo.social/functions/interfaces/http/middleware"
+    "github.com/kha0sys/nodo.social/functions/services"
+)
+
+// PointsHandler maneja las peticiones HTTP del historial de puntos
+type PointsHandler struct {
+    pointsService *services.PointsService
+}
+
+// NewPointsHandler crea una nueva instancia de PointsHandler
+func NewPointsHandler(pointsService *services.PointsService) *PointsHandler {
+    return &PointsHandler{
+        pointsService: pointsService,
+    }
+}
+
+// RegisterRoutes registra las rutas del usuario autenticado en el router
+func (h *PointsHandler) RegisterRoutes(r *mux.Router) {
+    r.HandleFunc("/points", h.GetOwnHistory).Methods("GET")
+}
+
+// RegisterAdminRoutes registra las rutas de administración.
+// El router debe exigir el rol de administrador.
+func (h *PointsHandler) RegisterAdminRoutes(r *mux.Router) {
+    r.HandleFunc("/users/{id}/points", h.GetUserHistory).Methods("GET")
+}
+
+// GetOwnHistory maneja la obtención del total y el historial de puntos del usuario.
+// Acepta la paginación con limit y cursor.
+func (h *PointsHandler) GetOwnHistory(w http.ResponseWriter, r *http.Request) {
+    userID, _, _ := middleware.GetUserFromContext(r.Context())
+    if userID == "" {
+        http.Error(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+    h.writeHistory(w, r, userID)
+}
+
+// GetUserHistory maneja la obtención del historial de puntos de cualquier usuario
+func (h *PointsHandler) GetUserHistory(w http.ResponseWriter, r *http.Request) {
+    h.writeHistory(w, r, mux.Vars(r)["id"])
+}
+
+func (h *PointsHandler) writeHistory(w http.ResponseWriter, r *http.Request, userID string) {
+    query := r.URL.Query()
+    limit := 0
+    if limitStr := query.Get("limit"); limitStr != "" {
+        var err error
+        limit, err = strconv.Atoi(limitStr)
+        if err != nil {
+            http.Error(w, "Invalid limit parameter", http.StatusBadRequest)
+            return
+        }
+    }
+
+    page, err := h.pointsService.History(r.Context(), userID, query.Get("cursor"), limit)
+    if err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(page)
+}
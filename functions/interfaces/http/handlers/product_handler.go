@@ -1,22 +1,29 @@
 package handlers
 
 import (
+    "context"
     "encoding/json"
+    "log"
     "net/http"
     "github.com/gorilla/mux"
     "github.com/kha0sys/nodo.social/functions/domain/dto"
+    "github.com/kha0sys/nodo.social/functions/domain/models"
+    "github.com/kha0sys/nodo.social/functions/interfaces/http/middleware"
     "github.com/kha0sys/nodo.social/functions/services"
 )
 
 // ProductHandler maneja las peticiones HTTP relacionadas con productos
 type ProductHandler struct {
     productService *services.ProductService
+    mediaService   *services.MediaService
 }
 
-// NewProductHandler crea una nueva instancia de ProductHandler
-func NewProductHandler(productService *services.ProductService) *ProductHandler {
+// NewProductHandler crea una nueva instancia de ProductHandler.
+// mediaService puede ser nil si las imágenes se responden sin dimensiones ni placeholder.
+func NewProductHandler(productService *services.ProductService, mediaService *services.MediaService) *ProductHandler {
     return &ProductHandler{
         productService: productService,
+        mediaService:   mediaService,
     }
 }
 
@@ -27,6 +34,7 @@ func (h *ProductHandler) RegisterRoutes(r *mux.Router) {
     r.HandleFunc("/products/{id}", h.UpdateProduct).Methods("PUT")
     r.HandleFunc("/products/{id}", h.DeleteProduct).Methods("DELETE")
     r.HandleFunc("/products/{id}/approve", h.ApproveProduct).Methods("POST")
+    r.HandleFunc("/products/{id}/reject", h.RejectProduct).Methods("POST")
     r.HandleFunc("/nodes/{nodeId}/products", h.GetProductsByNode).Methods("GET")
 }
 
@@ -40,6 +48,8 @@ func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 
     // Convertir DTO a modelo
     product := productDTO.ToModel()
+    // El creador es quien puede agregarle imágenes
+    product.UserID, _, _ = middleware.GetUserFromContext(r.Context())
     if err := h.productService.CreateProduct(r.Context(), product); err != nil {
         http.Error(w, err.Error(), http.StatusInternalServerError)
         return
@@ -58,7 +68,9 @@ func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
         return
     }
 
-    json.NewEncoder(w).Encode(dto.FromProductModel(product))
+    productDTO := dto.FromProductModel(product)
+    h.describeMedia(r.Context(), productDTO)
+    json.NewEncoder(w).Encode(productDTO)
 }
 
 // UpdateProduct maneja la actualización de un producto
@@ -103,6 +115,27 @@ func (h *ProductHandler) ApproveProduct(w http.ResponseWriter, r *http.Request)
     w.WriteHeader(http.StatusOK)
 }
 
+// RejectProduct maneja el rechazo de un producto
+func (h *ProductHandler) RejectProduct(w http.ResponseWriter, r *http.Request) {
+    var req struct {
+        Reason string `json:"reason"`
+    }
+    if r.ContentLength != 0 {
+        if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+            http.Error(w, "Invalid request body", http.StatusBadRequest)
+            return
+        }
+    }
+
+    vars := mux.Vars(r)
+    if err := h.productService.RejectProduct(r.Context(), vars["id"], req.Reason); err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    w.WriteHeader(http.StatusOK)
+}
+
 // GetProductsByNode maneja la obtención de productos por nodo
 func (h *ProductHandler) GetProductsByNode(w http.ResponseWriter, r *http.Request) {
     vars := mux.Vars(r)
@@ -117,6 +150,45 @@ func (h *ProductHandler) GetProductsByNode(w http.ResponseWriter, r *http.Reques
     for i, product := range products {
         productDTOs[i] = dto.FromProductModel(product)
     }
+    h.describeMedia(r.Context(), productDTOs...)
 
     json.NewEncoder(w).Encode(productDTOs)
 }
+
+// describeMedia completa los datos de las imágenes de los productos. Las que no tienen
+// datos guardados en el producto se consultan todas a la vez; si falla se responden solo
+// las URLs.
+func (h *ProductHandler) describeMedia(ctx context.Context, products ...*dto.ProductDTO) {
+    missing := make([]string, 0)
+    for _, product := range products {
+        stored := mediaByURL(product.MediaDetails)
+        for _, imageURL := range product.Images {
+            if _, ok := stored[imageURL]; !ok {
+                missing = append(missing, imageURL)
+            }
+        }
+    }
+
+    described := make(map[string]models.MediaURL)
+    if len(missing) > 0 && h.mediaService != nil {
+        media, err := h.mediaService.Describe(ctx, missing)
+        if err != nil {
+            log.Printf("Error describing product media: %v", err)
+        }
+        described = mediaByURL(media)
+    }
+
+    for _, product := range products {
+        stored := mediaByURL(product.MediaDetails)
+        product.MediaDetails = make([]models.MediaURL, 0, len(product.Images))
+        for _, imageURL := range product.Images {
+            item, ok := stored[imageURL]
+            if !ok {
+                if item, ok = described[imageURL]; !ok {
+                    item = models.MediaURL{URL: imageURL, Type: "image"}
+                }
+            }
+            product.MediaDetails = append(product.MediaDetails, item)
+        }
+    }
+}
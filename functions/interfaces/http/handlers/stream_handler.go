@@ -0,0 +1,158 @@
+package handlers
+
+import (
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "sync"
+    "time"
+
+    "github.com/gorilla/mux"
+    "github.com/kha0sys/nodo.social/functions/domain/models"
+    "github.com/kha0sys/nodo.social/functions/interfaces/http/middleware"
+    "github.com/kha0sys/nodo.social/functions/services"
+)
+
+const (
+    // DefaultMaxStreamsPerUser es el número de conexiones simultáneas permitidas por usuario
+    DefaultMaxStreamsPerUser = 5
+    streamHeartbeatInterval  = 25 * time.Second
+    streamRetryMillis        = 5000
+)
+
+// StreamHandler maneja el stream Server-Sent Events con las actualizaciones en vivo del usuario
+type StreamHandler struct {
+    publisher           services.EventPublisher
+    notificationService *services.NotificationService
+    maxStreamsPerUser   int
+
+    mu      sync.Mutex
+    streams map[string]int
+}
+
+// NewStreamHandler crea una nueva instancia de StreamHandler
+func NewStreamHandler(
+    publisher services.EventPublisher,
+    notificationService *services.NotificationService,
+    maxStreamsPerUser int,
+) *StreamHandler {
+    if maxStreamsPerUser <= 0 {
+        maxStreamsPerUser = DefaultMaxStreamsPerUser
+    }
+    return &StreamHandler{
+        publisher:           publisher,
+        notificationService: notificationService,
+        maxStreamsPerUser:   maxStreamsPerUser,
+        streams:             make(map[string]int),
+    }
+}
+
+// RegisterRoutes registra las rutas del handler en el router
+func (h *StreamHandler) RegisterRoutes(r *mux.Router) {
+    r.HandleFunc("/stream", h.Stream).Methods("GET")
+}
+
+// Stream mantiene abierta la conexión SSE y envía notificaciones, items del feed y el contador de no leídas
+func (h *StreamHandler) Stream(w http.ResponseWriter, r *http.Request) {
+    userID, _, _ := middleware.GetUserFromContext(r.Context())
+    if userID == "" {
+        http.Error(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+
+    flusher, ok := w.(http.Flusher)
+    if !ok {
+        http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+        return
+    }
+
+    if !h.acquire(userID) {
+        http.Error(w, "Too many open streams", http.StatusTooManyRequests)
+        return
+    }
+    defer h.release(userID)
+
+    lastEventID := r.Header.Get("Last-Event-ID")
+    if lastEventID == "" {
+        lastEventID = r.URL.Query().Get("lastEventId")
+    }
+
+    ctx := r.Context()
+    events, err := h.publisher.Subscribe(ctx, userID, lastEventID)
+    if err != nil {
+        http.Error(w, "Error subscribing to events", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "text/event-stream")
+    w.Header().Set("Cache-Control", "no-cache")
+    w.Header().Set("Connection", "keep-alive")
+    w.Header().Set("X-Accel-Buffering", "no")
+    w.WriteHeader(http.StatusOK)
+    fmt.Fprintf(w, "retry: %d\n\n", streamRetryMillis)
+
+    // En una conexión nueva se envía el contador actual; al reanudar llegan los eventos perdidos
+    if lastEventID == "" && h.notificationService != nil {
+        if count, err := h.notificationService.GetUnreadCount(ctx, userID); err == nil {
+            writeStreamEvent(w, &models.LiveEvent{
+                Type: models.LiveEventUnreadCount,
+                Data: map[string]int{"unread": count},
+            })
+        }
+    }
+    flusher.Flush()
+
+    heartbeat := time.NewTicker(streamHeartbeatInterval)
+    defer heartbeat.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case event, ok := <-events:
+            if !ok {
+                return
+            }
+            writeStreamEvent(w, event)
+            flusher.Flush()
+        case <-heartbeat.C:
+            fmt.Fprint(w, ": heartbeat\n\n")
+            flusher.Flush()
+        }
+    }
+}
+
+// acquire reserva una conexión para el usuario si no superó el límite
+func (h *StreamHandler) acquire(userID string) bool {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+
+    if h.streams[userID] >= h.maxStreamsPerUser {
+        return false
+    }
+    h.streams[userID]++
+    return true
+}
+
+// release libera la conexión reservada por acquire
+func (h *StreamHandler) release(userID string) {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+
+    h.streams[userID]--
+    if h.streams[userID] <= 0 {
+        delete(h.streams, userID)
+    }
+}
+
+// writeStreamEvent escribe un evento con el formato de Server-Sent Events
+func writeStreamEvent(w http.ResponseWriter, event *models.LiveEvent) {
+    data, err := json.Marshal(event.Data)
+    if err != nil {
+        return
+    }
+    if event.ID != "" {
+        fmt.Fprintf(w, "id: %s\n", event.ID)
+    }
+    fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+}
@@ -1,22 +1,27 @@
 package handlers
 
 import (
+    "context"
     "encoding/json"
+    "log"
     "net/http"
     "github.com/gorilla/mux"
     "github.com/kha0sys/nodo.social/functions/domain/dto"
+    "github.com/kha0sys/nodo.social/functions/domain/models"
     "github.com/kha0sys/nodo.social/functions/services"
 )
 
 // UserHandler maneja las peticiones HTTP relacionadas con usuarios
 type UserHandler struct {
-    userService *services.UserService
+    userService  *services.UserService
+    levelService *services.LevelService
 }
 
 // NewUserHandler crea una nueva instancia de UserHandler
-func NewUserHandler(userService *services.UserService) *UserHandler {
+func NewUserHandler(userService *services.UserService, levelService *services.LevelService) *UserHandler {
     return &UserHandler{
-        userService: userService,
+        userService:  userService,
+        levelService: levelService,
     }
 }
 
@@ -35,7 +40,7 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
     }
 
     w.WriteHeader(http.StatusCreated)
-    json.NewEncoder(w).Encode(dto.FromUserModel(user))
+    json.NewEncoder(w).Encode(h.toDTO(r.Context(), user))
 }
 
 // GetUser maneja la obtención de un usuario por ID
@@ -47,7 +52,7 @@ func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
         return
     }
 
-    json.NewEncoder(w).Encode(dto.FromUserModel(user))
+    json.NewEncoder(w).Encode(h.toDTO(r.Context(), user))
 }
 
 // UpdateUser maneja la actualización de un usuario
@@ -80,3 +85,15 @@ func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 
     w.WriteHeader(http.StatusNoContent)
 }
+
+// toDTO convierte el usuario para la respuesta incluyendo su nivel y el avance hacia el siguiente
+func (h *UserHandler) toDTO(ctx context.Context, user *models.User) *dto.UserDTO {
+    userDTO := dto.FromUserModel(user)
+    level, err := h.levelService.Progress(ctx, user.Points)
+    if err != nil {
+        log.Printf("Error getting level of user %s: %v", user.ID, err)
+        return userDTO
+    }
+    userDTO.Level = level
+    return userDTO
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+    "encoding/json"
+    "net/http"
+    "strconv"
+
+    "github.com/gorilla/mux"
+    "github.com/kha0sys/nodo.social/functions/domain/errors"
+    "github.com/kha0sys/nodo.social/functions/domain/models"
+    "github.com/kha0sys/nodo.social/functions/interfaces/http/middleware"
+    "github.com/kha0sys/nodo.social/functions/services"
+)
+
+// WebhookHandler maneja las peticiones HTTP de los webhooks de nodos y tiendas
+type WebhookHandler struct {
+    webhookService *services.WebhookService
+}
+
+// webhookRequest es el cuerpo para crear o actualizar un webhook
+type webhookRequest struct {
+    OwnerType string   `json:"owner_type"`
+    OwnerID   string   `json:"owner_id"`
+    URL       string   `json:"url"`
+    Events    []string `json:"events"`
+    Active    *bool    `json:"active"`
+}
+
+// NewWebhookHandler crea una nueva instancia de WebhookHandler
+func NewWebhookHandler(webhookService *services.WebhookService) *WebhookHandler {
+    return &WebhookHandler{
+        webhookService: webhookService,
+    }
+}
+
+// RegisterRoutes registra las rutas del handler en el router
+func (h *WebhookHandler) RegisterRoutes(r *mux.Router) {
+    r.HandleFunc("/webhooks", h.ListSubscriptions).Methods("GET")
+    r.HandleFunc("/webhooks", h.CreateSubscription).Methods("POST")
+    r.HandleFunc("/webhooks/{id}", h.UpdateSubscription).Methods("PUT")
+    r.HandleFunc("/webhooks/{id}", h.DeleteSubscription).Methods("DELETE")
+    r.HandleFunc("/webhooks/{id}/test", h.SendTestEvent).Methods("POST")
+    r.HandleFunc("/webhooks/{id}/deliveries", h.ListDeliveries).Methods("GET")
+}
+
+// CreateSubscription maneja la creación de un webhook. La respuesta incluye el secreto de firma.
+func (h *WebhookHandler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
+    userID, _, _ := middleware.GetUserFromContext(r.Context())
+    if userID == "" {
+        http.Error(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+
+    var req webhookRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+
+    subscription, err := h.webhookService.CreateSubscription(r.Context(), userID, &models.WebhookSubscription{
+        OwnerType: req.OwnerType,
+        OwnerID:   req.OwnerID,
+        URL:       req.URL,
+        Events:    req.Events,
+    })
+    if err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusCreated)
+    json.NewEncoder(w).Encode(subscription)
+}
+
+// ListSubscriptions maneja la obtención de los webhooks de un nodo o una tienda,
+// indicados con los parámetros owner_type y owner_id
+func (h *WebhookHandler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
+    userID, _, _ := middleware.GetUserFromContext(r.Context())
+    if userID == "" {
+        http.Error(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+
+    query := r.URL.Query()
+    subscriptions, err := h.webhookService.ListSubscriptions(r.Context(), userID, query.Get("owner_type"), query.Get("owner_id"))
+    if err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(subscriptions)
+}
+
+// UpdateSubscription maneja el cambio de URL, eventos o estado de un webhook
+func (h *WebhookHandler) UpdateSubscription(w http.ResponseWriter, r *http.Request) {
+    userID, _, _ := middleware.GetUserFromContext(r.Context())
+    if userID == "" {
+        http.Error(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+
+    var req webhookRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+
+    changes := &models.WebhookSubscription{
+        URL:    req.URL,
+        Events: req.Events,
+        Active: req.Active == nil || *req.Active,
+    }
+    subscription, err := h.webhookService.UpdateSubscription(r.Context(), userID, mux.Vars(r)["id"], changes)
+    if err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(subscription)
+}
+
+// DeleteSubscription maneja la eliminación de un webhook
+func (h *WebhookHandler) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
+    userID, _, _ := middleware.GetUserFromContext(r.Context())
+    if userID == "" {
+        http.Error(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+
+    if err := h.webhookService.DeleteSubscription(r.Context(), userID, mux.Vars(r)["id"]); err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.WriteHeader(http.StatusNoContent)
+}
+
+// SendTestEvent maneja el envío de un evento de prueba y retorna el resultado del intento
+func (h *WebhookHandler) SendTestEvent(w http.ResponseWriter, r *http.Request) {
+    userID, _, _ := middleware.GetUserFromContext(r.Context())
+    if userID == "" {
+        http.Error(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+
+    delivery, err := h.webhookService.SendTestEvent(r.Context(), userID, mux.Vars(r)["id"])
+    if err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(delivery)
+}
+
+// ListDeliveries maneja la obtención del registro de entregas de un webhook
+func (h *WebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+    userID, _, _ := middleware.GetUserFromContext(r.Context())
+    if userID == "" {
+        http.Error(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+
+    limit := 0
+    if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+        var err error
+        limit, err = strconv.Atoi(limitStr)
+        if err != nil {
+            http.Error(w, "Invalid limit parameter", http.StatusBadRequest)
+            return
+        }
+    }
+
+    deliveries, err := h.webhookService.ListDeliveries(r.Context(), userID, mux.Vars(r)["id"], limit)
+    if err != nil {
+        http.Error(w, err.Error(), errors.GetErrorCode(err))
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(deliveries)
+}
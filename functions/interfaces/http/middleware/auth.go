@@ -50,6 +50,39 @@ func (m *AuthMiddleware) Authenticate(next http.Handler) http.Handler {
     })
 }
 
+// AuthenticateStream verifica el token igual que Authenticate, pero también lo acepta en el
+// parámetro access_token porque EventSource no permite enviar cabeceras
+func (m *AuthMiddleware) AuthenticateStream(next http.Handler) http.Handler {
+    authenticate := m.Authenticate(next)
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.Header.Get("Authorization") == "" {
+            if token := r.URL.Query().Get("access_token"); token != "" {
+                r.Header.Set("Authorization", "Bearer "+token)
+            }
+        }
+        authenticate.ServeHTTP(w, r)
+    })
+}
+
+// RequireRole deja pasar solo a los usuarios autenticados cuyo token tiene el rol indicado.
+// Debe usarse después de Authenticate.
+func RequireRole(role string) func(http.Handler) http.Handler {
+    return func(next http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            userID, _, userRole := GetUserFromContext(r.Context())
+            if userID == "" {
+                http.Error(w, "Unauthorized", http.StatusUnauthorized)
+                return
+            }
+            if userRole != role {
+                http.Error(w, "Forbidden", http.StatusForbidden)
+                return
+            }
+            next.ServeHTTP(w, r)
+        })
+    }
+}
+
 // GetUserFromContext obtiene la información del usuario del contexto
 func GetUserFromContext(ctx context.Context) (userId string, userEmail string, userRole string) {
     userId, _ = ctx.Value("user_id").(string)
@@ -7,6 +7,7 @@ import (
 
     "github.com/gorilla/mux"
     "github.com/kha0sys/nodo.social/functions/domain/dto"
+    "github.com/kha0sys/nodo.social/functions/interfaces/http/middleware"
     "github.com/kha0sys/nodo.social/functions/services"
 )
 
@@ -65,7 +66,9 @@ func (h *NodeHandler) GetNode(w http.ResponseWriter, r *http.Request) {
 
 // GetNodeFeed maneja GET /nodes/feed
 func (h *NodeHandler) GetNodeFeed(w http.ResponseWriter, r *http.Request) {
-    nodes, err := h.nodeService.GetNodeFeed(r.Context())
+    userID, _, _ := middleware.GetUserFromContext(r.Context())
+
+    nodes, err := h.nodeService.GetNodeFeed(r.Context(), userID)
     if err != nil {
         http.Error(w, err.Error(), http.StatusInternalServerError)
         return
@@ -1,11 +1,22 @@
 package router
 
 import (
+    "context"
+    "log"
     "net/http"
+    "os"
+    "strings"
+
     firebase "firebase.google.com/go/v4"
     "github.com/gorilla/mux"
+    "github.com/kha0sys/nodo.social/functions/domain/repositories"
+    "github.com/kha0sys/nodo.social/functions/infrastructure/email"
+    infrafirebase "github.com/kha0sys/nodo.social/functions/infrastructure/firebase"
+    infrafirestore "github.com/kha0sys/nodo.social/functions/infrastructure/firestore"
     "github.com/kha0sys/nodo.social/functions/interfaces/http/handlers"
     "github.com/kha0sys/nodo.social/functions/interfaces/http/middleware"
+    "github.com/kha0sys/nodo.social/functions/internal/config"
+    "github.com/kha0sys/nodo.social/functions/services"
 )
 
 // Router maneja la configuración de rutas de la aplicación
@@ -26,14 +37,136 @@ func NewRouter(app *firebase.App) *Router {
 
 // SetupRoutes configura todas las rutas de la aplicación
 func (r *Router) SetupRoutes() http.Handler {
+    cfg, err := config.LoadConfig()
+    if err != nil {
+        log.Fatalf("Error loading config: %v\n", err)
+    }
+
+    client, err := r.app.Firestore(context.Background())
+    if err != nil {
+        log.Fatalf("Error initializing Firestore client: %v\n", err)
+    }
+
+    // Crear repositorios y servicios
+    feedRepo := repositories.NewFirestoreFeedRepository(client)
+    feedMuteRepo := repositories.NewFirestoreFeedMuteRepository(client)
+    nodeRepo := repositories.NewFirestoreNodeRepository(client)
+    userRepo := repositories.NewFirestoreUserRepository(client)
+    deviceRepo := repositories.NewFirestoreDeviceTokenRepository(client)
+    notificationRepo := repositories.NewFirestoreNotificationRepository(client)
+    preferencesRepo := repositories.NewFirestoreNotificationPreferencesRepository(client)
+    deferredRepo := repositories.NewFirestoreDeferredNotificationRepository(client)
+    pendingRepo := repositories.NewFirestorePendingNotificationRepository(client)
+    rateRepo := repositories.NewFirestoreNotificationRateRepository(client)
+    suppressionRepo := repositories.NewFirestoreEmailSuppressionRepository(client)
+    storeRepo := repositories.NewFirestoreStoreRepository(client)
+    webhookSubscriptionRepo := repositories.NewFirestoreWebhookSubscriptionRepository(client)
+    webhookDeliveryRepo := repositories.NewFirestoreWebhookDeliveryRepository(client)
+    productRepo := repositories.NewFirestoreProductRepository(client)
+    achievementRepo := repositories.NewFirestoreAchievementRepository(client)
+    pointsRepo := repositories.NewFirestorePointsRepository(client)
+    leaderboardRepo := repositories.NewFirestoreLeaderboardRepository(client)
+    streakRepo := repositories.NewFirestoreStreakRepository(client)
+    challengeRepo := repositories.NewFirestoreChallengeRepository(client)
+    challengeProgressRepo := repositories.NewFirestoreChallengeProgressRepository(client)
+    levelRepo := repositories.NewFirestoreLevelRepository(client)
+    backfillRepo := repositories.NewFirestoreAchievementBackfillRepository(client)
+    feedRules, err := services.ParseFeedAggregationRules(cfg.Feed.AggregationRules)
+    if err != nil {
+        log.Fatalf("Error parsing feed aggregation rules: %v\n", err)
+    }
+    feedService := services.NewFeedService(feedRepo, feedMuteRepo, nodeRepo, feedRules)
+    webhookService := services.NewWebhookService(webhookSubscriptionRepo, webhookDeliveryRepo, nodeRepo, storeRepo, services.WebhookConfig{
+        AllowPrivateNetworks: cfg.Webhook.AllowPrivateNetworks,
+    })
+
+    // Las actualizaciones en vivo usan un listener de Firestore salvo que se pida el publicador local
+    var publisher services.EventPublisher
+    if os.Getenv("LIVE_EVENTS_BACKEND") == "memory" {
+        publisher = services.NewInMemoryEventPublisher()
+    } else {
+        publisher = infrafirestore.NewLiveEventPublisher(client)
+    }
+
+    // Las entregas fallidas se reintentan desde Firestore salvo que se pida la cola local
+    var deliveryQueue services.DeliveryQueue
+    if os.Getenv("DELIVERY_QUEUE_BACKEND") == "memory" {
+        deliveryQueue = services.NewInMemoryDeliveryQueue()
+    } else {
+        deliveryQueue = infrafirestore.NewDeliveryQueue(client)
+    }
+
+    // El canal de correo solo se activa si hay un servidor SMTP configurado
+    var emailService *services.EmailService
+    if cfg.Email.SMTPHost != "" {
+        sender := email.NewSMTPSender(email.SMTPConfig{
+            Host:     cfg.Email.SMTPHost,
+            Port:     cfg.Email.SMTPPort,
+            Username: cfg.Email.SMTPUsername,
+            Password: cfg.Email.SMTPPassword,
+            From:     cfg.Email.From,
+        })
+        emailService, err = services.NewEmailService(sender, suppressionRepo, preferencesRepo, services.EmailConfig{
+            AppURL:         cfg.Email.AppURL,
+            UnsubscribeURL: cfg.Email.UnsubscribeURL,
+            Secret:         cfg.Email.UnsubscribeSecret,
+        })
+        if err != nil {
+            log.Fatalf("Error initializing email service: %v\n", err)
+        }
+    }
+
+    notificationService, err := services.NewNotificationService(r.app, userRepo, deviceRepo, notificationRepo, preferencesRepo, deferredRepo, pendingRepo, rateRepo, deliveryQueue, publisher, emailService)
+    if err != nil {
+        log.Fatalf("Error initializing notification service: %v\n", err)
+    }
+
+    // Los puntos dependen de la curva de niveles para avisar de las subidas de nivel
+    levelService := services.NewLevelService(levelRepo, userRepo, feedRepo, notificationService)
+    pointsService := services.NewPointsService(pointsRepo, userRepo, levelService)
+    leaderboardService := services.NewLeaderboardService(pointsRepo, userRepo, nodeRepo, leaderboardRepo, levelService)
+    achievementService := services.NewAchievementService(userRepo, nodeRepo, productRepo, achievementRepo, pointsService)
+    backfillService := services.NewAchievementBackfillService(backfillRepo, userRepo, achievementRepo, achievementService)
+    streakService := services.NewStreakService(streakRepo, preferencesRepo, userRepo)
+    challengeService := services.NewChallengeService(challengeRepo, challengeProgressRepo, achievementService)
+
+    // La recolección de archivos huérfanos solo se activa si hay un bucket configurado
+    var mediaGCHandler *handlers.MediaGCHandler
+    if cfg.MediaGC.Bucket != "" {
+        storageRepo, err := infrafirebase.NewFirebaseStorageRepository(context.Background(), r.app, cfg.MediaGC.Bucket)
+        if err != nil {
+            log.Fatalf("Error initializing storage repository: %v\n", err)
+        }
+        mediaGCService := services.NewMediaGCService(repositories.NewFirestoreMediaGCRepository(client), storageRepo, services.MediaGCConfig{
+            Prefixes:    strings.Split(cfg.MediaGC.Prefixes, ","),
+            GracePeriod: cfg.MediaGC.GracePeriod,
+            DeleteDelay: cfg.MediaGC.DeleteDelay,
+            DryRun:      cfg.MediaGC.DryRun,
+        })
+        mediaGCHandler = handlers.NewMediaGCHandler(mediaGCService)
+    }
+
     // Crear handlers
-    nodeHandler := handlers.NewNodeHandler(r.app)
+    nodeHandler := handlers.NewNodeHandler(r.app, levelService, feedService)
+    feedHandler := handlers.NewFeedHandler(feedService)
+    notificationHandler := handlers.NewNotificationHandler(notificationService)
+    deliveryHandler := handlers.NewDeliveryHandler(notificationService)
+    webhookHandler := handlers.NewWebhookHandler(webhookService)
+    achievementHandler := handlers.NewAchievementHandler(achievementService, backfillService)
+    pointsHandler := handlers.NewPointsHandler(pointsService)
+    leaderboardHandler := handlers.NewLeaderboardHandler(leaderboardService)
+    challengeHandler := handlers.NewChallengeHandler(streakService, challengeService)
+    levelHandler := handlers.NewLevelHandler(levelService)
+    streamHandler := handlers.NewStreamHandler(publisher, notificationService, handlers.DefaultMaxStreamsPerUser)
 
     // API Router
     api := r.router.PathPrefix("/api").Subrouter()
 
     // Rutas públicas
     api.HandleFunc("/health", handlers.HealthCheck).Methods("GET")
+    if emailService != nil {
+        handlers.NewEmailHandler(emailService, cfg.Email.WebhookSecret).RegisterRoutes(api)
+    }
 
     // Rutas de nodos (protegidas)
     nodes := api.PathPrefix("/nodes").Subrouter()
@@ -44,6 +177,43 @@ func (r *Router) SetupRoutes() http.Handler {
     // Registrar rutas de nodos
     nodeHandler.RegisterRoutes(nodes)
 
+    // Rutas protegidas generales
+    protected := api.PathPrefix("").Subrouter()
+    protected.Use(func(next http.Handler) http.Handler {
+        return r.auth.Authenticate(next)
+    })
+
+    // Registrar rutas del feed, de notificaciones y de webhooks
+    feedHandler.RegisterRoutes(protected)
+    notificationHandler.RegisterRoutes(protected)
+    webhookHandler.RegisterRoutes(protected)
+    pointsHandler.RegisterRoutes(protected)
+    leaderboardHandler.RegisterRoutes(protected)
+    challengeHandler.RegisterRoutes(protected)
+    levelHandler.RegisterRoutes(protected)
+
+    // Rutas de administración
+    admin := api.PathPrefix("/admin").Subrouter()
+    admin.Use(func(next http.Handler) http.Handler {
+        return r.auth.Authenticate(next)
+    })
+    admin.Use(middleware.RequireRole("admin"))
+    deliveryHandler.RegisterRoutes(admin)
+    achievementHandler.RegisterRoutes(admin)
+    pointsHandler.RegisterAdminRoutes(admin)
+    challengeHandler.RegisterAdminRoutes(admin)
+    levelHandler.RegisterAdminRoutes(admin)
+    if mediaGCHandler != nil {
+        mediaGCHandler.RegisterRoutes(admin)
+    }
+
+    // Stream de actualizaciones en vivo (acepta el token por query para EventSource)
+    stream := api.PathPrefix("").Subrouter()
+    stream.Use(func(next http.Handler) http.Handler {
+        return r.auth.AuthenticateStream(next)
+    })
+    streamHandler.RegisterRoutes(stream)
+
     return r.router
 }
 
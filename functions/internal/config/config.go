@@ -2,6 +2,7 @@ package config
 
 import (
     "os"
+    "strconv"
     "time"
 )
 
@@ -10,19 +11,25 @@ type Config struct {
     Firebase FirebaseConfig
     Server   ServerConfig
     Auth     AuthConfig
+    Email    EmailConfig
+    Webhook  WebhookConfig
+    Image    ImageConfig
+    Upload   UploadConfig
+    MediaGC  MediaGCConfig
+    Feed     FeedConfig
 }
 
 // FirebaseConfig contiene la configuración de Firebase
 type FirebaseConfig struct {
-    ProjectID              string
-    PrivateKeyID          string
-    PrivateKey            string
-    ClientEmail           string
-    ClientID              string
-    AuthURI               string
-    TokenURI              string
-    AuthProviderCertURL   string
-    ClientX509CertURL     string
+    ProjectID           string
+    PrivateKeyID        string
+    PrivateKey          string
+    ClientEmail         string
+    ClientID            string
+    AuthURI             string
+    TokenURI            string
+    AuthProviderCertURL string
+    ClientX509CertURL   string
 }
 
 // ServerConfig contiene la configuración del servidor
@@ -39,19 +46,81 @@ type AuthConfig struct {
     TokenDuration time.Duration
 }
 
+// EmailConfig contiene la configuración del canal de correo.
+// Si SMTPHost está vacío el canal de correo queda desactivado.
+type EmailConfig struct {
+    SMTPHost          string
+    SMTPPort          string
+    SMTPUsername      string
+    SMTPPassword      string
+    From              string
+    AppURL            string
+    UnsubscribeURL    string
+    UnsubscribeSecret string
+    WebhookSecret     string
+}
+
+// WebhookConfig contiene la configuración de los webhooks salientes
+type WebhookConfig struct {
+    // AllowPrivateNetworks permite enviar webhooks a direcciones locales, solo para desarrollo
+    AllowPrivateNetworks bool
+}
+
+// ImageConfig contiene la configuración del procesamiento de imágenes
+type ImageConfig struct {
+    // ThumbnailSizes es la lista de tamaños de thumbnails con el formato "small:150,medium:300"
+    ThumbnailSizes string
+    // KeepMetadata conserva los metadatos EXIF, XMP e IPTC de las imágenes subidas. Por
+    // defecto se eliminan porque pueden incluir la ubicación GPS donde se tomó la foto.
+    KeepMetadata bool
+    // MaxPixels es el máximo de píxeles de las imágenes que se procesan
+    MaxPixels int
+}
+
+// UploadConfig contiene los límites de los archivos subidos, en bytes
+type UploadConfig struct {
+    MaxImageBytes    int64
+    MaxDocumentBytes int64
+    MaxVideoBytes    int64
+    MaxAudioBytes    int64
+    // UserQuotaBytes es el espacio total que puede ocupar cada usuario; 0 lo deja sin límite
+    UserQuotaBytes int64
+}
+
+// FeedConfig contiene la configuración del feed
+type FeedConfig struct {
+    // AggregationRules es la lista de reglas de agregación con el formato
+    // "node_followed:1h:3,node_updated:6h:3" (tipo:ventana:actores de muestra)
+    AggregationRules string
+}
+
+// MediaGCConfig contiene la configuración de la recolección de archivos huérfanos del storage
+type MediaGCConfig struct {
+    // Bucket es el bucket que se recorre; si está vacío la recolección queda desactivada
+    Bucket string
+    // Prefixes es la lista de prefijos del bucket que se recorren, separados por comas
+    Prefixes string
+    // GracePeriod es la antigüedad mínima de un archivo sin referencias para marcarlo
+    GracePeriod time.Duration
+    // DeleteDelay es el tiempo mínimo entre que un archivo se marca y se elimina
+    DeleteDelay time.Duration
+    // DryRun hace que las recolecciones programadas solo reporten lo que harían
+    DryRun bool
+}
+
 // LoadConfig carga la configuración desde variables de entorno
 func LoadConfig() (*Config, error) {
     return &Config{
         Firebase: FirebaseConfig{
-            ProjectID:              os.Getenv("FIREBASE_PROJECT_ID"),
-            PrivateKeyID:          os.Getenv("FIREBASE_PRIVATE_KEY_ID"),
-            PrivateKey:            os.Getenv("FIREBASE_PRIVATE_KEY"),
-            ClientEmail:           os.Getenv("FIREBASE_CLIENT_EMAIL"),
-            ClientID:              os.Getenv("FIREBASE_CLIENT_ID"),
-            AuthURI:               os.Getenv("FIREBASE_AUTH_URI"),
-            TokenURI:              os.Getenv("FIREBASE_TOKEN_URI"),
-            AuthProviderCertURL:   os.Getenv("FIREBASE_AUTH_PROVIDER_CERT_URL"),
-            ClientX509CertURL:     os.Getenv("FIREBASE_CLIENT_CERT_URL"),
+            ProjectID:           os.Getenv("FIREBASE_PROJECT_ID"),
+            PrivateKeyID:        os.Getenv("FIREBASE_PRIVATE_KEY_ID"),
+            PrivateKey:          os.Getenv("FIREBASE_PRIVATE_KEY"),
+            ClientEmail:         os.Getenv("FIREBASE_CLIENT_EMAIL"),
+            ClientID:            os.Getenv("FIREBASE_CLIENT_ID"),
+            AuthURI:             os.Getenv("FIREBASE_AUTH_URI"),
+            TokenURI:            os.Getenv("FIREBASE_TOKEN_URI"),
+            AuthProviderCertURL: os.Getenv("FIREBASE_AUTH_PROVIDER_CERT_URL"),
+            ClientX509CertURL:   os.Getenv("FIREBASE_CLIENT_CERT_URL"),
         },
         Server: ServerConfig{
             Port:         getEnvOrDefault("SERVER_PORT", "8080"),
@@ -63,6 +132,42 @@ func LoadConfig() (*Config, error) {
             JWTSecret:     os.Getenv("JWT_SECRET"),
             TokenDuration: getDurationOrDefault("TOKEN_DURATION", 24*time.Hour),
         },
+        Email: EmailConfig{
+            SMTPHost:          os.Getenv("SMTP_HOST"),
+            SMTPPort:          getEnvOrDefault("SMTP_PORT", "1025"),
+            SMTPUsername:      os.Getenv("SMTP_USERNAME"),
+            SMTPPassword:      os.Getenv("SMTP_PASSWORD"),
+            From:              getEnvOrDefault("EMAIL_FROM", "Nodo Social <no-reply@nodo.social>"),
+            AppURL:            getEnvOrDefault("APP_URL", "https://nodo.social"),
+            UnsubscribeURL:    getEnvOrDefault("EMAIL_UNSUBSCRIBE_URL", "https://nodo.social/api/email/unsubscribe"),
+            UnsubscribeSecret: os.Getenv("EMAIL_UNSUBSCRIBE_SECRET"),
+            WebhookSecret:     os.Getenv("EMAIL_WEBHOOK_SECRET"),
+        },
+        Webhook: WebhookConfig{
+            AllowPrivateNetworks: os.Getenv("WEBHOOKS_ALLOW_PRIVATE_NETWORKS") == "true",
+        },
+        Image: ImageConfig{
+            ThumbnailSizes: getEnvOrDefault("THUMBNAIL_SIZES", "small:150,medium:300,large:600"),
+            KeepMetadata:   os.Getenv("IMAGE_KEEP_METADATA") == "true",
+            MaxPixels:      int(getInt64OrDefault("IMAGE_MAX_PIXELS", 40_000_000)),
+        },
+        Upload: UploadConfig{
+            MaxImageBytes:    getInt64OrDefault("UPLOAD_MAX_IMAGE_BYTES", 10<<20),
+            MaxDocumentBytes: getInt64OrDefault("UPLOAD_MAX_DOCUMENT_BYTES", 20<<20),
+            MaxVideoBytes:    getInt64OrDefault("UPLOAD_MAX_VIDEO_BYTES", 100<<20),
+            MaxAudioBytes:    getInt64OrDefault("UPLOAD_MAX_AUDIO_BYTES", 20<<20),
+            UserQuotaBytes:   getInt64OrDefault("UPLOAD_USER_QUOTA_BYTES", 1<<30),
+        },
+        MediaGC: MediaGCConfig{
+            Bucket:      os.Getenv("STORAGE_BUCKET"),
+            Prefixes:    getEnvOrDefault("MEDIA_GC_PREFIXES", "users/"),
+            GracePeriod: getDurationOrDefault("MEDIA_GC_GRACE_PERIOD", 7*24*time.Hour),
+            DeleteDelay: getDurationOrDefault("MEDIA_GC_DELETE_DELAY", 20*time.Hour),
+            DryRun:      os.Getenv("MEDIA_GC_DRY_RUN") == "true",
+        },
+        Feed: FeedConfig{
+            AggregationRules: getEnvOrDefault("FEED_AGGREGATION_RULES", "node_followed:1h:3,node_updated:6h:3"),
+        },
     }, nil
 }
 
@@ -82,3 +187,11 @@ func getDurationOrDefault(key string, defaultValue time.Duration) time.Duration
     return defaultValue
 }
 
+func getInt64OrDefault(key string, defaultValue int64) int64 {
+    if value := os.Getenv(key); value != "" {
+        if number, err := strconv.ParseInt(value, 10, 64); err == nil {
+            return number
+        }
+    }
+    return defaultValue
+}
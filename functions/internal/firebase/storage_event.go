@@ -6,6 +6,7 @@ import "time"
 type StorageEvent struct {
 	Bucket         string    `json:"bucket"`
 	Name           string    `json:"name"`
+	Generation     string    `json:"generation"`
 	Metageneration string    `json:"metageneration"`
 	TimeCreated    time.Time `json:"timeCreated"`
 	Updated        time.Time `json:"updated"`
@@ -0,0 +1,119 @@
+// Package i18n proporciona el catálogo de mensajes traducidos usado en notificaciones y correos
+package i18n
+
+import (
+    "fmt"
+    "strings"
+)
+
+// Idiomas soportados
+const (
+    Spanish = "es"
+    English = "en"
+    // DefaultLanguage es el idioma usado cuando el del usuario no está soportado
+    DefaultLanguage = Spanish
+)
+
+// Message es un texto traducido. One se usa cuando el parámetro "count" vale 1 o no
+// se indica, Zero cuando vale 0; en cualquier otro caso, o si faltan, se usa Other.
+type Message struct {
+    Zero  string
+    One   string
+    Other string
+}
+
+// Catalog contiene los mensajes traducidos por idioma y clave
+type Catalog struct {
+    messages map[string]map[string]Message
+}
+
+// NewCatalog crea un catálogo vacío
+func NewCatalog() *Catalog {
+    return &Catalog{messages: make(map[string]map[string]Message)}
+}
+
+// Add registra el mensaje de una clave en un idioma
+func (c *Catalog) Add(lang string, key string, message Message) {
+    if c.messages[lang] == nil {
+        c.messages[lang] = make(map[string]Message)
+    }
+    c.messages[lang][key] = message
+}
+
+// Has indica si la clave existe en el idioma por defecto
+func (c *Catalog) Has(key string) bool {
+    _, ok := c.messages[DefaultLanguage][key]
+    return ok
+}
+
+// Translate retorna el mensaje de key en lang, con los parámetros {nombre} reemplazados.
+// Si el idioma no tiene la clave se usa el idioma por defecto, y si tampoco existe se retorna la clave.
+func (c *Catalog) Translate(lang string, key string, params map[string]interface{}) string {
+    message, ok := c.messages[NormalizeLanguage(lang)][key]
+    if !ok {
+        if message, ok = c.messages[DefaultLanguage][key]; !ok {
+            return key
+        }
+    }
+
+    text := message.Other
+    count, ok := params["count"]
+    switch {
+    case message.Zero != "" && ok && countIs(count, 0):
+        text = message.Zero
+    case message.One != "" && (!ok || countIs(count, 1)):
+        text = message.One
+    }
+    return interpolate(text, params)
+}
+
+// NormalizeLanguage reduce una etiqueta como "en-US" o "EN_gb" a un idioma soportado
+func NormalizeLanguage(lang string) string {
+    lang = strings.ToLower(strings.TrimSpace(lang))
+    if i := strings.IndexAny(lang, "-_"); i >= 0 {
+        lang = lang[:i]
+    }
+    switch lang {
+    case Spanish, English:
+        return lang
+    }
+    return DefaultLanguage
+}
+
+// FromAcceptLanguage elige el primer idioma soportado de una cabecera Accept-Language
+func FromAcceptLanguage(header string) string {
+    for _, part := range strings.Split(header, ",") {
+        tag := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+        if tag == "" {
+            continue
+        }
+        if lang := NormalizeLanguage(tag); strings.HasPrefix(strings.ToLower(tag), lang) {
+            return lang
+        }
+    }
+    return DefaultLanguage
+}
+
+func interpolate(text string, params map[string]interface{}) string {
+    if len(params) == 0 || !strings.Contains(text, "{") {
+        return text
+    }
+
+    replacements := make([]string, 0, len(params)*2)
+    for name, value := range params {
+        replacements = append(replacements, "{"+name+"}", fmt.Sprint(value))
+    }
+    return strings.NewReplacer(replacements...).Replace(text)
+}
+
+func countIs(count interface{}, value int64) bool {
+    switch n := count.(type) {
+    case int:
+        return int64(n) == value
+    case int64:
+        return n == value
+    case float64:
+        return n == float64(value)
+    }
+    return false
+}
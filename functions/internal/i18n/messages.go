@@ -0,0 +1,109 @@
+package i18n
+
+// notificationMessages contiene los textos de las notificaciones y correos.
+// Las claves de notificación son "<tipo>.title" y "<tipo>.body".
+var notificationMessages = map[string]map[string]Message{
+    Spanish: {
+        "welcome.title":               {Other: "¡Bienvenido a Nodo Social!"},
+        "welcome.body":                {Other: "Gracias por unirte a nuestra comunidad. Explora las causas que te interesan y comienza a generar impacto."},
+        "node_created.title":          {Other: "Nuevo nodo: {title}"},
+        "node_created.body":           {Other: "Se ha creado un nuevo nodo: {description}"},
+        "node_updated.title":          {Other: "Actualización en nodo: {title}"},
+        "node_updated.body":           {One: "Se han realizado cambios importantes en este nodo", Other: "Se realizaron {count} actualizaciones en este nodo"},
+        "node_deleted.title":          {Other: "Nodo eliminado: {title}"},
+        "node_deleted.body":           {Other: "Un nodo que seguías ha sido eliminado"},
+        "weekly_digest.title":         {Other: "Tu resumen semanal"},
+        "weekly_digest.body":          {Zero: "Mira cómo les fue a tus nodos y qué causas están en tendencia", One: "Tienes {count} novedad esta semana", Other: "Tienes {count} novedades esta semana"},
+        "achievement_followers.title": {One: "¡Felicitaciones! Tu nodo ha alcanzado {count} seguidor", Other: "¡Felicitaciones! Tu nodo ha alcanzado {count} seguidores"},
+        "achievement_followers.body":  {Other: "Tu nodo '{title}' está creciendo"},
+        "product_approved.title":      {Other: "Tu producto fue aprobado"},
+        "product_approved.body":       {Other: "Tu producto '{name}' fue aprobado y ya está publicado."},
+        "product_rejected.title":      {Other: "Tu producto no fue aprobado"},
+        "product_rejected.body":       {Other: "Tu producto '{name}' no fue aprobado."},
+        "level_up.title":              {Other: "¡Subiste al nivel {level}!"},
+        "level_up.body":               {Other: "Ahora eres {name}. Descubre los beneficios de tu nuevo nivel."},
+
+        "email.greeting":              {Other: "Hola {name},"},
+        "email.greeting_anonymous":    {Other: "Hola,"},
+        "email.footer":                {Other: "Recibes este correo porque tienes una cuenta en Nodo Social."},
+        "email.unsubscribe_type":      {Other: "Dejar de recibir este tipo de correos"},
+        "email.unsubscribe_all":       {Other: "Dejar de recibir todos los correos"},
+        "email.open_app":              {Other: "Abrir Nodo Social"},
+        "email.welcome.cta":           {Other: "Explorar causas"},
+        "email.digest.updates":        {Other: "Novedades de los nodos que sigues:"},
+        "email.digest.milestones":     {Other: "Tus nodos alcanzaron nuevos hitos:"},
+        "email.digest.milestone":      {One: "{title} llegó a {count} seguidor", Other: "{title} llegó a {count} seguidores"},
+        "email.digest.achievements":   {Other: "Logros que desbloqueaste:"},
+        "email.digest.achievement":    {One: "{name} (+{count} punto)", Other: "{name} (+{count} puntos)"},
+        "email.digest.trending":       {Other: "Causas en tendencia que te pueden interesar:"},
+        "email.digest.stats":          {Other: "Así les fue a tus nodos esta semana:"},
+        "email.digest.stats_line":     {Other: "{followers} seguidores (+{new_followers}), {views} vistas (+{new_views}), {likes} me gusta (+{new_likes})"},
+        "email.digest.view_all":       {Other: "Ver todo en Nodo Social"},
+        "email.product.visible":       {Other: "Ya es visible para toda la comunidad."},
+        "email.product.view":          {Other: "Ver producto"},
+        "email.product.reason":        {Other: "Motivo: {reason}"},
+        "email.product.retry":         {Other: "Puedes editarlo y enviarlo de nuevo a revisión."},
+        "email.milestone.followers":   {One: "Tu nodo ya tiene {count} seguidor.", Other: "Tu nodo ya tiene {count} seguidores."},
+        "email.milestone.view":        {Other: "Ver tu nodo"},
+        "email.unsubscribe.confirm":   {Other: "¿Quieres dejar de recibir estos correos de Nodo Social?"},
+        "email.unsubscribe.button":    {Other: "Confirmar baja"},
+        "email.unsubscribe.done":      {Other: "Listo, no volverás a recibir estos correos. Puedes cambiarlo cuando quieras desde la configuración de notificaciones."},
+    },
+    English: {
+        "welcome.title":               {Other: "Welcome to Nodo Social!"},
+        "welcome.body":                {Other: "Thanks for joining our community. Explore the causes you care about and start making an impact."},
+        "node_created.title":          {Other: "New node: {title}"},
+        "node_created.body":           {Other: "A new node was created: {description}"},
+        "node_updated.title":          {Other: "Update on node: {title}"},
+        "node_updated.body":           {One: "Important changes were made to this node", Other: "{count} updates were made to this node"},
+        "node_deleted.title":          {Other: "Node deleted: {title}"},
+        "node_deleted.body":           {Other: "A node you followed has been deleted"},
+        "weekly_digest.title":         {Other: "Your weekly digest"},
+        "weekly_digest.body":          {Zero: "See how your nodes did and which causes are trending", One: "You have {count} new highlight this week", Other: "You have {count} new highlights this week"},
+        "achievement_followers.title": {One: "Congratulations! Your node reached {count} follower", Other: "Congratulations! Your node reached {count} followers"},
+        "achievement_followers.body":  {Other: "Your node '{title}' is growing"},
+        "product_approved.title":      {Other: "Your product was approved"},
+        "product_approved.body":       {Other: "Your product '{name}' was approved and is now published."},
+        "product_rejected.title":      {Other: "Your product was not approved"},
+        "product_rejected.body":       {Other: "Your product '{name}' was not approved."},
+        "level_up.title":              {Other: "You reached level {level}!"},
+        "level_up.body":               {Other: "You are now {name}. Check out the perks of your new level."},
+
+        "email.greeting":              {Other: "Hi {name},"},
+        "email.greeting_anonymous":    {Other: "Hi,"},
+        "email.footer":                {Other: "You are receiving this email because you have a Nodo Social account."},
+        "email.unsubscribe_type":      {Other: "Stop receiving this type of email"},
+        "email.unsubscribe_all":       {Other: "Stop receiving all emails"},
+        "email.open_app":              {Other: "Open Nodo Social"},
+        "email.welcome.cta":           {Other: "Explore causes"},
+        "email.digest.updates":        {Other: "News from the nodes you follow:"},
+        "email.digest.milestones":     {Other: "Your nodes reached new milestones:"},
+        "email.digest.milestone":      {One: "{title} reached {count} follower", Other: "{title} reached {count} followers"},
+        "email.digest.achievements":   {Other: "Achievements you unlocked:"},
+        "email.digest.achievement":    {One: "{name} (+{count} point)", Other: "{name} (+{count} points)"},
+        "email.digest.trending":       {Other: "Trending causes you might like:"},
+        "email.digest.stats":          {Other: "How your nodes did this week:"},
+        "email.digest.stats_line":     {Other: "{followers} followers (+{new_followers}), {views} views (+{new_views}), {likes} likes (+{new_likes})"},
+        "email.digest.view_all":       {Other: "See everything on Nodo Social"},
+        "email.product.visible":       {Other: "It is now visible to the whole community."},
+        "email.product.view":          {Other: "View product"},
+        "email.product.reason":        {Other: "Reason: {reason}"},
+        "email.product.retry":         {Other: "You can edit it and submit it for review again."},
+        "email.milestone.followers":   {One: "Your node now has {count} follower.", Other: "Your node now has {count} followers."},
+        "email.milestone.view":        {Other: "View your node"},
+        "email.unsubscribe.confirm":   {Other: "Do you want to stop receiving these emails from Nodo Social?"},
+        "email.unsubscribe.button":    {Other: "Confirm"},
+        "email.unsubscribe.done":      {Other: "Done, you will no longer receive these emails. You can change this at any time in your notification settings."},
+    },
+}
+
+// DefaultCatalog retorna un catálogo con los mensajes de notificaciones y correos
+func DefaultCatalog() *Catalog {
+    catalog := NewCatalog()
+    for lang, messages := range notificationMessages {
+        for key, message := range messages {
+            catalog.Add(lang, key, message)
+        }
+    }
+    return catalog
+}
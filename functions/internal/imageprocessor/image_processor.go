@@ -2,61 +2,273 @@ package imageprocessor
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"image"
+	"image/draw"
+	"image/gif"
 	"image/jpeg"
 	"image/png"
 	"io"
+	"sort"
+	"strconv"
+	"strings"
 
+	"github.com/HugoSmits86/nativewebp"
 	"github.com/disintegration/imaging"
+	_ "golang.org/x/image/webp"
 )
 
+// Size es un tamaño de thumbnail: Name se usa en el nombre del archivo y Width es el ancho
+// en píxeles; el alto se calcula manteniendo el aspecto
+type Size struct {
+	Name  string
+	Width int
+}
+
+// Thumbnail es un thumbnail generado en un tamaño y formato
+type Thumbnail struct {
+	Size        string
+	Format      string
+	Extension   string
+	ContentType string
+	Width       int
+	Height      int
+	Data        []byte
+}
+
+// ProcessedImage es el resultado de procesar una imagen: su formato, sus thumbnails y su
+// placeholder
+type ProcessedImage struct {
+	Placeholder
+	Format     string
+	Thumbnails []Thumbnail
+}
+
+// formatInfo describe un formato de salida
+type formatInfo struct {
+	extension   string
+	contentType string
+}
+
+var formats = map[string]formatInfo{
+	"jpeg": {".jpg", "image/jpeg"},
+	"png":  {".png", "image/png"},
+	"gif":  {".gif", "image/gif"},
+	"webp": {".webp", "image/webp"},
+}
+
+// DefaultMaxPixels es el máximo de píxeles por defecto de las imágenes que se decodifican
+const DefaultMaxPixels = 40_000_000
+
+// ErrTooManyPixels indica que la imagen supera el máximo de píxeles. Un archivo pequeño
+// puede declarar dimensiones enormes y agotar la memoria al decodificarse.
+var ErrTooManyPixels = errors.New("image exceeds the maximum number of pixels")
+
 type ImageProcessor struct {
-	sizes map[string]int
+	sizes     []Size
+	maxPixels int
 }
 
-func NewImageProcessor() *ImageProcessor {
+// NewImageProcessor crea un procesador que genera thumbnails en los tamaños indicados y
+// rechaza las imágenes de más de maxPixels píxeles. Si sizes está vacío se usan
+// DefaultSizes, y si maxPixels es 0, DefaultMaxPixels.
+func NewImageProcessor(sizes []Size, maxPixels int) *ImageProcessor {
+	if len(sizes) == 0 {
+		sizes = DefaultSizes()
+	}
+	if maxPixels <= 0 {
+		maxPixels = DefaultMaxPixels
+	}
 	return &ImageProcessor{
-		sizes: map[string]int{
-			"small":  150,
-			"medium": 300,
-			"large":  600,
-		},
+		sizes:     sizes,
+		maxPixels: maxPixels,
+	}
+}
+
+// DefaultSizes retorna los tamaños de thumbnails por defecto
+func DefaultSizes() []Size {
+	return []Size{
+		{Name: "small", Width: 150},
+		{Name: "medium", Width: 300},
+		{Name: "large", Width: 600},
+	}
+}
+
+// ParseSizes lee una lista de tamaños con el formato "small:150,medium:300,large:600"
+func ParseSizes(value string) ([]Size, error) {
+	sizes := make([]Size, 0)
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		pieces := strings.SplitN(part, ":", 2)
+		if len(pieces) != 2 {
+			return nil, fmt.Errorf("invalid thumbnail size %q, expected name:width", part)
+		}
+		name := strings.TrimSpace(pieces[0])
+		width, err := strconv.Atoi(strings.TrimSpace(pieces[1]))
+		if err != nil || width <= 0 {
+			return nil, fmt.Errorf("invalid width for thumbnail size %q", name)
+		}
+		if name == "" || strings.ContainsAny(name, "/.") {
+			return nil, fmt.Errorf("invalid thumbnail size name %q", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicated thumbnail size %q", name)
+		}
+		seen[name] = true
+		sizes = append(sizes, Size{Name: name, Width: width})
+	}
+
+	sort.Slice(sizes, func(i, j int) bool { return sizes[i].Width < sizes[j].Width })
+	return sizes, nil
+}
+
+// Sizes retorna los tamaños de thumbnails configurados
+func (p *ImageProcessor) Sizes() []Size {
+	return p.sizes
+}
+
+// OutputExtensions retorna las extensiones de los thumbnails que se generan para una
+// imagen con la extensión indicada: la del formato original y siempre WebP
+func OutputExtensions(extension string) []string {
+	extension = strings.ToLower(extension)
+	if extension == ".jpeg" {
+		extension = ".jpg"
 	}
+	if extension == ".webp" {
+		return []string{".webp"}
+	}
+	return []string{extension, ".webp"}
 }
 
-// ProcessImage genera thumbnails de diferentes tamaños para una imagen
-func (p *ImageProcessor) ProcessImage(reader io.Reader) (map[string][]byte, error) {
+// ProcessImage genera thumbnails de diferentes tamaños para una imagen, en su formato
+// original y en WebP, orientados según su EXIF, junto con sus dimensiones, su BlurHash y
+// su color dominante. Acepta JPEG, PNG, GIF y WebP; de los GIF animados se usa el primer
+// fotograma.
+func (p *ImageProcessor) ProcessImage(reader io.Reader) (*ProcessedImage, error) {
 	// Decodificar imagen original
-	img, format, err := image.Decode(reader)
+	img, format, err := p.decode(reader)
 	if err != nil {
-		return nil, fmt.Errorf("error decoding image: %v", err)
+		return nil, fmt.Errorf("error decoding image: %w", err)
+	}
+	if _, ok := formats[format]; !ok {
+		return nil, fmt.Errorf("unsupported image format: %s", format)
 	}
 
-	thumbnails := make(map[string][]byte)
+	outputs := []string{format}
+	if format != "webp" {
+		outputs = append(outputs, "webp")
+	}
+
+	thumbnails := make([]Thumbnail, 0, len(p.sizes)*len(outputs))
 
 	// Generar thumbnails para cada tamaño
-	for size, width := range p.sizes {
-		// Redimensionar imagen manteniendo aspecto
-		thumb := imaging.Resize(img, width, 0, imaging.Lanczos)
-
-		// Codificar thumbnail
-		var buf bytes.Buffer
-		switch format {
-		case "jpeg":
-			err = jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: 85})
-		case "png":
-			err = png.Encode(&buf, thumb)
-		default:
-			err = fmt.Errorf("unsupported image format: %s", format)
+	for _, size := range p.sizes {
+		// Redimensionar imagen manteniendo aspecto, sin agrandar las más angostas que el tamaño
+		thumb := imaging.Resize(img, min(size.Width, img.Bounds().Dx()), 0, imaging.Lanczos)
+
+		for _, output := range outputs {
+			data, err := encode(thumb, output)
+			if err != nil {
+				return nil, fmt.Errorf("error encoding %s thumbnail: %v", output, err)
+			}
+			thumbnails = append(thumbnails, Thumbnail{
+				Size:        size.Name,
+				Format:      output,
+				Extension:   formats[output].extension,
+				ContentType: formats[output].contentType,
+				Width:       thumb.Bounds().Dx(),
+				Height:      thumb.Bounds().Dy(),
+				Data:        data,
+			})
 		}
+	}
+
+	return &ProcessedImage{
+		Placeholder: placeholder(img),
+		Format:      format,
+		Thumbnails:  thumbnails,
+	}, nil
+}
+
+// Inspect retorna el formato y las dimensiones de una imagen sin decodificarla. Falla si
+// el formato no es uno de los aceptados, aunque haya un decodificador registrado para él
+// (p. ej. TIFF o BMP), y con ErrTooManyPixels si supera el máximo de píxeles.
+func (p *ImageProcessor) Inspect(data []byte) (string, int, int, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", 0, 0, err
+	}
+	if _, ok := formats[format]; !ok {
+		return "", 0, 0, fmt.Errorf("unsupported image format: %s", format)
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return "", 0, 0, fmt.Errorf("invalid image dimensions %dx%d", config.Width, config.Height)
+	}
+	if int64(config.Width)*int64(config.Height) > int64(p.maxPixels) {
+		return "", 0, 0, fmt.Errorf("%w: %dx%d", ErrTooManyPixels, config.Width, config.Height)
+	}
+	return format, config.Width, config.Height, nil
+}
+
+// decode decodifica la imagen y retorna su formato. De los GIF solo se decodifica el primer
+// fotograma, que se compone sobre el lienzo porque puede ocupar solo una parte.
+func (p *ImageProcessor) decode(reader io.Reader) (image.Image, string, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, "", err
+	}
 
-		if err != nil {
-			return nil, fmt.Errorf("error encoding thumbnail: %v", err)
+	format, _, _, err := p.Inspect(data)
+	if err != nil {
+		return nil, "", err
+	}
+	if format != "gif" {
+		// Aplicar la orientación EXIF de las fotos tomadas con el teléfono girado; imaging
+		// solo la lee de los JPEG
+		img, err := imaging.Decode(bytes.NewReader(data), imaging.AutoOrientation(true))
+		if err == nil && format == "webp" {
+			img = orient(img, webpOrientation(data))
 		}
+		return img, format, err
+	}
 
-		thumbnails[size] = buf.Bytes()
+	config, err := gif.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+	frame, err := gif.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
 	}
+	canvas := image.NewNRGBA(image.Rect(0, 0, config.Width, config.Height))
+	draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+	return canvas, format, nil
+}
 
-	return thumbnails, nil
+// encode codifica un thumbnail en el formato indicado
+func encode(img image.Image, format string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85})
+	case "png":
+		err = png.Encode(&buf, img)
+	case "gif":
+		err = gif.Encode(&buf, img, &gif.Options{NumColors: 256})
+	case "webp":
+		err = nativewebp.Encode(&buf, img, nil)
+	default:
+		err = fmt.Errorf("unsupported image format: %s", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
 }
@@ -0,0 +1,318 @@
+package imageprocessor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"github.com/HugoSmits86/nativewebp"
+	"github.com/disintegration/imaging"
+	"golang.org/x/image/webp"
+)
+
+// Segmentos JPEG que pueden contener metadatos: APP1 (EXIF, XMP), APP13 (IPTC) y comentarios
+const (
+	jpegMarkerAPP1  = 0xe1
+	jpegMarkerAPP13 = 0xed
+	jpegMarkerCOM   = 0xfe
+	jpegMarkerSOS   = 0xda
+)
+
+// maxGIFFrames es la cantidad máxima de fotogramas que se conservan de un GIF animado
+const maxGIFFrames = 500
+
+// Indicadores de metadatos en el chunk VP8X de WebP
+const (
+	webpFlagEXIF = 0x08
+	webpFlagXMP  = 0x04
+)
+
+// StripMetadata retorna la imagen sin metadatos EXIF, XMP ni IPTC, con la orientación
+// EXIF ya aplicada, y su formato. Los JPEG con metadatos y los PNG se vuelven a codificar;
+// de los GIF se conservan los fotogramas que quepan en el máximo de píxeles, hasta
+// maxGIFFrames, y de los WebP se quitan los chunks de metadatos sin recodificar, salvo que
+// su orientación EXIF obligue a girarlos. Un JPEG sin metadatos se retorna tal cual para no perder calidad.
+func (p *ImageProcessor) StripMetadata(reader io.Reader) ([]byte, string, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, "", fmt.Errorf("error reading image: %v", err)
+	}
+
+	format, width, height, err := p.Inspect(data)
+	if err != nil {
+		return nil, "", fmt.Errorf("error decoding image: %w", err)
+	}
+
+	var buf bytes.Buffer
+	var encodeErr error
+	switch format {
+	case "jpeg":
+		if !jpegHasMetadata(data) {
+			return data, format, nil
+		}
+		img, err := imaging.Decode(bytes.NewReader(data), imaging.AutoOrientation(true))
+		if err != nil {
+			return nil, "", fmt.Errorf("error decoding image: %v", err)
+		}
+		encodeErr = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 92})
+	case "png":
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, "", fmt.Errorf("error decoding image: %v", err)
+		}
+		encodeErr = png.Encode(&buf, img)
+	case "gif":
+		maxFrames := p.maxPixels / (width * height)
+		if maxFrames > maxGIFFrames {
+			maxFrames = maxGIFFrames
+		}
+		// Cortar el archivo antes de decodificar los fotogramas que sobran
+		data, err := truncateGIF(data, maxFrames)
+		if err != nil {
+			return nil, "", fmt.Errorf("error decoding image: %v", err)
+		}
+		animation, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return nil, "", fmt.Errorf("error decoding image: %v", err)
+		}
+		encodeErr = gif.EncodeAll(&buf, animation)
+	case "webp":
+		if orientation := webpOrientation(data); orientation != 1 {
+			img, err := webp.Decode(bytes.NewReader(data))
+			if err != nil {
+				return nil, "", fmt.Errorf("error decoding image: %v", err)
+			}
+			encodeErr = nativewebp.Encode(&buf, orient(img, orientation), nil)
+			break
+		}
+		stripped, err := stripWebPMetadata(data)
+		if err != nil {
+			return nil, "", fmt.Errorf("error stripping webp metadata: %v", err)
+		}
+		return stripped, format, nil
+	default:
+		return nil, "", fmt.Errorf("unsupported image format: %s", format)
+	}
+	if encodeErr != nil {
+		return nil, "", fmt.Errorf("error encoding image: %v", encodeErr)
+	}
+	return buf.Bytes(), format, nil
+}
+
+// ContentType retorna el tipo MIME de un formato de imagen
+func ContentType(format string) string {
+	if info, ok := formats[format]; ok {
+		return info.contentType
+	}
+	return "application/octet-stream"
+}
+
+// jpegHasMetadata indica si el JPEG tiene segmentos de metadatos antes de los datos de imagen
+func jpegHasMetadata(data []byte) bool {
+	// Después del marcador SOI cada segmento es 0xFF, tipo y una longitud de 2 bytes
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xff {
+			// JPEG mal formado: se recodifica para asegurar que no queden metadatos
+			return true
+		}
+		marker := data[i+1]
+		if marker == 0xff {
+			i++
+			continue
+		}
+		switch marker {
+		case jpegMarkerAPP1, jpegMarkerAPP13, jpegMarkerCOM:
+			return true
+		case jpegMarkerSOS:
+			return false
+		}
+		i += 2 + int(binary.BigEndian.Uint16(data[i+2:]))
+	}
+	return false
+}
+
+// truncateGIF retorna el GIF con solo sus primeros maxFrames fotogramas
+func truncateGIF(data []byte, maxFrames int) ([]byte, error) {
+	// Cabecera y descriptor de pantalla lógica, con la tabla de colores global si tiene
+	if len(data) < 13 {
+		return nil, fmt.Errorf("truncated gif header")
+	}
+	i := 13
+	if data[10]&0x80 != 0 {
+		i += 3 << (data[10]&0x07 + 1)
+	}
+
+	frames := 0
+	for i < len(data) {
+		switch data[i] {
+		case 0x21:
+			// Extensión: etiqueta y sub-bloques
+			end, err := skipGIFSubBlocks(data, i+2)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+		case 0x2c:
+			// Descriptor de imagen, tabla de colores local, tamaño de código LZW y sub-bloques
+			if i+10 > len(data) {
+				return nil, fmt.Errorf("truncated gif image descriptor")
+			}
+			packed := data[i+9]
+			i += 10
+			if packed&0x80 != 0 {
+				i += 3 << (packed&0x07 + 1)
+			}
+			end, err := skipGIFSubBlocks(data, i+1)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+			frames++
+			if frames >= maxFrames {
+				return append(data[:i:i], 0x3b), nil
+			}
+		case 0x3b:
+			return data, nil
+		default:
+			return nil, fmt.Errorf("invalid gif block 0x%02x", data[i])
+		}
+	}
+	return data, nil
+}
+
+// skipGIFSubBlocks retorna la posición después de los sub-bloques que empiezan en i
+func skipGIFSubBlocks(data []byte, i int) (int, error) {
+	for {
+		if i >= len(data) {
+			return 0, fmt.Errorf("truncated gif block")
+		}
+		size := int(data[i])
+		i += 1 + size
+		if size == 0 {
+			return i, nil
+		}
+	}
+}
+
+// webpOrientation retorna la orientación EXIF de un WebP, o 1 si no tiene o no se puede leer
+func webpOrientation(data []byte) int {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return 1
+	}
+	for i := 12; i+8 <= len(data); {
+		size := int(binary.LittleEndian.Uint32(data[i+4 : i+8]))
+		end := i + 8 + size
+		if size < 0 || end > len(data) {
+			return 1
+		}
+		if string(data[i:i+4]) == "EXIF" {
+			return exifOrientation(data[i+8 : end])
+		}
+		i = end + size%2
+	}
+	return 1
+}
+
+// exifOrientation lee la etiqueta Orientation del primer IFD de un bloque EXIF, con o sin
+// el prefijo "Exif\0\0". Retorna 1 si no la tiene o no es válida.
+func exifOrientation(exif []byte) int {
+	exif = bytes.TrimPrefix(exif, []byte("Exif\x00\x00"))
+	if len(exif) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(exif[0:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(exif[4:8]))
+	if ifd < 8 || ifd+2 > len(exif) {
+		return 1
+	}
+	entries := int(order.Uint16(exif[ifd:]))
+	for i := 0; i < entries; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(exif) {
+			return 1
+		}
+		// Orientation es un SHORT (tipo 3) guardado en el campo del valor
+		if order.Uint16(exif[entry:]) == 0x0112 && order.Uint16(exif[entry+2:]) == 3 {
+			if orientation := int(order.Uint16(exif[entry+8:])); orientation >= 1 && orientation <= 8 {
+				return orientation
+			}
+			return 1
+		}
+	}
+	return 1
+}
+
+// orient aplica una orientación EXIF a la imagen
+func orient(img image.Image, orientation int) image.Image {
+	switch orientation {
+	case 2:
+		return imaging.FlipH(img)
+	case 3:
+		return imaging.Rotate180(img)
+	case 4:
+		return imaging.FlipV(img)
+	case 5:
+		return imaging.Transpose(img)
+	case 6:
+		return imaging.Rotate270(img)
+	case 7:
+		return imaging.Transverse(img)
+	case 8:
+		return imaging.Rotate90(img)
+	}
+	return img
+}
+
+// stripWebPMetadata quita los chunks EXIF y XMP de un WebP y sus indicadores en VP8X
+func stripWebPMetadata(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, fmt.Errorf("invalid webp header")
+	}
+
+	var body bytes.Buffer
+	for i := 12; i < len(data); {
+		if i+8 > len(data) {
+			return nil, fmt.Errorf("truncated webp chunk")
+		}
+		fourCC := string(data[i : i+4])
+		size := int(binary.LittleEndian.Uint32(data[i+4 : i+8]))
+		end := i + 8 + size + size%2
+		if size < 0 || end > len(data) {
+			return nil, fmt.Errorf("truncated webp chunk %s", fourCC)
+		}
+
+		chunk := data[i:end]
+		i = end
+		switch fourCC {
+		case "EXIF", "XMP ":
+			continue
+		case "VP8X":
+			chunk = append([]byte(nil), chunk...)
+			if len(chunk) > 8 {
+				chunk[8] &^= webpFlagEXIF | webpFlagXMP
+			}
+		}
+		body.Write(chunk)
+	}
+
+	out := make([]byte, 12, 12+body.Len())
+	copy(out, "RIFF")
+	binary.LittleEndian.PutUint32(out[4:8], uint32(4+body.Len()))
+	copy(out[8:], "WEBP")
+	return append(out, body.Bytes()...), nil
+}
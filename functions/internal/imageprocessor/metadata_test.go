@@ -0,0 +1,208 @@
+package imageprocessor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"testing"
+
+	"github.com/HugoSmits86/nativewebp"
+	"golang.org/x/image/webp"
+)
+
+// webpChunk arma un chunk RIFF con su relleno a tamaño par
+func webpChunk(fourCC string, payload []byte) []byte {
+	chunk := make([]byte, 8, 8+len(payload)+1)
+	copy(chunk, fourCC)
+	binary.LittleEndian.PutUint32(chunk[4:], uint32(len(payload)))
+	chunk = append(chunk, payload...)
+	if len(payload)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+// webpFile arma un WebP con los chunks indicados
+func webpFile(chunks ...[]byte) []byte {
+	body := bytes.Join(chunks, nil)
+	file := make([]byte, 12, 12+len(body))
+	copy(file, "RIFF")
+	binary.LittleEndian.PutUint32(file[4:], uint32(4+len(body)))
+	copy(file[8:], "WEBP")
+	return append(file, body...)
+}
+
+// exifWithOrientation arma un bloque EXIF con la etiqueta Orientation en el orden indicado
+func exifWithOrientation(order binary.ByteOrder, orientation uint16) []byte {
+	exif := make([]byte, 26)
+	if order == binary.LittleEndian {
+		copy(exif, "II")
+	} else {
+		copy(exif, "MM")
+	}
+	order.PutUint16(exif[2:], 42)
+	order.PutUint32(exif[4:], 8)
+	order.PutUint16(exif[8:], 1)
+	order.PutUint16(exif[10:], 0x0112)
+	order.PutUint16(exif[12:], 3)
+	order.PutUint32(exif[14:], 1)
+	order.PutUint16(exif[18:], orientation)
+	return exif
+}
+
+func TestStripWebPMetadata(t *testing.T) {
+	vp8x := func(flags byte) []byte {
+		return webpChunk("VP8X", []byte{flags, 0, 0, 0, 9, 0, 0, 9, 0, 0})
+	}
+	vp8l := webpChunk("VP8L", []byte{0x2f, 1, 2, 3, 4})
+
+	tests := []struct {
+		name    string
+		input   []byte
+		want    []byte
+		wantErr bool
+	}{
+		{
+			name:  "sin metadatos",
+			input: webpFile(vp8l),
+			want:  webpFile(vp8l),
+		},
+		{
+			name:  "quita EXIF y XMP y sus indicadores en VP8X",
+			input: webpFile(vp8x(0x10|webpFlagEXIF|webpFlagXMP), vp8l, webpChunk("EXIF", []byte("exif")), webpChunk("XMP ", []byte("<xmp/>"))),
+			want:  webpFile(vp8x(0x10), vp8l),
+		},
+		{
+			name:  "chunks de tamaño impar con relleno",
+			input: webpFile(webpChunk("EXIF", []byte("odd")), vp8l, webpChunk("ICCP", []byte("abc"))),
+			want:  webpFile(vp8l, webpChunk("ICCP", []byte("abc"))),
+		},
+		{
+			name:    "cabecera inválida",
+			input:   []byte("RIFF\x04\x00\x00\x00WEBX"),
+			wantErr: true,
+		},
+		{
+			name:    "cabecera de chunk truncada",
+			input:   append(webpFile(vp8l), 'E', 'X', 'I'),
+			wantErr: true,
+		},
+		{
+			name:    "datos de chunk truncados",
+			input:   webpFile(vp8l, webpChunk("EXIF", []byte("exif"))[:10]),
+			wantErr: true,
+		},
+		{
+			name:    "chunk impar sin relleno",
+			input:   webpFile(vp8l, webpChunk("EXIF", []byte("odd"))[:11]),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := stripWebPMetadata(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("se esperaba un error y se obtuvo % x", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error inesperado: %v", err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Fatalf("resultado\n% x\nse esperaba\n% x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJPEGHasMetadata(t *testing.T) {
+	soi := []byte{0xff, 0xd8}
+	segment := func(marker byte, payload string) []byte {
+		s := []byte{0xff, marker, 0, 0}
+		binary.BigEndian.PutUint16(s[2:], uint16(2+len(payload)))
+		return append(s, payload...)
+	}
+	join := func(parts ...[]byte) []byte {
+		return bytes.Join(parts, nil)
+	}
+	app0 := segment(0xe0, "JFIF\x00")
+	sos := segment(jpegMarkerSOS, "\x01\x01\x00\x00\x3f\x00")
+
+	tests := []struct {
+		name  string
+		input []byte
+		want  bool
+	}{
+		{"sin metadatos", join(soi, app0, sos, []byte{0x12, 0x34}), false},
+		{"APP1 con EXIF", join(soi, app0, segment(jpegMarkerAPP1, "Exif\x00\x00"), sos), true},
+		{"APP13 con IPTC", join(soi, segment(jpegMarkerAPP13, "Photoshop 3.0\x00"), sos), true},
+		{"comentario", join(soi, segment(jpegMarkerCOM, "hola"), sos), true},
+		{"SOS antes que APP1", join(soi, app0, sos, segment(jpegMarkerAPP1, "Exif\x00\x00")), false},
+		{"bytes de relleno antes del marcador", join(soi, []byte{0xff, 0xff}, segment(jpegMarkerAPP1, "Exif\x00\x00")), true},
+		{"segmento mal formado", join(soi, app0, []byte{0x00, 0xe1, 0x00, 0x04}), true},
+		{"segmento truncado", join(soi, segment(0xe0, "JFIF\x00")[:5]), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := jpegHasMetadata(tt.input); got != tt.want {
+				t.Fatalf("jpegHasMetadata() = %v, se esperaba %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExifOrientation(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+		want  int
+	}{
+		{"little endian", exifWithOrientation(binary.LittleEndian, 6), 6},
+		{"big endian", exifWithOrientation(binary.BigEndian, 8), 8},
+		{"con prefijo Exif", append([]byte("Exif\x00\x00"), exifWithOrientation(binary.LittleEndian, 3)...), 3},
+		{"orientación inválida", exifWithOrientation(binary.LittleEndian, 9), 1},
+		{"IFD truncado", exifWithOrientation(binary.LittleEndian, 6)[:16], 1},
+		{"orden de bytes inválido", []byte("XX\x2a\x00\x08\x00\x00\x00"), 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exifOrientation(tt.input); got != tt.want {
+				t.Fatalf("exifOrientation() = %d, se esperaba %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripMetadataOrientsWebP(t *testing.T) {
+	var encoded bytes.Buffer
+	if err := nativewebp.Encode(&encoded, image.NewNRGBA(image.Rect(0, 0, 4, 2)), nil); err != nil {
+		t.Fatal(err)
+	}
+	// Agregar un chunk EXIF con orientación 6 (girar 90° en sentido horario)
+	chunks := encoded.Bytes()[12:]
+	input := webpFile(chunks, webpChunk("EXIF", exifWithOrientation(binary.LittleEndian, 6)))
+
+	output, format, err := NewImageProcessor(nil, 0).StripMetadata(bytes.NewReader(input))
+	if err != nil {
+		t.Fatalf("error inesperado: %v", err)
+	}
+	if format != "webp" {
+		t.Fatalf("formato %q, se esperaba webp", format)
+	}
+	if bytes.Contains(output, []byte("EXIF")) {
+		t.Fatal("el resultado conserva el chunk EXIF")
+	}
+
+	config, err := webp.DecodeConfig(bytes.NewReader(output))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Width != 2 || config.Height != 4 {
+		t.Fatalf("dimensiones %dx%d, se esperaba 2x4", config.Width, config.Height)
+	}
+}
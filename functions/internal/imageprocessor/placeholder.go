@@ -0,0 +1,173 @@
+package imageprocessor
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strings"
+
+	"github.com/disintegration/imaging"
+)
+
+// Componentes del BlurHash: 4 horizontales y 3 verticales alcanzan para un placeholder
+// de unos 30 caracteres
+const (
+	blurHashComponentsX = 4
+	blurHashComponentsY = 3
+	// placeholderSampleSize es el lado máximo al que se reduce la imagen antes de calcular el
+	// placeholder; el resultado es prácticamente igual y mucho más rápido
+	placeholderSampleSize = 32
+)
+
+const base83Chars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+// Placeholder describe una imagen para que los clientes reserven su espacio y muestren
+// algo mientras carga
+type Placeholder struct {
+	Width         int
+	Height        int
+	BlurHash      string
+	DominantColor string
+}
+
+// placeholder calcula el BlurHash y el color dominante de una imagen ya orientada
+func placeholder(img image.Image) Placeholder {
+	bounds := img.Bounds()
+	sample := imaging.Fit(img, placeholderSampleSize, placeholderSampleSize, imaging.Box)
+	return Placeholder{
+		Width:         bounds.Dx(),
+		Height:        bounds.Dy(),
+		BlurHash:      blurHash(sample, blurHashComponentsX, blurHashComponentsY),
+		DominantColor: dominantColor(sample),
+	}
+}
+
+// blurHash codifica la imagen según https://github.com/woltapp/blurhash
+func blurHash(img *image.NRGBA, componentsX, componentsY int) string {
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+
+	// Pasar los píxeles a RGB lineal una sola vez
+	linear := make([][3]float64, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			offset := img.PixOffset(x, y)
+			linear[y*width+x] = [3]float64{
+				sRGBToLinear(img.Pix[offset]),
+				sRGBToLinear(img.Pix[offset+1]),
+				sRGBToLinear(img.Pix[offset+2]),
+			}
+		}
+	}
+
+	factors := make([][3]float64, 0, componentsX*componentsY)
+	for j := 0; j < componentsY; j++ {
+		for i := 0; i < componentsX; i++ {
+			normalisation := 2.0
+			if i == 0 && j == 0 {
+				normalisation = 1
+			}
+			var factor [3]float64
+			for y := 0; y < height; y++ {
+				basisY := math.Cos(math.Pi * float64(j) * float64(y) / float64(height))
+				for x := 0; x < width; x++ {
+					basis := normalisation * basisY * math.Cos(math.Pi*float64(i)*float64(x)/float64(width))
+					pixel := linear[y*width+x]
+					factor[0] += basis * pixel[0]
+					factor[1] += basis * pixel[1]
+					factor[2] += basis * pixel[2]
+				}
+			}
+			scale := 1 / float64(width*height)
+			factors = append(factors, [3]float64{factor[0] * scale, factor[1] * scale, factor[2] * scale})
+		}
+	}
+
+	var hash strings.Builder
+	hash.WriteString(encode83((componentsX-1)+(componentsY-1)*9, 1))
+
+	dc, ac := factors[0], factors[1:]
+	maximumValue := 1.0
+	if len(ac) > 0 {
+		actualMaximum := 0.0
+		for _, factor := range ac {
+			for _, value := range factor {
+				actualMaximum = math.Max(actualMaximum, math.Abs(value))
+			}
+		}
+		quantisedMaximum := int(math.Max(0, math.Min(82, math.Floor(actualMaximum*166-0.5))))
+		maximumValue = float64(quantisedMaximum+1) / 166
+		hash.WriteString(encode83(quantisedMaximum, 1))
+	} else {
+		hash.WriteString(encode83(0, 1))
+	}
+
+	hash.WriteString(encode83(linearToSRGB(dc[0])<<16|linearToSRGB(dc[1])<<8|linearToSRGB(dc[2]), 4))
+	for _, factor := range ac {
+		value := 0
+		for _, component := range factor {
+			quantised := int(math.Max(0, math.Min(18, math.Floor(signPow(component/maximumValue, 0.5)*9+9.5))))
+			value = value*19 + quantised
+		}
+		hash.WriteString(encode83(value, 2))
+	}
+	return hash.String()
+}
+
+// dominantColor retorna en formato #rrggbb el color más frecuente de la imagen. Agrupa los
+// píxeles en 4096 tonos y promedia los del grupo más numeroso; los transparentes no cuentan.
+func dominantColor(img *image.NRGBA) string {
+	var counts [4096]int
+	var sums [4096][3]int
+	best := -1
+	for y := 0; y < img.Bounds().Dy(); y++ {
+		for x := 0; x < img.Bounds().Dx(); x++ {
+			offset := img.PixOffset(x, y)
+			r, g, b, a := img.Pix[offset], img.Pix[offset+1], img.Pix[offset+2], img.Pix[offset+3]
+			if a < 128 {
+				continue
+			}
+			bucket := int(r>>4)<<8 | int(g>>4)<<4 | int(b>>4)
+			counts[bucket]++
+			sums[bucket][0] += int(r)
+			sums[bucket][1] += int(g)
+			sums[bucket][2] += int(b)
+			if best < 0 || counts[bucket] > counts[best] {
+				best = bucket
+			}
+		}
+	}
+	if best < 0 {
+		return ""
+	}
+	count := counts[best]
+	return fmt.Sprintf("#%02x%02x%02x", sums[best][0]/count, sums[best][1]/count, sums[best][2]/count)
+}
+
+func encode83(value, length int) string {
+	result := make([]byte, length)
+	for i := length - 1; i >= 0; i-- {
+		result[i] = base83Chars[value%83]
+		value /= 83
+	}
+	return string(result)
+}
+
+func sRGBToLinear(value uint8) float64 {
+	v := float64(value) / 255
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(value float64) int {
+	v := math.Max(0, math.Min(1, value))
+	if v <= 0.0031308 {
+		return int(v*12.92*255 + 0.5)
+	}
+	return int((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func signPow(value, exp float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(value), exp), value)
+}
@@ -0,0 +1,39 @@
+package imageprocessor
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestBlurHash(t *testing.T) {
+	// Degradado con un tablero de 4x4 en azul
+	pattern := image.NewNRGBA(image.Rect(0, 0, 32, 24))
+	for y := 0; y < 24; y++ {
+		for x := 0; x < 32; x++ {
+			pattern.SetNRGBA(x, y, color.NRGBA{R: uint8(x * 8), G: uint8(y * 10), B: uint8((x/4+y/4)%2) * 200, A: 255})
+		}
+	}
+	black := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	for i := 3; i < len(black.Pix); i += 4 {
+		black.Pix[i] = 255
+	}
+
+	// Valores esperados calculados con el codificador en C de github.com/woltapp/blurhash
+	tests := []struct {
+		name string
+		img  *image.NRGBA
+		want string
+	}{
+		{"degradado con tablero", pattern, "LxH27%2swxX8mHWUjtf5gJfjfQfj"},
+		{"negro", black, "L00000fQfQfQfQfQfQfQfQfQfQfQ"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := blurHash(tt.img, blurHashComponentsX, blurHashComponentsY); got != tt.want {
+				t.Fatalf("blurHash() = %q, se esperaba %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kha0sys/nodo.social/functions/domain/errors"
+	"github.com/kha0sys/nodo.social/functions/domain/models"
+	"github.com/kha0sys/nodo.social/functions/domain/repositories"
+)
+
+const (
+	backfillUserPageSize      = 50
+	defaultBackfillChangePage = 100
+	maxBackfillChangePage     = 500
+	maxBackfillList           = 50
+	// backfillRunBudget es el tiempo máximo que una ejecución procesa usuarios antes de
+	// guardar su avance y dejar el resto para la siguiente
+	backfillRunBudget = 4 * time.Minute
+	// backfillDeadlineMargin es el tiempo que se reserva antes del plazo del contexto para
+	// guardar el avance
+	backfillDeadlineMargin = 30 * time.Second
+)
+
+// AchievementBackfillService recalcula los logros de todos los usuarios con las reglas vigentes
+type AchievementBackfillService struct {
+	backfillRepo    repositories.AchievementBackfillRepository
+	userRepo        repositories.UserRepository
+	achievementRepo repositories.AchievementRepository
+	achievementSvc  *AchievementService
+}
+
+// NewAchievementBackfillService crea una nueva instancia de AchievementBackfillService
+func NewAchievementBackfillService(
+	backfillRepo repositories.AchievementBackfillRepository,
+	userRepo repositories.UserRepository,
+	achievementRepo repositories.AchievementRepository,
+	achievementSvc *AchievementService,
+) *AchievementBackfillService {
+	return &AchievementBackfillService{
+		backfillRepo:    backfillRepo,
+		userRepo:        userRepo,
+		achievementRepo: achievementRepo,
+		achievementSvc:  achievementSvc,
+	}
+}
+
+// Start crea un recálculo y procesa usuarios hasta agotar el tiempo de la ejecución.
+// Lo que quede se retoma con Run o con ResumeNext.
+func (s *AchievementBackfillService) Start(ctx context.Context, adminID string, dryRun bool) (*models.AchievementBackfill, error) {
+	now := time.Now()
+	backfill := &models.AchievementBackfill{
+		DryRun:    dryRun,
+		Status:    models.BackfillRunning,
+		CreatedBy: adminID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.backfillRepo.Create(ctx, backfill); err != nil {
+		return nil, fmt.Errorf("error creating backfill: %v", err)
+	}
+	return s.Run(ctx, backfill.ID)
+}
+
+// Run retoma un recálculo desde el último usuario procesado. Guarda el avance después de
+// cada página, así que si la función se corta solo se repite la página en curso; otorgar
+// un logro dos veces no suma sus puntos de nuevo.
+func (s *AchievementBackfillService) Run(ctx context.Context, backfillID string) (*models.AchievementBackfill, error) {
+	now := time.Now()
+	deadline := now.Add(backfillRunBudget)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Add(-backfillDeadlineMargin).Before(deadline) {
+		deadline = ctxDeadline.Add(-backfillDeadlineMargin)
+	}
+
+	backfill, err := s.backfillRepo.Claim(ctx, backfillID, now, deadline.Add(backfillDeadlineMargin))
+	if err != nil {
+		return nil, err
+	}
+
+	definitions, err := s.achievementRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing achievements: %v", err)
+	}
+
+	for backfill.Status == models.BackfillRunning && time.Now().Before(deadline) {
+		if err := s.processPage(ctx, backfill, definitions); err != nil {
+			backfill.LastError = err.Error()
+			break
+		}
+	}
+
+	// Liberar la reserva para que la siguiente ejecución pueda continuar
+	backfill.LeaseUntil = time.Time{}
+	backfill.UpdatedAt = time.Now()
+	if err := s.backfillRepo.Save(ctx, backfill); err != nil {
+		return nil, fmt.Errorf("error saving backfill: %v", err)
+	}
+	return backfill, nil
+}
+
+// ResumeNext retoma el primer recálculo sin terminar que no se esté ejecutando. Cada
+// ejecución continúa uno solo para no pasarse del tiempo de la función.
+func (s *AchievementBackfillService) ResumeNext(ctx context.Context) error {
+	backfills, err := s.backfillRepo.ListRunning(ctx)
+	if err != nil {
+		return fmt.Errorf("error listing running backfills: %v", err)
+	}
+
+	for _, backfill := range backfills {
+		if backfill.LeaseUntil.After(time.Now()) {
+			continue
+		}
+		if _, err := s.Run(ctx, backfill.ID); err != nil {
+			// Otra ejecución lo reservó o lo terminó mientras tanto
+			if errors.IsDomainError(err) {
+				continue
+			}
+			return fmt.Errorf("error resuming backfill %s: %v", backfill.ID, err)
+		}
+		return nil
+	}
+	return nil
+}
+
+// List obtiene los últimos recálculos
+func (s *AchievementBackfillService) List(ctx context.Context) ([]*models.AchievementBackfill, error) {
+	backfills, err := s.backfillRepo.List(ctx, maxBackfillList)
+	if err != nil {
+		return nil, fmt.Errorf("error listing backfills: %v", err)
+	}
+	return backfills, nil
+}
+
+// Report obtiene el estado de un recálculo y una página de los logros que otorgó o, en
+// una simulación, otorgaría
+func (s *AchievementBackfillService) Report(ctx context.Context, backfillID string, cursor string, limit int) (*models.AchievementBackfillReport, error) {
+	if limit <= 0 {
+		limit = defaultBackfillChangePage
+	}
+	if limit > maxBackfillChangePage {
+		limit = maxBackfillChangePage
+	}
+
+	backfill, err := s.backfillRepo.Get(ctx, backfillID)
+	if err != nil {
+		return nil, err
+	}
+	changes, next, err := s.backfillRepo.ListChanges(ctx, backfillID, cursor, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error listing backfill changes: %v", err)
+	}
+	return &models.AchievementBackfillReport{Backfill: backfill, Changes: changes, NextCursor: next}, nil
+}
+
+// processPage recalcula una página de usuarios y guarda sus cambios y el avance
+func (s *AchievementBackfillService) processPage(ctx context.Context, backfill *models.AchievementBackfill, definitions []*models.Achievement) error {
+	users, err := s.userRepo.List(ctx, backfill.Cursor, backfillUserPageSize)
+	if err != nil {
+		return fmt.Errorf("error listing users: %v", err)
+	}
+
+	changes := make([]*models.AchievementBackfillChange, 0)
+	for _, user := range users {
+		userChanges, err := s.backfillUser(ctx, user, definitions, backfill.DryRun)
+		if err != nil {
+			fmt.Printf("error backfilling achievements of user %s: %v\n", user.ID, err)
+			backfill.UsersFailed++
+			backfill.LastError = fmt.Sprintf("%s: %v", user.ID, err)
+		}
+		if len(userChanges) > 0 {
+			backfill.UsersChanged++
+		}
+		for _, change := range userChanges {
+			backfill.Granted++
+			backfill.Points += change.Points
+		}
+		changes = append(changes, userChanges...)
+	}
+
+	if err := s.backfillRepo.AddChanges(ctx, backfill.ID, changes); err != nil {
+		return fmt.Errorf("error saving backfill changes: %v", err)
+	}
+
+	backfill.UsersProcessed += len(users)
+	if len(users) > 0 {
+		backfill.Cursor = users[len(users)-1].ID
+	}
+	if len(users) < backfillUserPageSize {
+		completedAt := time.Now()
+		backfill.Status = models.BackfillCompleted
+		backfill.CompletedAt = &completedAt
+	}
+	backfill.UpdatedAt = time.Now()
+	if err := s.backfillRepo.Save(ctx, backfill); err != nil {
+		return fmt.Errorf("error saving backfill: %v", err)
+	}
+	return nil
+}
+
+// backfillUser evalúa los logros del usuario con métricas calculadas desde los nodos y,
+// salvo en una simulación, otorga los que le faltan
+func (s *AchievementBackfillService) backfillUser(ctx context.Context, user *models.User, definitions []*models.Achievement, dryRun bool) ([]*models.AchievementBackfillChange, error) {
+	met, metrics, err := s.achievementSvc.evaluate(ctx, user, definitions, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// Al aplicar, el informe solo incluye los desbloqueos nuevos
+	applied := met
+	if !dryRun {
+		if applied, err = s.achievementSvc.grantAll(ctx, user, met); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	changes := make([]*models.AchievementBackfillChange, 0, len(applied))
+	for _, achievement := range applied {
+		used := make(map[string]int)
+		for _, metric := range achievement.Metrics() {
+			used[metric] = metrics[metric]
+		}
+		changes = append(changes, &models.AchievementBackfillChange{
+			UserID:        user.ID,
+			AchievementID: achievement.ID,
+			Name:          achievement.Name,
+			Points:        achievement.Points,
+			Metrics:       used,
+			Applied:       !dryRun,
+			CreatedAt:     now,
+		})
+	}
+	return changes, nil
+}
@@ -3,224 +3,362 @@ package services
 import (
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
-	"cloud.google.com/go/firestore"
+	"github.com/kha0sys/nodo.social/functions/domain/errors"
 	"github.com/kha0sys/nodo.social/functions/domain/models"
 	"github.com/kha0sys/nodo.social/functions/domain/repositories"
 )
 
-// AchievementService maneja los logros de los usuarios
+// AchievementService evalúa las reglas de los logros y los otorga a los usuarios
 type AchievementService struct {
-	client     *firestore.Client
-	userRepo   repositories.UserRepository
-	collection string
+	userRepo        repositories.UserRepository
+	nodeRepo        repositories.NodeRepository
+	productRepo     repositories.ProductRepository
+	achievementRepo repositories.AchievementRepository
+	pointsSvc       *PointsService
 }
 
 // NewAchievementService crea una nueva instancia de AchievementService
-func NewAchievementService(client *firestore.Client, userRepo repositories.UserRepository) *AchievementService {
+func NewAchievementService(
+	userRepo repositories.UserRepository,
+	nodeRepo repositories.NodeRepository,
+	productRepo repositories.ProductRepository,
+	achievementRepo repositories.AchievementRepository,
+	pointsSvc *PointsService,
+) *AchievementService {
 	return &AchievementService{
-		client:     client,
-		userRepo:   userRepo,
-		collection: "achievements",
+		userRepo:        userRepo,
+		nodeRepo:        nodeRepo,
+		productRepo:     productRepo,
+		achievementRepo: achievementRepo,
+		pointsSvc:       pointsSvc,
 	}
 }
 
-// CheckNodeAchievements verifica y otorga logros relacionados con nodos
-func (s *AchievementService) CheckNodeAchievements(ctx context.Context, userID string) error {
-	// Obtener el usuario
+// CheckAchievements evalúa los logros activos que el usuario aún no tiene y otorga los
+// que cumple. Retorna los logros desbloqueados en esta evaluación.
+func (s *AchievementService) CheckAchievements(ctx context.Context, userID string) ([]*models.Achievement, error) {
+	definitions, err := s.achievementRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing achievements: %v", err)
+	}
+
 	user, err := s.userRepo.Get(ctx, userID)
 	if err != nil {
-		return fmt.Errorf("error getting user: %v", err)
+		return nil, fmt.Errorf("error getting user: %v", err)
 	}
 
-	// Verificar logros basados en el número de nodos creados
-	nodeCount := len(user.Nodes)
-	achievements := make([]models.Achievement, 0)
+	met, _, err := s.evaluate(ctx, user, definitions, false)
+	if err != nil {
+		return nil, err
+	}
+	return s.grantAll(ctx, user, met)
+}
 
-	switch {
-	case nodeCount >= 100:
-		achievements = append(achievements, models.Achievement{
-			Type:        models.NodeCreation,
-			Name:        "Maestro de Nodos",
-			Description: "Has creado 100 nodos",
-			Points:      1000,
-			Conditions: []models.Condition{
-				{
-					Type:     "node_count",
-					Value:    100,
-					Operator: ">=",
-				},
-			},
-		})
-	case nodeCount >= 50:
-		achievements = append(achievements, models.Achievement{
-			Type:        models.NodeCreation,
-			Name:        "Experto en Nodos",
-			Description: "Has creado 50 nodos",
-			Points:      500,
-			Conditions: []models.Condition{
-				{
-					Type:     "node_count",
-					Value:    50,
-					Operator: ">=",
-				},
-			},
-		})
-	case nodeCount >= 25:
-		achievements = append(achievements, models.Achievement{
-			Type:        models.NodeCreation,
-			Name:        "Entusiasta de Nodos",
-			Description: "Has creado 25 nodos",
-			Points:      250,
-			Conditions: []models.Condition{
-				{
-					Type:     "node_count",
-					Value:    25,
-					Operator: ">=",
-				},
-			},
-		})
-	case nodeCount >= 10:
-		achievements = append(achievements, models.Achievement{
-			Type:        models.NodeCreation,
-			Name:        "Creador de Nodos",
-			Description: "Has creado 10 nodos",
-			Points:      100,
-			Conditions: []models.Condition{
-				{
-					Type:     "node_count",
-					Value:    10,
-					Operator: ">=",
-				},
-			},
-		})
-	case nodeCount >= 1:
-		achievements = append(achievements, models.Achievement{
-			Type:        models.NodeCreation,
-			Name:        "Primer Nodo",
-			Description: "Has creado tu primer nodo",
-			Points:      50,
-			Conditions: []models.Condition{
-				{
-					Type:     "node_count",
-					Value:    1,
-					Operator: ">=",
-				},
-			},
-		})
+// grantAll otorga al usuario los logros indicados y lo guarda. Retorna los que no estaban
+// registrados ya.
+func (s *AchievementService) grantAll(ctx context.Context, user *models.User, achievements []*models.Achievement) ([]*models.Achievement, error) {
+	if len(achievements) == 0 {
+		return nil, nil
 	}
 
-	// Otorgar logros
+	previous := len(user.Achievements)
+	granted := make([]*models.Achievement, 0, len(achievements))
 	for _, achievement := range achievements {
-		if err := s.grantAchievement(ctx, userID, &achievement); err != nil {
-			return fmt.Errorf("error granting achievement %s: %v", achievement.Name, err)
+		ok, err := s.grantAchievement(ctx, user, achievement)
+		if err != nil {
+			return granted, fmt.Errorf("error granting achievement %s: %v", achievement.ID, err)
+		}
+		if ok {
+			granted = append(granted, achievement)
 		}
 	}
 
-	return nil
+	if err := s.userRepo.AddAchievements(ctx, user.ID, user.Achievements[previous:]); err != nil {
+		return granted, fmt.Errorf("error updating user achievements: %v", err)
+	}
+	return granted, nil
 }
 
-// CheckInteractionAchievements verifica y otorga logros relacionados con interacciones
-func (s *AchievementService) CheckInteractionAchievements(ctx context.Context, userID string) error {
-	// Obtener el usuario
+// Unlock otorga al usuario un logro que no depende de reglas, como el de completar un reto.
+// Retorna false si ya lo tenía.
+func (s *AchievementService) Unlock(ctx context.Context, userID string, achievement *models.Achievement) (bool, error) {
 	user, err := s.userRepo.Get(ctx, userID)
 	if err != nil {
-		return fmt.Errorf("error getting user: %v", err)
+		return false, fmt.Errorf("error getting user: %v", err)
+	}
+	for _, unlocked := range user.Achievements {
+		if unlocked.AchievementID == achievement.ID {
+			return false, nil
+		}
 	}
 
-	// Verificar logros basados en el número de interacciones
-	interactions := user.Metrics.TotalInteractions
-	achievements := make([]models.Achievement, 0)
+	previous := len(user.Achievements)
+	granted, err := s.grantAchievement(ctx, user, achievement)
+	if err != nil {
+		return false, err
+	}
+	if err := s.userRepo.AddAchievements(ctx, user.ID, user.Achievements[previous:]); err != nil {
+		return granted, fmt.Errorf("error updating user achievements: %v", err)
+	}
+	return granted, nil
+}
 
-	switch {
-	case interactions >= 1000:
-		achievements = append(achievements, models.Achievement{
-			Type:        models.NodeShare,
-			Name:        "Super Activo",
-			Description: "Has realizado 1000 interacciones",
-			Points:      1000,
-			Conditions: []models.Condition{
-				{
-					Type:     "interaction_count",
-					Value:    1000,
-					Operator: ">=",
-				},
-			},
-		})
-	case interactions >= 500:
-		achievements = append(achievements, models.Achievement{
-			Type:        models.NodeShare,
-			Name:        "Muy Activo",
-			Description: "Has realizado 500 interacciones",
-			Points:      500,
-			Conditions: []models.Condition{
-				{
-					Type:     "interaction_count",
-					Value:    500,
-					Operator: ">=",
-				},
-			},
-		})
-	case interactions >= 100:
-		achievements = append(achievements, models.Achievement{
-			Type:        models.NodeShare,
-			Name:        "Activo",
-			Description: "Has realizado 100 interacciones",
-			Points:      100,
-			Conditions: []models.Condition{
-				{
-					Type:     "interaction_count",
-					Value:    100,
-					Operator: ">=",
-				},
-			},
-		})
-	case interactions >= 10:
-		achievements = append(achievements, models.Achievement{
-			Type:        models.NodeShare,
-			Name:        "Principiante",
-			Description: "Has realizado 10 interacciones",
-			Points:      50,
-			Conditions: []models.Condition{
-				{
-					Type:     "interaction_count",
-					Value:    10,
-					Operator: ">=",
-				},
-			},
-		})
+// evaluate retorna los logros activos que el usuario aún no tiene y cumple, con los niveles
+// bajos antes que los altos del mismo tipo, y las métricas con las que se evaluaron.
+// fromSource calcula las métricas de nodos y de seguimientos desde la colección de nodos en
+// lugar de las listas guardadas en el usuario. interaction_count y streak_days no tienen otra
+// fuente que los contadores del usuario, así que siempre se leen de su documento.
+func (s *AchievementService) evaluate(ctx context.Context, user *models.User, definitions []*models.Achievement, fromSource bool) ([]*models.Achievement, map[string]int, error) {
+	unlocked := make(map[string]bool, len(user.Achievements))
+	for _, achievement := range user.Achievements {
+		unlocked[achievement.AchievementID] = true
 	}
 
-	// Otorgar logros
-	for _, achievement := range achievements {
-		if err := s.grantAchievement(ctx, userID, &achievement); err != nil {
-			return fmt.Errorf("error granting achievement %s: %v", achievement.Name, err)
+	pending := make([]*models.Achievement, 0, len(definitions))
+	needed := make([]string, 0)
+	for _, definition := range definitions {
+		if !definition.Active || unlocked[definition.ID] {
+			continue
+		}
+		pending = append(pending, definition)
+		needed = append(needed, definition.Metrics()...)
+	}
+	if len(pending) == 0 {
+		return nil, nil, nil
+	}
+
+	sort.Slice(pending, func(i, j int) bool {
+		if pending[i].Type != pending[j].Type {
+			return pending[i].Type < pending[j].Type
 		}
+		return pending[i].Tier < pending[j].Tier
+	})
+
+	metrics, err := s.userMetrics(ctx, user, needed, fromSource)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return nil
+	met := make([]*models.Achievement, 0, len(pending))
+	for _, achievement := range pending {
+		if achievement.Evaluate(metrics) {
+			met = append(met, achievement)
+		}
+	}
+	return met, metrics, nil
 }
 
-// grantAchievement otorga un logro a un usuario
-func (s *AchievementService) grantAchievement(ctx context.Context, userID string, achievement *models.Achievement) error {
+// userMetrics calcula solo las métricas que usan las reglas pendientes
+func (s *AchievementService) userMetrics(ctx context.Context, user *models.User, needed []string, fromSource bool) (map[string]int, error) {
+	var nodes []*models.Node
+	if fromSource {
+		var err error
+		if nodes, err = s.nodeRepo.GetByUser(ctx, user.ID); err != nil {
+			return nil, fmt.Errorf("error getting user nodes: %v", err)
+		}
+	}
+
+	metrics := make(map[string]int, len(needed))
+	for _, metric := range needed {
+		if _, ok := metrics[metric]; ok {
+			continue
+		}
+
+		switch metric {
+		case models.MetricNodeCount:
+			if fromSource {
+				metrics[metric] = len(nodes)
+			} else {
+				metrics[metric] = len(user.Nodes)
+			}
+		case models.MetricFollowCount:
+			if !fromSource {
+				metrics[metric] = len(user.FollowedNodes)
+				continue
+			}
+			count, err := s.nodeRepo.CountFollowedBy(ctx, user.ID)
+			if err != nil {
+				return nil, fmt.Errorf("error counting followed nodes: %v", err)
+			}
+			metrics[metric] = count
+		case models.MetricInteractionCount:
+			metrics[metric] = user.Metrics.TotalInteractions
+		case models.MetricStreakDays:
+			metrics[metric] = user.Metrics.StreakDays
+		case models.MetricUpdateCount:
+			count := 0
+			if fromSource {
+				for _, node := range nodes {
+					count += len(node.Updates)
+				}
+				metrics[metric] = count
+				continue
+			}
+			for _, nodeID := range user.Nodes {
+				node, err := s.nodeRepo.Get(ctx, nodeID)
+				if err != nil {
+					fmt.Printf("error getting node %s for achievements: %v\n", nodeID, err)
+					continue
+				}
+				count += len(node.Updates)
+			}
+			metrics[metric] = count
+		case models.MetricProductLinkCount:
+			products, err := s.productRepo.GetByUser(ctx, user.ID)
+			if err != nil {
+				return nil, fmt.Errorf("error getting user products: %v", err)
+			}
+			metrics[metric] = len(products)
+		}
+	}
+	return metrics, nil
+}
+
+// grantAchievement otorga un logro al usuario y suma sus puntos. Retorna false si el
+// desbloqueo ya estaba registrado, p. ej. porque falló el guardado del usuario en una
+// evaluación anterior; en ese caso solo se agrega a su lista. El llamador debe guardar el usuario.
+// Los puntos usan la clave "achievement:<ID>", así que un reintento no los suma dos veces.
+func (s *AchievementService) grantAchievement(ctx context.Context, user *models.User, achievement *models.Achievement) (bool, error) {
+	now := time.Now()
+	userAchievement := models.UserAchievement{
+		ID:            fmt.Sprintf("%s_%s", user.ID, achievement.ID),
+		UserID:        user.ID,
+		AchievementID: achievement.ID,
+		Name:          achievement.Name,
+		Type:          achievement.Type,
+		Points:        achievement.Points,
+		UnlockedAt:    now,
+	}
+
+	created, err := s.achievementRepo.Unlock(ctx, &userAchievement)
+	if err != nil {
+		return false, err
+	}
+	user.Achievements = append(user.Achievements, userAchievement)
+
+	// La transacción de los puntos guarda el total y el nivel; se copian para quien siga usando el usuario
+	award, err := s.pointsSvc.Award(ctx, user.ID, achievement.Points, models.PointsReasonAchievement, achievement.ID, "achievement:"+achievement.ID)
+	if err != nil {
+		return false, err
+	}
+	user.Points = award.Total
+	user.Level = award.Level
+	return created, nil
+}
+
+// ListDefinitions obtiene todas las definiciones de logros, ordenadas por tipo y nivel
+func (s *AchievementService) ListDefinitions(ctx context.Context) ([]*models.Achievement, error) {
+	definitions, err := s.achievementRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing achievements: %v", err)
+	}
+	sort.Slice(definitions, func(i, j int) bool {
+		if definitions[i].Type != definitions[j].Type {
+			return definitions[i].Type < definitions[j].Type
+		}
+		return definitions[i].Tier < definitions[j].Tier
+	})
+	return definitions, nil
+}
+
+// GetDefinition obtiene la definición de un logro
+func (s *AchievementService) GetDefinition(ctx context.Context, achievementID string) (*models.Achievement, error) {
+	return s.achievementRepo.Get(ctx, achievementID)
+}
+
+// CreateDefinition valida y guarda una definición nueva
+func (s *AchievementService) CreateDefinition(ctx context.Context, achievement *models.Achievement) error {
+	if err := achievement.Validate(); err != nil {
+		return errors.NewValidationError(err.Error(), err)
+	}
+
 	now := time.Now().Unix()
-	achievement.ID = fmt.Sprintf("%s_%s", userID, achievement.Type)
 	achievement.CreatedAt = now
 	achievement.UpdatedAt = now
+	return s.achievementRepo.Create(ctx, achievement)
+}
 
-	_, err := s.client.Collection(s.collection).Doc(achievement.ID).Set(ctx, achievement)
+// UpdateDefinition reemplaza una definición existente. Cambiarla no retira el logro a
+// quienes ya lo desbloquearon.
+func (s *AchievementService) UpdateDefinition(ctx context.Context, achievementID string, achievement *models.Achievement) error {
+	existing, err := s.achievementRepo.Get(ctx, achievementID)
 	if err != nil {
 		return err
 	}
 
-	// Actualizar puntos del usuario
-	userPoints := &models.UserPoints{
-		UserID:    userID,
-		Total:     achievement.Points,
-		UpdatedAt: now,
+	achievement.ID = achievementID
+	if err := achievement.Validate(); err != nil {
+		return errors.NewValidationError(err.Error(), err)
+	}
+	achievement.CreatedAt = existing.CreatedAt
+	achievement.UpdatedAt = time.Now().Unix()
+	return s.achievementRepo.Update(ctx, achievement)
+}
+
+// DeleteDefinition elimina una definición
+func (s *AchievementService) DeleteDefinition(ctx context.Context, achievementID string) error {
+	if _, err := s.achievementRepo.Get(ctx, achievementID); err != nil {
+		return err
+	}
+	return s.achievementRepo.Delete(ctx, achievementID)
+}
+
+// SeedDefaultDefinitions crea las definiciones de DefaultAchievements que aún no existen
+// y retorna las creadas
+func (s *AchievementService) SeedDefaultDefinitions(ctx context.Context) ([]*models.Achievement, error) {
+	existing, err := s.achievementRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing achievements: %v", err)
+	}
+	ids := make(map[string]bool, len(existing))
+	for _, achievement := range existing {
+		ids[achievement.ID] = true
+	}
+
+	created := make([]*models.Achievement, 0)
+	for _, achievement := range DefaultAchievements() {
+		if ids[achievement.ID] {
+			continue
+		}
+		if err := s.CreateDefinition(ctx, achievement); err != nil {
+			return created, fmt.Errorf("error creating achievement %s: %v", achievement.ID, err)
+		}
+		created = append(created, achievement)
 	}
+	return created, nil
+}
 
-	_, err = s.client.Collection("user_points").Doc(userID).Set(ctx, userPoints, firestore.MergeAll)
-	return err
+// DefaultAchievements retorna los niveles de logros iniciales de la plataforma
+func DefaultAchievements() []*models.Achievement {
+	tier := func(id string, achievementType models.AchievementType, tier int, name string, description string, points int, metric string, value int) *models.Achievement {
+		return &models.Achievement{
+			ID:          id,
+			Type:        achievementType,
+			Name:        name,
+			Description: description,
+			Points:      points,
+			Tier:        tier,
+			Active:      true,
+			Conditions: []models.Condition{
+				{Type: metric, Operator: ">=", Value: value},
+			},
+		}
+	}
+
+	return []*models.Achievement{
+		tier("node_creation_1", models.NodeCreation, 1, "Primer Nodo", "Has creado tu primer nodo", 50, models.MetricNodeCount, 1),
+		tier("node_creation_10", models.NodeCreation, 2, "Creador de Nodos", "Has creado 10 nodos", 100, models.MetricNodeCount, 10),
+		tier("node_creation_25", models.NodeCreation, 3, "Entusiasta de Nodos", "Has creado 25 nodos", 250, models.MetricNodeCount, 25),
+		tier("node_creation_50", models.NodeCreation, 4, "Experto en Nodos", "Has creado 50 nodos", 500, models.MetricNodeCount, 50),
+		tier("node_creation_100", models.NodeCreation, 5, "Maestro de Nodos", "Has creado 100 nodos", 1000, models.MetricNodeCount, 100),
+		tier("interaction_10", models.NodeShare, 1, "Principiante", "Has realizado 10 interacciones", 50, models.MetricInteractionCount, 10),
+		tier("interaction_100", models.NodeShare, 2, "Activo", "Has realizado 100 interacciones", 100, models.MetricInteractionCount, 100),
+		tier("interaction_500", models.NodeShare, 3, "Muy Activo", "Has realizado 500 interacciones", 500, models.MetricInteractionCount, 500),
+		tier("interaction_1000", models.NodeShare, 4, "Super Activo", "Has realizado 1000 interacciones", 1000, models.MetricInteractionCount, 1000),
+		tier("streak_7", models.StreakAchievement, 1, "Semana Constante", "Has tenido actividad 7 días seguidos", 100, models.MetricStreakDays, 7),
+		tier("streak_30", models.StreakAchievement, 2, "Mes Constante", "Has tenido actividad 30 días seguidos", 500, models.MetricStreakDays, 30),
+	}
 }
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kha0sys/nodo.social/functions/domain/models"
+)
+
+// ActivityService registra las acciones de los usuarios en sus rachas y retos
+type ActivityService struct {
+	streakSvc      *StreakService
+	challengeSvc   *ChallengeService
+	achievementSvc *AchievementService
+}
+
+// NewActivityService crea una nueva instancia de ActivityService
+func NewActivityService(streakSvc *StreakService, challengeSvc *ChallengeService, achievementSvc *AchievementService) *ActivityService {
+	return &ActivityService{
+		streakSvc:      streakSvc,
+		challengeSvc:   challengeSvc,
+		achievementSvc: achievementSvc,
+	}
+}
+
+// Record registra una actividad. Si cambia la racha diaria se vuelven a evaluar los logros,
+// porque pueden depender de ella.
+func (s *ActivityService) Record(ctx context.Context, activity *models.Activity) error {
+	if activity.At.IsZero() {
+		activity.At = time.Now()
+	}
+
+	streakChanged, err := s.streakSvc.Record(ctx, activity)
+	if err != nil {
+		return fmt.Errorf("error recording streak: %v", err)
+	}
+	if _, err := s.challengeSvc.Record(ctx, activity); err != nil {
+		return fmt.Errorf("error recording challenges: %v", err)
+	}
+	if streakChanged {
+		if _, err := s.achievementSvc.CheckAchievements(ctx, activity.UserID); err != nil {
+			return fmt.Errorf("error checking achievements: %v", err)
+		}
+	}
+	return nil
+}
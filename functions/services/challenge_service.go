@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kha0sys/nodo.social/functions/domain/errors"
+	"github.com/kha0sys/nodo.social/functions/domain/models"
+	"github.com/kha0sys/nodo.social/functions/domain/repositories"
+)
+
+// ChallengeService maneja los retos y el avance de los usuarios en ellos
+type ChallengeService struct {
+	challengeRepo  repositories.ChallengeRepository
+	progressRepo   repositories.ChallengeProgressRepository
+	achievementSvc *AchievementService
+}
+
+// NewChallengeService crea una nueva instancia de ChallengeService
+func NewChallengeService(
+	challengeRepo repositories.ChallengeRepository,
+	progressRepo repositories.ChallengeProgressRepository,
+	achievementSvc *AchievementService,
+) *ChallengeService {
+	return &ChallengeService{
+		challengeRepo:  challengeRepo,
+		progressRepo:   progressRepo,
+		achievementSvc: achievementSvc,
+	}
+}
+
+// Record suma la actividad a los retos en curso que la aceptan. Al completar un reto se
+// desbloquea su logro, que otorga sus puntos; si eso falla, se reintenta con la siguiente
+// actividad del reto. Retorna los retos cuyo logro se desbloqueó.
+func (s *ChallengeService) Record(ctx context.Context, activity *models.Activity) ([]*models.Challenge, error) {
+	challenges, err := s.challengeRepo.ListOpen(ctx, activity.At)
+	if err != nil {
+		return nil, fmt.Errorf("error listing challenges: %v", err)
+	}
+
+	completed := make([]*models.Challenge, 0)
+	for _, challenge := range challenges {
+		if !challenge.Matches(activity) {
+			continue
+		}
+
+		progress, _, err := s.progressRepo.Update(ctx, challenge.ID, activity.UserID, func(progress *models.ChallengeProgress) bool {
+			count := progress.Count
+			progress.Add(challenge, activity)
+			return progress.Count != count
+		})
+		if err != nil {
+			return completed, fmt.Errorf("error updating progress of challenge %s: %v", challenge.ID, err)
+		}
+		if !progress.Completed {
+			continue
+		}
+
+		// Unlock no otorga dos veces el mismo logro
+		unlocked, err := s.achievementSvc.Unlock(ctx, activity.UserID, challengeAchievement(challenge))
+		if err != nil {
+			return completed, fmt.Errorf("error unlocking achievement of challenge %s: %v", challenge.ID, err)
+		}
+		if unlocked {
+			completed = append(completed, challenge)
+		}
+	}
+	return completed, nil
+}
+
+// challengeAchievement retorna el logro que se desbloquea al completar el reto
+func challengeAchievement(challenge *models.Challenge) *models.Achievement {
+	return &models.Achievement{
+		ID:          challenge.AchievementID(),
+		Type:        models.ChallengeAchievement,
+		Name:        challenge.Name,
+		Description: challenge.Description,
+		Points:      challenge.Points,
+	}
+}
+
+// ListForUser obtiene los retos en curso con el avance del usuario en cada uno
+func (s *ChallengeService) ListForUser(ctx context.Context, userID string, now time.Time) ([]*models.ChallengeStatus, error) {
+	challenges, err := s.challengeRepo.ListOpen(ctx, now)
+	if err != nil {
+		return nil, fmt.Errorf("error listing challenges: %v", err)
+	}
+	progress, err := s.progressRepo.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting challenge progress: %v", err)
+	}
+
+	byChallenge := make(map[string]*models.ChallengeProgress, len(progress))
+	for _, p := range progress {
+		byChallenge[p.ChallengeID] = p
+	}
+
+	statuses := make([]*models.ChallengeStatus, 0, len(challenges))
+	for _, challenge := range challenges {
+		p, ok := byChallenge[challenge.ID]
+		if !ok {
+			p = &models.ChallengeProgress{ChallengeID: challenge.ID, UserID: userID}
+		}
+		statuses = append(statuses, &models.ChallengeStatus{Challenge: challenge, Progress: p})
+	}
+	return statuses, nil
+}
+
+// ListChallenges obtiene todos los retos
+func (s *ChallengeService) ListChallenges(ctx context.Context) ([]*models.Challenge, error) {
+	return s.challengeRepo.List(ctx)
+}
+
+// CreateChallenge valida y guarda un reto nuevo
+func (s *ChallengeService) CreateChallenge(ctx context.Context, challenge *models.Challenge) error {
+	if err := challenge.Validate(); err != nil {
+		return errors.NewValidationError(err.Error(), err)
+	}
+
+	now := time.Now()
+	challenge.CreatedAt = now
+	challenge.UpdatedAt = now
+	return s.challengeRepo.Create(ctx, challenge)
+}
+
+// UpdateChallenge reemplaza un reto existente. El avance ya registrado se conserva.
+func (s *ChallengeService) UpdateChallenge(ctx context.Context, challengeID string, challenge *models.Challenge) error {
+	existing, err := s.challengeRepo.Get(ctx, challengeID)
+	if err != nil {
+		return err
+	}
+
+	challenge.ID = challengeID
+	if err := challenge.Validate(); err != nil {
+		return errors.NewValidationError(err.Error(), err)
+	}
+	challenge.CreatedAt = existing.CreatedAt
+	challenge.UpdatedAt = time.Now()
+	return s.challengeRepo.Update(ctx, challenge)
+}
+
+// DeleteChallenge elimina un reto
+func (s *ChallengeService) DeleteChallenge(ctx context.Context, challengeID string) error {
+	if _, err := s.challengeRepo.Get(ctx, challengeID); err != nil {
+		return err
+	}
+	return s.challengeRepo.Delete(ctx, challengeID)
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kha0sys/nodo.social/functions/domain/errors"
+	"github.com/kha0sys/nodo.social/functions/domain/models"
+)
+
+// DeliveryQueue guarda las entregas externas fallidas para reintentarlas
+type DeliveryQueue interface {
+	// Enqueue agrega un trabajo a la cola y le asigna un ID
+	Enqueue(ctx context.Context, job *models.DeliveryJob) error
+	// Lease toma hasta limit trabajos con next_attempt_at <= now y los reserva durante lease.
+	// Si el proceso muere sin resolverlos, vuelven a estar disponibles al vencer la reserva.
+	Lease(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*models.DeliveryJob, error)
+	// Complete elimina de la cola un trabajo entregado
+	Complete(ctx context.Context, job *models.DeliveryJob) error
+	// Retry guarda el intento fallido y deja el trabajo pendiente hasta job.NextAttemptAt
+	Retry(ctx context.Context, job *models.DeliveryJob) error
+	// DeadLetter mueve un trabajo que agotó sus intentos a la cola de trabajos muertos
+	DeadLetter(ctx context.Context, job *models.DeliveryJob) error
+	// ListDeadLetters obtiene los trabajos muertos, del más reciente al más antiguo
+	ListDeadLetters(ctx context.Context, limit int) ([]*models.DeliveryJob, error)
+	// Requeue devuelve a la cola un trabajo muerto concediéndole extraAttempts intentos más
+	Requeue(ctx context.Context, jobID string, extraAttempts int, now time.Time) (*models.DeliveryJob, error)
+}
+
+// InMemoryDeliveryQueue implementa DeliveryQueue dentro del proceso.
+// Sirve para pruebas y desarrollo local; los trabajos se pierden al reiniciar.
+type InMemoryDeliveryQueue struct {
+	mu          sync.Mutex
+	seq         int64
+	jobs        map[string]*models.DeliveryJob
+	deadLetters map[string]*models.DeliveryJob
+}
+
+// NewInMemoryDeliveryQueue crea una nueva instancia de InMemoryDeliveryQueue
+func NewInMemoryDeliveryQueue() *InMemoryDeliveryQueue {
+	return &InMemoryDeliveryQueue{
+		jobs:        make(map[string]*models.DeliveryJob),
+		deadLetters: make(map[string]*models.DeliveryJob),
+	}
+}
+
+// Enqueue implementa DeliveryQueue.Enqueue
+func (q *InMemoryDeliveryQueue) Enqueue(ctx context.Context, job *models.DeliveryJob) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.seq++
+	job.ID = strconv.FormatInt(q.seq, 10)
+	job.Status = models.DeliveryPending
+	q.jobs[job.ID] = copyDeliveryJob(job)
+	return nil
+}
+
+// Lease implementa DeliveryQueue.Lease
+func (q *InMemoryDeliveryQueue) Lease(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*models.DeliveryJob, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	due := make([]*models.DeliveryJob, 0)
+	for _, job := range q.jobs {
+		if !job.NextAttemptAt.After(now) {
+			due = append(due, job)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].NextAttemptAt.Before(due[j].NextAttemptAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	leased := make([]*models.DeliveryJob, 0, len(due))
+	for _, job := range due {
+		job.Status = models.DeliveryProcessing
+		job.NextAttemptAt = now.Add(lease)
+		leased = append(leased, copyDeliveryJob(job))
+	}
+	return leased, nil
+}
+
+// Complete implementa DeliveryQueue.Complete
+func (q *InMemoryDeliveryQueue) Complete(ctx context.Context, job *models.DeliveryJob) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.jobs, job.ID)
+	return nil
+}
+
+// Retry implementa DeliveryQueue.Retry
+func (q *InMemoryDeliveryQueue) Retry(ctx context.Context, job *models.DeliveryJob) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job.Status = models.DeliveryPending
+	q.jobs[job.ID] = copyDeliveryJob(job)
+	return nil
+}
+
+// DeadLetter implementa DeliveryQueue.DeadLetter
+func (q *InMemoryDeliveryQueue) DeadLetter(ctx context.Context, job *models.DeliveryJob) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job.Status = models.DeliveryDead
+	delete(q.jobs, job.ID)
+	q.deadLetters[job.ID] = copyDeliveryJob(job)
+	return nil
+}
+
+// ListDeadLetters implementa DeliveryQueue.ListDeadLetters
+func (q *InMemoryDeliveryQueue) ListDeadLetters(ctx context.Context, limit int) ([]*models.DeliveryJob, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	jobs := make([]*models.DeliveryJob, 0, len(q.deadLetters))
+	for _, job := range q.deadLetters {
+		jobs = append(jobs, copyDeliveryJob(job))
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].UpdatedAt.After(jobs[j].UpdatedAt) })
+	if len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+	return jobs, nil
+}
+
+// Requeue implementa DeliveryQueue.Requeue
+func (q *InMemoryDeliveryQueue) Requeue(ctx context.Context, jobID string, extraAttempts int, now time.Time) (*models.DeliveryJob, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, ok := q.deadLetters[jobID]
+	if !ok {
+		return nil, errors.NewNotFoundError("entrega no encontrada")
+	}
+	delete(q.deadLetters, jobID)
+
+	job.Status = models.DeliveryPending
+	job.MaxAttempts = len(job.Attempts) + extraAttempts
+	job.NextAttemptAt = now
+	job.UpdatedAt = now
+	q.jobs[job.ID] = job
+	return copyDeliveryJob(job), nil
+}
+
+func copyDeliveryJob(job *models.DeliveryJob) *models.DeliveryJob {
+	copied := *job
+	copied.Attempts = append([]models.DeliveryAttempt(nil), job.Attempts...)
+	return &copied
+}
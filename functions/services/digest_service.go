@@ -0,0 +1,347 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kha0sys/nodo.social/functions/domain/models"
+	"github.com/kha0sys/nodo.social/functions/domain/repositories"
+)
+
+const (
+	// DigestHour es la hora local del lunes a partir de la cual se envía el resumen semanal
+	DigestHour = 9
+
+	digestUserPageSize     = 200
+	digestMaxItems         = 5
+	digestMaxFollowedNodes = 100
+	digestTrendingPool     = 50
+)
+
+// digestFollowerMilestones son los números de seguidores que se celebran en el resumen
+var digestFollowerMilestones = []int{10, 50, 100, 500, 1000, 5000, 10000}
+
+// DigestService arma y envía el resumen semanal de cada usuario
+type DigestService struct {
+	userRepo        repositories.UserRepository
+	nodeRepo        repositories.NodeRepository
+	muteRepo        repositories.FeedMuteRepository
+	stateRepo       repositories.DigestStateRepository
+	notificationSvc *NotificationService
+}
+
+// NewDigestService crea una nueva instancia de DigestService
+func NewDigestService(
+	userRepo repositories.UserRepository,
+	nodeRepo repositories.NodeRepository,
+	muteRepo repositories.FeedMuteRepository,
+	stateRepo repositories.DigestStateRepository,
+	notificationSvc *NotificationService,
+) *DigestService {
+	return &DigestService{
+		userRepo:        userRepo,
+		nodeRepo:        nodeRepo,
+		muteRepo:        muteRepo,
+		stateRepo:       stateRepo,
+		notificationSvc: notificationSvc,
+	}
+}
+
+// SendDue envía el resumen a los usuarios para quienes ya es lunes por la mañana en su
+// zona horaria y que aún no lo recibieron esta semana. Debe ejecutarse cada hora.
+func (s *DigestService) SendDue(ctx context.Context, now time.Time) error {
+	if !digestWindowOpen(now) {
+		return nil
+	}
+
+	startAfter := ""
+	for {
+		users, err := s.userRepo.List(ctx, startAfter, digestUserPageSize)
+		if err != nil {
+			return fmt.Errorf("error listing users: %v", err)
+		}
+
+		for _, user := range users {
+			if err := s.sendIfDue(ctx, user, now); err != nil {
+				fmt.Printf("error sending weekly digest to user %s: %v\n", user.ID, err)
+			}
+		}
+
+		if len(users) < digestUserPageSize {
+			return nil
+		}
+		startAfter = users[len(users)-1].ID
+	}
+}
+
+// digestWindowOpen indica si en alguna zona horaria es lunes después de DigestHour.
+// Las zonas van de UTC-12 a UTC+14, así que eso ocurre entre el domingo a las 19:00
+// y el martes a las 12:00 UTC; fuera de ese rango no hace falta recorrer los usuarios.
+func digestWindowOpen(now time.Time) bool {
+	utc := now.UTC()
+	switch utc.Weekday() {
+	case time.Sunday:
+		return utc.Hour() >= 24+DigestHour-14
+	case time.Monday:
+		return true
+	case time.Tuesday:
+		return utc.Hour() < 12
+	}
+	return false
+}
+
+// sendIfDue envía el resumen a un usuario si ya le corresponde
+func (s *DigestService) sendIfDue(ctx context.Context, user *models.User, now time.Time) error {
+	preferences, err := s.notificationSvc.GetPreferences(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	local := now.In(preferences.Location())
+	if local.Weekday() != time.Monday || local.Hour() < DigestHour {
+		return nil
+	}
+
+	state, err := s.stateRepo.Get(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("error getting digest state: %v", err)
+	}
+	week := digestWeek(local)
+	if state.Week == week {
+		return nil
+	}
+
+	from := now.AddDate(0, 0, -7)
+	if state.LastSentAt.After(from) {
+		from = state.LastSentAt
+	}
+
+	digest, stats, err := s.Build(ctx, user, state.NodeStats, from, now)
+	if err != nil {
+		return err
+	}
+
+	// El estado se guarda antes de enviar: es preferible perder un resumen que enviarlo dos veces
+	state.Week = week
+	state.LastSentAt = now
+	state.NodeStats = stats
+	if err := s.stateRepo.Save(ctx, state); err != nil {
+		return fmt.Errorf("error saving digest state: %v", err)
+	}
+
+	if digest.IsEmpty() {
+		return nil
+	}
+
+	notification := &models.Notification{
+		UserID:    user.ID,
+		Type:      models.NotificationWeeklyDigest,
+		CreatedAt: now,
+		Params: map[string]interface{}{
+			"count": digest.Highlights(),
+		},
+		Data: digest.ToData(),
+	}
+	return s.notificationSvc.SendNotification(ctx, notification)
+}
+
+// digestWeek identifica la semana ISO de t, p. ej. "2024-W07"
+func digestWeek(t time.Time) string {
+	year, week := t.ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
+
+// Build arma el resumen de un usuario entre from y to. previous son las métricas de sus
+// nodos al enviar el resumen anterior; se retornan las actuales para guardarlas como referencia.
+func (s *DigestService) Build(ctx context.Context, user *models.User, previous map[string]models.InteractionMetrics, from time.Time, to time.Time) (*models.WeeklyDigest, map[string]models.InteractionMetrics, error) {
+	digest := &models.WeeklyDigest{From: from, To: to}
+
+	// Los silencios del feed también se aplican al resumen
+	mutes, err := s.muteRepo.GetByUser(ctx, user.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting feed mutes: %v", err)
+	}
+	muteSet := models.NewFeedMuteSet(mutes)
+
+	digest.Updates = s.followedUpdates(ctx, user, muteSet, from, to)
+	digest.Achievements = unlockedAchievements(user, from, to)
+
+	stats := make(map[string]models.InteractionMetrics, len(user.Nodes))
+	for _, nodeID := range user.Nodes {
+		node, err := s.nodeRepo.Get(ctx, nodeID)
+		if err != nil {
+			fmt.Printf("error getting node %s for digest: %v\n", nodeID, err)
+			continue
+		}
+
+		current := node.Metrics
+		current.Followers = node.FollowersCount
+		stats[node.ID] = current
+
+		nodeStats := models.DigestNodeStats{NodeID: node.ID, Title: node.Title, Metrics: current}
+		if before, ok := previous[node.ID]; ok {
+			nodeStats.Change = metricsChange(before, current)
+			if milestone := crossedMilestone(before.Followers, current.Followers); milestone > 0 {
+				digest.Milestones = append(digest.Milestones, models.DigestMilestone{
+					NodeID:    node.ID,
+					NodeTitle: node.Title,
+					Followers: milestone,
+				})
+			}
+		}
+		digest.NodeStats = append(digest.NodeStats, nodeStats)
+	}
+	sort.Slice(digest.NodeStats, func(i, j int) bool {
+		return digest.NodeStats[i].Metrics.Followers > digest.NodeStats[j].Metrics.Followers
+	})
+	if len(digest.NodeStats) > digestMaxItems {
+		digest.NodeStats = digest.NodeStats[:digestMaxItems]
+	}
+
+	trending, err := s.trendingNodes(ctx, user, muteSet)
+	if err != nil {
+		return nil, nil, err
+	}
+	digest.Trending = trending
+
+	return digest, stats, nil
+}
+
+// followedUpdates obtiene las actualizaciones más recientes de los nodos que sigue el
+// usuario, sin los que silenció
+func (s *DigestService) followedUpdates(ctx context.Context, user *models.User, muteSet *models.FeedMuteSet, from time.Time, to time.Time) []models.DigestNodeUpdate {
+	followed := user.FollowedNodes
+	if len(followed) > digestMaxFollowedNodes {
+		followed = followed[:digestMaxFollowedNodes]
+	}
+
+	updates := make([]models.DigestNodeUpdate, 0)
+	for _, nodeID := range followed {
+		node, err := s.nodeRepo.Get(ctx, nodeID)
+		if err != nil {
+			fmt.Printf("error getting node %s for digest: %v\n", nodeID, err)
+			continue
+		}
+		if muteSet.HidesNode(node) {
+			continue
+		}
+		for _, update := range node.Updates {
+			if update.CreatedAt.Before(from) || !update.CreatedAt.Before(to) {
+				continue
+			}
+			updates = append(updates, models.DigestNodeUpdate{
+				NodeID:    node.ID,
+				NodeTitle: node.Title,
+				Title:     update.Title,
+				CreatedAt: update.CreatedAt,
+			})
+		}
+	}
+
+	sort.Slice(updates, func(i, j int) bool { return updates[i].CreatedAt.After(updates[j].CreatedAt) })
+	if len(updates) > digestMaxItems {
+		updates = updates[:digestMaxItems]
+	}
+	return updates
+}
+
+// trendingNodes obtiene los nodos populares que coinciden con los intereses del usuario,
+// sin los que ya sigue, los suyos ni los que silenció
+func (s *DigestService) trendingNodes(ctx context.Context, user *models.User, muteSet *models.FeedMuteSet) ([]models.DigestNode, error) {
+	popular, err := s.nodeRepo.GetPopularNodes(ctx, digestTrendingPool)
+	if err != nil {
+		return nil, fmt.Errorf("error getting popular nodes: %v", err)
+	}
+
+	followed := make(map[string]bool, len(user.FollowedNodes))
+	for _, nodeID := range user.FollowedNodes {
+		followed[nodeID] = true
+	}
+
+	trending := make([]models.DigestNode, 0, digestMaxItems)
+	for _, node := range popular {
+		if len(trending) == digestMaxItems {
+			break
+		}
+		if node.UserID == user.ID || followed[node.ID] || muteSet.HidesNode(node) {
+			continue
+		}
+		if !matchesInterests(node, user.Profile.Interests) {
+			continue
+		}
+		trending = append(trending, models.DigestNode{
+			ID:             node.ID,
+			Title:          node.Title,
+			Type:           string(node.Type),
+			FollowersCount: node.FollowersCount,
+		})
+	}
+	return trending, nil
+}
+
+// matchesInterests indica si el tipo o alguna etiqueta del nodo coincide con los intereses.
+// Un usuario sin intereses acepta cualquier nodo.
+func matchesInterests(node *models.Node, interests []string) bool {
+	if len(interests) == 0 {
+		return true
+	}
+	for _, interest := range interests {
+		if strings.EqualFold(interest, string(node.Type)) {
+			return true
+		}
+		for _, tag := range node.Tags {
+			if strings.EqualFold(interest, tag) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// unlockedAchievements obtiene los logros que el usuario desbloqueó entre from y to
+func unlockedAchievements(user *models.User, from time.Time, to time.Time) []models.DigestAchievement {
+	achievements := make([]models.DigestAchievement, 0)
+	for _, achievement := range user.Achievements {
+		if achievement.UnlockedAt.Before(from) || !achievement.UnlockedAt.Before(to) {
+			continue
+		}
+		achievements = append(achievements, models.DigestAchievement{
+			AchievementID: achievement.AchievementID,
+			Name:          achievement.Name,
+			Type:          string(achievement.Type),
+			Points:        achievement.Points,
+			UnlockedAt:    achievement.UnlockedAt,
+		})
+	}
+	return achievements
+}
+
+// crossedMilestone retorna el mayor hito de seguidores superado entre before y after, o 0
+func crossedMilestone(before int, after int) int {
+	crossed := 0
+	for _, milestone := range digestFollowerMilestones {
+		if before < milestone && after >= milestone {
+			crossed = milestone
+		}
+	}
+	return crossed
+}
+
+// metricsChange retorna cuánto creció cada métrica; las que bajaron cuentan como cero
+func metricsChange(before models.InteractionMetrics, after models.InteractionMetrics) models.InteractionMetrics {
+	growth := func(before int, after int) int {
+		if after > before {
+			return after - before
+		}
+		return 0
+	}
+	return models.InteractionMetrics{
+		Views:     growth(before.Views, after.Views),
+		Likes:     growth(before.Likes, after.Likes),
+		Shares:    growth(before.Shares, after.Shares),
+		Comments:  growth(before.Comments, after.Comments),
+		Followers: growth(before.Followers, after.Followers),
+	}
+}
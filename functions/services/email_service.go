@@ -0,0 +1,232 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/kha0sys/nodo.social/functions/domain/errors"
+	"github.com/kha0sys/nodo.social/functions/domain/models"
+	"github.com/kha0sys/nodo.social/functions/domain/repositories"
+	"github.com/kha0sys/nodo.social/functions/internal/i18n"
+)
+
+const (
+	// UnsubscribeAll es el tipo usado en los tokens que dan de baja todos los correos
+	UnsubscribeAll = "*"
+	// maxSoftBounces es el número de rebotes temporales tras el cual se suprime una dirección
+	maxSoftBounces = 3
+)
+
+// EmailSender envía correos ya armados
+type EmailSender interface {
+	Send(ctx context.Context, message *models.EmailMessage) error
+}
+
+// EmailConfig contiene la configuración del canal de correo
+type EmailConfig struct {
+	// AppURL es la URL de la aplicación usada en los enlaces de los correos
+	AppURL string
+	// UnsubscribeURL es la URL pública del endpoint de baja
+	UnsubscribeURL string
+	// Secret firma los tokens de baja
+	Secret string
+}
+
+// EmailService arma y envía los correos de notificación, y lleva el registro
+// de bajas, rebotes y direcciones suprimidas
+type EmailService struct {
+	sender          EmailSender
+	suppressionRepo repositories.EmailSuppressionRepository
+	preferencesRepo repositories.NotificationPreferencesRepository
+	templates       *emailTemplates
+	catalog         *i18n.Catalog
+	config          EmailConfig
+}
+
+// NewEmailService crea una nueva instancia de EmailService
+func NewEmailService(
+	sender EmailSender,
+	suppressionRepo repositories.EmailSuppressionRepository,
+	preferencesRepo repositories.NotificationPreferencesRepository,
+	config EmailConfig,
+) (*EmailService, error) {
+	if config.Secret == "" {
+		return nil, fmt.Errorf("email unsubscribe secret is required")
+	}
+
+	templates, err := newEmailTemplates()
+	if err != nil {
+		return nil, err
+	}
+
+	return &EmailService{
+		sender:          sender,
+		suppressionRepo: suppressionRepo,
+		preferencesRepo: preferencesRepo,
+		templates:       templates,
+		catalog:         i18n.DefaultCatalog(),
+		config:          config,
+	}, nil
+}
+
+// SendNotification envía por correo una notificación a un usuario,
+// salvo que su dirección esté suprimida
+func (s *EmailService) SendNotification(ctx context.Context, user *models.User, notification *models.Notification) error {
+	if user.Email == "" {
+		return nil
+	}
+
+	suppression, err := s.suppressionRepo.Get(ctx, user.Email)
+	if err != nil {
+		return fmt.Errorf("error getting email suppression: %v", err)
+	}
+	if suppression.Suppressed {
+		return nil
+	}
+
+	data := &emailTemplateData{
+		User:              user,
+		Notification:      notification,
+		Data:              notification.Data,
+		Language:          i18n.NormalizeLanguage(user.Profile.Language),
+		AppURL:            s.config.AppURL,
+		UnsubscribeURL:    s.unsubscribeURL(user.ID, notification.Type),
+		UnsubscribeAllURL: s.unsubscribeURL(user.ID, UnsubscribeAll),
+		catalog:           s.catalog,
+	}
+	if data.Data == nil {
+		data.Data = make(map[string]interface{})
+	}
+
+	html, text, err := s.templates.render(data)
+	if err != nil {
+		return err
+	}
+
+	message := &models.EmailMessage{
+		To:       user.Email,
+		Subject:  notification.Title,
+		HTMLBody: html,
+		TextBody: text,
+		Headers: map[string]string{
+			// Baja en un clic según RFC 8058
+			"List-Unsubscribe":      "<" + data.UnsubscribeURL + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		},
+	}
+	if err := s.sender.Send(ctx, message); err != nil {
+		return fmt.Errorf("error sending email: %v", err)
+	}
+	return nil
+}
+
+// UnsubscribeToken genera un token firmado para dar de baja los correos de un tipo
+// de notificación, o todos si notificationType es UnsubscribeAll
+func (s *EmailService) UnsubscribeToken(userID string, notificationType string) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(userID + "\n" + notificationType))
+	return payload + "." + s.sign(payload)
+}
+
+// Unsubscribe valida el token y desactiva el correo para el tipo de notificación indicado
+func (s *EmailService) Unsubscribe(ctx context.Context, token string) (*models.NotificationPreferences, error) {
+	userID, notificationType, err := s.parseUnsubscribeToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	// Se aplica en una transacción para no pisar un cambio de preferencias simultáneo
+	preferences, err := s.preferencesRepo.Update(ctx, userID, func(preferences *models.NotificationPreferences) error {
+		if notificationType == UnsubscribeAll {
+			preferences.EmailUnsubscribed = true
+		} else {
+			if preferences.Types == nil {
+				preferences.Types = make(map[string]map[models.NotificationChannel]bool)
+			}
+			if preferences.Types[notificationType] == nil {
+				preferences.Types[notificationType] = make(map[models.NotificationChannel]bool)
+			}
+			preferences.Types[notificationType][models.ChannelEmail] = false
+		}
+		preferences.UpdatedAt = time.Now()
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error saving notification preferences: %v", err)
+	}
+	return preferences, nil
+}
+
+// RecordBounce registra un rebote o una queja de una dirección. Los rebotes permanentes
+// y las quejas la suprimen de inmediato; los temporales, al acumularse.
+func (s *EmailService) RecordBounce(ctx context.Context, email string, bounceType string) (*models.EmailSuppression, error) {
+	if strings.TrimSpace(email) == "" {
+		return nil, errors.NewValidationError("el correo es obligatorio", nil)
+	}
+
+	suppression, err := s.suppressionRepo.Get(ctx, email)
+	if err != nil {
+		return nil, fmt.Errorf("error getting email suppression: %v", err)
+	}
+
+	switch bounceType {
+	case models.BounceHard:
+		suppression.HardBounces++
+		suppression.Suppressed = true
+	case models.BounceSoft:
+		suppression.SoftBounces++
+		if suppression.SoftBounces >= maxSoftBounces {
+			suppression.Suppressed = true
+		}
+	case models.BounceComplaint:
+		suppression.Complaints++
+		suppression.Suppressed = true
+	default:
+		return nil, errors.NewValidationError("tipo de rebote inválido", nil)
+	}
+	if suppression.Suppressed && suppression.Reason == "" {
+		suppression.Reason = bounceType
+	}
+
+	now := time.Now()
+	suppression.LastBounceAt = now
+	suppression.UpdatedAt = now
+
+	if err := s.suppressionRepo.Save(ctx, suppression); err != nil {
+		return nil, fmt.Errorf("error saving email suppression: %v", err)
+	}
+	return suppression, nil
+}
+
+func (s *EmailService) unsubscribeURL(userID string, notificationType string) string {
+	return s.config.UnsubscribeURL + "?token=" + url.QueryEscape(s.UnsubscribeToken(userID, notificationType))
+}
+
+func (s *EmailService) parseUnsubscribeToken(token string) (string, string, error) {
+	parts := strings.SplitN(token, ".", 2)
+	if len(parts) != 2 || !hmac.Equal([]byte(parts[1]), []byte(s.sign(parts[0]))) {
+		return "", "", errors.NewValidationError("token de baja inválido", nil)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", "", errors.NewValidationError("token de baja inválido", err)
+	}
+
+	fields := strings.SplitN(string(payload), "\n", 2)
+	if len(fields) != 2 || fields[0] == "" || fields[1] == "" {
+		return "", "", errors.NewValidationError("token de baja inválido", nil)
+	}
+	return fields[0], fields[1], nil
+}
+
+func (s *EmailService) sign(payload string) string {
+	mac := hmac.New(sha256.New, []byte(s.config.Secret))
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+
+	"github.com/kha0sys/nodo.social/functions/domain/models"
+	"github.com/kha0sys/nodo.social/functions/internal/i18n"
+)
+
+// emailTemplateData son los datos disponibles en las plantillas de correo
+type emailTemplateData struct {
+	User              *models.User
+	Notification      *models.Notification
+	Data              map[string]interface{}
+	Language          string
+	AppURL            string
+	UnsubscribeURL    string
+	UnsubscribeAllURL string
+	catalog           *i18n.Catalog
+}
+
+// T traduce una clave del catálogo al idioma del correo. Los argumentos son pares nombre, valor.
+func (d *emailTemplateData) T(key string, args ...interface{}) string {
+	params := make(map[string]interface{}, len(args)/2)
+	for i := 0; i+1 < len(args); i += 2 {
+		params[fmt.Sprint(args[i])] = args[i+1]
+	}
+	return d.catalog.Translate(d.Language, key, params)
+}
+
+const emailLayoutHTML = `<!DOCTYPE html>
+<html lang="{{.Language}}">
+<head><meta charset="utf-8"><title>{{.Notification.Title}}</title></head>
+<body style="margin:0;padding:0;background:#f4f5f7;font-family:Helvetica,Arial,sans-serif;color:#222;">
+<table width="100%" cellpadding="0" cellspacing="0"><tr><td align="center" style="padding:24px;">
+<table width="600" cellpadding="0" cellspacing="0" style="background:#fff;border-radius:8px;">
+<tr><td style="padding:24px 32px;border-bottom:1px solid #eee;"><a href="{{.AppURL}}" style="color:#2f855a;font-size:20px;font-weight:bold;text-decoration:none;">Nodo Social</a></td></tr>
+<tr><td style="padding:32px;font-size:15px;line-height:1.6;">
+{{if .User.DisplayName}}<p>{{.T "email.greeting" "name" .User.DisplayName}}</p>{{else}}<p>{{.T "email.greeting_anonymous"}}</p>{{end}}
+<h1 style="font-size:22px;">{{.Notification.Title}}</h1>
+{{template "content" .}}
+</td></tr>
+<tr><td style="padding:16px 32px;border-top:1px solid #eee;font-size:12px;color:#888;">
+{{.T "email.footer"}}
+<a href="{{.UnsubscribeURL}}" style="color:#888;">{{.T "email.unsubscribe_type"}}</a> ·
+<a href="{{.UnsubscribeAllURL}}" style="color:#888;">{{.T "email.unsubscribe_all"}}</a>
+</td></tr>
+</table>
+</td></tr></table>
+</body>
+</html>`
+
+const emailLayoutText = `{{if .User.DisplayName}}{{.T "email.greeting" "name" .User.DisplayName}}{{else}}{{.T "email.greeting_anonymous"}}{{end}}
+
+{{.Notification.Title}}
+
+{{template "content" .}}
+
+--
+Nodo Social - {{.AppURL}}
+{{.T "email.unsubscribe_type"}}: {{.UnsubscribeURL}}
+{{.T "email.unsubscribe_all"}}: {{.UnsubscribeAllURL}}
+`
+
+// emailBodies contiene el contenido HTML y de texto de cada tipo de notificación.
+// Los textos salen del catálogo de i18n; los tipos sin plantilla propia usan "default".
+var emailBodies = map[string][2]string{
+	"default": {
+		`<p>{{.Notification.Description}}</p>
+<p><a href="{{.AppURL}}" style="color:#2f855a;">{{.T "email.open_app"}}</a></p>`,
+		`{{.Notification.Description}}
+
+{{.T "email.open_app"}}: {{.AppURL}}`,
+	},
+	models.NotificationWelcome: {
+		`<p>{{.Notification.Description}}</p>
+<p><a href="{{.AppURL}}" style="display:inline-block;padding:12px 20px;background:#2f855a;color:#fff;border-radius:4px;text-decoration:none;">{{.T "email.welcome.cta"}}</a></p>`,
+		`{{.Notification.Description}}
+
+{{.T "email.welcome.cta"}}: {{.AppURL}}`,
+	},
+	models.NotificationWeeklyDigest: {
+		`<p>{{.Notification.Description}}</p>
+{{with digest .Data}}{{if .Updates}}<h2 style="font-size:17px;">{{$.T "email.digest.updates"}}</h2>
+<ul>{{range .Updates}}<li><a href="{{$.AppURL}}/nodes/{{.NodeID}}" style="color:#2f855a;">{{.NodeTitle}}</a>: {{.Title}}</li>{{end}}</ul>{{end}}
+{{if .Milestones}}<h2 style="font-size:17px;">{{$.T "email.digest.milestones"}}</h2>
+<ul>{{range .Milestones}}<li>{{$.T "email.digest.milestone" "title" .NodeTitle "count" .Followers}}</li>{{end}}</ul>{{end}}
+{{if .Achievements}}<h2 style="font-size:17px;">{{$.T "email.digest.achievements"}}</h2>
+<ul>{{range .Achievements}}<li>{{$.T "email.digest.achievement" "name" .Name "count" .Points}}</li>{{end}}</ul>{{end}}
+{{if .NodeStats}}<h2 style="font-size:17px;">{{$.T "email.digest.stats"}}</h2>
+<ul>{{range .NodeStats}}<li><a href="{{$.AppURL}}/nodes/{{.NodeID}}" style="color:#2f855a;">{{.Title}}</a>: {{$.T "email.digest.stats_line" "followers" .Metrics.Followers "new_followers" .Change.Followers "views" .Metrics.Views "new_views" .Change.Views "likes" .Metrics.Likes "new_likes" .Change.Likes}}</li>{{end}}</ul>{{end}}
+{{if .Trending}}<h2 style="font-size:17px;">{{$.T "email.digest.trending"}}</h2>
+<ul>{{range .Trending}}<li><a href="{{$.AppURL}}/nodes/{{.ID}}" style="color:#2f855a;">{{.Title}}</a></li>{{end}}</ul>{{end}}{{end}}
+<p><a href="{{.AppURL}}" style="display:inline-block;padding:12px 20px;background:#2f855a;color:#fff;border-radius:4px;text-decoration:none;">{{.T "email.digest.view_all"}}</a></p>`,
+		`{{.Notification.Description}}
+{{with digest .Data}}{{if .Updates}}
+{{$.T "email.digest.updates"}}
+{{range .Updates}}- {{.NodeTitle}}: {{.Title}} ({{$.AppURL}}/nodes/{{.NodeID}})
+{{end}}{{end}}{{if .Milestones}}
+{{$.T "email.digest.milestones"}}
+{{range .Milestones}}- {{$.T "email.digest.milestone" "title" .NodeTitle "count" .Followers}}
+{{end}}{{end}}{{if .Achievements}}
+{{$.T "email.digest.achievements"}}
+{{range .Achievements}}- {{$.T "email.digest.achievement" "name" .Name "count" .Points}}
+{{end}}{{end}}{{if .NodeStats}}
+{{$.T "email.digest.stats"}}
+{{range .NodeStats}}- {{.Title}}: {{$.T "email.digest.stats_line" "followers" .Metrics.Followers "new_followers" .Change.Followers "views" .Metrics.Views "new_views" .Change.Views "likes" .Metrics.Likes "new_likes" .Change.Likes}}
+{{end}}{{end}}{{if .Trending}}
+{{$.T "email.digest.trending"}}
+{{range .Trending}}- {{.Title}}: {{$.AppURL}}/nodes/{{.ID}}
+{{end}}{{end}}{{end}}
+{{.T "email.digest.view_all"}}: {{.AppURL}}`,
+	},
+	models.NotificationProductApproved: {
+		`<p>{{.Notification.Description}}</p>
+<p>{{.T "email.product.visible"}}</p>
+{{with .Data.productId}}<p><a href="{{$.AppURL}}/products/{{.}}" style="color:#2f855a;">{{$.T "email.product.view"}}</a></p>{{end}}`,
+		`{{.Notification.Description}}
+{{.T "email.product.visible"}}
+{{with .Data.productId}}
+{{$.T "email.product.view"}}: {{$.AppURL}}/products/{{.}}{{end}}`,
+	},
+	models.NotificationProductRejected: {
+		`<p>{{.Notification.Description}}</p>
+{{with .Data.reason}}<p>{{$.T "email.product.reason" "reason" .}}</p>{{end}}
+<p>{{.T "email.product.retry"}}</p>`,
+		`{{.Notification.Description}}
+{{with .Data.reason}}{{$.T "email.product.reason" "reason" .}}
+{{end}}
+{{.T "email.product.retry"}}`,
+	},
+	models.NotificationFollowerMilestone: {
+		`<p>{{.Notification.Description}}</p>
+{{with .Data.followers}}<p>{{$.T "email.milestone.followers" "count" .}}</p>{{end}}
+{{with .Data.nodeID}}<p><a href="{{$.AppURL}}/nodes/{{.}}" style="color:#2f855a;">{{$.T "email.milestone.view"}}</a></p>{{end}}`,
+		`{{.Notification.Description}}
+{{with .Data.followers}}{{$.T "email.milestone.followers" "count" .}}
+{{end}}{{with .Data.nodeID}}
+{{$.T "email.milestone.view"}}: {{$.AppURL}}/nodes/{{.}}{{end}}`,
+	},
+}
+
+// emailTemplates contiene las plantillas compiladas por tipo de notificación
+type emailTemplates struct {
+	html map[string]*htmltemplate.Template
+	text map[string]*texttemplate.Template
+}
+
+func newEmailTemplates() (*emailTemplates, error) {
+	funcs := map[string]interface{}{
+		"digest": models.DigestFromData,
+	}
+
+	templates := &emailTemplates{
+		html: make(map[string]*htmltemplate.Template),
+		text: make(map[string]*texttemplate.Template),
+	}
+	for notificationType, body := range emailBodies {
+		html, err := htmltemplate.New(notificationType).Funcs(funcs).Parse(emailLayoutHTML)
+		if err == nil {
+			_, err = html.New("content").Parse(body[0])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error parsing HTML email template %s: %v", notificationType, err)
+		}
+
+		text, err := texttemplate.New(notificationType).Funcs(funcs).Parse(emailLayoutText)
+		if err == nil {
+			_, err = text.New("content").Parse(body[1])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error parsing text email template %s: %v", notificationType, err)
+		}
+
+		templates.html[notificationType] = html
+		templates.text[notificationType] = text
+	}
+	return templates, nil
+}
+
+// render genera las versiones HTML y de texto del correo de una notificación
+func (t *emailTemplates) render(data *emailTemplateData) (string, string, error) {
+	notificationType := data.Notification.Type
+	if _, ok := t.html[notificationType]; !ok {
+		notificationType = "default"
+	}
+
+	var html, text bytes.Buffer
+	if err := t.html[notificationType].Execute(&html, data); err != nil {
+		return "", "", fmt.Errorf("error rendering HTML email: %v", err)
+	}
+	if err := t.text[notificationType].Execute(&text, data); err != nil {
+		return "", "", fmt.Errorf("error rendering text email: %v", err)
+	}
+	return html.String(), text.String(), nil
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kha0sys/nodo.social/functions/domain/models"
+)
+
+// EventPublisher distribuye eventos en vivo a los usuarios conectados
+type EventPublisher interface {
+	// Publish entrega un evento a las suscripciones de event.UserID y le asigna un ID
+	Publish(ctx context.Context, event *models.LiveEvent) error
+	// Subscribe retorna los eventos de userID posteriores a lastEventID (vacío para solo eventos nuevos).
+	// El canal se cierra cuando ctx se cancela.
+	Subscribe(ctx context.Context, userID string, lastEventID string) (<-chan *models.LiveEvent, error)
+}
+
+const defaultEventHistorySize = 100
+
+// InMemoryEventPublisher implementa EventPublisher dentro del proceso.
+// Sirve para desarrollo local; en producción cada instancia tendría su propia memoria.
+type InMemoryEventPublisher struct {
+	mu          sync.Mutex
+	seq         int64
+	historySize int
+	history     map[string][]*models.LiveEvent
+	subscribers map[string]map[chan *models.LiveEvent]struct{}
+}
+
+// NewInMemoryEventPublisher crea una nueva instancia de InMemoryEventPublisher
+func NewInMemoryEventPublisher() *InMemoryEventPublisher {
+	return &InMemoryEventPublisher{
+		historySize: defaultEventHistorySize,
+		history:     make(map[string][]*models.LiveEvent),
+		subscribers: make(map[string]map[chan *models.LiveEvent]struct{}),
+	}
+}
+
+// Publish implementa EventPublisher.Publish
+func (p *InMemoryEventPublisher) Publish(ctx context.Context, event *models.LiveEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.seq++
+	event.ID = strconv.FormatInt(p.seq, 10)
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	// Guardar el evento para poder reanudar con Last-Event-ID
+	history := append(p.history[event.UserID], event)
+	if len(history) > p.historySize {
+		history = history[len(history)-p.historySize:]
+	}
+	p.history[event.UserID] = history
+
+	for ch := range p.subscribers[event.UserID] {
+		select {
+		case ch <- event:
+		default:
+			log.Printf("dropping live event %s for slow subscriber of user %s", event.ID, event.UserID)
+		}
+	}
+
+	return nil
+}
+
+// Subscribe implementa EventPublisher.Subscribe
+func (p *InMemoryEventPublisher) Subscribe(ctx context.Context, userID string, lastEventID string) (<-chan *models.LiveEvent, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ch := make(chan *models.LiveEvent, p.historySize+16)
+
+	// Reenviar los eventos que el cliente no alcanzó a recibir
+	if lastEventID != "" {
+		if lastSeq, err := strconv.ParseInt(lastEventID, 10, 64); err == nil {
+			for _, event := range p.history[userID] {
+				if seq, _ := strconv.ParseInt(event.ID, 10, 64); seq > lastSeq {
+					ch <- event
+				}
+			}
+		}
+	}
+
+	if p.subscribers[userID] == nil {
+		p.subscribers[userID] = make(map[chan *models.LiveEvent]struct{})
+	}
+	p.subscribers[userID][ch] = struct{}{}
+
+	go func() {
+		<-ctx.Done()
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		delete(p.subscribers[userID], ch)
+		if len(p.subscribers[userID]) == 0 {
+			delete(p.subscribers, userID)
+		}
+		close(ch)
+	}()
+
+	return ch, nil
+}
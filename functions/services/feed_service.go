@@ -0,0 +1,508 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kha0sys/nodo.social/functions/domain/errors"
+	"github.com/kha0sys/nodo.social/functions/domain/models"
+	"github.com/kha0sys/nodo.social/functions/domain/repositories"
+)
+
+const (
+	defaultFeedPageSize = 20
+	maxFeedPageSize     = 100
+	// maxRawFeedItems limita cuántos items crudos se leen para armar una página
+	maxRawFeedItems = 500
+)
+
+// DefaultFeedAggregationRules retorna las reglas de agregación usadas por defecto
+func DefaultFeedAggregationRules() map[string]models.FeedAggregationRule {
+	return map[string]models.FeedAggregationRule{
+		models.FeedItemNodeFollowed: {Window: time.Hour, MaxActorSamples: 3},
+		models.FeedItemNodeUpdated:  {Window: 6 * time.Hour, MaxActorSamples: 3},
+	}
+}
+
+// ParseFeedAggregationRules lee una lista de reglas con el formato
+// "node_followed:1h:3,node_updated:6h:3" (tipo:ventana:actores de muestra)
+func ParseFeedAggregationRules(value string) (map[string]models.FeedAggregationRule, error) {
+	rules := make(map[string]models.FeedAggregationRule)
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		pieces := strings.Split(part, ":")
+		if len(pieces) != 3 {
+			return nil, fmt.Errorf("invalid feed aggregation rule %q, expected type:window:samples", part)
+		}
+		itemType := strings.TrimSpace(pieces[0])
+		window, err := time.ParseDuration(strings.TrimSpace(pieces[1]))
+		if err != nil || window < time.Second {
+			return nil, fmt.Errorf("invalid window for feed aggregation rule %q", itemType)
+		}
+		samples, err := strconv.Atoi(strings.TrimSpace(pieces[2]))
+		if err != nil || samples < 0 {
+			return nil, fmt.Errorf("invalid actor samples for feed aggregation rule %q", itemType)
+		}
+		if _, ok := rules[itemType]; ok || itemType == "" {
+			return nil, fmt.Errorf("invalid or duplicated feed aggregation rule %q", itemType)
+		}
+		rules[itemType] = models.FeedAggregationRule{Window: window, MaxActorSamples: samples}
+	}
+	return rules, nil
+}
+
+// FeedService maneja la lectura del feed, la agregación de actividad similar
+// y las preferencias negativas ("ocultar", "no me interesa") de cada usuario
+type FeedService struct {
+	feedRepo repositories.FeedRepository
+	muteRepo repositories.FeedMuteRepository
+	nodeRepo repositories.NodeRepository
+	rules    map[string]models.FeedAggregationRule
+}
+
+// NewFeedService crea una nueva instancia de FeedService.
+// Si rules es nil se usan las reglas de DefaultFeedAggregationRules.
+func NewFeedService(
+	feedRepo repositories.FeedRepository,
+	muteRepo repositories.FeedMuteRepository,
+	nodeRepo repositories.NodeRepository,
+	rules map[string]models.FeedAggregationRule,
+) *FeedService {
+	if rules == nil {
+		rules = DefaultFeedAggregationRules()
+	}
+	return &FeedService{
+		feedRepo: feedRepo,
+		muteRepo: muteRepo,
+		nodeRepo: nodeRepo,
+		rules:    rules,
+	}
+}
+
+// SetAggregationRule configura la regla de agregación de un tipo de item.
+// Una ventana de cero desactiva la agregación para ese tipo.
+func (s *FeedService) SetAggregationRule(itemType string, rule models.FeedAggregationRule) {
+	if rule.Window <= 0 {
+		delete(s.rules, itemType)
+		return
+	}
+	s.rules[itemType] = rule
+}
+
+// GetFeed obtiene una página del feed de viewerID con la actividad similar agrupada
+// y sin el contenido que el usuario silenció. Cada grupo se muestra completo en la página
+// de su item más reciente; el cursor es la posición (created_at, id) del último item de la
+// página, y las páginas siguientes omiten los grupos que ya tenían items antes del cursor.
+func (s *FeedService) GetFeed(ctx context.Context, viewerID string, filters models.FeedFilters) (*models.FeedPage, error) {
+	limit := filters.PageSize
+	if limit <= 0 {
+		limit = defaultFeedPageSize
+	}
+	if limit > maxFeedPageSize {
+		limit = maxFeedPageSize
+	}
+
+	mutes, err := s.GetMuteSet(ctx, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make(map[string]*models.Node)
+
+	batch := filters
+	batch.PageSize = limit
+	batch.LastID = ""
+	shown := make(map[string]bool)
+	if filters.Cursor != "" {
+		cursor, err := parseFeedCursor(filters.Cursor)
+		if err != nil {
+			return nil, errors.NewValidationError("cursor de feed inválido", err)
+		}
+		batch.EndTime, batch.LastID = cursor.createdAt, cursor.id
+		if shown, err = s.shownGroups(ctx, filters, cursor, mutes, nodes); err != nil {
+			return nil, err
+		}
+	}
+
+	raw := make([]*models.FeedItem, 0, limit)
+	seen := make(map[string]bool)
+	add := func(items []*models.FeedItem, keep func(item *models.FeedItem) bool) {
+		for _, item := range items {
+			if seen[item.ID] || shown[s.groupID(item)] {
+				continue
+			}
+			seen[item.ID] = true
+			if keep(item) && !s.hidesItem(ctx, mutes, nodes, item) {
+				raw = append(raw, item)
+			}
+		}
+	}
+	all := func(*models.FeedItem) bool { return true }
+
+	// Leer lotes hasta tener un grupo más de los que caben en la página
+	var last *models.FeedItem
+	fetched := 0
+	exhausted := false
+	for len(s.visibleGroups(raw, mutes)) <= limit && fetched < maxRawFeedItems {
+		items, err := s.feedRepo.GetFeed(ctx, batch)
+		if err != nil {
+			return nil, fmt.Errorf("error getting feed: %v", err)
+		}
+		fetched += len(items)
+		add(items, all)
+		if len(items) < batch.PageSize {
+			exhausted = true
+			break
+		}
+		last = items[len(items)-1]
+		batch.EndTime, batch.LastID = last.CreatedAt, last.ID
+	}
+
+	groups := s.visibleGroups(raw, mutes)
+	overflow := len(groups) > limit
+	page := &models.FeedPage{Items: groups}
+	if !overflow && (exhausted || last == nil) {
+		return page, nil
+	}
+	if overflow {
+		groups = groups[:limit]
+	}
+
+	// Completar los grupos de la página con sus items más antiguos que lo leído
+	if !exhausted && last != nil {
+		selected := make(map[string]bool, len(groups))
+		oldest := last.CreatedAt
+		for _, group := range groups {
+			selected[s.groupID(group)] = true
+			if window := s.window(group); window > 0 {
+				if start := windowStart(group.CreatedAt, window); start < oldest {
+					oldest = start
+				}
+			}
+		}
+		if oldest < last.CreatedAt {
+			rest := batch
+			if oldest > rest.StartTime {
+				rest.StartTime = oldest
+			}
+			rest.PageSize = maxRawFeedItems
+			items, err := s.feedRepo.GetFeed(ctx, rest)
+			if err != nil {
+				return nil, fmt.Errorf("error getting feed: %v", err)
+			}
+			add(items, func(item *models.FeedItem) bool { return selected[s.groupID(item)] })
+
+			groups = groups[:0]
+			for _, group := range s.visibleGroups(raw, mutes) {
+				if selected[s.groupID(group)] {
+					groups = append(groups, group)
+				}
+			}
+		}
+	}
+	page.Items = groups
+
+	// El cursor es el item más reciente del último grupo si sobraron grupos, o el último
+	// item leído si se alcanzó el máximo de items por página
+	next := last
+	if overflow {
+		lastGroup := s.groupID(groups[len(groups)-1])
+		for _, item := range raw {
+			if s.groupID(item) == lastGroup {
+				next = item
+				break
+			}
+		}
+	}
+	page.NextCursor = feedCursor{createdAt: next.CreatedAt, id: next.ID}.String()
+	return page, nil
+}
+
+// shownGroups retorna los grupos que tienen items visibles más recientes que el cursor; se
+// mostraron completos en una página anterior. Solo hace falta revisar la ventana más larga
+// posterior al cursor.
+func (s *FeedService) shownGroups(ctx context.Context, filters models.FeedFilters, cursor feedCursor, mutes *models.FeedMuteSet, nodes map[string]*models.Node) (map[string]bool, error) {
+	shown := make(map[string]bool)
+	window := s.maxWindow()
+	if window <= 0 {
+		return shown, nil
+	}
+
+	recent := filters
+	recent.LastID = ""
+	recent.StartTime = cursor.createdAt
+	recent.EndTime = cursor.createdAt + window
+	recent.PageSize = maxRawFeedItems
+	items, err := s.feedRepo.GetFeed(ctx, recent)
+	if err != nil {
+		return nil, fmt.Errorf("error getting feed: %v", err)
+	}
+
+	for _, item := range items {
+		if cursor.precedes(item) || s.hidesItem(ctx, mutes, nodes, item) {
+			continue
+		}
+		if key := s.groupKey(item); key != "" {
+			shown[key] = true
+		}
+	}
+	return shown, nil
+}
+
+// visibleGroups agrupa los items y quita los grupos ocultos por su ID estable
+func (s *FeedService) visibleGroups(raw []*models.FeedItem, mutes *models.FeedMuteSet) []*models.FeedItem {
+	groups := make([]*models.FeedItem, 0, len(raw))
+	for _, item := range s.aggregate(raw) {
+		if !mutes.HidesItem(item, nil) {
+			groups = append(groups, item)
+		}
+	}
+	return groups
+}
+
+// FilterNodes elimina de nodes los nodos que userID silenció
+func (s *FeedService) FilterNodes(ctx context.Context, userID string, nodes []*models.Node) ([]*models.Node, error) {
+	mutes, err := s.GetMuteSet(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]*models.Node, 0, len(nodes))
+	for _, node := range nodes {
+		if !mutes.HidesNode(node) {
+			filtered = append(filtered, node)
+		}
+	}
+	return filtered, nil
+}
+
+// GetMuteSet obtiene los silenciamientos de un usuario. Un usuario anónimo no tiene ninguno.
+func (s *FeedService) GetMuteSet(ctx context.Context, userID string) (*models.FeedMuteSet, error) {
+	if userID == "" {
+		return models.NewFeedMuteSet(nil), nil
+	}
+	mutes, err := s.muteRepo.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting feed mutes: %v", err)
+	}
+	return models.NewFeedMuteSet(mutes), nil
+}
+
+// HideItem oculta un item del feed para un usuario
+func (s *FeedService) HideItem(ctx context.Context, userID string, itemID string) (*models.FeedMute, error) {
+	return s.Mute(ctx, userID, models.MuteFeedItem, itemID)
+}
+
+// Mute registra que un usuario no quiere ver un item, un nodo, un tipo de nodo o una etiqueta
+func (s *FeedService) Mute(ctx context.Context, userID string, muteType models.FeedMuteType, value string) (*models.FeedMute, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, errors.NewValidationError("el valor a silenciar es obligatorio", nil)
+	}
+
+	switch muteType {
+	case models.MuteFeedItem, models.MuteTag:
+	case models.MuteNode:
+		if _, err := s.nodeRepo.Get(ctx, value); err != nil {
+			return nil, errors.NewNotFoundError("nodo no encontrado")
+		}
+	case models.MuteNodeType:
+		switch models.NodeType(value) {
+		case models.Social, models.Environmental, models.Animal:
+		default:
+			return nil, errors.NewValidationError("tipo de nodo inválido", nil)
+		}
+	default:
+		return nil, errors.NewValidationError("tipo de silenciamiento inválido", nil)
+	}
+
+	mute := &models.FeedMute{
+		UserID:    userID,
+		Type:      muteType,
+		Value:     value,
+		CreatedAt: time.Now(),
+	}
+	if err := s.muteRepo.Create(ctx, mute); err != nil {
+		return nil, fmt.Errorf("error creating feed mute: %v", err)
+	}
+	return mute, nil
+}
+
+// ListMutes obtiene todo lo que un usuario silenció
+func (s *FeedService) ListMutes(ctx context.Context, userID string) ([]*models.FeedMute, error) {
+	mutes, err := s.muteRepo.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting feed mutes: %v", err)
+	}
+	return mutes, nil
+}
+
+// Unmute deshace un silenciamiento del usuario
+func (s *FeedService) Unmute(ctx context.Context, userID string, muteID string) error {
+	mute, err := s.muteRepo.Get(ctx, muteID)
+	if err != nil {
+		return errors.NewNotFoundError("silenciamiento no encontrado")
+	}
+	if mute.UserID != userID {
+		return errors.NewForbiddenError("el silenciamiento pertenece a otro usuario")
+	}
+	if err := s.muteRepo.Delete(ctx, muteID); err != nil {
+		return fmt.Errorf("error deleting feed mute: %v", err)
+	}
+	return nil
+}
+
+// hidesItem indica si el item debe ocultarse, cargando el nodo solo cuando hace falta
+func (s *FeedService) hidesItem(ctx context.Context, mutes *models.FeedMuteSet, nodes map[string]*models.Node, item *models.FeedItem) bool {
+	if mutes.HidesItem(item, nil) {
+		return true
+	}
+	if !mutes.NeedsNodeData() || item.NodeID == "" {
+		return false
+	}
+
+	node, ok := nodes[item.NodeID]
+	if !ok {
+		// Si el nodo no se puede leer se muestra el item
+		node, _ = s.nodeRepo.Get(ctx, item.NodeID)
+		nodes[item.NodeID] = node
+	}
+	return node != nil && mutes.HidesNode(node)
+}
+
+// maxWindow retorna la ventana de agregación más larga, en segundos
+func (s *FeedService) maxWindow() int64 {
+	var max int64
+	for _, rule := range s.rules {
+		if window := int64(rule.Window / time.Second); window > max {
+			max = window
+		}
+	}
+	return max
+}
+
+// window retorna la ventana en segundos con la que se agrupa el item, o 0 si no se agrupa
+func (s *FeedService) window(item *models.FeedItem) int64 {
+	rule, ok := s.rules[item.Type]
+	if !ok || item.NodeID == "" || rule.Window <= 0 {
+		return 0
+	}
+	return int64(rule.Window / time.Second)
+}
+
+// groupKey retorna la clave del grupo al que pertenece el item, o "" si no se agrupa
+func (s *FeedService) groupKey(item *models.FeedItem) string {
+	window := s.window(item)
+	if window <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s_%s_%d", item.Type, item.NodeID, windowStart(item.CreatedAt, window))
+}
+
+// groupID identifica el grupo de un item crudo o agregado; los items que no se agrupan se
+// identifican por su ID
+func (s *FeedService) groupID(item *models.FeedItem) string {
+	if item.Aggregated {
+		return item.ID
+	}
+	if key := s.groupKey(item); key != "" {
+		return key
+	}
+	return item.ID
+}
+
+// aggregate agrupa los items del mismo tipo sobre el mismo nodo que caen en la misma ventana.
+// Los items deben venir ordenados del más reciente al más antiguo.
+func (s *FeedService) aggregate(items []*models.FeedItem) []*models.FeedItem {
+	result := make([]*models.FeedItem, 0, len(items))
+	groups := make(map[string]*models.FeedItem)
+	originals := make(map[string]*models.FeedItem)
+
+	for _, item := range items {
+		key := s.groupKey(item)
+		if key == "" {
+			result = append(result, item)
+			continue
+		}
+
+		rule := s.rules[item.Type]
+		group, exists := groups[key]
+		if !exists {
+			group = &models.FeedItem{
+				ID:         key,
+				Type:       item.Type,
+				NodeID:     item.NodeID,
+				UserID:     item.UserID,
+				Content:    item.Content,
+				CreatedAt:  item.CreatedAt,
+				Aggregated: true,
+			}
+			groups[key] = group
+			originals[key] = item
+			result = append(result, group)
+		}
+
+		group.Count++
+		if len(group.ActorSamples) < rule.MaxActorSamples && !containsString(group.ActorSamples, item.UserID) {
+			group.ActorSamples = append(group.ActorSamples, item.UserID)
+		}
+	}
+
+	// Un grupo con un único item se muestra como el item original
+	for i, item := range result {
+		if item.Aggregated && item.Count == 1 {
+			result[i] = originals[item.ID]
+		}
+	}
+
+	return result
+}
+
+// feedCursor es una posición del feed, ordenado por created_at e ID de forma descendente
+type feedCursor struct {
+	createdAt int64
+	id        string
+}
+
+func parseFeedCursor(value string) (feedCursor, error) {
+	createdAt, id, ok := strings.Cut(value, ":")
+	if !ok || id == "" {
+		return feedCursor{}, fmt.Errorf("expected created_at:id")
+	}
+	ts, err := strconv.ParseInt(createdAt, 10, 64)
+	if err != nil {
+		return feedCursor{}, err
+	}
+	return feedCursor{createdAt: ts, id: id}, nil
+}
+
+func (c feedCursor) String() string {
+	return fmt.Sprintf("%d:%s", c.createdAt, c.id)
+}
+
+// precedes indica si el item va después del cursor en el feed
+func (c feedCursor) precedes(item *models.FeedItem) bool {
+	return item.CreatedAt < c.createdAt || item.CreatedAt == c.createdAt && item.ID < c.id
+}
+
+func windowStart(ts, window int64) int64 {
+	start := ts - ts%window
+	if ts < 0 && ts%window != 0 {
+		start -= window
+	}
+	return start
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
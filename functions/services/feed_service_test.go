@@ -0,0 +1,211 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/kha0sys/nodo.social/functions/domain/models"
+)
+
+// memoryFeedRepository es un FeedRepository en memoria que ordena y pagina como Firestore:
+// por created_at y luego por ID, de forma descendente
+type memoryFeedRepository struct {
+	items []*models.FeedItem
+}
+
+func (r *memoryFeedRepository) Create(ctx context.Context, item *models.FeedItem) error {
+	r.items = append(r.items, item)
+	return nil
+}
+
+func (r *memoryFeedRepository) Delete(ctx context.Context, itemID string) error {
+	return nil
+}
+
+func (r *memoryFeedRepository) GetUserFeed(ctx context.Context, userID string, limit int, lastTimestamp int64) ([]*models.FeedItem, error) {
+	return nil, nil
+}
+
+func (r *memoryFeedRepository) DeleteByNodeID(ctx context.Context, nodeID string) error {
+	return nil
+}
+
+func (r *memoryFeedRepository) UpdateMetrics(ctx context.Context, nodeID string, metrics models.InteractionMetrics) error {
+	return nil
+}
+
+func (r *memoryFeedRepository) GetFeed(ctx context.Context, filters models.FeedFilters) ([]*models.FeedItem, error) {
+	sorted := append([]*models.FeedItem(nil), r.items...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].CreatedAt != sorted[j].CreatedAt {
+			return sorted[i].CreatedAt > sorted[j].CreatedAt
+		}
+		return sorted[i].ID > sorted[j].ID
+	})
+
+	paged := filters.EndTime > 0 && filters.LastID != ""
+	after := feedCursor{createdAt: filters.EndTime, id: filters.LastID}
+	items := make([]*models.FeedItem, 0)
+	for _, item := range sorted {
+		switch {
+		case filters.NodeID != "" && item.NodeID != filters.NodeID,
+			filters.Type != "" && item.Type != filters.Type,
+			paged && !after.precedes(item),
+			!paged && filters.EndTime > 0 && item.CreatedAt >= filters.EndTime,
+			filters.StartTime > 0 && item.CreatedAt < filters.StartTime:
+			continue
+		}
+		copied := *item
+		items = append(items, &copied)
+		if filters.PageSize > 0 && len(items) == filters.PageSize {
+			break
+		}
+	}
+	return items, nil
+}
+
+// feedPages recorre el feed siguiendo los cursores y retorna cada página con sus items
+// como "ID" o, los agrupados, como "ID×cantidad"
+func feedPages(t *testing.T, service *FeedService, limit int) [][]string {
+	t.Helper()
+
+	var pages [][]string
+	cursor := ""
+	for len(pages) < 20 {
+		page, err := service.GetFeed(context.Background(), "", models.FeedFilters{PageSize: limit, Cursor: cursor})
+		if err != nil {
+			t.Fatalf("error inesperado: %v", err)
+		}
+
+		ids := make([]string, 0, len(page.Items))
+		for _, item := range page.Items {
+			if item.Aggregated {
+				ids = append(ids, fmt.Sprintf("%s×%d", item.ID, item.Count))
+			} else {
+				ids = append(ids, item.ID)
+			}
+		}
+		pages = append(pages, ids)
+
+		if page.NextCursor == "" {
+			return pages
+		}
+		cursor = page.NextCursor
+	}
+	t.Fatal("el feed no terminó")
+	return nil
+}
+
+func TestGetFeedPaging(t *testing.T) {
+	post := func(id string, createdAt int64) *models.FeedItem {
+		return &models.FeedItem{ID: id, Type: models.FeedItemNodeCreated, NodeID: "post_" + id, UserID: "u_" + id, CreatedAt: createdAt}
+	}
+	follow := func(id string, nodeID string, createdAt int64) *models.FeedItem {
+		return &models.FeedItem{ID: id, Type: models.FeedItemNodeFollowed, NodeID: nodeID, UserID: "u_" + id, CreatedAt: createdAt}
+	}
+	const hour = 3600
+
+	tests := []struct {
+		name  string
+		items []*models.FeedItem
+		limit int
+		want  [][]string
+	}{
+		{
+			name:  "feed vacío",
+			limit: 2,
+			want:  [][]string{{}},
+		},
+		{
+			name: "mismo created_at se desempata por ID",
+			items: []*models.FeedItem{
+				post("a", 1000), post("b", 1000), post("c", 1000), post("d", 1000), post("e", 1000),
+			},
+			limit: 2,
+			want:  [][]string{{"e", "d"}, {"c", "b"}, {"a"}},
+		},
+		{
+			name: "el grupo se muestra completo en la página de su item más reciente",
+			items: []*models.FeedItem{
+				post("p1", 2*hour+3000),
+				follow("f1", "n1", 2*hour+2900),
+				post("p2", 2*hour+2800),
+				post("p3", 2*hour+2700),
+				follow("f2", "n1", 2*hour+100),
+				post("p4", 2*hour+50),
+			},
+			limit: 2,
+			want:  [][]string{{"p1", "node_followed_n1_7200×2"}, {"p2", "p3"}, {"p4"}},
+		},
+		{
+			name: "grupo con el mismo created_at que el cursor",
+			items: []*models.FeedItem{
+				follow("a", "n1", 5*hour+10),
+				post("b", 5*hour+10),
+				follow("c", "n1", 5*hour+10),
+				post("d", 5*hour+10),
+			},
+			limit: 1,
+			want:  [][]string{{"d"}, {"node_followed_n1_18000×2"}, {"b"}},
+		},
+		{
+			name: "ventanas distintas del mismo nodo son grupos distintos",
+			items: []*models.FeedItem{
+				follow("f1", "n1", 3*hour+20),
+				follow("f2", "n1", 3*hour+10),
+				follow("f3", "n1", 2*hour+3500),
+				follow("f4", "n1", 2*hour+3400),
+				post("p1", 2*hour+3300),
+			},
+			limit: 1,
+			want:  [][]string{{"node_followed_n1_10800×2"}, {"node_followed_n1_7200×2"}, {"p1"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &memoryFeedRepository{items: tt.items}
+			service := NewFeedService(repo, nil, nil, nil)
+
+			got := feedPages(t, service, tt.limit)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("páginas %q, se esperaba %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFeedCursor(t *testing.T) {
+	cursor, err := parseFeedCursor("1000:b")
+	if err != nil {
+		t.Fatalf("error inesperado: %v", err)
+	}
+	if cursor.String() != "1000:b" {
+		t.Fatalf("cursor %q, se esperaba 1000:b", cursor.String())
+	}
+
+	tests := []struct {
+		item *models.FeedItem
+		want bool
+	}{
+		{&models.FeedItem{ID: "a", CreatedAt: 1000}, true},
+		{&models.FeedItem{ID: "b", CreatedAt: 1000}, false},
+		{&models.FeedItem{ID: "c", CreatedAt: 1000}, false},
+		{&models.FeedItem{ID: "z", CreatedAt: 999}, true},
+		{&models.FeedItem{ID: "a", CreatedAt: 1001}, false},
+	}
+	for _, tt := range tests {
+		if got := cursor.precedes(tt.item); got != tt.want {
+			t.Errorf("precedes(%d:%s) = %v, se esperaba %v", tt.item.CreatedAt, tt.item.ID, got, tt.want)
+		}
+	}
+
+	for _, value := range []string{"", "1000", "1000:", "abc:b"} {
+		if _, err := parseFeedCursor(value); err == nil {
+			t.Errorf("parseFeedCursor(%q) no falló", value)
+		}
+	}
+}
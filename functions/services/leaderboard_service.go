@@ -0,0 +1,284 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kha0sys/nodo.social/functions/domain/errors"
+	"github.com/kha0sys/nodo.social/functions/domain/models"
+	"github.com/kha0sys/nodo.social/functions/domain/repositories"
+)
+
+const (
+	defaultLeaderboardSize      = 10
+	maxLeaderboardSize          = 100
+	defaultLeaderboardNeighbors = 2
+	maxLeaderboardNeighbors     = 10
+	leaderboardUserPageSize     = 200
+)
+
+// leaderboardNodeTypes son los tipos de causa que tienen su propio ranking
+var leaderboardNodeTypes = []models.NodeType{models.Social, models.Environmental, models.Animal}
+
+// LeaderboardService precalcula los rankings de puntos y los consulta
+type LeaderboardService struct {
+	pointsRepo      repositories.PointsRepository
+	userRepo        repositories.UserRepository
+	nodeRepo        repositories.NodeRepository
+	leaderboardRepo repositories.LeaderboardRepository
+	levelSvc        *LevelService
+}
+
+// NewLeaderboardService crea una nueva instancia de LeaderboardService
+func NewLeaderboardService(
+	pointsRepo repositories.PointsRepository,
+	userRepo repositories.UserRepository,
+	nodeRepo repositories.NodeRepository,
+	leaderboardRepo repositories.LeaderboardRepository,
+	levelSvc *LevelService,
+) *LeaderboardService {
+	return &LeaderboardService{
+		pointsRepo:      pointsRepo,
+		userRepo:        userRepo,
+		nodeRepo:        nodeRepo,
+		leaderboardRepo: leaderboardRepo,
+		levelSvc:        levelSvc,
+	}
+}
+
+// Recompute recalcula desde el historial de puntos los rankings de cada ventana, el global
+// y el de cada tipo de causa. Un usuario figura en el ranking de un tipo si creó algún nodo
+// de ese tipo, y en el de una ventana solo si ganó puntos en ella.
+func (s *LeaderboardService) Recompute(ctx context.Context, now time.Time) error {
+	scores := make(map[string]map[string]int, len(models.LeaderboardWindows))
+	for _, window := range models.LeaderboardWindows {
+		var err error
+		if window == models.LeaderboardAllTime {
+			scores[window], err = s.pointsRepo.Totals(ctx)
+		} else {
+			scores[window], err = s.pointsRepo.SumSince(ctx, models.LeaderboardWindowStart(window, now))
+		}
+		if err != nil {
+			return fmt.Errorf("error summing %s points: %v", window, err)
+		}
+	}
+
+	curve, err := s.levelSvc.Curve(ctx)
+	if err != nil {
+		return err
+	}
+
+	boards := make(map[string][]*models.LeaderboardEntry)
+	nodeTypes := make(map[string]models.NodeType)
+	startAfter := ""
+	for {
+		users, err := s.userRepo.List(ctx, startAfter, leaderboardUserPageSize)
+		if err != nil {
+			return fmt.Errorf("error listing users: %v", err)
+		}
+
+		for _, user := range users {
+			if scores[models.LeaderboardAllTime][user.ID] <= 0 {
+				continue
+			}
+			types := s.userNodeTypes(ctx, user, nodeTypes)
+			level, _ := curve.ForPoints(scores[models.LeaderboardAllTime][user.ID])
+			for _, window := range models.LeaderboardWindows {
+				points := scores[window][user.ID]
+				if points <= 0 {
+					continue
+				}
+				scopes := []string{models.LeaderboardScopeGlobal}
+				for _, nodeType := range types {
+					scopes = append(scopes, models.LeaderboardScopeForType(nodeType))
+				}
+				for _, scope := range scopes {
+					boardID := models.LeaderboardID(window, scope)
+					boards[boardID] = append(boards[boardID], &models.LeaderboardEntry{
+						UserID:      user.ID,
+						DisplayName: user.DisplayName,
+						PhotoURL:    user.PhotoURL,
+						Points:      points,
+						Level:       level.Number,
+						Highlighted: level.Perks.HighlightedProfile,
+					})
+				}
+			}
+		}
+
+		if len(users) < leaderboardUserPageSize {
+			break
+		}
+		startAfter = users[len(users)-1].ID
+	}
+
+	// Se guardan también los rankings vacíos para descartar las posiciones de la ventana anterior
+	for _, window := range models.LeaderboardWindows {
+		scopes := []string{models.LeaderboardScopeGlobal}
+		for _, nodeType := range leaderboardNodeTypes {
+			scopes = append(scopes, models.LeaderboardScopeForType(nodeType))
+		}
+		for _, scope := range scopes {
+			board := &models.Leaderboard{
+				ID:          models.LeaderboardID(window, scope),
+				Window:      window,
+				Scope:       scope,
+				WindowStart: models.LeaderboardWindowStart(window, now),
+				ComputedAt:  now,
+			}
+			entries := boards[board.ID]
+			models.RankLeaderboard(board.ID, entries)
+			board.Size = len(entries)
+			if err := s.leaderboardRepo.Replace(ctx, board, entries); err != nil {
+				return fmt.Errorf("error saving leaderboard %s: %v", board.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// userNodeTypes obtiene los tipos de los nodos creados por el usuario. cache guarda el tipo
+// de cada nodo ya consultado.
+func (s *LeaderboardService) userNodeTypes(ctx context.Context, user *models.User, cache map[string]models.NodeType) []models.NodeType {
+	seen := make(map[models.NodeType]bool)
+	types := make([]models.NodeType, 0)
+	for _, nodeID := range user.Nodes {
+		nodeType, ok := cache[nodeID]
+		if !ok {
+			node, err := s.nodeRepo.Get(ctx, nodeID)
+			if err != nil {
+				fmt.Printf("error getting node %s for leaderboard: %v\n", nodeID, err)
+				continue
+			}
+			nodeType = node.Type
+			cache[nodeID] = nodeType
+		}
+		if nodeType != "" && !seen[nodeType] {
+			seen[nodeType] = true
+			types = append(types, nodeType)
+		}
+	}
+	return types
+}
+
+// Get obtiene los primeros limit puestos de un ranking y la posición del usuario con
+// neighbors puestos antes y después. El alcance "following" se arma al consultar con el
+// ranking global de los usuarios que sigue y el suyo.
+func (s *LeaderboardService) Get(ctx context.Context, userID string, window string, scope string, limit int, neighbors int) (*models.LeaderboardPage, error) {
+	if err := models.ValidateLeaderboard(window, scope); err != nil {
+		return nil, errors.NewValidationError(err.Error(), err)
+	}
+	if limit <= 0 {
+		limit = defaultLeaderboardSize
+	}
+	if limit > maxLeaderboardSize {
+		limit = maxLeaderboardSize
+	}
+	if neighbors < 0 {
+		neighbors = defaultLeaderboardNeighbors
+	}
+	if neighbors > maxLeaderboardNeighbors {
+		neighbors = maxLeaderboardNeighbors
+	}
+
+	if scope == models.LeaderboardScopeFollowing {
+		return s.followingPage(ctx, userID, window, limit, neighbors)
+	}
+
+	boardID := models.LeaderboardID(window, scope)
+	board, err := s.leaderboardRepo.Get(ctx, boardID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting leaderboard: %v", err)
+	}
+	top, err := s.leaderboardRepo.Top(ctx, boardID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error getting leaderboard top: %v", err)
+	}
+	page := &models.LeaderboardPage{
+		Window:     window,
+		Scope:      scope,
+		ComputedAt: board.ComputedAt,
+		Size:       board.Size,
+		Top:        top,
+		Neighbors:  make([]*models.LeaderboardEntry, 0),
+	}
+
+	me, err := s.leaderboardRepo.GetEntry(ctx, boardID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting leaderboard entry: %v", err)
+	}
+	if me == nil {
+		return page, nil
+	}
+	page.Me = me
+	if neighbors > 0 {
+		page.Neighbors, err = s.leaderboardRepo.Range(ctx, boardID, me.Index-neighbors, me.Index+neighbors)
+		if err != nil {
+			return nil, fmt.Errorf("error getting leaderboard neighbors: %v", err)
+		}
+	}
+	return page, nil
+}
+
+// followingPage arma el ranking entre el usuario y quienes sigue
+func (s *LeaderboardService) followingPage(ctx context.Context, userID string, window string, limit int, neighbors int) (*models.LeaderboardPage, error) {
+	user, err := s.userRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user: %v", err)
+	}
+
+	seen := map[string]bool{userID: true}
+	userIDs := []string{userID}
+	for _, followedID := range user.Following {
+		if !seen[followedID] {
+			seen[followedID] = true
+			userIDs = append(userIDs, followedID)
+		}
+	}
+
+	globalID := models.LeaderboardID(window, models.LeaderboardScopeGlobal)
+	board, err := s.leaderboardRepo.Get(ctx, globalID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting leaderboard: %v", err)
+	}
+	entries, err := s.leaderboardRepo.GetEntries(ctx, globalID, userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error getting leaderboard entries: %v", err)
+	}
+	if entries == nil {
+		entries = make([]*models.LeaderboardEntry, 0)
+	}
+	models.RankLeaderboard(models.LeaderboardID(window, models.LeaderboardScopeFollowing), entries)
+
+	page := &models.LeaderboardPage{
+		Window:     window,
+		Scope:      models.LeaderboardScopeFollowing,
+		ComputedAt: board.ComputedAt,
+		Size:       len(entries),
+		Top:        entries,
+		Neighbors:  make([]*models.LeaderboardEntry, 0),
+	}
+	if len(page.Top) > limit {
+		page.Top = page.Top[:limit]
+	}
+
+	for _, entry := range entries {
+		if entry.UserID != userID {
+			continue
+		}
+		page.Me = entry
+		if neighbors > 0 {
+			from, to := entry.Index-neighbors, entry.Index+neighbors+1
+			if from < 0 {
+				from = 0
+			}
+			if to > len(entries) {
+				to = len(entries)
+			}
+			page.Neighbors = entries[from:to]
+		}
+		break
+	}
+	return page, nil
+}
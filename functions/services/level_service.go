@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kha0sys/nodo.social/functions/domain/errors"
+	"github.com/kha0sys/nodo.social/functions/domain/models"
+	"github.com/kha0sys/nodo.social/functions/domain/repositories"
+)
+
+// LevelService maneja la curva de niveles, el avance de los usuarios y sus beneficios
+type LevelService struct {
+	levelRepo       repositories.LevelRepository
+	userRepo        repositories.UserRepository
+	feedRepo        repositories.FeedRepository
+	notificationSvc *NotificationService
+}
+
+// NewLevelService crea una nueva instancia de LevelService.
+// notificationSvc puede ser nil si las subidas de nivel no se notifican.
+func NewLevelService(
+	levelRepo repositories.LevelRepository,
+	userRepo repositories.UserRepository,
+	feedRepo repositories.FeedRepository,
+	notificationSvc *NotificationService,
+) *LevelService {
+	return &LevelService{
+		levelRepo:       levelRepo,
+		userRepo:        userRepo,
+		feedRepo:        feedRepo,
+		notificationSvc: notificationSvc,
+	}
+}
+
+// Curve obtiene la curva de niveles configurada o la curva por defecto si no hay ninguna
+func (s *LevelService) Curve(ctx context.Context) (*models.LevelCurve, error) {
+	curve, err := s.levelRepo.Get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting level curve: %v", err)
+	}
+	if curve == nil {
+		return models.DefaultLevelCurve(), nil
+	}
+	return curve, nil
+}
+
+// UpdateCurve reemplaza la curva de niveles. Los niveles guardados en los usuarios se
+// ajustan a la nueva curva con su siguiente movimiento de puntos o en la reconciliación.
+func (s *LevelService) UpdateCurve(ctx context.Context, curve *models.LevelCurve) (*models.LevelCurve, error) {
+	if err := curve.Validate(); err != nil {
+		return nil, errors.NewValidationError(err.Error(), err)
+	}
+
+	curve.UpdatedAt = time.Now()
+	if err := s.levelRepo.Save(ctx, curve); err != nil {
+		return nil, fmt.Errorf("error saving level curve: %v", err)
+	}
+	return curve, nil
+}
+
+// Progress obtiene el nivel que corresponde a points y el avance hacia el siguiente
+func (s *LevelService) Progress(ctx context.Context, points int) (*models.LevelProgress, error) {
+	curve, err := s.Curve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return curve.Progress(points), nil
+}
+
+// UserProgress obtiene el nivel de un usuario y su avance hacia el siguiente
+func (s *LevelService) UserProgress(ctx context.Context, userID string) (*models.LevelProgress, error) {
+	user, err := s.userRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user: %v", err)
+	}
+	return s.Progress(ctx, user.Points)
+}
+
+// CheckNodeLimit verifica que el nivel del usuario le permita crear otro nodo
+func (s *LevelService) CheckNodeLimit(ctx context.Context, user *models.User) error {
+	curve, err := s.Curve(ctx)
+	if err != nil {
+		return err
+	}
+
+	level, _ := curve.ForPoints(user.Points)
+	if level.Perks.MaxNodes > 0 && len(user.Nodes) >= level.Perks.MaxNodes {
+		return errors.NewForbiddenError(fmt.Sprintf("el nivel %s permite crear hasta %d nodos", level.Name, level.Perks.MaxNodes))
+	}
+	return nil
+}
+
+// LevelUp avisa al usuario y publica en el feed si award lo hizo subir de nivel
+func (s *LevelService) LevelUp(ctx context.Context, userID string, award *models.PointsAward) {
+	if award == nil || award.Level <= award.PreviousLevel {
+		return
+	}
+
+	curve, err := s.Curve(ctx)
+	if err != nil {
+		fmt.Printf("error getting level curve for user %s: %v\n", userID, err)
+		return
+	}
+	level, _ := curve.ForPoints(award.Total)
+
+	feedItem := &models.FeedItem{
+		ID:        fmt.Sprintf("%s_level_%d", userID, level.Number),
+		UserID:    userID,
+		Type:      models.FeedItemLevelUp,
+		Content:   level,
+		CreatedAt: time.Now().Unix(),
+	}
+	if err := s.feedRepo.Create(ctx, feedItem); err != nil {
+		fmt.Printf("error creating level up feed item for user %s: %v\n", userID, err)
+	}
+
+	if s.notificationSvc == nil {
+		return
+	}
+	notification := &models.Notification{
+		Type:      models.NotificationLevelUp,
+		UserID:    userID,
+		Target:    fmt.Sprintf("level_%d", level.Number),
+		CreatedAt: time.Now(),
+		Data: map[string]interface{}{
+			"level":     level.Number,
+			"levelName": level.Name,
+		},
+		Params: map[string]interface{}{
+			"level": level.Number,
+			"name":  level.Name,
+		},
+	}
+	if err := s.notificationSvc.SendNotification(ctx, notification); err != nil {
+		fmt.Printf("error sending level up notification to user %s: %v\n", userID, err)
+	}
+}
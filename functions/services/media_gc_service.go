@@ -0,0 +1,240 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/kha0sys/nodo.social/functions/domain/errors"
+	"github.com/kha0sys/nodo.social/functions/domain/models"
+	"github.com/kha0sys/nodo.social/functions/domain/repositories"
+)
+
+const (
+	maxMediaGCList = 50
+	// maxMediaGCDeletes es la cantidad máxima de archivos que elimina una ejecución; el
+	// resto sigue marcado para la siguiente
+	maxMediaGCDeletes = 1000
+	// maxMediaGCOrphans es la cantidad de rutas huérfanas que se guardan en el reporte
+	maxMediaGCOrphans = 200
+)
+
+// MediaGCConfig contiene la configuración de la recolección de archivos huérfanos
+type MediaGCConfig struct {
+	// Prefixes son los prefijos del bucket que se recorren
+	Prefixes []string
+	// GracePeriod es la antigüedad mínima de un archivo sin referencias para marcarlo. Da
+	// tiempo a que el cliente guarde la URL de un archivo recién subido.
+	GracePeriod time.Duration
+	// DeleteDelay es el tiempo mínimo entre que un archivo se marca y se elimina
+	DeleteDelay time.Duration
+	// DryRun hace que las recolecciones programadas solo reporten lo que harían
+	DryRun bool
+}
+
+// MediaGCService elimina del storage los archivos que quedaron sin referencias, como las
+// imágenes de un nodo eliminado o las que se quitaron de un nodo o producto
+type MediaGCService struct {
+	gcRepo  repositories.MediaGCRepository
+	storage repositories.StorageRepository
+	config  MediaGCConfig
+}
+
+// NewMediaGCService crea una nueva instancia de MediaGCService
+func NewMediaGCService(gcRepo repositories.MediaGCRepository, storage repositories.StorageRepository, config MediaGCConfig) *MediaGCService {
+	return &MediaGCService{
+		gcRepo:  gcRepo,
+		storage: storage,
+		config:  config,
+	}
+}
+
+// Collect ejecuta la recolección programada, en simulación si así está configurada
+func (s *MediaGCService) Collect(ctx context.Context) (*models.MediaGCRun, error) {
+	run, err := s.Start(ctx, "scheduler", s.config.DryRun)
+	if err != nil {
+		return nil, err
+	}
+	if run.Status == models.MediaGCFailed {
+		return run, fmt.Errorf("media gc run %s failed: %s", run.ID, run.LastError)
+	}
+	return run, nil
+}
+
+// Start recorre el bucket y compara cada archivo con las URLs guardadas en Firestore. Los
+// archivos sin referencias con más antigüedad que el período de gracia se marcan; los que
+// ya estaban marcados desde hace DeleteDelay se eliminan, y su evento de eliminación borra
+// sus thumbnails y los resta de la cuota de su usuario. En dryRun no cambia nada.
+func (s *MediaGCService) Start(ctx context.Context, createdBy string, dryRun bool) (*models.MediaGCRun, error) {
+	now := time.Now()
+	run := &models.MediaGCRun{
+		DryRun:    dryRun,
+		Status:    models.MediaGCRunning,
+		Prefixes:  s.config.Prefixes,
+		Orphans:   make([]string, 0),
+		CreatedBy: createdBy,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.gcRepo.CreateRun(ctx, run); err != nil {
+		return nil, fmt.Errorf("error creating media gc run: %v", err)
+	}
+
+	run.Status = models.MediaGCCompleted
+	if err := s.collect(ctx, run, now); err != nil {
+		run.Status = models.MediaGCFailed
+		run.LastError = err.Error()
+	}
+
+	completedAt := time.Now()
+	run.CompletedAt = &completedAt
+	run.UpdatedAt = completedAt
+	if err := s.gcRepo.SaveRun(ctx, run); err != nil {
+		return nil, fmt.Errorf("error saving media gc run: %v", err)
+	}
+
+	log.Printf("media gc run %s: status=%s dry_run=%t duration=%s references=%d scanned=%d scanned_bytes=%d referenced=%d recent=%d marked=%d marked_bytes=%d pending=%d unmarked=%d deleted=%d deleted_bytes=%d failed=%d",
+		run.ID, run.Status, run.DryRun, completedAt.Sub(now).Round(time.Millisecond), run.References,
+		run.Scanned, run.ScannedBytes, run.Referenced, run.Recent, run.Marked, run.MarkedBytes,
+		run.Pending, run.Unmarked, run.Deleted, run.DeletedBytes, run.Failed)
+	return run, nil
+}
+
+// List obtiene las últimas recolecciones
+func (s *MediaGCService) List(ctx context.Context) ([]*models.MediaGCRun, error) {
+	runs, err := s.gcRepo.ListRuns(ctx, maxMediaGCList)
+	if err != nil {
+		return nil, fmt.Errorf("error listing media gc runs: %v", err)
+	}
+	return runs, nil
+}
+
+// Get obtiene el reporte de una recolección
+func (s *MediaGCService) Get(ctx context.Context, runID string) (*models.MediaGCRun, error) {
+	return s.gcRepo.GetRun(ctx, runID)
+}
+
+func (s *MediaGCService) collect(ctx context.Context, run *models.MediaGCRun, now time.Time) error {
+	referenced := make(map[string]bool)
+	err := s.gcRepo.ForEachReference(ctx, func(mediaURL string) {
+		if filePath, ok := s.storage.PathFromURL(mediaURL); ok {
+			referenced[filePath] = true
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("error collecting references: %v", err)
+	}
+	run.References = len(referenced)
+
+	marks, err := s.gcRepo.ListMarks(ctx)
+	if err != nil {
+		return fmt.Errorf("error listing orphaned media: %v", err)
+	}
+
+	graceCutoff := now.Add(-s.config.GracePeriod)
+	deleteCutoff := now.Add(-s.config.DeleteDelay)
+	for _, prefix := range s.config.Prefixes {
+		err := s.storage.List(ctx, prefix, func(object repositories.StoredObject) error {
+			// Los thumbnails se eliminan con su original
+			if isThumbnailPath(object.Path) {
+				return nil
+			}
+			run.Scanned++
+			run.ScannedBytes += object.Size
+
+			mark := marks[object.Path]
+			delete(marks, object.Path)
+
+			switch {
+			case referenced[object.Path]:
+				run.Referenced++
+				if mark != nil {
+					run.Unmarked++
+					return s.unmark(ctx, run, object.Path)
+				}
+			case object.Created.After(graceCutoff):
+				run.Recent++
+			case mark == nil:
+				run.Marked++
+				run.MarkedBytes += object.Size
+				addMediaGCOrphan(run, object.Path)
+				if !run.DryRun {
+					orphan := &models.OrphanedMedia{Path: object.Path, Size: object.Size, RunID: run.ID, MarkedAt: now}
+					if err := s.gcRepo.Mark(ctx, orphan); err != nil {
+						return fmt.Errorf("error marking %s: %v", object.Path, err)
+					}
+				}
+			case mark.MarkedAt.After(deleteCutoff) || run.Deleted+run.Failed >= maxMediaGCDeletes:
+				run.Pending++
+			default:
+				return s.delete(ctx, run, object)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Las marcas que quedan son de archivos que ya no existen
+	for markedPath := range marks {
+		if hasAnyPrefix(markedPath, s.config.Prefixes) {
+			if err := s.unmark(ctx, run, markedPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// delete elimina un archivo marcado. Un fallo se cuenta y no detiene la recolección.
+func (s *MediaGCService) delete(ctx context.Context, run *models.MediaGCRun, object repositories.StoredObject) error {
+	if !run.DryRun {
+		if err := s.storage.Delete(ctx, object.Path); err != nil {
+			if _, _, statErr := s.storage.Stat(ctx, object.Path); !errors.IsDomainError(statErr) {
+				run.Failed++
+				run.LastError = fmt.Sprintf("error deleting %s: %v", object.Path, err)
+				return nil
+			}
+		}
+	}
+
+	run.Deleted++
+	run.DeletedBytes += object.Size
+	addMediaGCOrphan(run, object.Path)
+	return s.unmark(ctx, run, object.Path)
+}
+
+func (s *MediaGCService) unmark(ctx context.Context, run *models.MediaGCRun, filePath string) error {
+	if run.DryRun {
+		return nil
+	}
+	if err := s.gcRepo.Unmark(ctx, filePath); err != nil {
+		return fmt.Errorf("error unmarking %s: %v", filePath, err)
+	}
+	return nil
+}
+
+// addMediaGCOrphan agrega una ruta huérfana al reporte si aún hay lugar
+func addMediaGCOrphan(run *models.MediaGCRun, filePath string) {
+	if len(run.Orphans) < maxMediaGCOrphans {
+		run.Orphans = append(run.Orphans, filePath)
+	}
+}
+
+// isThumbnailPath indica si la ruta es de un thumbnail generado
+func isThumbnailPath(filePath string) bool {
+	return path.Base(path.Dir(filePath)) == "thumbnails"
+}
+
+func hasAnyPrefix(value string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+	return false
+}
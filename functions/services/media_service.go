@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kha0sys/nodo.social/functions/domain/models"
+	"github.com/kha0sys/nodo.social/functions/domain/repositories"
+	"github.com/kha0sys/nodo.social/functions/internal/imageprocessor"
+)
+
+// MediaService guarda las dimensiones, el placeholder y los thumbnails de las imágenes
+// subidas y los agrega a los recursos de nodos y productos
+type MediaService struct {
+	mediaRepo repositories.MediaRepository
+}
+
+// NewMediaService crea una nueva instancia de MediaService
+func NewMediaService(mediaRepo repositories.MediaRepository) *MediaService {
+	return &MediaService{
+		mediaRepo: mediaRepo,
+	}
+}
+
+// Record guarda los datos de una imagen procesada con las URLs de sus thumbnails, en el
+// mismo orden que processed.Thumbnails, y los retorna. Como miniatura principal se usa el
+// thumbnail más pequeño en el formato original.
+func (s *MediaService) Record(ctx context.Context, imageURL string, processed *imageprocessor.ProcessedImage, thumbnailURLs []string) (*models.MediaURL, error) {
+	media := &models.MediaURL{
+		URL:           imageURL,
+		Type:          "image",
+		Width:         processed.Width,
+		Height:        processed.Height,
+		BlurHash:      processed.BlurHash,
+		DominantColor: processed.DominantColor,
+		Variants:      make([]models.MediaVariant, 0, len(processed.Thumbnails)),
+	}
+
+	smallest := 0
+	for i, thumbnail := range processed.Thumbnails {
+		media.Variants = append(media.Variants, models.MediaVariant{
+			Size:   thumbnail.Size,
+			Format: thumbnail.Format,
+			URL:    thumbnailURLs[i],
+			Width:  thumbnail.Width,
+			Height: thumbnail.Height,
+		})
+		if thumbnail.Format == processed.Format && (media.Thumbnail == "" || thumbnail.Width < smallest) {
+			media.Thumbnail = thumbnailURLs[i]
+			smallest = thumbnail.Width
+		}
+	}
+
+	if err := s.mediaRepo.Save(ctx, media); err != nil {
+		return nil, fmt.Errorf("error saving media: %v", err)
+	}
+	return media, nil
+}
+
+// BeginRewrite registra que la imagen original se va a reemplazar por una copia con el hash
+// MD5 indicado, en base64
+func (s *MediaService) BeginRewrite(ctx context.Context, imageURL string, md5 string) error {
+	if err := s.mediaRepo.SaveRewrite(ctx, imageURL, models.MediaRewrite{MD5: md5}); err != nil {
+		return fmt.Errorf("error saving media rewrite: %v", err)
+	}
+	return nil
+}
+
+// FinishRewrite registra la versión del storage que escribió el reemplazo
+func (s *MediaService) FinishRewrite(ctx context.Context, imageURL string, md5 string, generation int64) error {
+	if err := s.mediaRepo.SaveRewrite(ctx, imageURL, models.MediaRewrite{MD5: md5, Generation: generation}); err != nil {
+		return fmt.Errorf("error saving media rewrite: %v", err)
+	}
+	return nil
+}
+
+// IsRewrite indica si la versión de la imagen la escribió el reemplazo registrado. Mientras
+// el reemplazo no termina de registrarse su evento puede llegar antes que la versión; en ese
+// caso se compara el hash del contenido.
+func (s *MediaService) IsRewrite(ctx context.Context, imageURL string, generation int64, md5 string) (bool, error) {
+	rewrite, err := s.mediaRepo.GetRewrite(ctx, imageURL)
+	if err != nil {
+		return false, fmt.Errorf("error getting media rewrite: %v", err)
+	}
+	if rewrite == nil {
+		return false, nil
+	}
+	if rewrite.Generation != 0 {
+		return rewrite.Generation == generation, nil
+	}
+	return rewrite.MD5 != "" && rewrite.MD5 == md5, nil
+}
+
+// Forget elimina los datos de una imagen borrada
+func (s *MediaService) Forget(ctx context.Context, imageURL string) error {
+	if err := s.mediaRepo.Delete(ctx, imageURL); err != nil {
+		return fmt.Errorf("error deleting media: %v", err)
+	}
+	return nil
+}
+
+// Describe retorna los recursos de las URLs indicadas, en el mismo orden y sin repetir,
+// con sus dimensiones y placeholder si ya se procesaron
+func (s *MediaService) Describe(ctx context.Context, urls []string) ([]models.MediaURL, error) {
+	known, err := s.mediaRepo.GetByURLs(ctx, urls)
+	if err != nil {
+		return nil, fmt.Errorf("error getting media: %v", err)
+	}
+
+	media := make([]models.MediaURL, 0, len(urls))
+	seen := make(map[string]bool)
+	for _, mediaURL := range urls {
+		if seen[mediaURL] {
+			continue
+		}
+		seen[mediaURL] = true
+		if item, ok := known[mediaURL]; ok {
+			media = append(media, *item)
+			continue
+		}
+		media = append(media, models.MediaURL{URL: mediaURL, Type: "image"})
+	}
+	return media, nil
+}
@@ -6,27 +6,51 @@ import (
 	"time"
 
 	"github.com/kha0sys/nodo.social/functions/domain/dto"
+	"github.com/kha0sys/nodo.social/functions/domain/errors"
 	"github.com/kha0sys/nodo.social/functions/domain/models"
 	"github.com/kha0sys/nodo.social/functions/domain/repositories"
 )
 
+const (
+	// nodeFeedSize es el número de nodos del feed de nodos populares
+	nodeFeedSize = 20
+	// maxNodeFeedFetch es el máximo de nodos que se leen para completar el feed cuando el
+	// usuario silenció parte de ellos
+	maxNodeFeedFetch = 160
+)
+
 // NodeService maneja la lógica de negocio relacionada con nodos
 type NodeService struct {
-	nodeRepo repositories.NodeRepository
-	userRepo repositories.UserRepository
-	feedRepo repositories.FeedRepository
+	nodeRepo   repositories.NodeRepository
+	userRepo   repositories.UserRepository
+	feedRepo   repositories.FeedRepository
+	feedSvc     *FeedService
+	webhookSvc  *WebhookService
+	activitySvc *ActivityService
+	levelSvc    *LevelService
 }
 
-// NewNodeService crea una nueva instancia de NodeService
+// NewNodeService crea una nueva instancia de NodeService.
+// webhookSvc puede ser nil si no se envían eventos a los webhooks de los nodos, y
+// activitySvc si los seguimientos no cuentan para rachas y retos, y levelSvc si el nivel
+// del usuario no limita los nodos que puede crear.
 func NewNodeService(
 	nodeRepo repositories.NodeRepository,
 	userRepo repositories.UserRepository,
 	feedRepo repositories.FeedRepository,
+	feedSvc *FeedService,
+	webhookSvc *WebhookService,
+	activitySvc *ActivityService,
+	levelSvc *LevelService,
 ) *NodeService {
 	return &NodeService{
-		nodeRepo: nodeRepo,
-		userRepo: userRepo,
-		feedRepo: feedRepo,
+		nodeRepo:    nodeRepo,
+		userRepo:    userRepo,
+		feedRepo:    feedRepo,
+		feedSvc:     feedSvc,
+		webhookSvc:  webhookSvc,
+		activitySvc: activitySvc,
+		levelSvc:    levelSvc,
 	}
 }
 
@@ -42,6 +66,17 @@ func (s *NodeService) CreateNode(ctx context.Context, nodeDTO dto.NodeDTO) (*mod
 		UpdatedAt:   nodeDTO.UpdatedAt,
 	}
 
+	user, err := s.userRepo.Get(ctx, node.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user: %v", err)
+	}
+	// El nivel del usuario limita cuántos nodos puede crear
+	if s.levelSvc != nil {
+		if err := s.levelSvc.CheckNodeLimit(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := s.nodeRepo.Create(ctx, node); err != nil {
 		return nil, fmt.Errorf("error creating node: %v", err)
 	}
@@ -59,10 +94,6 @@ func (s *NodeService) CreateNode(ctx context.Context, nodeDTO dto.NodeDTO) (*mod
 	}
 
 	// Actualizar lista de nodos del usuario
-	user, err := s.userRepo.Get(ctx, node.UserID)
-	if err != nil {
-		return nil, fmt.Errorf("error getting user: %v", err)
-	}
 	user.Nodes = append(user.Nodes, node.ID)
 	if err := s.userRepo.Update(ctx, user); err != nil {
 		return nil, fmt.Errorf("error updating user: %v", err)
@@ -80,13 +111,27 @@ func (s *NodeService) GetNode(ctx context.Context, nodeID string) (*models.Node,
 	return node, nil
 }
 
-// GetNodeFeed obtiene el feed de nodos
-func (s *NodeService) GetNodeFeed(ctx context.Context) ([]*models.Node, error) {
-	nodes, err := s.nodeRepo.GetPopularNodes(ctx, 20)
-	if err != nil {
-		return nil, fmt.Errorf("error getting recent nodes: %v", err)
+// GetNodeFeed obtiene el feed de nodos, omitiendo los que userID silenció. Si lo silenciado
+// deja el feed incompleto, se leen más nodos populares hasta completarlo.
+// Un userID vacío retorna el feed sin filtrar.
+func (s *NodeService) GetNodeFeed(ctx context.Context, userID string) ([]*models.Node, error) {
+	for fetch := nodeFeedSize; ; fetch *= 2 {
+		nodes, err := s.nodeRepo.GetPopularNodes(ctx, fetch)
+		if err != nil {
+			return nil, fmt.Errorf("error getting recent nodes: %v", err)
+		}
+
+		filtered, err := s.feedSvc.FilterNodes(ctx, userID, nodes)
+		if err != nil {
+			return nil, err
+		}
+		if len(filtered) >= nodeFeedSize {
+			return filtered[:nodeFeedSize], nil
+		}
+		if len(nodes) < fetch || fetch >= maxNodeFeedFetch {
+			return filtered, nil
+		}
 	}
-	return nodes, nil
 }
 
 // FollowNode permite a un usuario seguir un nodo
@@ -123,6 +168,49 @@ func (s *NodeService) FollowNode(ctx context.Context, nodeID string, userID stri
 		return fmt.Errorf("error updating user: %v", err)
 	}
 
+	// Registrar la actividad en el feed; se agrupa al leer el feed
+	now := time.Now().Unix()
+	feedItem := &models.FeedItem{
+		ID:        fmt.Sprintf("%s_follow_%s_%d", nodeID, userID, now),
+		UserID:    userID,
+		NodeID:    nodeID,
+		Type:      models.FeedItemNodeFollowed,
+		Content:   map[string]interface{}{"title": node.Title},
+		CreatedAt: now,
+	}
+	if err := s.feedRepo.Create(ctx, feedItem); err != nil {
+		return fmt.Errorf("error creating feed item: %v", err)
+	}
+
+	if s.webhookSvc != nil {
+		event := &models.WebhookEvent{
+			Type:      models.WebhookEventNodeFollowed,
+			OwnerType: models.WebhookOwnerNode,
+			OwnerID:   nodeID,
+			Data: map[string]interface{}{
+				"node_id":         nodeID,
+				"user_id":         userID,
+				"followers_count": node.FollowersCount,
+			},
+		}
+		if err := s.webhookSvc.Publish(ctx, event); err != nil {
+			fmt.Printf("error publishing follow webhook for node %s: %v\n", nodeID, err)
+		}
+	}
+
+	if s.activitySvc != nil {
+		activity := &models.Activity{
+			UserID:   userID,
+			Type:     models.ActivityFollow,
+			NodeID:   nodeID,
+			NodeType: node.Type,
+			Target:   nodeID,
+		}
+		if err := s.activitySvc.Record(ctx, activity); err != nil {
+			fmt.Printf("error recording follow activity for user %s: %v\n", userID, err)
+		}
+	}
+
 	return nil
 }
 
@@ -158,6 +246,9 @@ func (s *NodeService) RemoveImage(ctx context.Context, nodeID string, imageURL s
 			break
 		}
 	}
+	if !containsString(node.Media, imageURL) {
+		node.MediaDetails = models.RemoveMedia(node.MediaDetails, imageURL)
+	}
 
 	// Actualizar el nodo
 	if err := s.nodeRepo.Update(ctx, node); err != nil {
@@ -199,6 +290,9 @@ func (s *NodeService) RemoveMedia(ctx context.Context, nodeID string, mediaURL s
 			break
 		}
 	}
+	if !containsString(node.Images, mediaURL) {
+		node.MediaDetails = models.RemoveMedia(node.MediaDetails, mediaURL)
+	}
 
 	// Actualizar el nodo
 	if err := s.nodeRepo.Update(ctx, node); err != nil {
@@ -207,3 +301,53 @@ func (s *NodeService) RemoveMedia(ctx context.Context, nodeID string, mediaURL s
 
 	return nil
 }
+
+// AttachMedia guarda en el nodo los datos y thumbnails de una imagen procesada. Si la
+// imagen aún no está en el nodo se agrega a Images. userID es quien subió la imagen, la
+// carpeta users/{userId}/ en la que las reglas de Storage solo dejan escribir a su dueño;
+// solo el creador del nodo puede agregarle imágenes.
+func (s *NodeService) AttachMedia(ctx context.Context, nodeID string, userID string, media models.MediaURL) error {
+	err := s.nodeRepo.UpdateMedia(ctx, nodeID, func(node *models.Node) error {
+		if node.UserID != userID {
+			return errors.NewForbiddenError("la imagen no fue subida por el creador del nodo")
+		}
+		if !containsString(node.Images, media.URL) && !containsString(node.Media, media.URL) {
+			node.Images = append(node.Images, media.URL)
+		}
+		node.MediaDetails = models.UpsertMedia(node.MediaDetails, media)
+		return nil
+	})
+	if err != nil {
+		if errors.IsDomainError(err) {
+			return err
+		}
+		return fmt.Errorf("error updating node media: %v", err)
+	}
+	return nil
+}
+
+// DetachMedia quita del nodo una imagen eliminada del storage junto con sus datos. Si el
+// nodo ya no existe no hay nada que quitar.
+func (s *NodeService) DetachMedia(ctx context.Context, nodeID string, mediaURL string) error {
+	err := s.nodeRepo.UpdateMedia(ctx, nodeID, func(node *models.Node) error {
+		node.Images = removeString(node.Images, mediaURL)
+		node.Media = removeString(node.Media, mediaURL)
+		node.MediaDetails = models.RemoveMedia(node.MediaDetails, mediaURL)
+		return nil
+	})
+	if err != nil && !errors.IsNotFoundError(err) {
+		return fmt.Errorf("error updating node media: %v", err)
+	}
+	return nil
+}
+
+// removeString retorna la lista sin las apariciones del valor
+func removeString(values []string, value string) []string {
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != value {
+			result = append(result, v)
+		}
+	}
+	return result
+}
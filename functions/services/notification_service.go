@@ -3,26 +3,82 @@ package services
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	firebase "firebase.google.com/go/v4"
 	"firebase.google.com/go/v4/messaging"
+	"github.com/kha0sys/nodo.social/functions/domain/errors"
 	"github.com/kha0sys/nodo.social/functions/domain/models"
 	"github.com/kha0sys/nodo.social/functions/domain/repositories"
+	"github.com/kha0sys/nodo.social/functions/internal/i18n"
 )
 
-// NotificationService maneja el envío de notificaciones
+const (
+	// fcmMaxTokensPerRequest es el máximo de tokens que FCM acepta en un envío multicast
+	fcmMaxTokensPerRequest = 500
+
+	defaultNotificationPageSize = 20
+	maxNotificationPageSize     = 100
+
+	// DefaultMaxNotificationsPerHour es el máximo de notificaciones agrupadas que recibe un usuario por hora
+	DefaultMaxNotificationsPerHour = 20
+
+	// DefaultMaxDeliveryAttempts es el máximo de intentos de una entrega antes de pasar a la cola de muertos
+	DefaultMaxDeliveryAttempts = 6
+
+	deliveryBaseBackoff  = 30 * time.Second
+	deliveryMaxBackoff   = time.Hour
+	deliveryLease        = 5 * time.Minute
+	deliveryBatchSize    = 100
+	recountUserPageSize  = 200
+	maxDeadLetterPageSize = 100
+)
+
+// DefaultCoalescingRules retorna las reglas de agrupación de notificaciones usadas por defecto
+func DefaultCoalescingRules() map[string]models.CoalescingRule {
+	return map[string]models.CoalescingRule{
+		models.NotificationNodeUpdated: {Window: 15 * time.Minute},
+		models.NotificationNodeDeleted: {Window: 15 * time.Minute},
+	}
+}
+
+// NotificationService maneja el envío de notificaciones.
+// Todas las notificaciones pasan por aquí para respetar las preferencias de cada usuario.
 type NotificationService struct {
 	fcmClient    *messaging.Client
 	userRepo     repositories.UserRepository
+	deviceRepo   repositories.DeviceTokenRepository
 	notificationRepo repositories.NotificationRepository
+	preferencesRepo  repositories.NotificationPreferencesRepository
+	deferredRepo     repositories.DeferredNotificationRepository
+	pendingRepo      repositories.PendingNotificationRepository
+	rateRepo         repositories.NotificationRateRepository
+	deliveryQueue    DeliveryQueue
+	publisher    EventPublisher
+	emailService *EmailService
+	catalog      *i18n.Catalog
+	coalescingRules map[string]models.CoalescingRule
+	maxPerHour      int
+	maxDeliveryAttempts int
 }
 
-// NewNotificationService crea una nueva instancia de NotificationService
+// NewNotificationService crea una nueva instancia de NotificationService.
+// deliveryQueue puede ser nil, en cuyo caso las entregas fallidas no se reintentan;
+// publisher puede ser nil si no se necesitan actualizaciones en vivo y
+// emailService puede ser nil si el canal de correo no está configurado.
 func NewNotificationService(
 	app *firebase.App,
 	userRepo repositories.UserRepository,
+	deviceRepo repositories.DeviceTokenRepository,
 	notificationRepo repositories.NotificationRepository,
+	preferencesRepo repositories.NotificationPreferencesRepository,
+	deferredRepo repositories.DeferredNotificationRepository,
+	pendingRepo repositories.PendingNotificationRepository,
+	rateRepo repositories.NotificationRateRepository,
+	deliveryQueue DeliveryQueue,
+	publisher EventPublisher,
+	emailService *EmailService,
 ) (*NotificationService, error) {
 	fcmClient, err := app.Messaging(context.Background())
 	if err != nil {
@@ -32,129 +88,671 @@ func NewNotificationService(
 	return &NotificationService{
 		fcmClient:    fcmClient,
 		userRepo:     userRepo,
+		deviceRepo:   deviceRepo,
 		notificationRepo: notificationRepo,
+		preferencesRepo:  preferencesRepo,
+		deferredRepo:     deferredRepo,
+		pendingRepo:      pendingRepo,
+		rateRepo:         rateRepo,
+		deliveryQueue:    deliveryQueue,
+		publisher:    publisher,
+		emailService: emailService,
+		catalog:      i18n.DefaultCatalog(),
+		coalescingRules: DefaultCoalescingRules(),
+		maxPerHour:      DefaultMaxNotificationsPerHour,
+		maxDeliveryAttempts: DefaultMaxDeliveryAttempts,
 	}, nil
 }
 
-// SendNotification envía una notificación a un usuario
-func (s *NotificationService) SendNotification(ctx context.Context, notification *models.Notification) error {
-	// Obtener el token FCM del usuario desde Firestore
-	user, err := s.userRepo.Get(ctx, notification.UserID)
+// SetCoalescingRule configura la ventana de agrupación de un tipo de notificación.
+// Una ventana de cero desactiva la agrupación para ese tipo.
+func (s *NotificationService) SetCoalescingRule(notificationType string, rule models.CoalescingRule) {
+	if rule.Window <= 0 {
+		delete(s.coalescingRules, notificationType)
+		return
+	}
+	s.coalescingRules[notificationType] = rule
+}
+
+// SetMaxPerHour configura cuántas notificaciones agrupadas recibe un usuario por hora.
+// Un valor de cero desactiva el límite.
+func (s *NotificationService) SetMaxPerHour(limit int) {
+	s.maxPerHour = limit
+}
+
+// SetMaxDeliveryAttempts configura cuántas veces se intenta una entrega antes de darla por muerta
+func (s *NotificationService) SetMaxDeliveryAttempts(attempts int) {
+	if attempts > 0 {
+		s.maxDeliveryAttempts = attempts
+	}
+}
+
+// Coalesce agrupa la notificación con las del mismo usuario, tipo y objetivo que lleguen
+// dentro de la ventana de su tipo, y la entrega cuando la ventana se cierra.
+// Las notificaciones sin regla de agrupación o sin objetivo se envían de inmediato.
+func (s *NotificationService) Coalesce(ctx context.Context, notification *models.Notification) error {
+	rule, ok := s.coalescingRules[notification.Type]
+	if !ok || notification.Target == "" {
+		return s.SendNotification(ctx, notification)
+	}
+
+	if _, err := s.pendingRepo.Add(ctx, notification, rule.Window); err != nil {
+		return fmt.Errorf("error coalescing notification: %v", err)
+	}
+	return nil
+}
+
+// DeliverCoalescedNotifications entrega las notificaciones agrupadas cuya ventana ya se cerró.
+// Si el usuario alcanzó su límite por hora, la entrega se pospone a la hora siguiente
+// y la notificación sigue agrupando eventos mientras tanto.
+func (s *NotificationService) DeliverCoalescedNotifications(ctx context.Context, now time.Time) error {
+	due, err := s.pendingRepo.GetDue(ctx, now, 100)
 	if err != nil {
-		return fmt.Errorf("error getting user: %v", err)
+		return fmt.Errorf("error getting pending notifications: %v", err)
+	}
+
+	for _, pending := range due {
+		// Tomarla antes de reservar el envío, para no gastar el cupo del usuario en una
+		// notificación que entrega otro proceso
+		claimed, err := s.pendingRepo.Claim(ctx, pending.ID)
+		if err != nil {
+			fmt.Printf("error claiming pending notification %s: %v\n", pending.ID, err)
+			continue
+		}
+		if claimed == nil {
+			continue // Otro proceso ya la entregó
+		}
+
+		if s.maxPerHour > 0 {
+			reserved, err := s.rateRepo.Reserve(ctx, claimed.UserID, now, s.maxPerHour)
+			if err != nil {
+				fmt.Printf("error checking notification rate for user %s: %v\n", claimed.UserID, err)
+				s.releasePending(ctx, claimed, now)
+				continue
+			}
+			if !reserved {
+				s.releasePending(ctx, claimed, now.Truncate(time.Hour).Add(time.Hour))
+				continue
+			}
+		}
+
+		notification := claimed.Notification
+		notification.Count = claimed.Count
+		notification.CreatedAt = claimed.LastAt
+		params := make(map[string]interface{}, len(notification.Params)+1)
+		for k, v := range notification.Params {
+			params[k] = v
+		}
+		params["count"] = claimed.Count
+		notification.Params = params
+
+		if err := s.SendNotification(ctx, &notification); err != nil {
+			fmt.Printf("error sending coalesced notification to user %s: %v\n", claimed.UserID, err)
+		}
 	}
 
+	return nil
+}
+
+// releasePending devuelve una notificación agrupada que no se entregó para entregarla en deliverAt
+func (s *NotificationService) releasePending(ctx context.Context, pending *models.PendingNotification, deliverAt time.Time) {
+	if err := s.pendingRepo.Release(ctx, pending, deliverAt); err != nil {
+		fmt.Printf("error releasing pending notification %s: %v\n", pending.ID, err)
+	}
+}
+
+// SendNotification envía una notificación a un usuario por los canales que tiene habilitados
+func (s *NotificationService) SendNotification(ctx context.Context, notification *models.Notification) error {
+	notification = s.localize(notification, s.userLanguage(ctx, notification.UserID))
+	preferences := s.getPreferences(ctx, notification.UserID)
+
 	// Guardar la notificación en Firestore
-	if err := s.notificationRepo.Create(ctx, notification); err != nil {
-		return fmt.Errorf("error saving notification: %v", err)
+	if preferences.IsEnabled(notification.Type, models.ChannelInApp) {
+		if err := s.notificationRepo.Create(ctx, notification); err != nil {
+			return fmt.Errorf("error saving notification: %v", err)
+		}
+		s.publishNotification(ctx, notification)
+	}
+
+	channels := s.externalChannels(preferences, notification.Type)
+	if len(channels) == 0 {
+		// Si el usuario desactivó el push y el correo, no se envía nada más
+		return nil
+	}
+
+	// Durante las horas de silencio el push y el correo se retienen hasta que terminen
+	if quietEnd, quiet := preferences.QuietHoursEnd(time.Now()); quiet {
+		for _, channel := range channels {
+			if err := s.deferNotification(ctx, notification, channel, quietEnd); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, channel := range channels {
+		// Si falla un canal, al menos la notificación ya está guardada
+		if err := s.dispatch(ctx, channel, notification); err != nil {
+			fmt.Printf("error sending %s notification: %v\n", channel, err)
+		}
+	}
+
+	return nil
+}
+
+// externalChannels retorna los canales fuera de la aplicación habilitados para un tipo de notificación
+func (s *NotificationService) externalChannels(preferences *models.NotificationPreferences, notificationType string) []models.NotificationChannel {
+	var channels []models.NotificationChannel
+	if preferences.IsEnabled(notificationType, models.ChannelPush) {
+		channels = append(channels, models.ChannelPush)
+	}
+	if s.emailService != nil && preferences.IsEnabled(notificationType, models.ChannelEmail) {
+		channels = append(channels, models.ChannelEmail)
 	}
+	return channels
+}
 
-	if user.FCMToken == "" {
-		// Si el usuario no tiene token FCM, solo guardamos la notificación
+// dispatch intenta entregar una notificación por un canal externo y, si falla,
+// la deja en la cola de entregas para reintentarla más tarde
+func (s *NotificationService) dispatch(ctx context.Context, channel models.NotificationChannel, notification *models.Notification) error {
+	err := s.deliver(ctx, channel, notification)
+	if err == nil {
 		return nil
 	}
+	return s.enqueueDelivery(ctx, channel, notification, err)
+}
 
-	// Crear mensaje FCM
-	message := &messaging.Message{
-		Token: user.FCMToken,
-		Notification: &messaging.Notification{
-			Title: notification.Title,
-			Body:  notification.Description,
-		},
-		Data: map[string]string{
-			"type": notification.Type,
-			"id":   notification.ID,
-		},
+// enqueueDelivery guarda en la cola de entregas un primer intento fallido
+func (s *NotificationService) enqueueDelivery(ctx context.Context, channel models.NotificationChannel, notification *models.Notification, cause error) error {
+	if s.deliveryQueue == nil {
+		return cause
+	}
+
+	now := time.Now()
+	job := &models.DeliveryJob{
+		UserID:       notification.UserID,
+		Channel:      channel,
+		Notification: *notification,
+		MaxAttempts:  s.maxDeliveryAttempts,
+		CreatedAt:    now,
+	}
+	job.RecordAttempt(now, cause)
+	job.NextAttemptAt = now.Add(deliveryBackoff(len(job.Attempts)))
+
+	if err := s.deliveryQueue.Enqueue(ctx, job); err != nil {
+		return fmt.Errorf("error queueing delivery after %v: %v", cause, err)
+	}
+	return nil
+}
+
+// deliveryBackoff retorna la espera antes del siguiente intento tras attempts intentos fallidos.
+// La espera se duplica con cada intento hasta deliveryMaxBackoff.
+func deliveryBackoff(attempts int) time.Duration {
+	backoff := deliveryBaseBackoff
+	for i := 1; i < attempts && backoff < deliveryMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > deliveryMaxBackoff {
+		return deliveryMaxBackoff
+	}
+	return backoff
+}
+
+// ProcessDeliveryQueue reintenta las entregas pendientes cuyo siguiente intento ya venció.
+// Las que agotan sus intentos pasan a la cola de entregas muertas.
+func (s *NotificationService) ProcessDeliveryQueue(ctx context.Context, now time.Time) error {
+	if s.deliveryQueue == nil {
+		return nil
+	}
+
+	jobs, err := s.deliveryQueue.Lease(ctx, now, deliveryBatchSize, deliveryLease)
+	if err != nil {
+		return fmt.Errorf("error leasing deliveries: %v", err)
+	}
+
+	for _, job := range jobs {
+		notification := job.Notification
+		deliveryErr := s.deliver(ctx, job.Channel, &notification)
+		job.RecordAttempt(time.Now(), deliveryErr)
+
+		switch {
+		case deliveryErr == nil:
+			err = s.deliveryQueue.Complete(ctx, job)
+		case len(job.Attempts) >= job.MaxAttempts:
+			fmt.Printf("delivery %s exhausted %d attempts: %v\n", job.ID, len(job.Attempts), deliveryErr)
+			err = s.deliveryQueue.DeadLetter(ctx, job)
+		default:
+			job.NextAttemptAt = now.Add(deliveryBackoff(len(job.Attempts)))
+			err = s.deliveryQueue.Retry(ctx, job)
+		}
+		if err != nil {
+			fmt.Printf("error updating delivery %s: %v\n", job.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// ListDeadLetters obtiene las entregas que agotaron sus intentos
+func (s *NotificationService) ListDeadLetters(ctx context.Context, limit int) ([]*models.DeliveryJob, error) {
+	if s.deliveryQueue == nil {
+		return []*models.DeliveryJob{}, nil
+	}
+	if limit <= 0 || limit > maxDeadLetterPageSize {
+		limit = maxDeadLetterPageSize
+	}
+	return s.deliveryQueue.ListDeadLetters(ctx, limit)
+}
+
+// RequeueDeadLetter devuelve una entrega muerta a la cola con un nuevo juego de intentos
+func (s *NotificationService) RequeueDeadLetter(ctx context.Context, jobID string) (*models.DeliveryJob, error) {
+	if s.deliveryQueue == nil {
+		return nil, errors.NewNotFoundError("entrega no encontrada")
+	}
+	return s.deliveryQueue.Requeue(ctx, jobID, s.maxDeliveryAttempts, time.Now())
+}
+
+// deliver entrega una notificación a su usuario por un canal externo
+func (s *NotificationService) deliver(ctx context.Context, channel models.NotificationChannel, notification *models.Notification) error {
+	switch channel {
+	case models.ChannelPush:
+		// Obtener los tokens FCM de todos los dispositivos del usuario
+		tokens, err := s.tokensForUser(ctx, notification.UserID)
+		if err != nil {
+			return err
+		}
+		if len(tokens) == 0 {
+			return nil
+		}
+		return s.sendPush(ctx, tokens, notification)
+	case models.ChannelEmail:
+		if s.emailService == nil {
+			return nil
+		}
+		user, err := s.userRepo.Get(ctx, notification.UserID)
+		if err != nil {
+			return fmt.Errorf("error getting user: %v", err)
+		}
+		return s.emailService.SendNotification(ctx, user, notification)
+	default:
+		return fmt.Errorf("unsupported channel %s", channel)
+	}
+}
+
+// sendPush envía una notificación por FCM a los tokens indicados.
+// Los envíos se dividen en lotes del tamaño máximo que admite FCM y los tokens
+// que FCM reporta como no registrados o inválidos se eliminan.
+func (s *NotificationService) sendPush(ctx context.Context, tokens []string, notification *models.Notification) error {
+	data := map[string]string{
+		"type": notification.Type,
+	}
+	if notification.ID != "" {
+		data["id"] = notification.ID
 	}
 
 	// Si hay datos adicionales, los agregamos al mensaje
 	if notification.Data != nil {
 		for k, v := range notification.Data {
 			if str, ok := v.(string); ok {
-				message.Data[k] = str
+				data[k] = str
 			}
 		}
 	}
 
-	// Enviar notificación FCM
-	_, err = s.fcmClient.Send(ctx, message)
+	var failures []string
+	var stale []string
+	for start := 0; start < len(tokens); start += fcmMaxTokensPerRequest {
+		end := start + fcmMaxTokensPerRequest
+		if end > len(tokens) {
+			end = len(tokens)
+		}
+		chunk := tokens[start:end]
+
+		message := &messaging.MulticastMessage{
+			Tokens: chunk,
+			Notification: &messaging.Notification{
+				Title: notification.Title,
+				Body:  notification.Description,
+			},
+			Data: data,
+		}
+
+		response, err := s.fcmClient.SendMulticast(ctx, message)
+		if err != nil {
+			failures = append(failures, err.Error())
+			continue
+		}
+
+		// Las respuestas vienen en el mismo orden que los tokens
+		staleInChunk := 0
+		for i, result := range response.Responses {
+			if result.Success || result.Error == nil {
+				continue
+			}
+			if isStaleTokenError(result.Error) {
+				stale = append(stale, chunk[i])
+				staleInChunk++
+			}
+		}
+
+		if response.FailureCount > 0 {
+			fmt.Printf("%d notifications failed to send\n", response.FailureCount)
+		}
+		// Si no llegó a ningún dispositivo y no todos los tokens estaban vencidos, vale la pena reintentar
+		if response.SuccessCount == 0 && staleInChunk < len(chunk) {
+			failures = append(failures, fmt.Sprintf("no device accepted the notification (%d failures)", response.FailureCount))
+		}
+	}
+
+	if len(stale) > 0 {
+		if err := s.deviceRepo.DeleteTokens(ctx, stale); err != nil {
+			fmt.Printf("error pruning %d stale FCM tokens: %v\n", len(stale), err)
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("error sending multicast notification: %s", strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// isStaleTokenError indica si FCM rechazó el token porque ya no sirve. InvalidArgument no
+// cuenta: también se retorna por un mensaje mal formado, con tokens válidos.
+func isStaleTokenError(err error) bool {
+	return messaging.IsUnregistered(err) ||
+		messaging.IsRegistrationTokenNotRegistered(err) ||
+		messaging.IsSenderIDMismatch(err)
+}
+
+// tokensForUser obtiene los tokens FCM de todos los dispositivos del usuario
+func (s *NotificationService) tokensForUser(ctx context.Context, userID string) ([]string, error) {
+	devices, err := s.deviceRepo.GetByUser(ctx, userID)
 	if err != nil {
-		// Si falla el envío FCM, al menos la notificación ya está guardada
-		fmt.Printf("error sending FCM notification: %v\n", err)
+		return nil, fmt.Errorf("error getting device tokens: %v", err)
+	}
+
+	tokens := make([]string, 0, len(devices)+1)
+	for _, device := range devices {
+		tokens = append(tokens, device.Token)
+	}
+
+	// Compatibilidad con el token único guardado en el usuario
+	user, err := s.userRepo.Get(ctx, userID)
+	if err == nil && user.FCMToken != "" && !containsString(tokens, user.FCMToken) {
+		tokens = append(tokens, user.FCMToken)
 	}
 
+	return tokens, nil
+}
+
+// RegisterDevice registra o actualiza el token FCM de un dispositivo del usuario
+func (s *NotificationService) RegisterDevice(ctx context.Context, userID string, token string, platform string) (*models.DeviceToken, error) {
+	now := time.Now()
+	device := &models.DeviceToken{
+		UserID:     userID,
+		Token:      strings.TrimSpace(token),
+		Platform:   platform,
+		LastSeenAt: now,
+		CreatedAt:  now,
+	}
+	if err := device.Validate(); err != nil {
+		return nil, errors.NewValidationError(err.Error(), err)
+	}
+
+	if err := s.deviceRepo.Register(ctx, device); err != nil {
+		return nil, fmt.Errorf("error registering device token: %v", err)
+	}
+	return device, nil
+}
+
+// UnregisterDevice elimina el token FCM de un dispositivo del usuario
+func (s *NotificationService) UnregisterDevice(ctx context.Context, userID string, token string) error {
+	device, err := s.deviceRepo.Get(ctx, token)
+	if err != nil {
+		return errors.NewNotFoundError("dispositivo no encontrado")
+	}
+	if device.UserID != userID {
+		return errors.NewForbiddenError("el dispositivo pertenece a otro usuario")
+	}
+
+	if err := s.deviceRepo.Delete(ctx, token); err != nil {
+		return fmt.Errorf("error deleting device token: %v", err)
+	}
 	return nil
 }
 
 // SendMulticastNotification envía una notificación a múltiples usuarios
 func (s *NotificationService) SendMulticastNotification(ctx context.Context, userIDs []string, notification *models.Notification) error {
-	tokens := make([]string, 0, len(userIDs))
-	notificationsByToken := make(map[string]*models.Notification)
+	// El push se agrupa por idioma, porque cada grupo recibe un texto distinto
+	tokensByLanguage := make(map[string][]string)
+	messages := make(map[string]*models.Notification)
+	recipients := make(map[string][]models.Notification)
 
 	// Obtener tokens FCM de todos los usuarios y crear notificaciones
 	for _, userID := range userIDs {
-		user, err := s.userRepo.Get(ctx, userID)
-		if err != nil {
-			continue // Skip users that can't be found
-		}
-
-		// Crear una copia de la notificación para este usuario
-		userNotification := *notification
+		// Crear una copia de la notificación para este usuario, en su idioma
+		language := s.userLanguage(ctx, userID)
+		userNotification := *s.localize(notification, language)
 		userNotification.UserID = userID
+		preferences := s.getPreferences(ctx, userID)
 
 		// Guardar la notificación
-		if err := s.notificationRepo.Create(ctx, &userNotification); err != nil {
-			fmt.Printf("error saving notification for user %s: %v\n", userID, err)
+		if preferences.IsEnabled(userNotification.Type, models.ChannelInApp) {
+			if err := s.notificationRepo.Create(ctx, &userNotification); err != nil {
+				fmt.Printf("error saving notification for user %s: %v\n", userID, err)
+				continue
+			}
+			s.publishNotification(ctx, &userNotification)
+		}
+
+		channels := s.externalChannels(preferences, userNotification.Type)
+		if quietEnd, quiet := preferences.QuietHoursEnd(time.Now()); quiet {
+			for _, channel := range channels {
+				if err := s.deferNotification(ctx, &userNotification, channel, quietEnd); err != nil {
+					fmt.Printf("error deferring notification for user %s: %v\n", userID, err)
+				}
+			}
+			continue
+		}
+
+		// El correo es individual; el push se agrupa en un solo envío multicast
+		push := false
+		for _, channel := range channels {
+			if channel == models.ChannelPush {
+				push = true
+				continue
+			}
+			if err := s.dispatch(ctx, channel, &userNotification); err != nil {
+				fmt.Printf("error sending %s notification to user %s: %v\n", channel, userID, err)
+			}
+		}
+		if !push {
 			continue
 		}
 
-		if user.FCMToken != "" {
-			tokens = append(tokens, user.FCMToken)
-			notificationsByToken[user.FCMToken] = &userNotification
+		userTokens, err := s.tokensForUser(ctx, userID)
+		if err != nil {
+			fmt.Printf("error getting device tokens for user %s: %v\n", userID, err)
+			continue
+		}
+		tokensByLanguage[language] = append(tokensByLanguage[language], userTokens...)
+		recipients[language] = append(recipients[language], userNotification)
+		if _, ok := messages[language]; !ok {
+			// El mensaje es el mismo para todo el grupo; no lleva el ID de cada notificación
+			message := userNotification
+			message.ID = ""
+			message.UserID = ""
+			messages[language] = &message
 		}
 	}
 
-	if len(tokens) == 0 {
-		return nil // No hay tokens FCM, pero las notificaciones se guardaron
+	var failures []string
+	for language, tokens := range tokensByLanguage {
+		if len(tokens) == 0 {
+			continue // No hay tokens FCM, pero las notificaciones se guardaron
+		}
+		err := s.sendPush(ctx, tokens, messages[language])
+		if err == nil {
+			continue
+		}
+		if s.deliveryQueue == nil {
+			failures = append(failures, err.Error())
+			continue
+		}
+		// Si falla el envío del grupo, cada usuario se reintenta por separado desde la cola
+		for i := range recipients[language] {
+			if queueErr := s.enqueueDelivery(ctx, models.ChannelPush, &recipients[language][i], err); queueErr != nil {
+				failures = append(failures, queueErr.Error())
+			}
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("%s", strings.Join(failures, "; "))
 	}
+	return nil
+}
 
-	// Crear mensaje multicast
-	message := &messaging.MulticastMessage{
-		Tokens: tokens,
-		Notification: &messaging.Notification{
-			Title: notification.Title,
-			Body:  notification.Description,
-		},
-		Data: map[string]string{
-			"type": notification.Type,
-		},
+// localize retorna una copia de la notificación con el título y la descripción traducidos
+// a language. Solo se traducen las notificaciones creadas sin título cuyo tipo está en el catálogo.
+func (s *NotificationService) localize(notification *models.Notification, language string) *models.Notification {
+	if notification.Title != "" || !s.catalog.Has(notification.Type+".title") {
+		return notification
 	}
 
-	// Si hay datos adicionales, los agregamos al mensaje
-	if notification.Data != nil {
-		for k, v := range notification.Data {
-			if str, ok := v.(string); ok {
-				message.Data[k] = str
-			}
+	localized := *notification
+	localized.Title = s.catalog.Translate(language, notification.Type+".title", notification.Params)
+	localized.Description = s.catalog.Translate(language, notification.Type+".body", notification.Params)
+	return &localized
+}
+
+// userLanguage obtiene el idioma preferido del usuario, o el idioma por defecto si no lo tiene
+func (s *NotificationService) userLanguage(ctx context.Context, userID string) string {
+	user, err := s.userRepo.Get(ctx, userID)
+	if err != nil {
+		return i18n.DefaultLanguage
+	}
+	return i18n.NormalizeLanguage(user.Profile.Language)
+}
+
+// ListNotifications obtiene una página de la bandeja de notificaciones de un usuario
+func (s *NotificationService) ListNotifications(ctx context.Context, userID string, filters models.NotificationFilters) (*models.NotificationPage, error) {
+	if filters.PageSize <= 0 {
+		filters.PageSize = defaultNotificationPageSize
+	}
+	if filters.PageSize > maxNotificationPageSize {
+		filters.PageSize = maxNotificationPageSize
+	}
+	if filters.Cursor != "" {
+		if _, err := s.getOwnNotification(ctx, userID, filters.Cursor); err != nil {
+			return nil, errors.NewValidationError("cursor de notificaciones inválido", err)
 		}
 	}
+	filters.UserID = userID
 
-	// Enviar notificación multicast
-	response, err := s.fcmClient.SendMulticast(ctx, message)
+	notifications, err := s.notificationRepo.List(ctx, filters)
 	if err != nil {
-		return fmt.Errorf("error sending multicast notification: %v", err)
+		return nil, fmt.Errorf("error listing notifications: %v", err)
 	}
 
-	if response.FailureCount > 0 {
-		fmt.Printf("%d notifications failed to send\n", response.FailureCount)
+	page := &models.NotificationPage{Items: notifications}
+	if page.Items == nil {
+		page.Items = make([]*models.Notification, 0)
+	}
+	if len(notifications) == filters.PageSize {
+		page.NextCursor = notifications[len(notifications)-1].ID
+	}
+	return page, nil
+}
+
+// MarkAsRead marca como leída una notificación del usuario
+func (s *NotificationService) MarkAsRead(ctx context.Context, userID string, notificationID string) error {
+	if _, err := s.getOwnNotification(ctx, userID, notificationID); err != nil {
+		return err
 	}
 
+	if err := s.notificationRepo.MarkAsRead(ctx, notificationID); err != nil {
+		return fmt.Errorf("error marking notification as read: %v", err)
+	}
+
+	s.publishUnreadCount(ctx, userID)
+	return nil
+}
+
+// MarkAllAsRead marca como leídas todas las notificaciones del usuario y retorna cuántas marcó
+func (s *NotificationService) MarkAllAsRead(ctx context.Context, userID string) (int, error) {
+	marked, err := s.notificationRepo.MarkAllAsRead(ctx, userID)
+	if err != nil {
+		return marked, fmt.Errorf("error marking notifications as read: %v", err)
+	}
+
+	s.publishUnreadCount(ctx, userID)
+	return marked, nil
+}
+
+// DeleteNotification elimina una notificación del usuario
+func (s *NotificationService) DeleteNotification(ctx context.Context, userID string, notificationID string) error {
+	notification, err := s.getOwnNotification(ctx, userID, notificationID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.notificationRepo.Delete(ctx, notificationID); err != nil {
+		return fmt.Errorf("error deleting notification: %v", err)
+	}
+
+	if !notification.Read {
+		s.publishUnreadCount(ctx, userID)
+	}
 	return nil
 }
 
-// MarkAsRead marca una notificación como leída
-func (s *NotificationService) MarkAsRead(ctx context.Context, notificationID string) error {
-	return s.notificationRepo.MarkAsRead(ctx, notificationID)
+// GetUnreadCount obtiene el número de notificaciones no leídas de un usuario
+func (s *NotificationService) GetUnreadCount(ctx context.Context, userID string) (int, error) {
+	return s.notificationRepo.CountUnread(ctx, userID)
+}
+
+// RecountAllUnread recalcula el contador de no leídas de cada usuario desde sus
+// notificaciones y retorna cuántos contadores estaban desalineados. Se ejecuta una vez para
+// contar las notificaciones anteriores a los contadores; CountUnread recuenta por su cuenta
+// los contadores que aún no se recontaron.
+func (s *NotificationService) RecountAllUnread(ctx context.Context) (int, error) {
+	fixed := 0
+	startAfter := ""
+	for {
+		users, err := s.userRepo.List(ctx, startAfter, recountUserPageSize)
+		if err != nil {
+			return fixed, fmt.Errorf("error listing users: %v", err)
+		}
+
+		for _, user := range users {
+			previous, count, err := s.notificationRepo.RecountUnread(ctx, user.ID)
+			if err != nil {
+				fmt.Printf("error recounting unread notifications for user %s: %v\n", user.ID, err)
+				continue
+			}
+			if previous != count {
+				fmt.Printf("unread notifications of user %s recounted: counter %d, notifications %d\n", user.ID, previous, count)
+				fixed++
+			}
+		}
+
+		if len(users) < recountUserPageSize {
+			return fixed, nil
+		}
+		startAfter = users[len(users)-1].ID
+	}
+}
+
+// getOwnNotification obtiene una notificación verificando que pertenezca al usuario
+func (s *NotificationService) getOwnNotification(ctx context.Context, userID string, notificationID string) (*models.Notification, error) {
+	notification, err := s.notificationRepo.Get(ctx, notificationID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("notificación no encontrada")
+	}
+	if notification.UserID != userID {
+		return nil, errors.NewForbiddenError("la notificación pertenece a otro usuario")
+	}
+	return notification, nil
 }
 
 // GetUnreadNotifications obtiene todas las notificaciones no leídas de un usuario
@@ -162,9 +760,20 @@ func (s *NotificationService) GetUnreadNotifications(ctx context.Context, userID
 	return s.notificationRepo.GetUnreadByUser(ctx, userID)
 }
 
-// CreateNotification crea una nueva notificación
+// CreateNotification crea una nueva notificación en la bandeja del usuario,
+// salvo que haya desactivado ese tipo de notificación en la aplicación
 func (s *NotificationService) CreateNotification(ctx context.Context, notification *models.Notification) error {
-	return s.notificationRepo.Create(ctx, notification)
+	notification = s.localize(notification, s.userLanguage(ctx, notification.UserID))
+	preferences := s.getPreferences(ctx, notification.UserID)
+	if !preferences.IsEnabled(notification.Type, models.ChannelInApp) {
+		return nil
+	}
+
+	if err := s.notificationRepo.Create(ctx, notification); err != nil {
+		return err
+	}
+	s.publishNotification(ctx, notification)
+	return nil
 }
 
 // DeleteOldNotifications elimina las notificaciones más antiguas que la fecha especificada
@@ -183,3 +792,121 @@ func (s *NotificationService) DeleteOldNotifications(ctx context.Context, olderT
 
 	return nil
 }
+
+// GetPreferences obtiene las preferencias de notificación de un usuario
+func (s *NotificationService) GetPreferences(ctx context.Context, userID string) (*models.NotificationPreferences, error) {
+	preferences, err := s.preferencesRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting notification preferences: %v", err)
+	}
+	return preferences, nil
+}
+
+// UpdatePreferences aplica los cambios a las preferencias de notificación guardadas de un
+// usuario, las valida y las guarda
+func (s *NotificationService) UpdatePreferences(ctx context.Context, userID string, update *models.NotificationPreferencesUpdate) (*models.NotificationPreferences, error) {
+	preferences, err := s.preferencesRepo.Update(ctx, userID, func(preferences *models.NotificationPreferences) error {
+		update.Apply(preferences)
+		if err := preferences.Validate(); err != nil {
+			return errors.NewValidationError(err.Error(), err)
+		}
+		if preferences.Types == nil {
+			preferences.Types = make(map[string]map[models.NotificationChannel]bool)
+		}
+		preferences.UpdatedAt = time.Now()
+		return nil
+	})
+	if err != nil {
+		if errors.IsDomainError(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("error saving notification preferences: %v", err)
+	}
+	return preferences, nil
+}
+
+// DeliverDeferredNotifications entrega las notificaciones retenidas cuyas horas de silencio ya terminaron
+func (s *NotificationService) DeliverDeferredNotifications(ctx context.Context, now time.Time) error {
+	due, err := s.deferredRepo.GetDue(ctx, now, 100)
+	if err != nil {
+		return fmt.Errorf("error getting deferred notifications: %v", err)
+	}
+
+	for _, deferred := range due {
+		notification := deferred.Notification
+		if err := s.dispatch(ctx, deferred.Channel, &notification); err != nil {
+			fmt.Printf("error sending deferred %s notification: %v\n", deferred.Channel, err)
+		}
+
+		if err := s.deferredRepo.Delete(ctx, deferred.ID); err != nil {
+			fmt.Printf("error deleting deferred notification %s: %v\n", deferred.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// deferNotification retiene una notificación hasta deliverAt
+func (s *NotificationService) deferNotification(ctx context.Context, notification *models.Notification, channel models.NotificationChannel, deliverAt time.Time) error {
+	deferred := &models.DeferredNotification{
+		UserID:       notification.UserID,
+		Channel:      channel,
+		Notification: *notification,
+		DeliverAt:    deliverAt,
+		CreatedAt:    time.Now(),
+	}
+	if err := s.deferredRepo.Create(ctx, deferred); err != nil {
+		return fmt.Errorf("error deferring notification: %v", err)
+	}
+	return nil
+}
+
+// getPreferences obtiene las preferencias del usuario; si fallan se usan las de por defecto
+func (s *NotificationService) getPreferences(ctx context.Context, userID string) *models.NotificationPreferences {
+	preferences, err := s.preferencesRepo.Get(ctx, userID)
+	if err != nil {
+		fmt.Printf("error getting notification preferences for user %s: %v\n", userID, err)
+		return models.DefaultNotificationPreferences(userID)
+	}
+	return preferences
+}
+
+// publishNotification envía la notificación nueva y el contador actualizado a los clientes conectados
+func (s *NotificationService) publishNotification(ctx context.Context, notification *models.Notification) {
+	if s.publisher == nil {
+		return
+	}
+
+	event := &models.LiveEvent{
+		UserID: notification.UserID,
+		Type:   models.LiveEventNotification,
+		Data:   notification,
+	}
+	if err := s.publisher.Publish(ctx, event); err != nil {
+		fmt.Printf("error publishing notification event: %v\n", err)
+	}
+
+	s.publishUnreadCount(ctx, notification.UserID)
+}
+
+// publishUnreadCount envía el número de notificaciones no leídas a los clientes conectados
+func (s *NotificationService) publishUnreadCount(ctx context.Context, userID string) {
+	if s.publisher == nil {
+		return
+	}
+
+	count, err := s.GetUnreadCount(ctx, userID)
+	if err != nil {
+		fmt.Printf("error counting unread notifications: %v\n", err)
+		return
+	}
+
+	event := &models.LiveEvent{
+		UserID: userID,
+		Type:   models.LiveEventUnreadCount,
+		Data:   map[string]int{"unread": count},
+	}
+	if err := s.publisher.Publish(ctx, event); err != nil {
+		fmt.Printf("error publishing unread count event: %v\n", err)
+	}
+}
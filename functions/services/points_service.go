@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kha0sys/nodo.social/functions/domain/errors"
+	"github.com/kha0sys/nodo.social/functions/domain/models"
+	"github.com/kha0sys/nodo.social/functions/domain/repositories"
+)
+
+const (
+	defaultPointsPageSize = 20
+	maxPointsPageSize     = 100
+	pointsUserPageSize    = 200
+)
+
+// PointsService registra los puntos de los usuarios en un historial del que se deriva su total
+type PointsService struct {
+	pointsRepo repositories.PointsRepository
+	userRepo   repositories.UserRepository
+	levelSvc   *LevelService
+}
+
+// NewPointsService crea una nueva instancia de PointsService
+func NewPointsService(pointsRepo repositories.PointsRepository, userRepo repositories.UserRepository, levelSvc *LevelService) *PointsService {
+	return &PointsService{
+		pointsRepo: pointsRepo,
+		userRepo:   userRepo,
+		levelSvc:   levelSvc,
+	}
+}
+
+// Award suma puntos a un usuario. key identifica el movimiento, p. ej. "achievement:node_creation_1":
+// si ya se registró uno con la misma clave no se vuelve a sumar. Retorna el total y el nivel
+// del usuario; si con el movimiento sube de nivel se le avisa.
+func (s *PointsService) Award(ctx context.Context, userID string, points int, reason string, reference string, key string) (*models.PointsAward, error) {
+	if key == "" || strings.Contains(key, "/") {
+		return nil, errors.NewValidationError("clave de idempotencia inválida", nil)
+	}
+	curve, err := s.levelSvc.Curve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &models.PointsEntry{
+		UserID:    userID,
+		Points:    points,
+		Reason:    reason,
+		Reference: reference,
+		Key:       key,
+		CreatedAt: time.Now(),
+	}
+	award, err := s.pointsRepo.Award(ctx, entry, curve.LevelFor)
+	if err != nil {
+		return nil, fmt.Errorf("error awarding points: %v", err)
+	}
+
+	s.levelSvc.LevelUp(ctx, userID, award)
+	return award, nil
+}
+
+// History obtiene el total de un usuario y una página de su historial de puntos
+func (s *PointsService) History(ctx context.Context, userID string, cursor string, limit int) (*models.PointsPage, error) {
+	if limit <= 0 {
+		limit = defaultPointsPageSize
+	}
+	if limit > maxPointsPageSize {
+		limit = maxPointsPageSize
+	}
+	// Los movimientos se guardan con el ID <usuario>_<clave>
+	if cursor != "" && !strings.HasPrefix(cursor, userID+"_") {
+		return nil, errors.NewValidationError("cursor de puntos inválido", nil)
+	}
+
+	total, err := s.pointsRepo.GetTotal(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting points total: %v", err)
+	}
+	entries, err := s.pointsRepo.List(ctx, userID, cursor, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error listing points: %v", err)
+	}
+
+	page := &models.PointsPage{Total: total.Total, Items: entries}
+	if len(entries) == limit {
+		page.NextCursor = entries[len(entries)-1].ID
+	}
+	return page, nil
+}
+
+// ReconcileAll recalcula desde el historial el total y el nivel de cada usuario y retorna
+// cuántos totales estaban desalineados
+func (s *PointsService) ReconcileAll(ctx context.Context) (int, error) {
+	curve, err := s.levelSvc.Curve(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	fixed := 0
+	startAfter := ""
+	for {
+		users, err := s.userRepo.List(ctx, startAfter, pointsUserPageSize)
+		if err != nil {
+			return fixed, fmt.Errorf("error listing users: %v", err)
+		}
+
+		for _, user := range users {
+			previous, total, err := s.pointsRepo.Reconcile(ctx, user.ID, curve.LevelFor)
+			if err != nil {
+				fmt.Printf("error reconciling points for user %s: %v\n", user.ID, err)
+				continue
+			}
+			if previous != total || user.Points != total {
+				fmt.Printf("points of user %s reconciled: total %d, user %d, ledger %d\n", user.ID, previous, user.Points, total)
+				fixed++
+			}
+		}
+
+		if len(users) < pointsUserPageSize {
+			return fixed, nil
+		}
+		startAfter = users[len(users)-1].ID
+	}
+}
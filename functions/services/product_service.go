@@ -3,22 +3,30 @@ package services
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"github.com/kha0sys/nodo.social/functions/domain/errors"
 	"github.com/kha0sys/nodo.social/functions/domain/models"
 	"github.com/kha0sys/nodo.social/functions/domain/repositories"
 )
 
 // ProductService maneja la lógica de negocio relacionada con productos
 type ProductService struct {
-	productRepo repositories.ProductRepository
-	nodeRepo    repositories.NodeRepository
+	productRepo     repositories.ProductRepository
+	nodeRepo        repositories.NodeRepository
+	notificationSvc *NotificationService
+	webhookSvc      *WebhookService
 }
 
-// NewProductService crea una nueva instancia de ProductService
-func NewProductService(productRepo repositories.ProductRepository, nodeRepo repositories.NodeRepository) *ProductService {
+// NewProductService crea una nueva instancia de ProductService.
+// notificationSvc puede ser nil si no se debe avisar a los creadores de las decisiones de aprobación,
+// y webhookSvc si no se envían eventos a los webhooks de nodos y tiendas.
+func NewProductService(productRepo repositories.ProductRepository, nodeRepo repositories.NodeRepository, notificationSvc *NotificationService, webhookSvc *WebhookService) *ProductService {
 	return &ProductService{
-		productRepo: productRepo,
-		nodeRepo:    nodeRepo,
+		productRepo:     productRepo,
+		nodeRepo:        nodeRepo,
+		notificationSvc: notificationSvc,
+		webhookSvc:      webhookSvc,
 	}
 }
 
@@ -39,6 +47,8 @@ func (s *ProductService) CreateProduct(ctx context.Context, product *models.Prod
 		return fmt.Errorf("error updating node: %v", err)
 	}
 
+	s.publishProductEvent(ctx, models.WebhookEventProductLinked, product, nil)
+
 	return nil
 }
 
@@ -101,13 +111,103 @@ func (s *ProductService) ApproveProduct(ctx context.Context, productID string) e
 	}
 
 	product.Status = "approved"
+	product.ApprovalStatus = "approved"
+	if err := s.productRepo.Update(ctx, product); err != nil {
+		return fmt.Errorf("error updating product: %v", err)
+	}
+
+	s.notifyApprovalDecision(ctx, product, &models.Notification{
+		Type: models.NotificationProductApproved,
+	})
+	s.publishProductEvent(ctx, models.WebhookEventProductApproved, product, nil)
+
+	return nil
+}
+
+// RejectProduct rechaza un producto e informa el motivo a su creador
+func (s *ProductService) RejectProduct(ctx context.Context, productID string, reason string) error {
+	product, err := s.productRepo.Get(ctx, productID)
+	if err != nil {
+		return fmt.Errorf("error getting product: %v", err)
+	}
+
+	product.Status = models.ProductStatusRejected
+	product.ApprovalStatus = "rejected"
 	if err := s.productRepo.Update(ctx, product); err != nil {
 		return fmt.Errorf("error updating product: %v", err)
 	}
 
+	notification := &models.Notification{
+		Type: models.NotificationProductRejected,
+	}
+	if reason != "" {
+		notification.Data = map[string]interface{}{"reason": reason}
+	}
+	s.notifyApprovalDecision(ctx, product, notification)
+	s.publishProductEvent(ctx, models.WebhookEventProductRejected, product, map[string]interface{}{"reason": reason})
+
 	return nil
 }
 
+// notifyApprovalDecision avisa al creador del producto la decisión de aprobación
+func (s *ProductService) notifyApprovalDecision(ctx context.Context, product *models.Product, notification *models.Notification) {
+	if s.notificationSvc == nil || product.UserID == "" {
+		return
+	}
+
+	notification.UserID = product.UserID
+	notification.CreatedAt = time.Now()
+	if notification.Data == nil {
+		notification.Data = make(map[string]interface{})
+	}
+	notification.Data["productId"] = product.ID
+	notification.Data["productName"] = product.Name
+	notification.Params = map[string]interface{}{"name": product.Name}
+
+	if err := s.notificationSvc.SendNotification(ctx, notification); err != nil {
+		// La decisión ya quedó guardada aunque falle el aviso
+		fmt.Printf("error sending approval notification for product %s: %v\n", product.ID, err)
+	}
+}
+
+// publishProductEvent envía un evento del producto a los webhooks de su nodo y de su tienda
+func (s *ProductService) publishProductEvent(ctx context.Context, eventType string, product *models.Product, extra map[string]interface{}) {
+	if s.webhookSvc == nil {
+		return
+	}
+
+	owners := map[string]string{
+		models.WebhookOwnerNode:  product.NodeID,
+		models.WebhookOwnerStore: product.StoreID,
+	}
+	for ownerType, ownerID := range owners {
+		if ownerID == "" {
+			continue
+		}
+
+		data := map[string]interface{}{
+			"product_id":      product.ID,
+			"name":            product.Name,
+			"node_id":         product.NodeID,
+			"store_id":        product.StoreID,
+			"approval_status": product.ApprovalStatus,
+		}
+		for k, v := range extra {
+			data[k] = v
+		}
+
+		event := &models.WebhookEvent{
+			Type:      eventType,
+			OwnerType: ownerType,
+			OwnerID:   ownerID,
+			Data:      data,
+		}
+		if err := s.webhookSvc.Publish(ctx, event); err != nil {
+			fmt.Printf("error publishing %s webhook for product %s: %v\n", eventType, product.ID, err)
+		}
+	}
+}
+
 // GetProductsByNode obtiene todos los productos asociados a un nodo
 func (s *ProductService) GetProductsByNode(ctx context.Context, nodeID string) ([]*models.Product, error) {
 	products, err := s.productRepo.GetByNode(ctx, nodeID)
@@ -145,6 +245,7 @@ func (s *ProductService) RemoveImage(ctx context.Context, productID string, imag
 			break
 		}
 	}
+	product.MediaDetails = models.RemoveMedia(product.MediaDetails, imageURL)
 
 	if err := s.productRepo.Update(ctx, product); err != nil {
 		return fmt.Errorf("error updating product: %v", err)
@@ -161,6 +262,14 @@ func (s *ProductService) UpdateImages(ctx context.Context, productID string, ima
 	}
 
 	product.Images = images
+	// Conservar solo los datos de las imágenes que siguen en el producto
+	details := make([]models.MediaURL, 0, len(product.MediaDetails))
+	for _, media := range product.MediaDetails {
+		if containsString(images, media.URL) {
+			details = append(details, media)
+		}
+	}
+	product.MediaDetails = details
 	
 	if err := s.productRepo.Update(ctx, product); err != nil {
 		return fmt.Errorf("error updating product: %v", err)
@@ -168,3 +277,40 @@ func (s *ProductService) UpdateImages(ctx context.Context, productID string, ima
 
 	return nil
 }
+
+// AttachMedia guarda en el producto los datos y thumbnails de una imagen procesada. Si la
+// imagen aún no está en el producto se agrega a Images. userID es quien subió la imagen;
+// solo el creador del producto puede agregarle imágenes.
+func (s *ProductService) AttachMedia(ctx context.Context, productID string, userID string, media models.MediaURL) error {
+	err := s.productRepo.UpdateMedia(ctx, productID, func(product *models.Product) error {
+		if product.UserID != userID {
+			return errors.NewForbiddenError("la imagen no fue subida por el creador del producto")
+		}
+		if !containsString(product.Images, media.URL) {
+			product.Images = append(product.Images, media.URL)
+		}
+		product.MediaDetails = models.UpsertMedia(product.MediaDetails, media)
+		return nil
+	})
+	if err != nil {
+		if errors.IsDomainError(err) {
+			return err
+		}
+		return fmt.Errorf("error updating product media: %v", err)
+	}
+	return nil
+}
+
+// DetachMedia quita del producto una imagen eliminada del storage junto con sus datos. Si
+// el producto ya no existe no hay nada que quitar.
+func (s *ProductService) DetachMedia(ctx context.Context, productID string, mediaURL string) error {
+	err := s.productRepo.UpdateMedia(ctx, productID, func(product *models.Product) error {
+		product.Images = removeString(product.Images, mediaURL)
+		product.MediaDetails = models.RemoveMedia(product.MediaDetails, mediaURL)
+		return nil
+	})
+	if err != nil && !errors.IsNotFoundError(err) {
+		return fmt.Errorf("error updating product media: %v", err)
+	}
+	return nil
+}
@@ -1,29 +1,55 @@
 package services
 
 import (
+	"bytes"
 	"context"
+	stderrors "errors"
 	"fmt"
 	"io"
 	"path"
 	"strings"
 
+	"github.com/kha0sys/nodo.social/functions/domain/errors"
+	"github.com/kha0sys/nodo.social/functions/domain/models"
 	"github.com/kha0sys/nodo.social/functions/domain/repositories"
+	"github.com/kha0sys/nodo.social/functions/internal/imageprocessor"
 )
 
 // StorageService maneja las operaciones de almacenamiento de archivos
 type StorageService struct {
-	storage repositories.StorageRepository
+	storage        repositories.StorageRepository
+	imageProcessor *imageprocessor.ImageProcessor
+	keepMetadata   bool
+	policy         UploadPolicy
+	usageRepo      repositories.StorageUsageRepository
 }
 
-// NewStorageService crea una nueva instancia de StorageService
-func NewStorageService(storage repositories.StorageRepository) *StorageService {
+// NewStorageService crea una nueva instancia de StorageService. Salvo que keepMetadata
+// lo permita, las imágenes se orientan y se suben sin sus metadatos. Los archivos se
+// validan con policy; usageRepo puede ser nil si no se controla la cuota de cada usuario.
+func NewStorageService(
+	storage repositories.StorageRepository,
+	imageProcessor *imageprocessor.ImageProcessor,
+	keepMetadata bool,
+	policy UploadPolicy,
+	usageRepo repositories.StorageUsageRepository,
+) *StorageService {
 	return &StorageService{
-		storage: storage,
+		storage:        storage,
+		imageProcessor: imageProcessor,
+		keepMetadata:   keepMetadata,
+		policy:         policy,
+		usageRepo:      usageRepo,
 	}
 }
 
-// UploadFile sube un archivo al storage y retorna su URL
-func (s *StorageService) UploadFile(ctx context.Context, userID string, content io.Reader, filename string, contentType string) (string, error) {
+// UploadFile sube un archivo al storage y retorna su URL. El tipo se detecta del contenido.
+func (s *StorageService) UploadFile(ctx context.Context, userID string, content io.Reader, size int64, filename string) (string, error) {
+	content, contentType, err := s.checkUpload(ctx, userID, content, size, filename)
+	if err != nil {
+		return "", err
+	}
+
 	// Sanitiza el nombre del archivo
 	sanitizedFilename := sanitizeFilename(filename)
 	
@@ -34,17 +60,206 @@ func (s *StorageService) UploadFile(ctx context.Context, userID string, content
 }
 
 // UploadImage sube una imagen al storage y retorna su URL
-func (s *StorageService) UploadImage(ctx context.Context, userID string, content io.Reader, filename string) (string, error) {
+func (s *StorageService) UploadImage(ctx context.Context, userID string, content io.Reader, size int64, filename string) (string, error) {
+	// Construye la ruta del archivo: users/{userID}/images/{filename}
+	return s.uploadImage(ctx, userID, path.Join("users", userID, "images"), content, size, filename)
+}
+
+// UploadOwnedImage sube una imagen de un nodo o producto y retorna su URL. Al procesarla,
+// sus dimensiones, placeholder y thumbnails se guardan en el nodo o producto.
+func (s *StorageService) UploadOwnedImage(ctx context.Context, userID string, owner string, ownerID string, content io.Reader, size int64, filename string) (string, error) {
+	dir, err := ownedImageDir(userID, owner, ownerID)
+	if err != nil {
+		return "", err
+	}
+	return s.uploadImage(ctx, userID, dir, content, size, filename)
+}
+
+// PrepareUpload verifica el tipo y el tamaño declarados de un archivo que el cliente subirá
+// directamente al storage y la cuota del usuario. Retorna la ruta donde debe subirse, con
+// las mismas convenciones que las subidas HTTP, y su tipo MIME. owner y ownerID pueden
+// estar vacíos si la imagen no pertenece a un nodo o producto.
+func (s *StorageService) PrepareUpload(ctx context.Context, userID string, owner string, ownerID string, filename string, contentType string, size int64) (string, string, error) {
+	contentType, err := s.policy.CheckDeclared(filename, contentType, size)
+	if err != nil {
+		return "", "", err
+	}
+	if err := s.checkQuota(ctx, userID, size); err != nil {
+		return "", "", err
+	}
+
+	// Construye la ruta del archivo: users/{userID}/files/{filename} o, para las imágenes,
+	// users/{userID}/images/{filename} o users/{userID}/{nodes|products}/{ownerID}/{filename}
+	dir := path.Join("users", userID, "files")
+	if isImageFile(filename) {
+		dir = path.Join("users", userID, "images")
+		if owner != "" {
+			if dir, err = ownedImageDir(userID, owner, ownerID); err != nil {
+				return "", "", err
+			}
+		}
+	} else if owner != "" {
+		return "", "", errors.NewValidationError("solo las imágenes pueden pertenecer a un nodo o producto", nil)
+	}
+	return path.Join(dir, sanitizeFilename(filename)), contentType, nil
+}
+
+// ownedImageDir retorna el directorio de las imágenes de un nodo o producto:
+// users/{userID}/{nodes|products}/{ownerID}. Los directorios thumbnails son de los
+// thumbnails generados, que no se validan al subirlos.
+func ownedImageDir(userID string, owner string, ownerID string) (string, error) {
+	if owner != models.MediaOwnerNode && owner != models.MediaOwnerProduct {
+		return "", errors.NewValidationError(fmt.Sprintf("dueño de imagen inválido: %s", owner), nil)
+	}
+
+	ownerID = sanitizeFilename(ownerID)
+	if strings.Trim(ownerID, ".") == "" || ownerID == "thumbnails" {
+		return "", errors.NewValidationError(fmt.Sprintf("ID de %s inválido", owner), nil)
+	}
+	return path.Join("users", userID, owner, ownerID), nil
+}
+
+// uploadImage sube una imagen en el directorio indicado
+func (s *StorageService) uploadImage(ctx context.Context, userID string, dir string, content io.Reader, size int64, filename string) (string, error) {
 	// Verifica que el archivo sea una imagen
 	if !isImageFile(filename) {
-		return "", fmt.Errorf("el archivo debe ser una imagen (jpg, png, gif)")
+		return "", errors.NewValidationError("el archivo debe ser una imagen (jpg, png, gif, webp)", nil)
 	}
-	
-	// Construye la ruta del archivo: users/{userID}/images/{filename}
-	storagePath := path.Join("users", userID, "images", sanitizeFilename(filename))
-	
-	contentType := getImageContentType(filename)
-	return s.storage.Upload(ctx, storagePath, content, contentType)
+
+	content, contentType, err := s.checkUpload(ctx, userID, content, size, filename)
+	if err != nil {
+		return "", err
+	}
+
+	storagePath := path.Join(dir, sanitizeFilename(filename))
+
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return "", fmt.Errorf("error reading image: %v", err)
+	}
+	if s.keepMetadata {
+		// Verificar las dimensiones antes de que el trigger decodifique la imagen
+		if _, _, _, err := s.imageProcessor.Inspect(data); err != nil {
+			return "", imageError(err)
+		}
+	} else {
+		// Quitar la ubicación y demás metadatos antes de que la imagen llegue al storage
+		stripped, format, err := s.imageProcessor.StripMetadata(bytes.NewReader(data))
+		if err != nil {
+			return "", imageError(err)
+		}
+		data = stripped
+		contentType = imageprocessor.ContentType(format)
+	}
+	return s.storage.Upload(ctx, storagePath, bytes.NewReader(data), contentType)
+}
+
+// MaxUploadBytes retorna el mayor tamaño permitido para un archivo
+func (s *StorageService) MaxUploadBytes() int64 {
+	return s.policy.MaxBytes()
+}
+
+// Usage obtiene el espacio que ocupan los archivos de un usuario y su cuota
+func (s *StorageService) Usage(ctx context.Context, userID string) (*models.StorageUsage, error) {
+	if s.usageRepo == nil {
+		return &models.StorageUsage{UserID: userID}, nil
+	}
+	usage, err := s.usageRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting storage usage: %v", err)
+	}
+	usage.QuotaBytes = s.policy.UserQuotaBytes
+	return usage, nil
+}
+
+// CheckStored verifica un archivo que ya está en el storage, p. ej. subido directamente
+// desde el cliente, con sus primeros SniffLength bytes. Retorna su tipo MIME.
+func (s *StorageService) CheckStored(filename string, head []byte, size int64) (string, error) {
+	return s.policy.Check(filename, head, size)
+}
+
+// RecordStored suma un archivo de un usuario a su uso. Retorna el uso resultante, con la
+// cuota, para que el trigger elimine el archivo si la supera.
+func (s *StorageService) RecordStored(ctx context.Context, userID string, size int64) (*models.StorageUsage, error) {
+	return s.addUsage(ctx, userID, size, 1)
+}
+
+// RecordDeleted resta un archivo eliminado del uso de su usuario
+func (s *StorageService) RecordDeleted(ctx context.Context, userID string, size int64) error {
+	_, err := s.addUsage(ctx, userID, -size, -1)
+	return err
+}
+
+func (s *StorageService) addUsage(ctx context.Context, userID string, size int64, files int) (*models.StorageUsage, error) {
+	if s.usageRepo == nil {
+		return &models.StorageUsage{UserID: userID}, nil
+	}
+	usage, err := s.usageRepo.Add(ctx, userID, size, files)
+	if err != nil {
+		return nil, fmt.Errorf("error updating storage usage: %v", err)
+	}
+	usage.QuotaBytes = s.policy.UserQuotaBytes
+	return usage, nil
+}
+
+// checkUpload verifica el tipo real, el tamaño y la cuota de un archivo antes de subirlo.
+// Retorna el contenido completo, ya que para detectar el tipo se leen sus primeros bytes.
+func (s *StorageService) checkUpload(ctx context.Context, userID string, content io.Reader, size int64, filename string) (io.Reader, string, error) {
+	head := make([]byte, SniffLength)
+	n, err := io.ReadFull(content, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, "", fmt.Errorf("error reading file: %v", err)
+	}
+	head = head[:n]
+
+	contentType, err := s.policy.Check(filename, head, size)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := s.checkQuota(ctx, userID, size); err != nil {
+		return nil, "", err
+	}
+
+	// El tamaño declarado no se confía: se corta un byte después del máximo para detectarlo
+	limited := &io.LimitedReader{R: io.MultiReader(bytes.NewReader(head), content), N: size + 1}
+	return &sizeCheckedReader{reader: limited, size: size}, contentType, nil
+}
+
+// checkQuota verifica que el usuario tenga espacio para un archivo de size bytes
+func (s *StorageService) checkQuota(ctx context.Context, userID string, size int64) error {
+	usage, err := s.Usage(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if usage.Exceeds(size) {
+		return errors.NewQuotaExceededError(fmt.Sprintf("el archivo supera tu espacio disponible: usas %d de %d bytes", usage.UsedBytes, usage.QuotaBytes))
+	}
+	return nil
+}
+
+// sizeCheckedReader falla si el contenido ocupa más bytes que los declarados
+type sizeCheckedReader struct {
+	reader *io.LimitedReader
+	size   int64
+	read   int64
+}
+
+func (r *sizeCheckedReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+	if r.read > r.size {
+		return n, errors.NewTooLargeError("el archivo ocupa más bytes que los declarados")
+	}
+	return n, err
+}
+
+// imageError traduce los errores al leer una imagen subida en errores para el cliente
+func imageError(err error) error {
+	if stderrors.Is(err, imageprocessor.ErrTooManyPixels) {
+		return errors.NewTooLargeError(fmt.Sprintf("la imagen tiene demasiados píxeles: %v", err))
+	}
+	return errors.NewValidationError("la imagen no es válida", err)
 }
 
 // DeleteFile elimina un archivo del storage
@@ -74,23 +289,9 @@ func sanitizeFilename(filename string) string {
 func isImageFile(filename string) bool {
 	ext := strings.ToLower(path.Ext(filename))
 	switch ext {
-	case ".jpg", ".jpeg", ".png", ".gif":
+	case ".jpg", ".jpeg", ".png", ".gif", ".webp":
 		return true
 	default:
 		return false
 	}
 }
-
-func getImageContentType(filename string) string {
-	ext := strings.ToLower(path.Ext(filename))
-	switch ext {
-	case ".jpg", ".jpeg":
-		return "image/jpeg"
-	case ".png":
-		return "image/png"
-	case ".gif":
-		return "image/gif"
-	default:
-		return "application/octet-stream"
-	}
-}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kha0sys/nodo.social/functions/domain/models"
+	"github.com/kha0sys/nodo.social/functions/domain/repositories"
+)
+
+// streakPeriods son los periodos en los que se lleva racha
+var streakPeriods = []string{models.StreakDaily, models.StreakWeekly}
+
+// StreakService lleva las rachas diarias y semanales de actividad de los usuarios
+type StreakService struct {
+	streakRepo      repositories.StreakRepository
+	preferencesRepo repositories.NotificationPreferencesRepository
+	userRepo        repositories.UserRepository
+}
+
+// NewStreakService crea una nueva instancia de StreakService
+func NewStreakService(
+	streakRepo repositories.StreakRepository,
+	preferencesRepo repositories.NotificationPreferencesRepository,
+	userRepo repositories.UserRepository,
+) *StreakService {
+	return &StreakService{
+		streakRepo:      streakRepo,
+		preferencesRepo: preferencesRepo,
+		userRepo:        userRepo,
+	}
+}
+
+// Record avanza las rachas del usuario con una actividad. Los días y semanas se cuentan
+// en su zona horaria. Retorna true si cambió la racha diaria, que se copia a las métricas
+// del usuario para las reglas de logros.
+func (s *StreakService) Record(ctx context.Context, activity *models.Activity) (bool, error) {
+	location, err := s.location(ctx, activity.UserID)
+	if err != nil {
+		return false, err
+	}
+	local := activity.At.In(location)
+
+	var daily *models.Streak
+	for _, period := range streakPeriods {
+		start := models.StreakPeriodStart(period, local)
+		streak, changed, err := s.streakRepo.Update(ctx, activity.UserID, period, func(streak *models.Streak) (bool, error) {
+			advanced, err := streak.Advance(start)
+			streak.UpdatedAt = activity.At
+			return advanced, err
+		})
+		if err != nil {
+			return false, fmt.Errorf("error updating %s streak: %v", period, err)
+		}
+		if changed && period == models.StreakDaily {
+			daily = streak
+		}
+	}
+	if daily == nil {
+		return false, nil
+	}
+
+	if err := s.userRepo.SetStreakDays(ctx, activity.UserID, daily.Current); err != nil {
+		return false, fmt.Errorf("error updating user streak: %v", err)
+	}
+	return true, nil
+}
+
+// GetStreaks obtiene las rachas del usuario en now. Una racha que ya no se puede
+// continuar se muestra con Current en cero aunque todavía no se haya registrado otra actividad.
+func (s *StreakService) GetStreaks(ctx context.Context, userID string, now time.Time) ([]*models.Streak, error) {
+	location, err := s.location(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := s.streakRepo.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting streaks: %v", err)
+	}
+
+	byPeriod := make(map[string]*models.Streak, len(stored))
+	for _, streak := range stored {
+		byPeriod[streak.Period] = streak
+	}
+
+	streaks := make([]*models.Streak, 0, len(streakPeriods))
+	for _, period := range streakPeriods {
+		streak, ok := byPeriod[period]
+		if !ok {
+			streak = &models.Streak{UserID: userID, Period: period}
+		}
+		if !streak.Alive(models.StreakPeriodStart(period, now.In(location))) {
+			streak.Current = 0
+		}
+		streaks = append(streaks, streak)
+	}
+	return streaks, nil
+}
+
+func (s *StreakService) location(ctx context.Context, userID string) (*time.Location, error) {
+	preferences, err := s.preferencesRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting preferences: %v", err)
+	}
+	return preferences.Location(), nil
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/kha0sys/nodo.social/functions/domain/errors"
+)
+
+// SniffLength es la cantidad de bytes del inicio de un archivo que se usan para detectar su tipo
+const SniffLength = 512
+
+// Categorías de archivos con un tamaño máximo propio
+const (
+	uploadImage    = "image"
+	uploadDocument = "document"
+	uploadVideo    = "video"
+	uploadAudio    = "audio"
+)
+
+// uploadType es un tipo de archivo permitido
+type uploadType struct {
+	contentType string
+	category    string
+}
+
+// uploadTypes son los tipos permitidos según la extensión. El contenido debe coincidir:
+// un ejecutable renombrado a .jpg se rechaza.
+var uploadTypes = map[string]uploadType{
+	".jpg":  {"image/jpeg", uploadImage},
+	".jpeg": {"image/jpeg", uploadImage},
+	".png":  {"image/png", uploadImage},
+	".gif":  {"image/gif", uploadImage},
+	".webp": {"image/webp", uploadImage},
+	".pdf":  {"application/pdf", uploadDocument},
+	".txt":  {"text/plain", uploadDocument},
+	".mp4":  {"video/mp4", uploadVideo},
+	".webm": {"video/webm", uploadVideo},
+	".mp3":  {"audio/mpeg", uploadAudio},
+	".wav":  {"audio/wave", uploadAudio},
+	".ogg":  {"application/ogg", uploadAudio},
+}
+
+// UploadPolicy define el tamaño máximo de cada categoría de archivo y la cuota de cada
+// usuario, en bytes. Una cuota de 0 no limita el espacio.
+type UploadPolicy struct {
+	MaxImageBytes    int64
+	MaxDocumentBytes int64
+	MaxVideoBytes    int64
+	MaxAudioBytes    int64
+	UserQuotaBytes   int64
+}
+
+// MaxBytes retorna el mayor tamaño permitido para cualquier archivo
+func (p UploadPolicy) MaxBytes() int64 {
+	max := p.MaxImageBytes
+	for _, limit := range []int64{p.MaxDocumentBytes, p.MaxVideoBytes, p.MaxAudioBytes} {
+		if limit > max {
+			max = limit
+		}
+	}
+	return max
+}
+
+// Check verifica que el contenido del archivo coincida con su extensión, a partir de sus
+// primeros SniffLength bytes, y que no supere el tamaño máximo de su categoría. Retorna
+// el tipo MIME del archivo.
+func (p UploadPolicy) Check(filename string, head []byte, size int64) (string, error) {
+	detected := http.DetectContentType(head)
+	if i := strings.Index(detected, ";"); i >= 0 {
+		detected = detected[:i]
+	}
+	return p.CheckDeclared(filename, detected, size)
+}
+
+// CheckDeclared verifica que el tipo declarado para un archivo que aún no se subió
+// coincida con su extensión y que el tamaño no supere el máximo de su categoría
+func (p UploadPolicy) CheckDeclared(filename string, contentType string, size int64) (string, error) {
+	allowed, ok := uploadTypes[strings.ToLower(path.Ext(filename))]
+	if !ok {
+		return "", errors.NewValidationError(fmt.Sprintf("tipo de archivo no permitido: %s", path.Ext(filename)), nil)
+	}
+	if contentType != allowed.contentType {
+		return "", errors.NewValidationError(fmt.Sprintf("el tipo del archivo (%s) no coincide con su extensión (%s)", contentType, allowed.contentType), nil)
+	}
+
+	maxBytes := p.maxBytes(allowed.category)
+	if maxBytes > 0 && size > maxBytes {
+		return "", errors.NewTooLargeError(fmt.Sprintf("el archivo ocupa %d bytes y el máximo para %s es %d", size, allowed.category, maxBytes))
+	}
+	return allowed.contentType, nil
+}
+
+func (p UploadPolicy) maxBytes(category string) int64 {
+	switch category {
+	case uploadImage:
+		return p.MaxImageBytes
+	case uploadDocument:
+		return p.MaxDocumentBytes
+	case uploadVideo:
+		return p.MaxVideoBytes
+	case uploadAudio:
+		return p.MaxAudioBytes
+	}
+	return 0
+}
@@ -0,0 +1,241 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/kha0sys/nodo.social/functions/domain/errors"
+	"github.com/kha0sys/nodo.social/functions/domain/models"
+	"github.com/kha0sys/nodo.social/functions/domain/repositories"
+)
+
+const (
+	// uploadURLDuration es el tiempo que la URL firmada permite subir el archivo
+	uploadURLDuration = 15 * time.Minute
+	// abandonedUploadAge es la antigüedad a partir de la cual la limpieza diaria elimina
+	// las sesiones y los archivos de las subidas sin confirmar
+	abandonedUploadAge = 24 * time.Hour
+	cleanupPageSize    = 200
+)
+
+// UploadSessionService emite URLs firmadas para subir archivos directamente al storage,
+// sin pasar por la función, y verifica que lo subido coincida con lo declarado
+type UploadSessionService struct {
+	sessionRepo repositories.UploadSessionRepository
+	storage     repositories.StorageRepository
+	storageSvc  *StorageService
+}
+
+// NewUploadSessionService crea una nueva instancia de UploadSessionService
+func NewUploadSessionService(
+	sessionRepo repositories.UploadSessionRepository,
+	storage repositories.StorageRepository,
+	storageSvc *StorageService,
+) *UploadSessionService {
+	return &UploadSessionService{
+		sessionRepo: sessionRepo,
+		storage:     storage,
+		storageSvc:  storageSvc,
+	}
+}
+
+// Create verifica el archivo declarado y crea una sesión con la URL firmada para subirlo.
+// La URL solo acepta el tipo, el tamaño y el hash MD5 declarados.
+func (s *UploadSessionService) Create(ctx context.Context, userID string, req models.UploadSessionRequest) (*models.UploadSession, error) {
+	md5, err := base64.StdEncoding.DecodeString(req.MD5)
+	if err != nil || len(md5) != 16 {
+		return nil, errors.NewValidationError("md5 debe ser el hash MD5 del archivo en base64", err)
+	}
+	if req.Size <= 0 {
+		return nil, errors.NewValidationError("size debe ser mayor que 0", nil)
+	}
+	if req.NodeID != "" && req.ProductID != "" {
+		return nil, errors.NewValidationError("la imagen solo puede pertenecer a un nodo o a un producto", nil)
+	}
+
+	owner, ownerID := "", ""
+	if req.NodeID != "" {
+		owner, ownerID = models.MediaOwnerNode, req.NodeID
+	} else if req.ProductID != "" {
+		owner, ownerID = models.MediaOwnerProduct, req.ProductID
+	}
+
+	// El ID de la sesión en el nombre evita pisar otro archivo con el mismo nombre
+	sessionID := s.sessionRepo.NewID()
+	filename := fmt.Sprintf("%s_%s", sessionID, req.Filename)
+	storagePath, contentType, err := s.storageSvc.PrepareUpload(ctx, userID, owner, ownerID, filename, req.ContentType, req.Size)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(uploadURLDuration)
+	uploadURL, err := s.storage.SignedUploadURL(ctx, storagePath, contentType, req.Size, req.MD5, expiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("error signing upload URL: %v", err)
+	}
+
+	session := &models.UploadSession{
+		ID:          sessionID,
+		UserID:      userID,
+		Path:        storagePath,
+		Filename:    req.Filename,
+		ContentType: contentType,
+		Size:        req.Size,
+		MD5:         req.MD5,
+		Status:      models.UploadSessionPending,
+		URL:         s.storage.PublicURL(storagePath),
+		UploadURL:   uploadURL,
+		ExpiresAt:   expiresAt,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.sessionRepo.Save(ctx, session); err != nil {
+		return nil, fmt.Errorf("error saving upload session: %v", err)
+	}
+	return session, nil
+}
+
+// Get obtiene una sesión del usuario
+func (s *UploadSessionService) Get(ctx context.Context, userID string, sessionID string) (*models.UploadSession, error) {
+	session, err := s.sessionRepo.Get(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.UserID != userID {
+		return nil, errors.NewForbiddenError("la sesión de subida pertenece a otro usuario")
+	}
+	return session, nil
+}
+
+// Complete confirma la subida de un archivo. Si el evento de Storage aún no la verificó,
+// compara el tamaño y el hash del archivo con los declarados y lo elimina si no coinciden.
+func (s *UploadSessionService) Complete(ctx context.Context, userID string, sessionID string) (*models.UploadSession, error) {
+	session, err := s.Get(ctx, userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch session.Status {
+	case models.UploadSessionCompleted:
+		return session, nil
+	case models.UploadSessionRejected:
+		return nil, errors.NewConflictError(fmt.Sprintf("la subida fue rechazada: %s", session.Error))
+	}
+
+	size, md5, err := s.storage.Stat(ctx, session.Path)
+	if err != nil {
+		if errors.IsDomainError(err) {
+			return nil, errors.NewConflictError("el archivo aún no se subió")
+		}
+		return nil, fmt.Errorf("error checking uploaded file: %v", err)
+	}
+
+	// Una vez verificada, la imagen original puede reescribirse sin metadatos y cambiar
+	// de tamaño y hash
+	if session.Status == models.UploadSessionPending {
+		if reason := uploadMismatch(session, size, md5); reason != "" {
+			if err := s.reject(ctx, session, reason); err != nil {
+				return nil, err
+			}
+			return nil, errors.NewValidationError(fmt.Sprintf("la subida fue rechazada: %s", reason), nil)
+		}
+	}
+
+	now := time.Now()
+	session.Status = models.UploadSessionCompleted
+	session.CompletedAt = &now
+	session.UpdatedAt = now
+	if err := s.sessionRepo.Save(ctx, session); err != nil {
+		return nil, fmt.Errorf("error saving upload session: %v", err)
+	}
+	return session, nil
+}
+
+// VerifyStored compara un archivo recién llegado al storage con su sesión, si se subió con
+// una. Retorna false si no coincide con lo declarado; la sesión queda rechazada y el
+// archivo debe eliminarse.
+func (s *UploadSessionService) VerifyStored(ctx context.Context, path string, size int64, md5 string) (bool, error) {
+	session, err := s.sessionRepo.GetByPath(ctx, path)
+	if err != nil {
+		return false, fmt.Errorf("error getting upload session: %v", err)
+	}
+	if session == nil || session.Status != models.UploadSessionPending {
+		return true, nil
+	}
+
+	if reason := uploadMismatch(session, size, md5); reason != "" {
+		return false, s.markRejected(ctx, session, reason)
+	}
+
+	session.Status = models.UploadSessionUploaded
+	session.UpdatedAt = time.Now()
+	if err := s.sessionRepo.Save(ctx, session); err != nil {
+		return false, fmt.Errorf("error saving upload session: %v", err)
+	}
+	return true, nil
+}
+
+// CleanupAbandoned elimina las sesiones de más de un día y, si no se confirmaron, sus
+// archivos. Retorna cuántas sesiones eliminó.
+func (s *UploadSessionService) CleanupAbandoned(ctx context.Context) (int, error) {
+	cutoff := time.Now().Add(-abandonedUploadAge)
+	deleted := 0
+	for {
+		sessions, err := s.sessionRepo.ListCreatedBefore(ctx, cutoff, cleanupPageSize)
+		if err != nil {
+			return deleted, fmt.Errorf("error listing upload sessions: %v", err)
+		}
+
+		for _, session := range sessions {
+			if session.Status != models.UploadSessionCompleted {
+				if err := s.storage.Delete(ctx, session.Path); err != nil {
+					if _, _, statErr := s.storage.Stat(ctx, session.Path); !errors.IsDomainError(statErr) {
+						return deleted, fmt.Errorf("error deleting abandoned upload %s: %v", session.Path, err)
+					}
+				}
+			}
+			if err := s.sessionRepo.Delete(ctx, session.ID); err != nil {
+				return deleted, fmt.Errorf("error deleting upload session %s: %v", session.ID, err)
+			}
+			deleted++
+		}
+
+		if len(sessions) < cleanupPageSize {
+			return deleted, nil
+		}
+	}
+}
+
+// reject elimina el archivo de una sesión que no coincide con lo declarado y la marca
+// como rechazada
+func (s *UploadSessionService) reject(ctx context.Context, session *models.UploadSession, reason string) error {
+	if err := s.storage.Delete(ctx, session.Path); err != nil {
+		return fmt.Errorf("error deleting rejected upload: %v", err)
+	}
+	return s.markRejected(ctx, session, reason)
+}
+
+func (s *UploadSessionService) markRejected(ctx context.Context, session *models.UploadSession, reason string) error {
+	session.Status = models.UploadSessionRejected
+	session.Error = reason
+	session.UpdatedAt = time.Now()
+	if err := s.sessionRepo.Save(ctx, session); err != nil {
+		return fmt.Errorf("error saving upload session: %v", err)
+	}
+	return nil
+}
+
+// uploadMismatch retorna el motivo por el que un archivo no coincide con lo declarado en
+// su sesión, o "" si coincide
+func uploadMismatch(session *models.UploadSession, size int64, md5 string) string {
+	if size != session.Size {
+		return fmt.Sprintf("se declararon %d bytes y se subieron %d", session.Size, size)
+	}
+	if md5 != session.MD5 {
+		return "el hash MD5 no coincide con el declarado"
+	}
+	return ""
+}
@@ -0,0 +1,472 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/kha0sys/nodo.social/functions/domain/errors"
+	"github.com/kha0sys/nodo.social/functions/domain/models"
+	"github.com/kha0sys/nodo.social/functions/domain/repositories"
+)
+
+const (
+	// WebhookSignatureHeader lleva la firma de la entrega: "t=<unix>,v1=<hex>", donde v1 es
+	// HMAC-SHA256 con el secreto de la suscripción sobre "<unix>.<cuerpo>"
+	WebhookSignatureHeader = "X-Nodo-Signature"
+	// WebhookEventIDHeader lleva el ID del evento, que se repite en cada reintento
+	WebhookEventIDHeader = "X-Nodo-Event-Id"
+	// WebhookEventTypeHeader lleva el tipo de evento
+	WebhookEventTypeHeader = "X-Nodo-Event"
+	// WebhookDeliveryIDHeader lleva el ID de la entrega
+	WebhookDeliveryIDHeader = "X-Nodo-Delivery"
+
+	webhookMaxAttempts     = 8
+	webhookBaseBackoff     = time.Minute
+	webhookMaxBackoff      = 6 * time.Hour
+	webhookTimeout         = 10 * time.Second
+	webhookLease           = 2 * time.Minute
+	webhookBatchSize       = 100
+	webhookMaxResponseBody = 512
+	defaultWebhookLogSize  = 20
+	maxWebhookLogSize      = 100
+)
+
+// WebhookConfig contiene la configuración de las entregas de webhooks
+type WebhookConfig struct {
+	// AllowPrivateNetworks permite enviar a direcciones locales o privadas.
+	// Solo debe activarse en desarrollo, para probar contra un receptor local.
+	AllowPrivateNetworks bool
+}
+
+// WebhookService maneja las suscripciones de webhooks de nodos y tiendas y la entrega de sus eventos
+type WebhookService struct {
+	subscriptionRepo repositories.WebhookSubscriptionRepository
+	deliveryRepo     repositories.WebhookDeliveryRepository
+	nodeRepo         repositories.NodeRepository
+	storeRepo        repositories.StoreRepository
+	httpClient       *http.Client
+}
+
+// NewWebhookService crea una nueva instancia de WebhookService
+func NewWebhookService(
+	subscriptionRepo repositories.WebhookSubscriptionRepository,
+	deliveryRepo repositories.WebhookDeliveryRepository,
+	nodeRepo repositories.NodeRepository,
+	storeRepo repositories.StoreRepository,
+	config WebhookConfig,
+) *WebhookService {
+	dialer := &net.Dialer{Timeout: webhookTimeout}
+	if !config.AllowPrivateNetworks {
+		dialer.Control = rejectPrivateAddress
+	}
+
+	return &WebhookService{
+		subscriptionRepo: subscriptionRepo,
+		deliveryRepo:     deliveryRepo,
+		nodeRepo:         nodeRepo,
+		storeRepo:        storeRepo,
+		httpClient: &http.Client{
+			Timeout:   webhookTimeout,
+			Transport: &http.Transport{DialContext: dialer.DialContext},
+			// Las redirecciones no se siguen, para que no lleven a una dirección no permitida
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+	}
+}
+
+// privateNetworks son los rangos a los que no se envían webhooks
+var privateNetworks = mustParseCIDRs(
+	"0.0.0.0/8", "10.0.0.0/8", "100.64.0.0/10", "127.0.0.0/8", "169.254.0.0/16",
+	"172.16.0.0/12", "192.168.0.0/16", "::1/128", "fc00::/7", "fe80::/10",
+)
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// rejectPrivateAddress impide conectar a direcciones locales o privadas. Se revisa la dirección
+// ya resuelta, de modo que un dominio público que apunte a una red interna también se rechaza.
+func rejectPrivateAddress(network string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("invalid webhook address %s", address)
+	}
+	for _, private := range privateNetworks {
+		if private.Contains(ip) {
+			return fmt.Errorf("webhook address %s is not allowed", ip)
+		}
+	}
+	return nil
+}
+
+// CreateSubscription registra un webhook para un nodo o una tienda de userID.
+// La suscripción retornada incluye el secreto de firma, que no se vuelve a mostrar.
+func (s *WebhookService) CreateSubscription(ctx context.Context, userID string, subscription *models.WebhookSubscription) (*models.WebhookSubscription, error) {
+	if err := subscription.Validate(); err != nil {
+		return nil, errors.NewValidationError(err.Error(), err)
+	}
+	if err := s.checkOwner(ctx, userID, subscription.OwnerType, subscription.OwnerID); err != nil {
+		return nil, err
+	}
+
+	secret, err := randomHex(32)
+	if err != nil {
+		return nil, fmt.Errorf("error generating webhook secret: %v", err)
+	}
+
+	now := time.Now()
+	subscription.UserID = userID
+	subscription.Secret = "whsec_" + secret
+	subscription.Active = true
+	subscription.CreatedAt = now
+	subscription.UpdatedAt = now
+	if err := s.subscriptionRepo.Create(ctx, subscription); err != nil {
+		return nil, fmt.Errorf("error creating webhook subscription: %v", err)
+	}
+	return subscription, nil
+}
+
+// ListSubscriptions obtiene los webhooks de un nodo o una tienda de userID, sin sus secretos
+func (s *WebhookService) ListSubscriptions(ctx context.Context, userID string, ownerType string, ownerID string) ([]*models.WebhookSubscription, error) {
+	if err := s.checkOwner(ctx, userID, ownerType, ownerID); err != nil {
+		return nil, err
+	}
+
+	subscriptions, err := s.subscriptionRepo.GetByOwner(ctx, ownerType, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting webhook subscriptions: %v", err)
+	}
+	for _, subscription := range subscriptions {
+		subscription.Secret = ""
+	}
+	return subscriptions, nil
+}
+
+// UpdateSubscription cambia la URL, los eventos o el estado de un webhook
+func (s *WebhookService) UpdateSubscription(ctx context.Context, userID string, subscriptionID string, changes *models.WebhookSubscription) (*models.WebhookSubscription, error) {
+	subscription, err := s.getOwnSubscription(ctx, userID, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	subscription.URL = changes.URL
+	subscription.Events = changes.Events
+	subscription.Active = changes.Active
+	if err := subscription.Validate(); err != nil {
+		return nil, errors.NewValidationError(err.Error(), err)
+	}
+
+	subscription.UpdatedAt = time.Now()
+	if err := s.subscriptionRepo.Update(ctx, subscription); err != nil {
+		return nil, fmt.Errorf("error updating webhook subscription: %v", err)
+	}
+	subscription.Secret = ""
+	return subscription, nil
+}
+
+// DeleteSubscription elimina un webhook
+func (s *WebhookService) DeleteSubscription(ctx context.Context, userID string, subscriptionID string) error {
+	if _, err := s.getOwnSubscription(ctx, userID, subscriptionID); err != nil {
+		return err
+	}
+	if err := s.subscriptionRepo.Delete(ctx, subscriptionID); err != nil {
+		return fmt.Errorf("error deleting webhook subscription: %v", err)
+	}
+	return nil
+}
+
+// ListDeliveries obtiene el registro de entregas más recientes de un webhook
+func (s *WebhookService) ListDeliveries(ctx context.Context, userID string, subscriptionID string, limit int) ([]*models.WebhookDelivery, error) {
+	if _, err := s.getOwnSubscription(ctx, userID, subscriptionID); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultWebhookLogSize
+	}
+	if limit > maxWebhookLogSize {
+		limit = maxWebhookLogSize
+	}
+
+	deliveries, err := s.deliveryRepo.GetBySubscription(ctx, subscriptionID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error getting webhook deliveries: %v", err)
+	}
+	return deliveries, nil
+}
+
+// SendTestEvent envía un evento de prueba a un webhook y retorna el resultado del intento.
+// La prueba no se reintenta, pero queda en el registro de entregas.
+func (s *WebhookService) SendTestEvent(ctx context.Context, userID string, subscriptionID string) (*models.WebhookDelivery, error) {
+	subscription, err := s.getOwnSubscription(ctx, userID, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	event := &models.WebhookEvent{
+		Type:      models.WebhookEventTest,
+		OwnerType: subscription.OwnerType,
+		OwnerID:   subscription.OwnerID,
+		Data: map[string]interface{}{
+			"message": "Este es un evento de prueba de Nodo Social",
+		},
+	}
+	delivery, err := s.newDelivery(subscription, event)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.deliveryRepo.Create(ctx, delivery); err != nil {
+		return nil, fmt.Errorf("error saving webhook delivery: %v", err)
+	}
+
+	s.attempt(ctx, subscription, delivery)
+	if delivery.Status == models.WebhookDeliveryPending {
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.NextAttemptAt = time.Time{}
+	}
+	if err := s.deliveryRepo.Update(ctx, delivery); err != nil {
+		return nil, fmt.Errorf("error updating webhook delivery: %v", err)
+	}
+	return delivery, nil
+}
+
+// Publish encola un evento para los webhooks activos de su dueño que lo reciben. Las
+// entregas se envían desde ProcessDeliveries para no demorar la acción que generó el evento.
+func (s *WebhookService) Publish(ctx context.Context, event *models.WebhookEvent) error {
+	subscriptions, err := s.subscriptionRepo.GetByOwner(ctx, event.OwnerType, event.OwnerID)
+	if err != nil {
+		return fmt.Errorf("error getting webhook subscriptions: %v", err)
+	}
+
+	for _, subscription := range subscriptions {
+		if !subscription.Active || !subscription.Subscribes(event.Type) {
+			continue
+		}
+
+		delivery, err := s.newDelivery(subscription, event)
+		if err != nil {
+			return err
+		}
+		delivery.NextAttemptAt = delivery.CreatedAt
+		if err := s.deliveryRepo.Create(ctx, delivery); err != nil {
+			fmt.Printf("error saving webhook delivery for subscription %s: %v\n", subscription.ID, err)
+		}
+	}
+	return nil
+}
+
+// ProcessDeliveries envía las entregas pendientes cuyo siguiente intento ya venció: las
+// recién encoladas y los reintentos
+func (s *WebhookService) ProcessDeliveries(ctx context.Context, now time.Time) error {
+	due, err := s.deliveryRepo.GetDue(ctx, now, webhookBatchSize)
+	if err != nil {
+		return fmt.Errorf("error getting due webhook deliveries: %v", err)
+	}
+
+	for _, candidate := range due {
+		delivery, err := s.deliveryRepo.Claim(ctx, candidate.ID, now, webhookLease)
+		if err != nil {
+			fmt.Printf("error claiming webhook delivery %s: %v\n", candidate.ID, err)
+			continue
+		}
+		if delivery == nil {
+			continue
+		}
+
+		subscription, err := s.subscriptionRepo.Get(ctx, delivery.SubscriptionID)
+		if err != nil || !subscription.Active {
+			// La suscripción se eliminó o se desactivó después de generar el evento
+			delivery.Status = models.WebhookDeliveryFailed
+			delivery.UpdatedAt = now
+		} else {
+			s.attempt(ctx, subscription, delivery)
+		}
+
+		if err := s.deliveryRepo.Update(ctx, delivery); err != nil {
+			fmt.Printf("error updating webhook delivery %s: %v\n", delivery.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// newDelivery prepara la entrega de un evento a una suscripción
+func (s *WebhookService) newDelivery(subscription *models.WebhookSubscription, event *models.WebhookEvent) (*models.WebhookDelivery, error) {
+	if event.ID == "" {
+		id, err := randomHex(16)
+		if err != nil {
+			return nil, fmt.Errorf("error generating event ID: %v", err)
+		}
+		event.ID = "evt_" + id
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding webhook event: %v", err)
+	}
+
+	return &models.WebhookDelivery{
+		SubscriptionID: subscription.ID,
+		EventID:        event.ID,
+		EventType:      event.Type,
+		Payload:        string(payload),
+		Status:         models.WebhookDeliveryPending,
+		Attempts:       make([]models.WebhookAttempt, 0, 1),
+		// Mientras se hace un primer intento inmediato, el worker no la toma
+		NextAttemptAt: time.Now().Add(webhookLease),
+		CreatedAt:     event.CreatedAt,
+		UpdatedAt:     event.CreatedAt,
+	}, nil
+}
+
+// attempt envía la entrega una vez, registra el intento y actualiza su estado.
+// Si falla y quedan intentos, programa el siguiente con espera exponencial.
+func (s *WebhookService) attempt(ctx context.Context, subscription *models.WebhookSubscription, delivery *models.WebhookDelivery) {
+	start := time.Now()
+	statusCode, body, err := s.post(ctx, subscription, delivery, start)
+	attempt := models.WebhookAttempt{
+		At:           start,
+		StatusCode:   statusCode,
+		ResponseBody: body,
+		DurationMs:   int64(time.Since(start) / time.Millisecond),
+	}
+	if err == nil && (statusCode < 200 || statusCode >= 300) {
+		err = fmt.Errorf("unexpected status %d", statusCode)
+	}
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+
+	delivery.Attempts = append(delivery.Attempts, attempt)
+	delivery.UpdatedAt = time.Now()
+	switch {
+	case err == nil:
+		delivery.Status = models.WebhookDeliverySucceeded
+		delivery.NextAttemptAt = time.Time{}
+	case len(delivery.Attempts) >= webhookMaxAttempts:
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.NextAttemptAt = time.Time{}
+	default:
+		delivery.Status = models.WebhookDeliveryPending
+		delivery.NextAttemptAt = delivery.UpdatedAt.Add(webhookBackoff(len(delivery.Attempts)))
+	}
+}
+
+// post envía el cuerpo firmado de la entrega y retorna el código y el inicio de la respuesta
+func (s *WebhookService) post(ctx context.Context, subscription *models.WebhookSubscription, delivery *models.WebhookDelivery, now time.Time) (int, string, error) {
+	request, err := http.NewRequest(http.MethodPost, subscription.URL, bytes.NewBufferString(delivery.Payload))
+	if err != nil {
+		return 0, "", err
+	}
+	request = request.WithContext(ctx)
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "NodoSocial-Webhooks/1.0")
+	request.Header.Set(WebhookEventTypeHeader, delivery.EventType)
+	request.Header.Set(WebhookEventIDHeader, delivery.EventID)
+	request.Header.Set(WebhookDeliveryIDHeader, delivery.ID)
+	request.Header.Set(WebhookSignatureHeader, SignWebhookPayload(subscription.Secret, now, delivery.Payload))
+
+	response, err := s.httpClient.Do(request)
+	if err != nil {
+		return 0, "", err
+	}
+	defer response.Body.Close()
+
+	body, _ := ioutil.ReadAll(io.LimitReader(response.Body, webhookMaxResponseBody))
+	return response.StatusCode, string(body), nil
+}
+
+// SignWebhookPayload calcula el valor de WebhookSignatureHeader para un cuerpo enviado en timestamp
+func SignWebhookPayload(secret string, timestamp time.Time, payload string) string {
+	unix := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unix + "." + payload))
+	return "t=" + unix + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookBackoff retorna la espera antes del siguiente intento tras attempts intentos fallidos
+func webhookBackoff(attempts int) time.Duration {
+	backoff := webhookBaseBackoff
+	for i := 1; i < attempts && backoff < webhookMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > webhookMaxBackoff {
+		return webhookMaxBackoff
+	}
+	return backoff
+}
+
+// getOwnSubscription obtiene una suscripción verificando que su dueño sea userID
+func (s *WebhookService) getOwnSubscription(ctx context.Context, userID string, subscriptionID string) (*models.WebhookSubscription, error) {
+	subscription, err := s.subscriptionRepo.Get(ctx, subscriptionID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("webhook no encontrado")
+	}
+	if err := s.checkOwner(ctx, userID, subscription.OwnerType, subscription.OwnerID); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// checkOwner verifica que userID sea el creador del nodo o el dueño de la tienda
+func (s *WebhookService) checkOwner(ctx context.Context, userID string, ownerType string, ownerID string) error {
+	var ownerUserID string
+	switch ownerType {
+	case models.WebhookOwnerNode:
+		node, err := s.nodeRepo.Get(ctx, ownerID)
+		if err != nil {
+			return errors.NewNotFoundError("nodo no encontrado")
+		}
+		ownerUserID = node.UserID
+	case models.WebhookOwnerStore:
+		store, err := s.storeRepo.Get(ctx, ownerID)
+		if err != nil {
+			return errors.NewNotFoundError("tienda no encontrada")
+		}
+		ownerUserID = store.UserID
+	default:
+		return errors.NewValidationError("owner_type debe ser node o store", nil)
+	}
+
+	if ownerUserID != userID {
+		return errors.NewForbiddenError("solo el dueño puede administrar sus webhooks")
+	}
+	return nil
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
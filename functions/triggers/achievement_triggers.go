@@ -27,29 +27,8 @@ func (t *AchievementTriggers) OnAchievement(ctx context.Context, e firebase.Fire
 	userID := e.Value.Fields["userId"].StringValue
 	achievementID := e.Value.Fields["achievementId"].StringValue
 
-	// TODO: Implementar lógica de rankings
-	// 1. Obtener el valor de puntos del logro
-	// 2. Actualizar el ranking del usuario
-	// 3. Recalcular posiciones si es necesario
-
+	// Los puntos del logro ya están en el historial; las posiciones de los rankings
+	// se recalculan en ScheduledTriggers.UpdateLeaderboards
 	log.Printf("Achievement %s unlocked for user %s", achievementID, userID)
 	return nil
 }
-
-// Achievement representa un logro en el sistema
-type Achievement struct {
-	ID          string `json:"id" firestore:"id"`
-	Name        string `json:"name" firestore:"name"`
-	Description string `json:"description" firestore:"description"`
-	Points      int    `json:"points" firestore:"points"`
-	Category    string `json:"category" firestore:"category"`
-}
-
-// UserRanking representa el ranking de un usuario
-type UserRanking struct {
-	UserID   string `json:"userId" firestore:"userId"`
-	Points   int    `json:"points" firestore:"points"`
-	Position int    `json:"position" firestore:"position"`
-	Level    int    `json:"level" firestore:"level"`
-}
-
@@ -55,12 +55,10 @@ func (t *AuthTriggers) OnUserCreated(ctx context.Context, user *auth.UserRecord)
 
 	// Enviar notificación de bienvenida
 	welcomeNotification := &models.Notification{
-		Type:        "welcome",
-		UserID:      user.UID,
-		Title:       "¡Bienvenido a Nodo Social!",
-		Description: "Gracias por unirte a nuestra comunidad. Explora las causas que te interesan y comienza a generar impacto.",
-		CreatedAt:   time.Now(),
-		Read:        false,
+		Type:      models.NotificationWelcome,
+		UserID:    user.UID,
+		CreatedAt: time.Now(),
+		Read:      false,
 	}
 
 	if err := t.notificationSvc.SendNotification(ctx, welcomeNotification); err != nil {
@@ -13,6 +13,9 @@ import (
 	"github.com/kha0sys/nodo.social/functions/internal/firebase"
 )
 
+// followerMilestone es el número de seguidores que se celebra con el creador del nodo
+const followerMilestone = 100
+
 // NodeTriggers maneja los triggers relacionados con nodos
 type NodeTriggers struct {
 	client          *firestore.Client
@@ -21,6 +24,9 @@ type NodeTriggers struct {
 	feedRepo        repositories.FeedRepository
 	notificationSvc *services.NotificationService
 	achievementSvc  *services.AchievementService
+	publisher       services.EventPublisher
+	webhookSvc      *services.WebhookService
+	activitySvc     *services.ActivityService
 }
 
 // NewNodeTriggers crea una nueva instancia de NodeTriggers
@@ -31,6 +37,9 @@ func NewNodeTriggers(
 	feedRepo repositories.FeedRepository,
 	notificationSvc *services.NotificationService,
 	achievementSvc *services.AchievementService,
+	publisher services.EventPublisher,
+	webhookSvc *services.WebhookService,
+	activitySvc *services.ActivityService,
 ) *NodeTriggers {
 	return &NodeTriggers{
 		client:          client,
@@ -39,6 +48,9 @@ func NewNodeTriggers(
 		feedRepo:        feedRepo,
 		notificationSvc: notificationSvc,
 		achievementSvc:  achievementSvc,
+		publisher:       publisher,
+		webhookSvc:      webhookSvc,
+		activitySvc:     activitySvc,
 	}
 }
 
@@ -70,11 +82,20 @@ func (t *NodeTriggers) OnCreate(ctx context.Context, e firebase.FirestoreEvent)
 		return nil
 	}
 
+	followerIDs := make([]string, 0, len(followers))
+	for _, follower := range followers {
+		followerIDs = append(followerIDs, follower.ID)
+	}
+	t.publishFeedItem(ctx, &feedItem, followerIDs)
+	t.checkAchievements(ctx, node.UserID)
+
 	notification := &models.Notification{
-		Title:       fmt.Sprintf("Nuevo nodo: %s", node.Title),
-		Description: fmt.Sprintf("Se ha creado un nuevo nodo: %s", node.Description),
-		Type:        "node_created",
-		UserID:      node.UserID,
+		Type:   models.NotificationNodeCreated,
+		UserID: node.UserID,
+		Params: map[string]interface{}{
+			"title":       node.Title,
+			"description": node.Description,
+		},
 		Data: map[string]interface{}{
 			"nodeID":   node.ID,
 			"nodeType": node.Type,
@@ -94,7 +115,8 @@ func (t *NodeTriggers) OnCreate(ctx context.Context, e firebase.FirestoreEvent)
 // OnUpdate se ejecuta cuando se actualiza un nodo
 func (t *NodeTriggers) OnUpdate(ctx context.Context, e firebase.FirestoreEvent) error {
 	var oldNode models.Node
-	if err := e.DataTo(&oldNode); err != nil {
+	previous := firebase.FirestoreEvent{Value: e.OldValue}
+	if err := previous.DataTo(&oldNode); err != nil {
 		return fmt.Errorf("error unmarshaling old node: %v", err)
 	}
 
@@ -117,14 +139,18 @@ func (t *NodeTriggers) OnUpdate(ctx context.Context, e firebase.FirestoreEvent)
 
 		if err := t.feedRepo.Create(ctx, &feedItem); err != nil {
 			log.Printf("error creating feed item: %v", err)
+		} else {
+			t.publishFeedItem(ctx, &feedItem, newNode.Followers)
 		}
 
 		// Notificar a los seguidores
 		notification := &models.Notification{
-			Title:       fmt.Sprintf("Actualización en nodo: %s", newNode.Title),
-			Description: "Se han realizado cambios importantes en este nodo",
-			Type:        "node_updated",
-			UserID:      newNode.UserID,
+			Type:   models.NotificationNodeUpdated,
+			UserID: newNode.UserID,
+			Target: newNode.ID,
+			Params: map[string]interface{}{
+				"title": newNode.Title,
+			},
 			Data: map[string]interface{}{
 				"nodeID":   newNode.ID,
 				"nodeType": newNode.Type,
@@ -133,12 +159,17 @@ func (t *NodeTriggers) OnUpdate(ctx context.Context, e firebase.FirestoreEvent)
 
 		for _, followerID := range newNode.Followers {
 			notification.UserID = followerID
-			if err := t.notificationSvc.CreateNotification(ctx, notification); err != nil {
+			if err := t.notificationSvc.Coalesce(ctx, notification); err != nil {
 				log.Printf("error sending notification to user %s: %v", followerID, err)
 			}
 		}
 	}
 
+	if len(newNode.Updates) > len(oldNode.Updates) {
+		t.recordUpdates(ctx, &newNode, newNode.Updates[len(oldNode.Updates):])
+		t.checkAchievements(ctx, newNode.UserID)
+	}
+
 	return nil
 }
 
@@ -156,10 +187,12 @@ func (t *NodeTriggers) OnDelete(ctx context.Context, e firebase.FirestoreEvent)
 
 	// Notificar a los seguidores
 	notification := &models.Notification{
-		Title:       fmt.Sprintf("Nodo eliminado: %s", node.Title),
-		Description: "Un nodo que seguías ha sido eliminado",
-		Type:        "node_deleted",
-		UserID:      node.UserID,
+		Type:   models.NotificationNodeDeleted,
+		UserID: node.UserID,
+		Target: node.ID,
+		Params: map[string]interface{}{
+			"title": node.Title,
+		},
 		Data: map[string]interface{}{
 			"nodeID":   node.ID,
 			"nodeType": node.Type,
@@ -168,7 +201,7 @@ func (t *NodeTriggers) OnDelete(ctx context.Context, e firebase.FirestoreEvent)
 
 	for _, followerID := range node.Followers {
 		notification.UserID = followerID
-		if err := t.notificationSvc.CreateNotification(ctx, notification); err != nil {
+		if err := t.notificationSvc.Coalesce(ctx, notification); err != nil {
 			log.Printf("error sending notification to user %s: %v", followerID, err)
 		}
 	}
@@ -197,23 +230,99 @@ func (t *NodeTriggers) OnInteraction(ctx context.Context, e firebase.FirestoreEv
 		return fmt.Errorf("error actualizando métricas en el feed: %v", err)
 	}
 
-	// Notificar al creador sobre hitos importantes
-	if metrics.Followers >= 100 {
+	// Notificar al creador solo cuando cruza el hito, no en cada interacción posterior
+	var oldNode models.Node
+	previous := firebase.FirestoreEvent{Value: e.OldValue}
+	if err := previous.DataTo(&oldNode); err != nil {
+		return fmt.Errorf("error unmarshaling previous node: %v", err)
+	}
+	t.publishNewComments(ctx, &node, oldNode.Metrics.Comments)
+
+	if oldNode.FollowersCount < followerMilestone && metrics.Followers >= followerMilestone {
 		notification := &models.Notification{
-			Title:       "¡Felicitaciones! Tu nodo ha alcanzado 100 seguidores",
-			Description: fmt.Sprintf("Tu nodo '%s' está creciendo", node.Title),
-			Type:        "achievement_followers",
-			UserID:      node.UserID,
+			Type:   models.NotificationFollowerMilestone,
+			UserID: node.UserID,
+			Params: map[string]interface{}{
+				"count": followerMilestone,
+				"title": node.Title,
+			},
 			Data: map[string]interface{}{
 				"nodeID":     node.ID,
 				"followers":  metrics.Followers,
-				"milestone": 100,
+				"milestone": followerMilestone,
 			},
 		}
-		if err := t.notificationSvc.CreateNotification(ctx, notification); err != nil {
+		if err := t.notificationSvc.SendNotification(ctx, notification); err != nil {
 			log.Printf("error sending milestone notification: %v", err)
 		}
 	}
 
 	return nil
 }
+
+// publishNewComments avisa a los webhooks del nodo cuando aumentó su número de comentarios
+func (t *NodeTriggers) publishNewComments(ctx context.Context, node *models.Node, previousComments int) {
+	if t.webhookSvc == nil || node.Metrics.Comments <= previousComments {
+		return
+	}
+
+	event := &models.WebhookEvent{
+		Type:      models.WebhookEventCommentCreated,
+		OwnerType: models.WebhookOwnerNode,
+		OwnerID:   node.ID,
+		Data: map[string]interface{}{
+			"node_id":        node.ID,
+			"new_comments":   node.Metrics.Comments - previousComments,
+			"comments_count": node.Metrics.Comments,
+		},
+	}
+	if err := t.webhookSvc.Publish(ctx, event); err != nil {
+		log.Printf("error publishing comment webhook for node %s: %v", node.ID, err)
+	}
+}
+
+// recordUpdates cuenta las actualizaciones nuevas del nodo en las rachas y retos de su creador
+func (t *NodeTriggers) recordUpdates(ctx context.Context, node *models.Node, updates []models.Update) {
+	for _, update := range updates {
+		target := update.ID
+		if target == "" {
+			target = fmt.Sprintf("%s_%d", node.ID, update.CreatedAt.UnixNano())
+		}
+		activity := &models.Activity{
+			UserID:   node.UserID,
+			Type:     models.ActivityUpdate,
+			NodeID:   node.ID,
+			NodeType: node.Type,
+			Target:   target,
+			At:       update.CreatedAt,
+		}
+		if err := t.activitySvc.Record(ctx, activity); err != nil {
+			log.Printf("error recording update activity for user %s: %v", node.UserID, err)
+		}
+	}
+}
+
+// checkAchievements otorga al usuario los logros que haya alcanzado
+func (t *NodeTriggers) checkAchievements(ctx context.Context, userID string) {
+	if _, err := t.achievementSvc.CheckAchievements(ctx, userID); err != nil {
+		log.Printf("error checking achievements for user %s: %v", userID, err)
+	}
+}
+
+// publishFeedItem envía un item nuevo del feed a los usuarios conectados que lo deben ver
+func (t *NodeTriggers) publishFeedItem(ctx context.Context, item *models.FeedItem, userIDs []string) {
+	if t.publisher == nil {
+		return
+	}
+
+	for _, userID := range userIDs {
+		event := &models.LiveEvent{
+			UserID: userID,
+			Type:   models.LiveEventFeedItem,
+			Data:   item,
+		}
+		if err := t.publisher.Publish(ctx, event); err != nil {
+			log.Printf("error publishing feed item to user %s: %v", userID, err)
+		}
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"time"
 
 	"cloud.google.com/go/firestore"
-	"github.com/kha0sys/nodo.social/functions/domain/models"
 	"github.com/kha0sys/nodo.social/functions/domain/repositories"
 	"github.com/kha0sys/nodo.social/functions/services"
 )
@@ -17,6 +16,13 @@ type ScheduledTriggers struct {
 	nodeRepo        repositories.NodeRepository
 	userRepo        repositories.UserRepository
 	notificationSvc *services.NotificationService
+	digestSvc       *services.DigestService
+	webhookSvc      *services.WebhookService
+	pointsSvc       *services.PointsService
+	leaderboardSvc  *services.LeaderboardService
+	backfillSvc     *services.AchievementBackfillService
+	uploadSvc       *services.UploadSessionService
+	mediaGCSvc      *services.MediaGCService
 }
 
 func NewScheduledTriggers(
@@ -24,12 +30,26 @@ func NewScheduledTriggers(
 	nodeRepo repositories.NodeRepository,
 	userRepo repositories.UserRepository,
 	notificationSvc *services.NotificationService,
+	digestSvc *services.DigestService,
+	webhookSvc *services.WebhookService,
+	pointsSvc *services.PointsService,
+	leaderboardSvc *services.LeaderboardService,
+	backfillSvc *services.AchievementBackfillService,
+	uploadSvc *services.UploadSessionService,
+	mediaGCSvc *services.MediaGCService,
 ) *ScheduledTriggers {
 	return &ScheduledTriggers{
 		client:          client,
 		nodeRepo:        nodeRepo,
 		userRepo:        userRepo,
 		notificationSvc: notificationSvc,
+		digestSvc:       digestSvc,
+		webhookSvc:      webhookSvc,
+		pointsSvc:       pointsSvc,
+		leaderboardSvc:  leaderboardSvc,
+		backfillSvc:     backfillSvc,
+		uploadSvc:       uploadSvc,
+		mediaGCSvc:      mediaGCSvc,
 	}
 }
 
@@ -53,58 +73,99 @@ func (t *ScheduledTriggers) DailyCleanup(ctx context.Context, _ interface{}) err
 	return nil
 }
 
-// WeeklyDigest se ejecuta semanalmente para enviar resúmenes a usuarios
+// CollectOrphanedMedia se ejecuta diariamente y elimina del storage los archivos que ya no
+// referencia ningún nodo, producto, tienda o usuario. mediaGCSvc puede ser nil.
+func (t *ScheduledTriggers) CollectOrphanedMedia(ctx context.Context, _ interface{}) error {
+	if t.mediaGCSvc == nil {
+		return nil
+	}
+	if _, err := t.mediaGCSvc.Collect(ctx); err != nil {
+		return fmt.Errorf("error collecting orphaned media: %v", err)
+	}
+	return nil
+}
+
+// WeeklyDigest se ejecuta cada hora y envía el resumen semanal a los usuarios para quienes
+// ya es lunes por la mañana en su zona horaria
 func (t *ScheduledTriggers) WeeklyDigest(ctx context.Context, _ interface{}) error {
-	// Obtener usuarios activos
-	activeUsersCount, err := t.userRepo.GetActiveUsers(ctx)
+	if err := t.digestSvc.SendDue(ctx, time.Now()); err != nil {
+		return fmt.Errorf("error sending weekly digests: %v", err)
+	}
+	return nil
+}
+
+// DeliverDeferredNotifications se ejecuta periódicamente para entregar las notificaciones
+// retenidas durante las horas de silencio de cada usuario
+func (t *ScheduledTriggers) DeliverDeferredNotifications(ctx context.Context, _ interface{}) error {
+	if err := t.notificationSvc.DeliverDeferredNotifications(ctx, time.Now()); err != nil {
+		return fmt.Errorf("error delivering deferred notifications: %v", err)
+	}
+	return nil
+}
+
+// DeliverCoalescedNotifications se ejecuta periódicamente para entregar las notificaciones
+// agrupadas cuya ventana ya se cerró
+func (t *ScheduledTriggers) DeliverCoalescedNotifications(ctx context.Context, _ interface{}) error {
+	if err := t.notificationSvc.DeliverCoalescedNotifications(ctx, time.Now()); err != nil {
+		return fmt.Errorf("error delivering coalesced notifications: %v", err)
+	}
+	return nil
+}
+
+// ProcessDeliveryQueue se ejecuta periódicamente para reintentar las entregas de push y correo
+// que fallaron
+func (t *ScheduledTriggers) ProcessDeliveryQueue(ctx context.Context, _ interface{}) error {
+	if err := t.notificationSvc.ProcessDeliveryQueue(ctx, time.Now()); err != nil {
+		return fmt.Errorf("error processing delivery queue: %v", err)
+	}
+	return nil
+}
+
+// ProcessWebhookDeliveries se ejecuta periódicamente para enviar las entregas de webhooks
+// encoladas y reintentar las que fallaron
+func (t *ScheduledTriggers) ProcessWebhookDeliveries(ctx context.Context, _ interface{}) error {
+	if err := t.webhookSvc.ProcessDeliveries(ctx, time.Now()); err != nil {
+		return fmt.Errorf("error processing webhook deliveries: %v", err)
+	}
+	return nil
+}
+
+// ReconcilePoints se ejecuta diariamente para recalcular el total de puntos de cada usuario
+// desde su historial
+func (t *ScheduledTriggers) ReconcilePoints(ctx context.Context, _ interface{}) error {
+	fixed, err := t.pointsSvc.ReconcileAll(ctx)
 	if err != nil {
-		return fmt.Errorf("error getting active users count: %v", err)
-	}
-
-	// Obtener todos los usuarios activos
-	users := make([]*models.User, 0, activeUsersCount)
-	iter := t.client.Collection("users").Where("active", "==", true).Documents(ctx)
-	defer iter.Stop()
-
-	for {
-		doc, err := iter.Next()
-		if err != nil {
-			break
-		}
-		var user models.User
-		if err := doc.DataTo(&user); err != nil {
-			log.Printf("error parsing user data: %v", err)
-			continue
-		}
-		users = append(users, &user)
-	}
-
-	weekAgo := time.Now().AddDate(0, 0, -7)
-
-	for _, user := range users {
-		// Obtener actividad de la semana para el usuario
-		weeklyActivity, err := t.generateWeeklyDigest(ctx, user.ID, weekAgo)
-		if err != nil {
-			log.Printf("error generating digest for user %s: %v", user.ID, err)
-			continue
-		}
-
-		// Enviar notificación con el resumen
-		notification := &models.Notification{
-			UserID:      user.ID,
-			Type:        "weekly_digest",
-			Title:       "Tu resumen semanal",
-			Description: "Aquí está tu resumen de actividad de la semana",
-			CreatedAt:   time.Now(),
-			Read:        false,
-			Data:        weeklyActivity,
-		}
-
-		if err := t.notificationSvc.CreateNotification(ctx, notification); err != nil {
-			log.Printf("error sending digest notification to user %s: %v", user.ID, err)
-		}
+		return fmt.Errorf("error reconciling points: %v", err)
+	}
+	log.Printf("points reconciled, %d totals fixed", fixed)
+	return nil
+}
+
+// RecountUnreadNotifications se ejecuta una vez para recalcular el contador de no leídas
+// de cada usuario, incluyendo las notificaciones creadas antes de los contadores
+func (t *ScheduledTriggers) RecountUnreadNotifications(ctx context.Context, _ interface{}) error {
+	fixed, err := t.notificationSvc.RecountAllUnread(ctx)
+	if err != nil {
+		return fmt.Errorf("error recounting unread notifications: %v", err)
+	}
+	log.Printf("unread notifications recounted, %d counters fixed", fixed)
+	return nil
+}
+
+// UpdateLeaderboards se ejecuta cada hora para recalcular las posiciones de los rankings
+func (t *ScheduledTriggers) UpdateLeaderboards(ctx context.Context, _ interface{}) error {
+	if err := t.leaderboardSvc.Recompute(ctx, time.Now()); err != nil {
+		return fmt.Errorf("error updating leaderboards: %v", err)
 	}
+	return nil
+}
 
+// ResumeAchievementBackfills se ejecuta periódicamente para continuar los recálculos de
+// logros que no terminaron dentro de su ejecución
+func (t *ScheduledTriggers) ResumeAchievementBackfills(ctx context.Context, _ interface{}) error {
+	if err := t.backfillSvc.ResumeNext(ctx); err != nil {
+		return fmt.Errorf("error resuming achievement backfills: %v", err)
+	}
 	return nil
 }
 
@@ -143,68 +204,18 @@ func (t *ScheduledTriggers) updateDailyStats(ctx context.Context) error {
 	return err
 }
 
+// cleanTempFiles elimina las sesiones de subida de más de un día y los archivos que
+// nunca se confirmaron. uploadSvc puede ser nil.
 func (t *ScheduledTriggers) cleanTempFiles(ctx context.Context) error {
-    // Obtener lista de archivos temporales más antiguos de 24 horas
-    files, err := t.client.Collection("temp_files").
-        Where("created_at", "<", time.Now().Add(-24*time.Hour)).
-        Documents(ctx).GetAll()
-    if err != nil {
-        return fmt.Errorf("error getting temp files: %v", err)
-    }
-
-    // Eliminar archivos en batch
-    batch := t.client.Batch()
-    for _, doc := range files {
-        batch.Delete(doc.Ref)
-    }
-
-    // Commit batch
-    _, err = batch.Commit(ctx)
-    if err != nil {
-        return fmt.Errorf("error deleting temp files: %v", err)
-    }
-
-    return nil
-}
-
-func (t *ScheduledTriggers) generateWeeklyDigest(ctx context.Context, userID string, weekAgo time.Time) (map[string]interface{}, error) {
-	// Obtener actividad del usuario
-	activity := make(map[string]interface{})
-	
-	// Obtener interacciones del usuario
-	interactions, err := t.client.Collection("user_activity").
-		Where("user_id", "==", userID).
-		Where("created_at", ">=", weekAgo).
-		Documents(ctx).GetAll()
-	if err != nil {
-		return nil, fmt.Errorf("error getting user activity: %v", err)
+	if t.uploadSvc == nil {
+		return nil
 	}
-
-	// Contar interacciones por tipo
-	activityCount := make(map[string]int)
-	for _, doc := range interactions {
-		data := doc.Data()
-		if activityType, ok := data["type"].(string); ok {
-			activityCount[activityType]++
-		}
-	}
-	activity["interactions"] = activityCount
-
-	// Obtener nodos populares
-	popularNodes, err := t.nodeRepo.GetPopularNodes(ctx, 5)
+	deleted, err := t.uploadSvc.CleanupAbandoned(ctx)
 	if err != nil {
-		log.Printf("error getting popular nodes: %v", err)
+		return fmt.Errorf("error deleting temp files: %v", err)
 	}
-
-	// Generar resumen
-	digest := map[string]interface{}{
-		"period": map[string]time.Time{
-			"from": weekAgo,
-			"to":   time.Now(),
-		},
-		"activity":     activity,
-		"popularNodes": popularNodes,
+	if deleted > 0 {
+		log.Printf("deleted %d abandoned upload sessions", deleted)
 	}
-
-	return digest, nil
+	return nil
 }
@@ -1,12 +1,24 @@
 package triggers
 
 import (
+	"bytes"
 	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"net/http"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"cloud.google.com/go/storage"
+	"google.golang.org/api/googleapi"
+	domainerrors "github.com/kha0sys/nodo.social/functions/domain/errors"
+	"github.com/kha0sys/nodo.social/functions/domain/models"
 	"github.com/kha0sys/nodo.social/functions/services"
 	"github.com/kha0sys/nodo.social/functions/internal/firebase"
 	"github.com/kha0sys/nodo.social/functions/internal/imageprocessor"
@@ -17,26 +29,67 @@ type StorageTriggers struct {
 	imageProcessor  *imageprocessor.ImageProcessor
 	nodeService     *services.NodeService
 	productService  *services.ProductService
+	mediaService    *services.MediaService
+	storageService  *services.StorageService
+	uploadSessions  *services.UploadSessionService
+	keepMetadata    bool
 }
 
+// NewStorageTriggers crea una nueva instancia de StorageTriggers. Salvo que keepMetadata
+// lo permita, las imágenes originales se reemplazan por una copia orientada y sin metadatos.
+// Con storageService se verifican los archivos subidos directamente y la cuota de cada usuario;
+// con uploadSessions, que los subidos con una URL firmada coincidan con lo declarado.
 func NewStorageTriggers(
 	storageClient *storage.Client,
 	imageProcessor *imageprocessor.ImageProcessor,
 	nodeService *services.NodeService,
 	productService *services.ProductService,
+	mediaService *services.MediaService,
+	storageService *services.StorageService,
+	uploadSessions *services.UploadSessionService,
+	keepMetadata bool,
 ) *StorageTriggers {
 	return &StorageTriggers{
 		storageClient:  storageClient,
 		imageProcessor: imageProcessor,
 		nodeService:    nodeService,
 		productService: productService,
+		mediaService:   mediaService,
+		storageService: storageService,
+		uploadSessions: uploadSessions,
+		keepMetadata:   keepMetadata,
 	}
 }
 
+// reservedMetadata es un metadato que antes marcaba la original reescrita. Las reglas de
+// Storage no dejan que los clientes lo usen, pero las URLs firmadas no pasan por ellas.
+const reservedMetadata = "thumbnailsGenerated"
+
 // OnImageUploaded se ejecuta cuando se sube una imagen al Storage
 func (t *StorageTriggers) OnImageUploaded(ctx context.Context, e firebase.StorageEvent) error {
+	// Los thumbnails generados no cuentan para la cuota ni se vuelven a procesar. Solo las
+	// funciones escriben en los directorios thumbnails: las reglas de Storage no dejan a
+	// los clientes y las subidas del servidor no usan esas rutas.
+	if isThumbnail(e.Name) {
+		return nil
+	}
+	// Reemplazar la original sin metadatos vuelve a disparar este trigger
+	rewritten, err := t.isRewrite(ctx, e)
+	if err != nil {
+		return err
+	}
+
+	// Los archivos subidos directamente desde el cliente no pasaron por las validaciones
+	// de la subida HTTP
+	if userID, _, _ := mediaOwner(e); userID != "" {
+		kept, err := t.enforceUpload(ctx, e, userID, rewritten)
+		if err != nil || !kept {
+			return err
+		}
+	}
+
 	// Solo procesar imágenes
-	if !isImage(e.Name) {
+	if !isImage(e.Name) || rewritten {
 		return nil
 	}
 
@@ -50,20 +103,44 @@ func (t *StorageTriggers) OnImageUploaded(ctx context.Context, e firebase.Storag
 		return fmt.Errorf("error reading image: %v", err)
 	}
 	defer reader.Close()
+	generation := reader.Attrs.Generation
+
+	original, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("error reading image: %v", err)
+	}
+
+	// Orientar la original y quitarle la ubicación y demás metadatos
+	format := ""
+	if !t.keepMetadata {
+		original, format, err = t.imageProcessor.StripMetadata(bytes.NewReader(original))
+		if errors.Is(err, imageprocessor.ErrTooManyPixels) {
+			return t.rejectUpload(ctx, e, err.Error())
+		}
+		if err != nil {
+			return fmt.Errorf("error stripping image metadata: %v", err)
+		}
+	}
 
 	// Procesar imagen y generar thumbnails
-	thumbnails, err := t.imageProcessor.ProcessImage(reader)
+	processed, err := t.imageProcessor.ProcessImage(bytes.NewReader(original))
+	if errors.Is(err, imageprocessor.ErrTooManyPixels) {
+		return t.rejectUpload(ctx, e, err.Error())
+	}
 	if err != nil {
 		return fmt.Errorf("error processing image: %v", err)
 	}
 
 	// Guardar thumbnails
-	for size, thumbnail := range thumbnails {
-		thumbPath := generateThumbnailPath(e.Name, size)
+	thumbnailURLs := make([]string, 0, len(processed.Thumbnails))
+	for _, thumbnail := range processed.Thumbnails {
+		thumbPath := generateThumbnailPath(e.Name, thumbnail.Size, thumbnail.Extension)
+		thumbnailURLs = append(thumbnailURLs, publicURL(e.Bucket, thumbPath))
 		thumbObj := bucket.Object(thumbPath)
 		writer := thumbObj.NewWriter(ctx)
+		writer.ContentType = thumbnail.ContentType
 		
-		if _, err := writer.Write(thumbnail); err != nil {
+		if _, err := writer.Write(thumbnail.Data); err != nil {
 			return fmt.Errorf("error writing thumbnail: %v", err)
 		}
 		
@@ -72,54 +149,262 @@ func (t *StorageTriggers) OnImageUploaded(ctx context.Context, e firebase.Storag
 		}
 	}
 
-	// Actualizar metadata del objeto original
-	objectAttrs := storage.ObjectAttrsToUpdate{
-		Metadata: map[string]string{
-			"thumbnailsGenerated": "true",
-			"processedAt":        "timestamp",
-		},
+	// Guardar dimensiones, placeholder y thumbnails, y agregarlos al nodo o producto dueño
+	// de la imagen para que los clientes no tengan que adivinar las rutas
+	media, err := t.mediaService.Record(ctx, publicURL(e.Bucket, e.Name), processed, thumbnailURLs)
+	if err != nil {
+		return fmt.Errorf("error recording media: %v", err)
 	}
-	
-	if _, err := obj.Update(ctx, objectAttrs); err != nil {
-		return fmt.Errorf("error updating metadata: %v", err)
+	if err := t.attachMedia(ctx, e, *media); err != nil {
+		return err
 	}
 
-	return nil
+	metadata := map[string]string{
+		"processedAt": time.Now().UTC().Format(time.RFC3339),
+	}
+
+	if t.keepMetadata {
+		// Actualizar metadata del objeto original
+		objectAttrs := storage.ObjectAttrsToUpdate{
+			Metadata: metadata,
+		}
+		
+		if _, err := obj.Update(ctx, objectAttrs); err != nil {
+			return fmt.Errorf("error updating metadata: %v", err)
+		}
+		return nil
+	}
+
+	// Reemplazar la original conservando sus metadatos de Storage, como el token de descarga,
+	// solo si no se subió otra versión mientras tanto
+	for key, value := range e.Metadata {
+		if _, ok := metadata[key]; !ok {
+			metadata[key] = value
+		}
+	}
+	imageURL := publicURL(e.Bucket, e.Name)
+	sum := md5.Sum(original)
+	hash := base64.StdEncoding.EncodeToString(sum[:])
+	if err := t.mediaService.BeginRewrite(ctx, imageURL, hash); err != nil {
+		return err
+	}
+	writer := obj.If(storage.Conditions{GenerationMatch: generation}).NewWriter(ctx)
+	writer.ContentType = imageprocessor.ContentType(format)
+	writer.Metadata = metadata
+	if _, err := writer.Write(original); err != nil {
+		return fmt.Errorf("error writing image: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		var apiErr *googleapi.Error
+		if errors.As(err, &apiErr) && apiErr.Code == http.StatusPreconditionFailed {
+			// La versión nueva dispara su propio procesamiento
+			return nil
+		}
+		return fmt.Errorf("error closing writer: %v", err)
+	}
+
+	return t.mediaService.FinishRewrite(ctx, imageURL, hash, writer.Attrs().Generation)
+}
+
+// isRewrite indica si el evento es del reemplazo de la original por su copia sin
+// metadatos, comparando su versión con la que registró el reemplazo
+func (t *StorageTriggers) isRewrite(ctx context.Context, e firebase.StorageEvent) (bool, error) {
+	if t.keepMetadata || !isImage(e.Name) {
+		return false, nil
+	}
+	generation, err := strconv.ParseInt(e.Generation, 10, 64)
+	if err != nil {
+		return false, nil
+	}
+	return t.mediaService.IsRewrite(ctx, publicURL(e.Bucket, e.Name), generation, e.MD5Hash)
 }
 
 // OnImageDeleted se ejecuta cuando se elimina una imagen del Storage
 func (t *StorageTriggers) OnImageDeleted(ctx context.Context, e firebase.StorageEvent) error {
+	if isThumbnail(e.Name) {
+		return nil
+	}
+
+	if userID, _, _ := mediaOwner(e); userID != "" && t.storageService != nil {
+		if err := t.storageService.RecordDeleted(ctx, userID, e.Size); err != nil {
+			return err
+		}
+	}
+
 	if !isImage(e.Name) {
 		return nil
 	}
 
-	// Eliminar thumbnails asociados
+	// Al reemplazar un archivo también se elimina la versión anterior; si el archivo sigue
+	// existiendo sus thumbnails y datos son de la versión nueva
 	bucket := t.storageClient.Bucket(e.Bucket)
-	sizes := []string{"small", "medium", "large"}
-
-	for _, size := range sizes {
-		thumbPath := generateThumbnailPath(e.Name, size)
-		if err := bucket.Object(thumbPath).Delete(ctx); err != nil {
-			// Ignorar errores si el thumbnail no existe
-			if err != storage.ErrObjectNotExist {
-				return fmt.Errorf("error deleting thumbnail: %v", err)
+	if _, err := bucket.Object(e.Name).Attrs(ctx); err == nil {
+		return nil
+	} else if err != storage.ErrObjectNotExist {
+		return fmt.Errorf("error checking image: %v", err)
+	}
+
+	// Eliminar thumbnails asociados en cada tamaño y formato
+	for _, size := range t.imageProcessor.Sizes() {
+		for _, ext := range imageprocessor.OutputExtensions(filepath.Ext(e.Name)) {
+			thumbPath := generateThumbnailPath(e.Name, size.Name, ext)
+			if err := bucket.Object(thumbPath).Delete(ctx); err != nil {
+				// Ignorar errores si el thumbnail no existe
+				if err != storage.ErrObjectNotExist {
+					return fmt.Errorf("error deleting thumbnail: %v", err)
+				}
 			}
 		}
 	}
 
+	imageURL := publicURL(e.Bucket, e.Name)
+	if err := t.mediaService.Forget(ctx, imageURL); err != nil {
+		return fmt.Errorf("error forgetting media: %v", err)
+	}
+
+	// Quitar la imagen de su nodo o producto
+	_, owner, ownerID := mediaOwner(e)
+	switch owner {
+	case models.MediaOwnerNode:
+		if err := t.nodeService.DetachMedia(ctx, ownerID, imageURL); err != nil {
+			return fmt.Errorf("error detaching media from node %s: %v", ownerID, err)
+		}
+	case models.MediaOwnerProduct:
+		if err := t.productService.DetachMedia(ctx, ownerID, imageURL); err != nil {
+			return fmt.Errorf("error detaching media from product %s: %v", ownerID, err)
+		}
+	}
+
 	return nil
 }
 
+// enforceUpload suma el archivo al uso de su usuario y lo elimina si su contenido no
+// coincide con su extensión, si supera el tamaño máximo de su tipo o si el usuario superó
+// su cuota. Retorna false si lo eliminó. La original reescrita sin metadatos ya se verificó.
+func (t *StorageTriggers) enforceUpload(ctx context.Context, e firebase.StorageEvent, userID string, rewritten bool) (bool, error) {
+	if t.storageService == nil {
+		return true, nil
+	}
+
+	usage, err := t.storageService.RecordStored(ctx, userID, e.Size)
+	if err != nil {
+		return false, err
+	}
+	if rewritten {
+		return true, nil
+	}
+	// El reemplazo se reconoce por su versión; este metadato solo puede venir del cliente
+	if _, ok := e.Metadata[reservedMetadata]; ok {
+		return false, t.rejectUpload(ctx, e, fmt.Sprintf("metadata %s is reserved", reservedMetadata))
+	}
+
+	if t.uploadSessions != nil {
+		matches, err := t.uploadSessions.VerifyStored(ctx, e.Name, e.Size, e.MD5Hash)
+		if err != nil {
+			return false, err
+		}
+		if !matches {
+			return false, t.rejectUpload(ctx, e, "file does not match its upload session")
+		}
+	}
+
+	reader, err := t.storageClient.Bucket(e.Bucket).Object(e.Name).NewRangeReader(ctx, 0, services.SniffLength)
+	if err != nil {
+		return false, fmt.Errorf("error reading file: %v", err)
+	}
+	head, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		return false, fmt.Errorf("error reading file: %v", err)
+	}
+
+	if _, err := t.storageService.CheckStored(e.Name, head, e.Size); err != nil {
+		return false, t.rejectUpload(ctx, e, err.Error())
+	}
+	if usage.QuotaBytes > 0 && usage.UsedBytes > usage.QuotaBytes {
+		return false, t.rejectUpload(ctx, e, fmt.Sprintf("user %s exceeded its storage quota", userID))
+	}
+	return true, nil
+}
+
+// rejectUpload elimina un archivo que no cumple las validaciones. Su evento de eliminación
+// lo resta del uso del usuario.
+func (t *StorageTriggers) rejectUpload(ctx context.Context, e firebase.StorageEvent, reason string) error {
+	log.Printf("Deleting rejected upload %s: %s", e.Name, reason)
+	if err := t.storageClient.Bucket(e.Bucket).Object(e.Name).Delete(ctx); err != nil && err != storage.ErrObjectNotExist {
+		return fmt.Errorf("error deleting rejected upload: %v", err)
+	}
+	return nil
+}
+
+// attachMedia guarda los datos de la imagen en el nodo o producto al que pertenece, si tiene
+func (t *StorageTriggers) attachMedia(ctx context.Context, e firebase.StorageEvent, media models.MediaURL) error {
+	userID, owner, ownerID := mediaOwner(e)
+	var err error
+	switch owner {
+	case models.MediaOwnerNode:
+		err = t.nodeService.AttachMedia(ctx, ownerID, userID, media)
+	case models.MediaOwnerProduct:
+		err = t.productService.AttachMedia(ctx, ownerID, userID, media)
+	default:
+		return nil
+	}
+	if err != nil {
+		if domainerrors.IsDomainError(err) {
+			// Reintentar no cambiaría el resultado
+			log.Printf("Image %s not attached to %s %s: %v", e.Name, owner, ownerID, err)
+			return nil
+		}
+		return fmt.Errorf("error attaching media to %s %s: %v", owner, ownerID, err)
+	}
+	return nil
+}
+
+// mediaOwner retorna quién subió la imagen y el nodo o producto al que pertenece. Las
+// imágenes se suben en users/{userId}/; el dueño se toma de los metadatos nodeId o
+// productId o, si no están, de la ruta users/{userId}/nodes/{nodeId}/ o
+// users/{userId}/products/{productId}/. Las reglas de Storage solo dejan escribir en
+// users/{userId}/ a ese usuario, y el nodo o producto debe ser suyo para recibir la imagen.
+func mediaOwner(e firebase.StorageEvent) (userID, owner, ownerID string) {
+	parts := strings.Split(e.Name, "/")
+	if len(parts) < 3 || parts[0] != "users" {
+		return "", "", ""
+	}
+	userID = parts[1]
+
+	switch {
+	case e.Metadata["nodeId"] != "":
+		return userID, models.MediaOwnerNode, e.Metadata["nodeId"]
+	case e.Metadata["productId"] != "":
+		return userID, models.MediaOwnerProduct, e.Metadata["productId"]
+	case len(parts) >= 5 && (parts[2] == models.MediaOwnerNode || parts[2] == models.MediaOwnerProduct):
+		return userID, parts[2], parts[3]
+	}
+	return userID, "", ""
+}
+
+// publicURL retorna la URL pública de un objeto, la misma que retorna el StorageRepository al subirlo
+func publicURL(bucket, name string) string {
+	return fmt.Sprintf("https://storage.googleapis.com/%s/%s", bucket, name)
+}
+
 func isImage(filename string) bool {
 	ext := strings.ToLower(filepath.Ext(filename))
 	return ext == ".jpg" || ext == ".jpeg" || ext == ".png" || ext == ".gif" || ext == ".webp"
 }
 
-func generateThumbnailPath(originalPath, size string) string {
+// isThumbnail indica si la ruta es de un thumbnail generado
+func isThumbnail(filename string) bool {
+	return filepath.Base(filepath.Dir(filename)) == "thumbnails"
+}
+
+// generateThumbnailPath retorna la ruta del thumbnail de una imagen en un tamaño, con la
+// extensión de su formato
+func generateThumbnailPath(originalPath, size, thumbExt string) string {
 	dir := filepath.Dir(originalPath)
 	filename := filepath.Base(originalPath)
 	ext := filepath.Ext(filename)
 	name := strings.TrimSuffix(filename, ext)
 	
-	return filepath.Join(dir, "thumbnails", fmt.Sprintf("%s_%s%s", name, size, ext))
+	return filepath.Join(dir, "thumbnails", fmt.Sprintf("%s_%s%s", name, size, thumbExt))
 }